@@ -0,0 +1,438 @@
+//Package cisbackup takes a portable backup of a whole CIS instance and
+//restores it into the same or another instance, for DR tests and for moving
+//between staging and production accounts.
+package cisbackup
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/IBM-Cloud/bluemix-go/api/cis/cisv1"
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+)
+
+//Error codes
+const (
+	ErrCodeInvalidBackup      = "InvalidCisBackup"
+	ErrCodeUnsupportedVersion = "UnsupportedCisBackupVersion"
+	ErrCodeRestoreIncomplete  = "CisRestoreIncomplete"
+)
+
+//FormatVersion is the version of the backup document written by Take
+const FormatVersion = 1
+
+//FirewallTypes are the legacy firewall rule types backed up
+var FirewallTypes = []string{"access_rules", "ua_rules", "lockdowns"}
+
+//Backup is a CIS instance with its IDs replaced by symbolic references.
+//Monitors and pools belong to the instance; everything else to a zone.
+type Backup struct {
+	Version  int       `json:"version"`
+	Source   string    `json:"source,omitempty"`
+	TakenAt  time.Time `json:"taken_at"`
+	Monitors []Monitor `json:"monitors"`
+	Pools    []Pool    `json:"pools"`
+	Zones    []Zone    `json:"zones"`
+}
+
+//Monitor is a health monitor known by Ref
+type Monitor struct {
+	Ref string `json:"ref"`
+	cisv1.MonitorBody
+}
+
+//Pool is an origin pool known by Ref. Its monitor is given by MonitorRef.
+type Pool struct {
+	Ref        string `json:"ref"`
+	MonitorRef string `json:"monitor_ref,omitempty"`
+	cisv1.PoolBody
+}
+
+//Zone holds the resources of one zone. Pools of load balancers are given
+//by Pool.Ref and firewall rules carry their filter expression inline.
+type Zone struct {
+	Name          string                  `json:"name"`
+	DnsRecords    []cisv1.DnsBody         `json:"dns_records"`
+	Glbs          []cisv1.GlbBody         `json:"glbs"`
+	Firewalls     []Firewall              `json:"firewalls"`
+	FirewallRules []cisv1.FirewallRule    `json:"firewall_rules"`
+	RateLimits    []cisv1.RateLimitRecord `json:"rate_limits"`
+	//Settings are the editable settings of the zone
+	Settings []cisv1.Setting `json:"settings"`
+}
+
+//Firewall is a legacy firewall rule, Type is one of FirewallTypes
+type Firewall struct {
+	Type string `json:"type"`
+	cisv1.FirewallBody
+}
+
+//Client holds the CIS APIs a backup reads and writes
+type Client struct {
+	Zones         cisv1.Zones
+	Dns           cisv1.Dns
+	Glbs          cisv1.Glbs
+	Pools         cisv1.Pools
+	Monitors      cisv1.Monitors
+	Firewall      cisv1.Firewall
+	FirewallRules cisv1.FirewallRules
+	RateLimit     cisv1.RateLimit
+	Settings      cisv1.Settings
+}
+
+//NewClient ...
+func NewClient(api cisv1.CisServiceAPI) Client {
+	return Client{
+		Zones:         api.Zones(),
+		Dns:           api.Dns(),
+		Glbs:          api.Glbs(),
+		Pools:         api.Pools(),
+		Monitors:      api.Monitors(),
+		Firewall:      api.Firewall(),
+		FirewallRules: api.FirewallRules(),
+		RateLimit:     api.RateLimit(),
+		Settings:      api.Settings(),
+	}
+}
+
+//refs hands out unique, readable references
+type refs map[string]bool
+
+func (r refs) next(kind, base string) string {
+	slug := strings.Map(func(c rune) rune {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '.':
+			return c
+		case c >= 'A' && c <= 'Z':
+			return c + 'a' - 'A'
+		}
+		return '-'
+	}, base)
+	slug = strings.Trim(slug, "-")
+	if slug == "" {
+		slug = kind
+	}
+	ref := kind + ":" + slug
+	for i := 2; r[ref]; i++ {
+		ref = fmt.Sprintf("%s:%s-%d", kind, slug, i)
+	}
+	r[ref] = true
+	return ref
+}
+
+//Take reads every supported resource of cisID
+func (c Client) Take(cisID string) (*Backup, error) {
+	b := &Backup{Version: FormatVersion, Source: cisID, TakenAt: time.Now().UTC(),
+		Monitors: []Monitor{}, Pools: []Pool{}, Zones: []Zone{}}
+	used := refs{}
+
+	monitors, err := c.Monitors.ListMonitors(cisID)
+	if err != nil {
+		return nil, err
+	}
+	monitorRefs := map[string]string{}
+	for _, m := range monitors {
+		base := m.Description
+		if base == "" {
+			base = m.MonType + m.Path
+		}
+		ref := used.next("monitor", base)
+		monitorRefs[m.Id] = ref
+		b.Monitors = append(b.Monitors, Monitor{Ref: ref, MonitorBody: monitorBody(m)})
+	}
+
+	pools, err := c.Pools.ListPools(cisID)
+	if err != nil {
+		return nil, err
+	}
+	poolRefs := map[string]string{}
+	for _, p := range pools {
+		ref := used.next("pool", p.Name)
+		poolRefs[p.Id] = ref
+		entry := Pool{Ref: ref, PoolBody: poolBody(p)}
+		if p.Monitor != "" {
+			if entry.MonitorRef = monitorRefs[p.Monitor]; entry.MonitorRef == "" {
+				return nil, bmxerror.New(ErrCodeInvalidBackup, fmt.Sprintf("Pool %s uses unknown monitor %s", p.Name, p.Monitor))
+			}
+		}
+		b.Pools = append(b.Pools, entry)
+	}
+
+	zones, err := c.Zones.ListZones(cisID)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(zones, func(i, j int) bool { return zones[i].Name < zones[j].Name })
+	for _, z := range zones {
+		zone, err := c.takeZone(cisID, z, poolRefs)
+		if err != nil {
+			return nil, fmt.Errorf("zone %s: %v", z.Name, err)
+		}
+		b.Zones = append(b.Zones, zone)
+	}
+	return b, nil
+}
+
+func (c Client) takeZone(cisID string, z cisv1.Zone, poolRefs map[string]string) (Zone, error) {
+	zone := Zone{Name: z.Name, DnsRecords: []cisv1.DnsBody{}, Glbs: []cisv1.GlbBody{}, Firewalls: []Firewall{},
+		FirewallRules: []cisv1.FirewallRule{}, RateLimits: []cisv1.RateLimitRecord{}, Settings: []cisv1.Setting{}}
+
+	records, err := c.Dns.ListDns(cisID, z.Id)
+	if err != nil {
+		return zone, err
+	}
+	for _, r := range records {
+		zone.DnsRecords = append(zone.DnsRecords, r.Body())
+	}
+
+	glbs, err := c.Glbs.ListGlbs(cisID, z.Id)
+	if err != nil {
+		return zone, err
+	}
+	for _, g := range glbs {
+		body, err := mapGlbPools(glbBody(g), poolRefs)
+		if err != nil {
+			return zone, fmt.Errorf("load balancer %s: %v", g.Name, err)
+		}
+		zone.Glbs = append(zone.Glbs, body)
+	}
+
+	if zone.Firewalls, err = c.listFirewalls(cisID, z.Id); err != nil {
+		return zone, err
+	}
+	if zone.FirewallRules, err = c.listFirewallRules(cisID, z.Id); err != nil {
+		return zone, err
+	}
+	if zone.RateLimits, err = c.listRateLimits(cisID, z.Id); err != nil {
+		return zone, err
+	}
+
+	settings, err := c.Settings.ListSettings(cisID, z.Id)
+	if err != nil {
+		return zone, err
+	}
+	for _, s := range settings {
+		if s.Editable {
+			s.ModifiedDate = ""
+			zone.Settings = append(zone.Settings, s)
+		}
+	}
+	sort.Slice(zone.Settings, func(i, j int) bool { return zone.Settings[i].ID < zone.Settings[j].ID })
+	return zone, nil
+}
+
+//listFirewalls returns the legacy firewall rules of every type of
+//FirewallTypes
+func (c Client) listFirewalls(cisID, zoneID string) ([]Firewall, error) {
+	list := []Firewall{}
+	for _, t := range FirewallTypes {
+		firewalls, err := c.Firewall.ListFirewall(cisID, zoneID, t)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range firewalls {
+			list = append(list, Firewall{Type: t, FirewallBody: firewallBody(f)})
+		}
+	}
+	return list, nil
+}
+
+//listFirewallRules returns the firewall rules with their filter expression
+//inline and without IDs
+func (c Client) listFirewallRules(cisID, zoneID string) ([]cisv1.FirewallRule, error) {
+	rules, err := c.FirewallRules.ListFirewallRules(cisID, zoneID)
+	if err != nil {
+		return nil, err
+	}
+	list := []cisv1.FirewallRule{}
+	for _, r := range rules {
+		if r.Filter.Expression == "" {
+			filter, err := c.FirewallRules.GetFilter(cisID, zoneID, r.Filter.ID)
+			if err != nil {
+				return nil, err
+			}
+			r.Filter = *filter
+		}
+		r.ID, r.Filter.ID, r.CreatedOn, r.ModifiedOn = "", "", nil, nil
+		list = append(list, r)
+	}
+	return list, nil
+}
+
+//listRateLimits returns the rate limits without IDs
+func (c Client) listRateLimits(cisID, zoneID string) ([]cisv1.RateLimitRecord, error) {
+	limits, err := c.RateLimit.ListRateLimit(cisID, zoneID)
+	if err != nil {
+		return nil, err
+	}
+	list := []cisv1.RateLimitRecord{}
+	for _, l := range limits {
+		l.ID = ""
+		list = append(list, l)
+	}
+	return list, nil
+}
+
+//mapGlbPools replaces the pools of a load balancer using mapping, from IDs
+//to references on backup and back on restore
+func mapGlbPools(g cisv1.GlbBody, mapping map[string]string) (cisv1.GlbBody, error) {
+	var missing []string
+	lookup := func(id string) string {
+		if id == "" {
+			return ""
+		}
+		v, ok := mapping[id]
+		if !ok {
+			missing = append(missing, id)
+		}
+		return v
+	}
+	list := func(ids []string) []string {
+		out := make([]string, len(ids))
+		for i, id := range ids {
+			out[i] = lookup(id)
+		}
+		return out
+	}
+	lists := func(m map[string][]string) map[string][]string {
+		if m == nil {
+			return nil
+		}
+		out := make(map[string][]string, len(m))
+		for k, ids := range m {
+			out[k] = list(ids)
+		}
+		return out
+	}
+	g.DefaultPools = list(g.DefaultPools)
+	g.FallbackPool = lookup(g.FallbackPool)
+	g.RegionPools = lists(g.RegionPools)
+	g.PopPools = lists(g.PopPools)
+	if len(missing) > 0 {
+		return g, bmxerror.New(ErrCodeInvalidBackup, fmt.Sprintf("Unknown pools %s", strings.Join(missing, ", ")))
+	}
+	return g, nil
+}
+
+func monitorBody(m cisv1.Monitor) cisv1.MonitorBody {
+	return cisv1.MonitorBody{
+		Description:     m.Description,
+		ExpCodes:        m.ExpCodes,
+		ExpBody:         m.ExpBody,
+		Path:            m.Path,
+		MonType:         m.MonType,
+		Method:          m.Method,
+		Timeout:         m.Timeout,
+		Retries:         m.Retries,
+		Interval:        m.Interval,
+		FollowRedirects: m.FollowRedirects,
+		AllowInsecure:   m.AllowInsecure,
+		Port:            m.Port,
+	}
+}
+
+func poolBody(p cisv1.Pool) cisv1.PoolBody {
+	origins := make([]cisv1.Origin, len(p.Origins))
+	for i, o := range p.Origins {
+		o.Healthy = false
+		origins[i] = o
+	}
+	return cisv1.PoolBody{
+		Name:         p.Name,
+		Description:  p.Description,
+		Origins:      origins,
+		CheckRegions: p.CheckRegions,
+		Enabled:      p.Enabled,
+		MinOrigins:   p.MinOrigins,
+		NotEmail:     p.NotEmail,
+	}
+}
+
+func glbBody(g cisv1.Glb) cisv1.GlbBody {
+	return cisv1.GlbBody{
+		Desc:            g.Desc,
+		Proxied:         g.Proxied,
+		Name:            g.Name,
+		FallbackPool:    g.FallbackPool,
+		DefaultPools:    g.DefaultPools,
+		SessionAffinity: g.SessionAffinity,
+		Ttl:             g.Ttl,
+		Enabled:         g.Enabled,
+		RegionPools:     g.RegionPools,
+		PopPools:        g.PopPools,
+	}
+}
+
+func firewallBody(f cisv1.FirewallRecord) cisv1.FirewallBody {
+	return cisv1.FirewallBody{
+		Description:    f.Description,
+		Urls:           f.Urls,
+		Configurations: f.Configurations,
+		Paused:         f.Paused,
+		Mode:           f.Mode,
+		Notes:          f.Notes,
+		Configuration:  f.Configuration,
+		Priority:       f.Priority,
+	}
+}
+
+//Save writes the backup as indented JSON
+func (b *Backup) Save(w io.Writer) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+//Load reads a backup written by Save and checks its references
+func Load(path string) (*Backup, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	b := &Backup{}
+	if err := json.Unmarshal(data, b); err != nil {
+		return nil, err
+	}
+	return b, b.Validate()
+}
+
+//Validate checks the version and that every reference resolves
+func (b *Backup) Validate() error {
+	if b.Version != FormatVersion {
+		return bmxerror.New(ErrCodeUnsupportedVersion,
+			fmt.Sprintf("Backup version %d is not supported, expected %d", b.Version, FormatVersion))
+	}
+	monitors := map[string]bool{}
+	for _, m := range b.Monitors {
+		if m.Ref == "" || monitors[m.Ref] {
+			return bmxerror.New(ErrCodeInvalidBackup, fmt.Sprintf("Monitor reference %q is empty or repeated", m.Ref))
+		}
+		monitors[m.Ref] = true
+	}
+	pools := map[string]string{}
+	for _, p := range b.Pools {
+		if _, ok := pools[p.Ref]; ok || p.Ref == "" {
+			return bmxerror.New(ErrCodeInvalidBackup, fmt.Sprintf("Pool reference %q is empty or repeated", p.Ref))
+		}
+		if p.MonitorRef != "" && !monitors[p.MonitorRef] {
+			return bmxerror.New(ErrCodeInvalidBackup, fmt.Sprintf("Pool %s uses unknown monitor %s", p.Ref, p.MonitorRef))
+		}
+		pools[p.Ref] = p.Ref
+	}
+	for _, z := range b.Zones {
+		for _, g := range z.Glbs {
+			if _, err := mapGlbPools(g, pools); err != nil {
+				return bmxerror.New(ErrCodeInvalidBackup, fmt.Sprintf("Load balancer %s of %s: %v", g.Name, z.Name, err))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+package cisbackup_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestCisbackup(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Cisbackup Suite")
+}
@@ -0,0 +1,463 @@
+package cisbackup
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/IBM-Cloud/bluemix-go/api/cis/cisv1"
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+)
+
+//store is an in-memory CIS instance shared by the fakes. Zone resources are
+//keyed by zone ID.
+type store struct {
+	seq      int
+	fail     map[string]bool
+	calls    []string
+	monitors []cisv1.Monitor
+	pools    []cisv1.Pool
+	zones    []cisv1.Zone
+	dns      map[string][]cisv1.DnsRecord
+	glbs     map[string][]cisv1.Glb
+	legacy   map[string][]cisv1.FirewallRecord
+	filters  map[string][]cisv1.FirewallFilter
+	rules    map[string][]cisv1.FirewallRule
+	limits   map[string][]cisv1.RateLimitRecord
+	settings map[string][]cisv1.Setting
+}
+
+func newStore() *store {
+	return &store{
+		fail:     map[string]bool{},
+		calls:    []string{},
+		dns:      map[string][]cisv1.DnsRecord{},
+		glbs:     map[string][]cisv1.Glb{},
+		legacy:   map[string][]cisv1.FirewallRecord{},
+		filters:  map[string][]cisv1.FirewallFilter{},
+		rules:    map[string][]cisv1.FirewallRule{},
+		limits:   map[string][]cisv1.RateLimitRecord{},
+		settings: map[string][]cisv1.Setting{},
+	}
+}
+
+func (s *store) id(kind string) string {
+	s.seq++
+	return fmt.Sprintf("%s-%d", kind, s.seq)
+}
+
+func (s *store) call(format string, args ...interface{}) {
+	s.calls = append(s.calls, fmt.Sprintf(format, args...))
+}
+
+func (s *store) writes() []string {
+	writes := []string{}
+	for _, c := range s.calls {
+		if !strings.HasPrefix(c, "list") && !strings.HasPrefix(c, "get") {
+			writes = append(writes, c)
+		}
+	}
+	return writes
+}
+
+type fakeMonitors struct {
+	cisv1.Monitors
+	*store
+}
+
+func (f fakeMonitors) ListMonitors(cisID string) ([]cisv1.Monitor, error) {
+	f.call("list monitors")
+	return f.monitors, nil
+}
+
+func (f fakeMonitors) CreateMonitor(cisID string, body cisv1.MonitorBody) (*cisv1.Monitor, error) {
+	m := cisv1.Monitor{Id: f.id("monitor"), Description: body.Description, MonType: body.MonType, Path: body.Path}
+	f.call("create monitor %s", m.Id)
+	f.monitors = append(f.monitors, m)
+	return &m, nil
+}
+
+type fakePools struct {
+	cisv1.Pools
+	*store
+}
+
+func (f fakePools) ListPools(cisID string) ([]cisv1.Pool, error) {
+	f.call("list pools")
+	return f.pools, nil
+}
+
+func (f fakePools) CreatePool(cisID string, body cisv1.PoolBody) (*cisv1.Pool, error) {
+	if f.fail[body.Name] {
+		return nil, errors.New("create failed")
+	}
+	p := cisv1.Pool{Id: f.id("pool"), Name: body.Name, Monitor: body.Monitor, Origins: body.Origins, Enabled: body.Enabled}
+	f.call("create pool %s monitor %s", p.Id, p.Monitor)
+	f.pools = append(f.pools, p)
+	return &p, nil
+}
+
+type fakeZones struct {
+	cisv1.Zones
+	*store
+}
+
+func (f fakeZones) ListZones(cisID string) ([]cisv1.Zone, error) {
+	f.call("list zones")
+	return f.zones, nil
+}
+
+func (f fakeZones) CreateZone(cisID string, body cisv1.ZoneBody) (*cisv1.Zone, error) {
+	z := cisv1.Zone{Id: f.id("zone"), Name: body.Name}
+	f.call("create zone %s", z.Id)
+	f.zones = append(f.zones, z)
+	//new zones come with default settings
+	f.settings[z.Id] = []cisv1.Setting{{ID: cisv1.SettingSSL, Value: json.RawMessage(`"off"`), Editable: true}}
+	return &z, nil
+}
+
+type fakeDns struct {
+	cisv1.Dns
+	*store
+}
+
+func (f fakeDns) ListDns(cisID, zoneID string) ([]cisv1.DnsRecord, error) {
+	f.call("list dns %s", zoneID)
+	return f.dns[zoneID], nil
+}
+
+func (f fakeDns) CreateDns(cisID, zoneID string, body cisv1.DnsBody) (*cisv1.DnsRecord, error) {
+	if f.fail[body.Name] {
+		return nil, errors.New("create failed")
+	}
+	r := cisv1.DnsRecord{Id: f.id("dns"), Name: body.Name, DnsType: body.DnsType, Content: body.Content, Ttl: body.Ttl}
+	f.call("create dns %s", zoneID)
+	f.dns[zoneID] = append(f.dns[zoneID], r)
+	return &r, nil
+}
+
+type fakeGlbs struct {
+	cisv1.Glbs
+	*store
+}
+
+func (f fakeGlbs) ListGlbs(cisID, zoneID string) ([]cisv1.Glb, error) {
+	f.call("list glbs %s", zoneID)
+	return f.glbs[zoneID], nil
+}
+
+func (f fakeGlbs) CreateGlb(cisID, zoneID string, body cisv1.GlbBody) (*cisv1.Glb, error) {
+	g := cisv1.Glb{Id: f.id("glb"), Name: body.Name, DefaultPools: body.DefaultPools,
+		FallbackPool: body.FallbackPool, RegionPools: body.RegionPools}
+	f.call("create glb %s pools %s fallback %s", zoneID, strings.Join(g.DefaultPools, ","), g.FallbackPool)
+	f.glbs[zoneID] = append(f.glbs[zoneID], g)
+	return &g, nil
+}
+
+type fakeFirewall struct {
+	cisv1.Firewall
+	*store
+}
+
+func (f fakeFirewall) ListFirewall(cisID, zoneID, firewallType string) ([]cisv1.FirewallRecord, error) {
+	f.call("list %s %s", firewallType, zoneID)
+	return f.legacy[zoneID+"/"+firewallType], nil
+}
+
+func (f fakeFirewall) CreateFirewall(cisID, zoneID, firewallType string, body cisv1.FirewallBody) (*cisv1.FirewallRecord, error) {
+	r := cisv1.FirewallRecord{ID: f.id("firewall"), Description: body.Description, Mode: body.Mode}
+	f.call("create %s %s", firewallType, zoneID)
+	f.legacy[zoneID+"/"+firewallType] = append(f.legacy[zoneID+"/"+firewallType], r)
+	return &r, nil
+}
+
+type fakeFirewallRules struct {
+	cisv1.FirewallRules
+	*store
+}
+
+func (f fakeFirewallRules) ListFirewallRules(cisID, zoneID string) ([]cisv1.FirewallRule, error) {
+	f.call("list firewall rules %s", zoneID)
+	return f.rules[zoneID], nil
+}
+
+func (f fakeFirewallRules) GetFilter(cisID, zoneID, filterID string) (*cisv1.FirewallFilter, error) {
+	f.call("get filter %s", filterID)
+	for _, filter := range f.filters[zoneID] {
+		if filter.ID == filterID {
+			return &filter, nil
+		}
+	}
+	return nil, fmt.Errorf("filter %s not found", filterID)
+}
+
+func (f fakeFirewallRules) CreateFilters(cisID, zoneID string, filters []cisv1.FirewallFilter) ([]cisv1.FirewallFilter, error) {
+	created := make([]cisv1.FirewallFilter, len(filters))
+	for i, filter := range filters {
+		filter.ID = f.id("filter")
+		created[i] = filter
+	}
+	f.call("create filters %s", zoneID)
+	f.filters[zoneID] = append(f.filters[zoneID], created...)
+	return created, nil
+}
+
+func (f fakeFirewallRules) CreateFirewallRules(cisID, zoneID string, rules []cisv1.FirewallRule) ([]cisv1.FirewallRule, error) {
+	for _, r := range rules {
+		f.call("create firewall rule %s filter %s", zoneID, r.Filter.ID)
+	}
+	f.rules[zoneID] = append(f.rules[zoneID], rules...)
+	return rules, nil
+}
+
+type fakeRateLimit struct {
+	cisv1.RateLimit
+	*store
+}
+
+func (f fakeRateLimit) ListRateLimit(cisID, zoneID string) ([]cisv1.RateLimitRecord, error) {
+	f.call("list rate limits %s", zoneID)
+	return f.limits[zoneID], nil
+}
+
+func (f fakeRateLimit) CreateRateLimit(cisID, zoneID string, body cisv1.RateLimitRecord) (*cisv1.RateLimitRecord, error) {
+	body.ID = f.id("ratelimit")
+	f.call("create rate limit %s", zoneID)
+	f.limits[zoneID] = append(f.limits[zoneID], body)
+	return &body, nil
+}
+
+type fakeSettings struct {
+	cisv1.Settings
+	*store
+}
+
+func (f fakeSettings) ListSettings(cisID, zoneID string) ([]cisv1.Setting, error) {
+	f.call("list settings %s", zoneID)
+	return f.settings[zoneID], nil
+}
+
+func (f fakeSettings) UpdateSettingValue(cisID, zoneID, setting string, value interface{}) (*cisv1.Setting, error) {
+	f.call("update setting %s %s", zoneID, setting)
+	return &cisv1.Setting{ID: setting}, nil
+}
+
+func newFakeClient(s *store) Client {
+	return Client{
+		Zones:         fakeZones{store: s},
+		Dns:           fakeDns{store: s},
+		Glbs:          fakeGlbs{store: s},
+		Pools:         fakePools{store: s},
+		Monitors:      fakeMonitors{store: s},
+		Firewall:      fakeFirewall{store: s},
+		FirewallRules: fakeFirewallRules{store: s},
+		RateLimit:     fakeRateLimit{store: s},
+		Settings:      fakeSettings{store: s},
+	}
+}
+
+func sourceStore() *store {
+	s := newStore()
+	s.monitors = []cisv1.Monitor{{Id: "m1", Description: "Origin check", MonType: "https", Path: "/health"}}
+	s.pools = []cisv1.Pool{
+		{Id: "p1", Name: "web-primary", Monitor: "m1", Enabled: true,
+			Origins: []cisv1.Origin{{Name: "a", Address: "10.0.0.1", Enabled: true, Weight: 1, Healthy: true}}},
+		{Id: "p2", Name: "web-backup", Enabled: true,
+			Origins: []cisv1.Origin{{Name: "b", Address: "10.0.0.2", Enabled: true, Weight: 1}}},
+	}
+	s.zones = []cisv1.Zone{{Id: "z1", Name: "example.com"}}
+	s.dns["z1"] = []cisv1.DnsRecord{{Id: "d1", Name: "www.example.com", DnsType: "CNAME", Content: "example.com", Ttl: 1}}
+	s.glbs["z1"] = []cisv1.Glb{{Id: "g1", Name: "www.example.com", DefaultPools: []string{"p1"}, FallbackPool: "p2",
+		RegionPools: map[string][]string{"WEU": {"p2", "p1"}}}}
+	s.legacy["z1/access_rules"] = []cisv1.FirewallRecord{{ID: "f1", Description: "office", Mode: "whitelist"}}
+	s.filters["z1"] = []cisv1.FirewallFilter{{ID: "flt1", Expression: `ip.src eq 192.0.2.1`}}
+	s.rules["z1"] = []cisv1.FirewallRule{{ID: "r1", Filter: cisv1.FirewallFilter{ID: "flt1"}, Action: "block"}}
+	s.limits["z1"] = []cisv1.RateLimitRecord{{ID: "l1", Description: "login", Threshold: 10, Period: 60}}
+	s.settings["z1"] = []cisv1.Setting{
+		{ID: cisv1.SettingSSL, Value: json.RawMessage(`"strict"`), Editable: true, ModifiedDate: "2020-01-01"},
+		{ID: cisv1.SettingWAF, Value: json.RawMessage(`"on"`), Editable: false},
+	}
+	return s
+}
+
+var _ = Describe("Cisbackup", func() {
+	var backup *Backup
+	BeforeEach(func() {
+		var err error
+		backup, err = newFakeClient(sourceStore()).Take("source-crn")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Describe("Take", func() {
+		It("should replace IDs with references", func() {
+			Expect(backup.Version).To(Equal(FormatVersion))
+			Expect(backup.Monitors).To(HaveLen(1))
+			Expect(backup.Monitors[0].Ref).To(Equal("monitor:origin-check"))
+			Expect(backup.Pools[0].Ref).To(Equal("pool:web-primary"))
+			Expect(backup.Pools[0].MonitorRef).To(Equal("monitor:origin-check"))
+			Expect(backup.Pools[0].Origins[0].Healthy).To(BeFalse())
+			Expect(backup.Pools[1].MonitorRef).To(BeEmpty())
+			zone := backup.Zones[0]
+			Expect(zone.Glbs[0].DefaultPools).To(Equal([]string{"pool:web-primary"}))
+			Expect(zone.Glbs[0].FallbackPool).To(Equal("pool:web-backup"))
+			Expect(zone.Glbs[0].RegionPools["WEU"]).To(Equal([]string{"pool:web-backup", "pool:web-primary"}))
+		})
+		It("should inline filters and keep only editable settings", func() {
+			zone := backup.Zones[0]
+			Expect(zone.DnsRecords).To(HaveLen(1))
+			Expect(zone.Firewalls).To(Equal([]Firewall{{Type: "access_rules",
+				FirewallBody: cisv1.FirewallBody{Description: "office", Mode: "whitelist"}}}))
+			Expect(zone.FirewallRules[0].ID).To(BeEmpty())
+			Expect(zone.FirewallRules[0].Filter).To(Equal(cisv1.FirewallFilter{Expression: `ip.src eq 192.0.2.1`}))
+			Expect(zone.RateLimits[0].ID).To(BeEmpty())
+			Expect(zone.Settings).To(HaveLen(1))
+			Expect(zone.Settings[0].ID).To(Equal(cisv1.SettingSSL))
+			Expect(zone.Settings[0].ModifiedDate).To(BeEmpty())
+		})
+		It("should make repeated references unique", func() {
+			r := refs{}
+			Expect(r.next("pool", "Web Pool")).To(Equal("pool:web-pool"))
+			Expect(r.next("pool", "web pool")).To(Equal("pool:web-pool-2"))
+			Expect(r.next("monitor", "")).To(Equal("monitor:monitor"))
+		})
+	})
+
+	Describe("Save and Load", func() {
+		var path string
+		BeforeEach(func() {
+			dir, err := ioutil.TempDir("", "cisbackup")
+			Expect(err).NotTo(HaveOccurred())
+			path = filepath.Join(dir, "backup.json")
+		})
+		AfterEach(func() {
+			os.RemoveAll(filepath.Dir(path))
+		})
+		save := func(b *Backup) {
+			buf := &bytes.Buffer{}
+			Expect(b.Save(buf)).To(Succeed())
+			Expect(ioutil.WriteFile(path, buf.Bytes(), 0600)).To(Succeed())
+		}
+		It("should round trip", func() {
+			save(backup)
+			loaded, err := Load(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(loaded.Pools).To(Equal(backup.Pools))
+			Expect(loaded.Zones[0].Glbs).To(Equal(backup.Zones[0].Glbs))
+		})
+		It("should reject other versions", func() {
+			backup.Version = FormatVersion + 1
+			save(backup)
+			_, err := Load(path)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("not supported"))
+		})
+		It("should reject unknown references", func() {
+			backup.Zones[0].Glbs[0].FallbackPool = "pool:missing"
+			Expect(backup.Validate()).To(MatchError(ContainSubstring("pool:missing")))
+			backup.Zones[0].Glbs[0].FallbackPool = "pool:web-backup"
+			backup.Pools[1].MonitorRef = "monitor:missing"
+			Expect(backup.Validate()).To(MatchError(ContainSubstring("monitor:missing")))
+		})
+	})
+
+	Describe("Restore", func() {
+		var target *store
+		BeforeEach(func() {
+			target = newStore()
+			target.seq = 100
+		})
+
+		It("should create resources in dependency order with new IDs", func() {
+			report, err := newFakeClient(target).Restore("target-crn", backup, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Errors).To(BeEmpty())
+			Expect(target.writes()).To(Equal([]string{
+				"create monitor monitor-101",
+				"create pool pool-102 monitor monitor-101",
+				"create pool pool-103 monitor ",
+				"create zone zone-104",
+				"create dns zone-104",
+				"create access_rules zone-104",
+				"create filters zone-104",
+				"create firewall rule zone-104 filter filter-107",
+				"create rate limit zone-104",
+				"update setting zone-104 ssl",
+				"create glb zone-104 pools pool-102 fallback pool-103",
+			}))
+			Expect(target.glbs["zone-104"][0].RegionPools["WEU"]).To(Equal([]string{"pool-103", "pool-102"}))
+			Expect(report.IDs["pool:web-primary"]).To(Equal("pool-102"))
+			Expect(report.Created).To(Equal(map[string]int{KindMonitor: 1, KindPool: 2, KindZone: 1, KindDnsRecord: 1,
+				KindFirewall: 1, KindFirewallRule: 1, KindRateLimit: 1, KindSetting: 1, KindGlb: 1}))
+		})
+
+		It("should reuse what already exists when repeated", func() {
+			client := newFakeClient(target)
+			_, err := client.Restore("target-crn", backup, false)
+			Expect(err).NotTo(HaveOccurred())
+			target.settings["zone-104"] = backup.Zones[0].Settings
+			report, err := client.Restore("target-crn", backup, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Errors).To(BeEmpty())
+			Expect(report.Existing).To(ConsistOf("monitor monitor:origin-check", "pool web-primary", "pool web-backup",
+				"zone example.com", "firewall access_rules office", "firewall_rule block ip.src eq 192.0.2.1",
+				"rate_limit login", "glb www.example.com"))
+			Expect(report.Created).To(BeEmpty())
+			Expect(report.IDs["monitor:origin-check"]).To(Equal("monitor-101"))
+			Expect(target.monitors).To(HaveLen(1))
+			Expect(target.pools).To(HaveLen(2))
+			Expect(target.dns["zone-104"]).To(HaveLen(1))
+			Expect(target.legacy["zone-104/access_rules"]).To(HaveLen(1))
+			Expect(target.rules["zone-104"]).To(HaveLen(1))
+			Expect(target.filters["zone-104"]).To(HaveLen(1))
+			Expect(target.limits["zone-104"]).To(HaveLen(1))
+			Expect(target.glbs["zone-104"]).To(HaveLen(1))
+		})
+
+		It("should not write anything on a dry run", func() {
+			report, err := newFakeClient(target).Restore("target-crn", backup, true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Errors).To(BeEmpty())
+			Expect(target.writes()).To(BeEmpty())
+			Expect(report.Created[KindGlb]).To(Equal(1))
+			Expect(report.Created[KindPool]).To(Equal(2))
+			out := &bytes.Buffer{}
+			report.Print(out)
+			Expect(out.String()).To(ContainSubstring("glb to create: 1"))
+		})
+
+		It("should skip load balancers whose pools failed", func() {
+			target.fail["web-primary"] = true
+			report, err := newFakeClient(target).Restore("target-crn", backup, false)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(bmxerror.Error).Code()).To(Equal(ErrCodeRestoreIncomplete))
+			Expect(report.Errors).To(HaveLen(2))
+			Expect(report.Errors["pool web-primary"]).To(MatchError("create failed"))
+			Expect(report.Errors["glb www.example.com"]).To(MatchError(ContainSubstring("pool:web-primary")))
+			Expect(report.Created[KindDnsRecord]).To(Equal(1))
+			Expect(target.glbs).To(BeEmpty())
+		})
+
+		It("should report each failed resource sharing a name", func() {
+			zone := &backup.Zones[0]
+			record := zone.DnsRecords[0]
+			record.Content = "192.0.2.2"
+			zone.DnsRecords = append(zone.DnsRecords, record)
+			target.fail[record.Name] = true
+			report, err := newFakeClient(target).Restore("target-crn", backup, false)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("2 resources"))
+			Expect(report.Errors).To(HaveLen(2))
+			key := KindDnsRecord + " " + record.DnsType + " " + record.Name
+			Expect(report.Errors).To(HaveKey(key))
+			Expect(report.Errors).To(HaveKey(key + " (2)"))
+		})
+	})
+})
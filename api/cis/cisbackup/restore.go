@@ -0,0 +1,403 @@
+package cisbackup
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/IBM-Cloud/bluemix-go/api/cis/cisv1"
+	"github.com/IBM-Cloud/bluemix-go/api/cis/zonefile"
+	"github.com/IBM-Cloud/bluemix-go/api/cis/zonesettings"
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+)
+
+//Resource kinds of the restore report
+const (
+	KindMonitor      = "monitor"
+	KindPool         = "pool"
+	KindZone         = "zone"
+	KindDnsRecord    = "dns_record"
+	KindGlb          = "glb"
+	KindFirewall     = "firewall"
+	KindFirewallRule = "firewall_rule"
+	KindRateLimit    = "rate_limit"
+	KindSetting      = "setting"
+)
+
+//plannedID stands in for the IDs of resources a dry run would create
+const plannedID = "(planned)"
+
+//Report is the outcome of Restore
+type Report struct {
+	DryRun bool
+	//Created counts the resources created, or to create on a dry run, by
+	//kind
+	Created map[string]int
+	//Existing lists the resources found in the target and left alone
+	Existing []string
+	//IDs maps the references of the backup to the IDs in the target
+	IDs map[string]string
+	//Errors are keyed by "kind name". Resources sharing a name, such as
+	//records of one name or rate limits without a description, get the
+	//suffix " (2)", " (3)" and so on
+	Errors map[string]error
+}
+
+func (r *Report) fail(kind, name string, err error) {
+	key := kind + " " + name
+	for n := 2; r.Errors[key] != nil; n++ {
+		key = fmt.Sprintf("%s %s (%d)", kind, name, n)
+	}
+	r.Errors[key] = err
+}
+
+//contents counts resources by their JSON encoding, to find those a restore
+//can leave alone
+type contents map[string]int
+
+func contentKey(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+func (c contents) add(v interface{}) {
+	c[contentKey(v)]++
+}
+
+//take reports whether an identical resource exists, using it up
+func (c contents) take(v interface{}) bool {
+	key := contentKey(v)
+	if c[key] == 0 {
+		return false
+	}
+	c[key]--
+	return true
+}
+
+//Restore re-creates the backup in cisID. Monitors are created first, then
+//pools, then zones with their records, firewall rules, rate limits and
+//settings, and load balancers last. Zones, pools and load balancers that
+//already exist by name are reused, as are monitors, DNS records, legacy
+//firewalls, firewall rules and rate limits with identical content, so a
+//restore can be repeated after a partial failure. Nothing is written when
+//dryRun is set. Resources that fail are recorded in the report and
+//returned as an ErrCodeRestoreIncomplete error once the others are
+//restored.
+func (c Client) Restore(cisID string, b *Backup, dryRun bool) (Report, error) {
+	report := Report{DryRun: dryRun, Created: map[string]int{}, Existing: []string{},
+		IDs: map[string]string{}, Errors: map[string]error{}}
+	if err := b.Validate(); err != nil {
+		return report, err
+	}
+
+	monitors, err := c.Monitors.ListMonitors(cisID)
+	if err != nil {
+		return report, err
+	}
+	monitorIDs := map[string][]string{}
+	for _, m := range monitors {
+		key := contentKey(monitorBody(m))
+		monitorIDs[key] = append(monitorIDs[key], m.Id)
+	}
+	for _, m := range b.Monitors {
+		key := contentKey(m.MonitorBody)
+		if ids := monitorIDs[key]; len(ids) > 0 {
+			report.IDs[m.Ref], monitorIDs[key] = ids[0], ids[1:]
+			report.Existing = append(report.Existing, KindMonitor+" "+m.Ref)
+			continue
+		}
+		if dryRun {
+			report.IDs[m.Ref] = plannedID
+			report.Created[KindMonitor]++
+			continue
+		}
+		created, err := c.Monitors.CreateMonitor(cisID, m.MonitorBody)
+		if err != nil {
+			report.fail(KindMonitor, m.Ref, err)
+			continue
+		}
+		report.IDs[m.Ref] = created.Id
+		report.Created[KindMonitor]++
+	}
+
+	pools, err := c.Pools.ListPools(cisID)
+	if err != nil {
+		return report, err
+	}
+	poolIDs := map[string]string{}
+	for _, p := range pools {
+		poolIDs[p.Name] = p.Id
+	}
+	for _, p := range b.Pools {
+		if id, ok := poolIDs[p.Name]; ok {
+			report.IDs[p.Ref] = id
+			report.Existing = append(report.Existing, KindPool+" "+p.Name)
+			continue
+		}
+		body := p.PoolBody
+		if p.MonitorRef != "" {
+			if body.Monitor = report.IDs[p.MonitorRef]; body.Monitor == "" {
+				report.fail(KindPool, p.Name, fmt.Errorf("monitor %s was not restored", p.MonitorRef))
+				continue
+			}
+		}
+		if dryRun {
+			report.IDs[p.Ref] = plannedID
+			report.Created[KindPool]++
+			continue
+		}
+		created, err := c.Pools.CreatePool(cisID, body)
+		if err != nil {
+			report.fail(KindPool, p.Name, err)
+			continue
+		}
+		report.IDs[p.Ref] = created.Id
+		report.Created[KindPool]++
+	}
+
+	zones, err := c.Zones.ListZones(cisID)
+	if err != nil {
+		return report, err
+	}
+	zoneIDs := map[string]string{}
+	for _, z := range zones {
+		zoneIDs[z.Name] = z.Id
+	}
+	for _, z := range b.Zones {
+		zoneID, exists := zoneIDs[z.Name]
+		if exists {
+			report.Existing = append(report.Existing, KindZone+" "+z.Name)
+		} else if dryRun {
+			zoneID = plannedID
+			report.Created[KindZone]++
+		} else {
+			created, err := c.Zones.CreateZone(cisID, cisv1.ZoneBody{Name: z.Name})
+			if err != nil {
+				report.fail(KindZone, z.Name, err)
+				continue
+			}
+			zoneID = created.Id
+			report.Created[KindZone]++
+		}
+		report.IDs[KindZone+":"+z.Name] = zoneID
+		if err := c.restoreZone(cisID, zoneID, exists, z, &report); err != nil {
+			report.fail(KindZone, z.Name, err)
+		}
+	}
+	if len(report.Errors) > 0 {
+		return report, bmxerror.New(ErrCodeRestoreIncomplete,
+			fmt.Sprintf("%d resources could not be restored", len(report.Errors)))
+	}
+	return report, nil
+}
+
+//restoreZone restores the resources of one zone. Listing errors abort the
+//zone; creation errors are recorded and the restore goes on.
+func (c Client) restoreZone(cisID, zoneID string, exists bool, z Zone, report *Report) error {
+	plan := report.DryRun
+	count := func(kind string, n int) {
+		if n > 0 {
+			report.Created[kind] += n
+		}
+	}
+
+	existing := map[string]bool{}
+	firewalls, rules, limits := contents{}, contents{}, contents{}
+	if exists {
+		records, err := c.Dns.ListDns(cisID, zoneID)
+		if err != nil {
+			return err
+		}
+		for _, r := range records {
+			existing[zonefile.RecordKey(r)] = true
+		}
+		list, err := c.listFirewalls(cisID, zoneID)
+		if err != nil {
+			return err
+		}
+		for _, f := range list {
+			firewalls.add(f)
+		}
+		ruleList, err := c.listFirewallRules(cisID, zoneID)
+		if err != nil {
+			return err
+		}
+		for _, r := range ruleList {
+			rules.add(r)
+		}
+		limitList, err := c.listRateLimits(cisID, zoneID)
+		if err != nil {
+			return err
+		}
+		for _, l := range limitList {
+			limits.add(l)
+		}
+	}
+	for _, r := range z.DnsRecords {
+		if existing[zonefile.RecordKey(r.Record())] {
+			continue
+		}
+		if plan {
+			count(KindDnsRecord, 1)
+			continue
+		}
+		if _, err := c.Dns.CreateDns(cisID, zoneID, r); err != nil {
+			report.fail(KindDnsRecord, r.DnsType+" "+r.Name, err)
+			continue
+		}
+		count(KindDnsRecord, 1)
+	}
+
+	for _, f := range z.Firewalls {
+		if firewalls.take(f) {
+			report.Existing = append(report.Existing, KindFirewall+" "+f.Type+" "+f.Description)
+			continue
+		}
+		if plan {
+			count(KindFirewall, 1)
+			continue
+		}
+		if _, err := c.Firewall.CreateFirewall(cisID, zoneID, f.Type, f.FirewallBody); err != nil {
+			report.fail(KindFirewall, f.Type+" "+f.Description, err)
+			continue
+		}
+		count(KindFirewall, 1)
+	}
+
+	missing := []cisv1.FirewallRule{}
+	for _, r := range z.FirewallRules {
+		if rules.take(r) {
+			report.Existing = append(report.Existing, KindFirewallRule+" "+r.Action+" "+r.Filter.Expression)
+			continue
+		}
+		missing = append(missing, r)
+	}
+	if len(missing) > 0 {
+		if plan {
+			count(KindFirewallRule, len(missing))
+		} else if err := c.restoreFirewallRules(cisID, zoneID, missing); err != nil {
+			report.fail(KindFirewallRule, z.Name, err)
+		} else {
+			count(KindFirewallRule, len(missing))
+		}
+	}
+
+	for _, l := range z.RateLimits {
+		if limits.take(l) {
+			report.Existing = append(report.Existing, KindRateLimit+" "+l.Description)
+			continue
+		}
+		if plan {
+			count(KindRateLimit, 1)
+			continue
+		}
+		if _, err := c.RateLimit.CreateRateLimit(cisID, zoneID, l); err != nil {
+			report.fail(KindRateLimit, l.Description, err)
+			continue
+		}
+		count(KindRateLimit, 1)
+	}
+
+	if len(z.Settings) > 0 {
+		if !exists && plan {
+			count(KindSetting, len(z.Settings))
+		} else {
+			summary, err := zonesettings.NewRestorer(c.Settings).Restore(cisID, zoneID,
+				zonesettings.Snapshot{Settings: z.Settings}, report.DryRun)
+			//failed updates are in summary.Errors
+			if err != nil && len(summary.Errors) == 0 {
+				return err
+			}
+			if report.DryRun {
+				count(KindSetting, len(summary.Plan.Changes))
+			} else {
+				count(KindSetting, summary.Applied)
+			}
+			for setting, err := range summary.Errors {
+				report.fail(KindSetting, z.Name+" "+setting, err)
+			}
+		}
+	}
+
+	glbNames := map[string]bool{}
+	if exists {
+		glbs, err := c.Glbs.ListGlbs(cisID, zoneID)
+		if err != nil {
+			return err
+		}
+		for _, g := range glbs {
+			glbNames[g.Name] = true
+		}
+	}
+	for _, g := range z.Glbs {
+		if glbNames[g.Name] {
+			report.Existing = append(report.Existing, KindGlb+" "+g.Name)
+			continue
+		}
+		body, err := mapGlbPools(g, report.IDs)
+		if err != nil {
+			report.fail(KindGlb, g.Name, fmt.Errorf("pools were not restored: %v", err))
+			continue
+		}
+		if plan {
+			count(KindGlb, 1)
+			continue
+		}
+		if _, err := c.Glbs.CreateGlb(cisID, zoneID, body); err != nil {
+			report.fail(KindGlb, g.Name, err)
+			continue
+		}
+		count(KindGlb, 1)
+	}
+	return nil
+}
+
+//restoreFirewallRules creates the filters, then the rules using them
+func (c Client) restoreFirewallRules(cisID, zoneID string, rules []cisv1.FirewallRule) error {
+	filters := make([]cisv1.FirewallFilter, len(rules))
+	for i, r := range rules {
+		filters[i] = r.Filter
+	}
+	created, err := c.FirewallRules.CreateFilters(cisID, zoneID, filters)
+	if err != nil {
+		return err
+	}
+	if len(created) != len(rules) {
+		return fmt.Errorf("%d filters created for %d rules", len(created), len(rules))
+	}
+	withIDs := make([]cisv1.FirewallRule, len(rules))
+	for i, r := range rules {
+		r.Filter = cisv1.FirewallFilter{ID: created[i].ID}
+		withIDs[i] = r
+	}
+	_, err = c.FirewallRules.CreateFirewallRules(cisID, zoneID, withIDs)
+	return err
+}
+
+//Print writes what was created and what failed
+func (r Report) Print(w io.Writer) {
+	kinds := make([]string, 0, len(r.Created))
+	for k := range r.Created {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	verb := "created"
+	if r.DryRun {
+		verb = "to create"
+	}
+	for _, k := range kinds {
+		fmt.Fprintf(w, "%s %s: %d\n", k, verb, r.Created[k])
+	}
+	for _, e := range r.Existing {
+		fmt.Fprintf(w, "= %s exists\n", e)
+	}
+	keys := make([]string, 0, len(r.Errors))
+	for k := range r.Errors {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(w, "failed %s: %v\n", k, r.Errors[k])
+	}
+}
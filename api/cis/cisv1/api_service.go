@@ -26,6 +26,11 @@ type CisServiceAPI interface {
 	Dns() Dns
 	Firewall() Firewall
 	RateLimit() RateLimit
+	FirewallRules() FirewallRules
+	Waf() Waf
+	PageRules() PageRules
+	Cache() Cache
+	Certificates() Certificates
 }
 
 //CisService holds the client
@@ -114,6 +119,31 @@ func (c *cisService) RateLimit() RateLimit {
 	return newRateLimitAPI(c.Client)
 }
 
+//FirewallRules implements firewall rules and filters API
+func (c *cisService) FirewallRules() FirewallRules {
+	return newFirewallRulesAPI(c.Client)
+}
+
+//Waf implements WAF packages API
+func (c *cisService) Waf() Waf {
+	return newWafAPI(c.Client)
+}
+
+//PageRules implements page rules API
+func (c *cisService) PageRules() PageRules {
+	return newPageRulesAPI(c.Client)
+}
+
+//Cache implements cache purge API
+func (c *cisService) Cache() Cache {
+	return newCacheAPI(c.Client)
+}
+
+//Certificates implements TLS certificates API
+func (c *cisService) Certificates() Certificates {
+	return newCertificatesAPI(c.Client)
+}
+
 func errorsToString(e []Error) string {
 
 	var errMsg string
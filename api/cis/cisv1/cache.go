@@ -0,0 +1,129 @@
+package cisv1
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+	"github.com/IBM-Cloud/bluemix-go/client"
+)
+
+//ErrCodeInvalidPurge ...
+const ErrCodeInvalidPurge = "InvalidPurge"
+
+//MaxPurgeItems is the number of URLs, tags or hosts a single purge request
+//accepts. Longer lists are sent in batches.
+const MaxPurgeItems = 30
+
+//PurgeBody selects what to purge. Set exactly one field.
+type PurgeBody struct {
+	PurgeEverything bool     `json:"purge_everything,omitempty"`
+	Files           []string `json:"files,omitempty"`
+	Tags            []string `json:"tags,omitempty"`
+	Hosts           []string `json:"hosts,omitempty"`
+}
+
+//PurgeResult ...
+type PurgeResult struct {
+	Result struct {
+		ID string `json:"id"`
+	} `json:"result"`
+	Success  bool     `json:"success"`
+	Errors   []Error  `json:"errors"`
+	Messages []string `json:"messages"`
+}
+
+//Cache purges cached content and sets the cache level and browser TTL of a
+//zone. Batched purges stop at the first failing batch and return the number
+//of items purged before it.
+type Cache interface {
+	PurgeEverything(cisID string, zoneID string) error
+	PurgeByURLs(cisID string, zoneID string, urls []string) (int, error)
+	PurgeByTags(cisID string, zoneID string, tags []string) (int, error)
+	PurgeByHosts(cisID string, zoneID string, hosts []string) (int, error)
+	//SetCacheLevel sets the cache level to basic, simplified or aggressive
+	SetCacheLevel(cisID string, zoneID string, level string) error
+	//SetBrowserCacheTTL sets the browser cache TTL in seconds, 0 respects
+	//the origin headers
+	SetBrowserCacheTTL(cisID string, zoneID string, seconds int) error
+}
+
+type cache struct {
+	client   *client.Client
+	settings Settings
+}
+
+func newCacheAPI(c *client.Client) Cache {
+	return &cache{
+		client:   c,
+		settings: newSettingsAPI(c),
+	}
+}
+
+func (r *cache) purge(cisID string, zoneID string, body PurgeBody) error {
+	result := PurgeResult{}
+	rawURL := fmt.Sprintf("/v1/%s/zones/%s/purge_cache", cisID, zoneID)
+	_, err := r.client.Post(rawURL, &body, &result)
+	return err
+}
+
+func (r *cache) purgeBatches(items []string, what string, send func(batch []string) error) (int, error) {
+	if len(items) == 0 {
+		return 0, bmxerror.New(ErrCodeInvalidPurge, fmt.Sprintf("No %s to purge", what))
+	}
+	purged := 0
+	for start := 0; start < len(items); start += MaxPurgeItems {
+		end := start + MaxPurgeItems
+		if end > len(items) {
+			end = len(items)
+		}
+		if err := send(items[start:end]); err != nil {
+			return purged, err
+		}
+		purged = end
+	}
+	return purged, nil
+}
+
+func (r *cache) PurgeEverything(cisID string, zoneID string) error {
+	return r.purge(cisID, zoneID, PurgeBody{PurgeEverything: true})
+}
+
+func (r *cache) PurgeByURLs(cisID string, zoneID string, urls []string) (int, error) {
+	for _, u := range urls {
+		parsed, err := url.Parse(u)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return 0, bmxerror.New(ErrCodeInvalidPurge, fmt.Sprintf("%q is not an absolute http or https URL", u))
+		}
+	}
+	return r.purgeBatches(urls, "URLs", func(batch []string) error {
+		return r.purge(cisID, zoneID, PurgeBody{Files: batch})
+	})
+}
+
+func (r *cache) PurgeByTags(cisID string, zoneID string, tags []string) (int, error) {
+	return r.purgeBatches(tags, "tags", func(batch []string) error {
+		return r.purge(cisID, zoneID, PurgeBody{Tags: batch})
+	})
+}
+
+func (r *cache) PurgeByHosts(cisID string, zoneID string, hosts []string) (int, error) {
+	for _, h := range hosts {
+		if err := validateName(h); err != nil || h == "@" {
+			return 0, bmxerror.New(ErrCodeInvalidPurge, fmt.Sprintf("%q is not a host name", h))
+		}
+	}
+	return r.purgeBatches(hosts, "hosts", func(batch []string) error {
+		return r.purge(cisID, zoneID, PurgeBody{Hosts: batch})
+	})
+}
+
+func (r *cache) SetCacheLevel(cisID string, zoneID string, level string) error {
+	_, err := r.settings.UpdateSettingValue(cisID, zoneID, SettingCacheLevel, level)
+	return err
+}
+
+func (r *cache) SetBrowserCacheTTL(cisID string, zoneID string, seconds int) error {
+	_, err := r.settings.UpdateSettingValue(cisID, zoneID, SettingBrowserCacheTTL, seconds)
+	return err
+}
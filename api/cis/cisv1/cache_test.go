@@ -0,0 +1,81 @@
+package cisv1
+
+import (
+	"fmt"
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Cache", func() {
+	var server *ghttp.Server
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+	})
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should purge URLs in batches", func() {
+		urls := []string{}
+		for i := 0; i < 65; i++ {
+			urls = append(urls, fmt.Sprintf("https://www.example.com/%d.css", i))
+		}
+		for _, batch := range [][]string{urls[:30], urls[30:60], urls[60:]} {
+			files := ""
+			for i, u := range batch {
+				if i > 0 {
+					files += ","
+				}
+				files += `"` + u + `"`
+			}
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodPost, "/v1/crn/zones/zone/purge_cache"),
+				ghttp.VerifyJSON(`{"files": [`+files+`]}`),
+				ghttp.RespondWith(http.StatusOK, `{"result": {"id": "zone"}, "success": true, "errors": []}`),
+			))
+		}
+		purged, err := newCacheAPI(newTestClient(server.URL())).PurgeByURLs("crn", "zone", urls)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(purged).To(Equal(65))
+		Expect(server.ReceivedRequests()).To(HaveLen(3))
+	})
+
+	It("should purge everything and set cache controls", func() {
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodPost, "/v1/crn/zones/zone/purge_cache"),
+				ghttp.VerifyJSON(`{"purge_everything": true}`),
+				ghttp.RespondWith(http.StatusOK, `{"result": {"id": "zone"}, "success": true}`),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodPatch, "/v1/crn/zones/zone/settings/cache_level"),
+				ghttp.VerifyJSON(`{"value": "aggressive"}`),
+				ghttp.RespondWith(http.StatusOK, `{"result": {"id": "cache_level", "value": "aggressive", "editable": true}, "success": true}`),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodPatch, "/v1/crn/zones/zone/settings/browser_cache_ttl"),
+				ghttp.VerifyJSON(`{"value": 14400}`),
+				ghttp.RespondWith(http.StatusOK, `{"result": {"id": "browser_cache_ttl", "value": 14400, "editable": true}, "success": true}`),
+			),
+		)
+		api := newCacheAPI(newTestClient(server.URL()))
+		Expect(api.PurgeEverything("crn", "zone")).To(Succeed())
+		Expect(api.SetCacheLevel("crn", "zone", "aggressive")).To(Succeed())
+		Expect(api.SetBrowserCacheTTL("crn", "zone", 14400)).To(Succeed())
+		Expect(api.SetBrowserCacheTTL("crn", "zone", 14401)).NotTo(Succeed())
+	})
+
+	It("should reject invalid purges without calling the API", func() {
+		api := newCacheAPI(newTestClient(server.URL()))
+		_, err := api.PurgeByURLs("crn", "zone", []string{"/relative.css"})
+		Expect(err).To(HaveOccurred())
+		_, err = api.PurgeByHosts("crn", "zone", []string{"bad host"})
+		Expect(err).To(HaveOccurred())
+		_, err = api.PurgeByTags("crn", "zone", nil)
+		Expect(err).To(HaveOccurred())
+		Expect(server.ReceivedRequests()).To(BeEmpty())
+	})
+})
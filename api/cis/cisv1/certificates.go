@@ -0,0 +1,421 @@
+package cisv1
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+	"github.com/IBM-Cloud/bluemix-go/client"
+)
+
+//ErrCodeInvalidCertificate ...
+const ErrCodeInvalidCertificate = "InvalidCertificate"
+
+//Bundle methods of custom certificates
+const (
+	BundleUbiquitous = "ubiquitous"
+	BundleOptimal    = "optimal"
+	BundleForce      = "force"
+)
+
+//Origin certificate key types
+const (
+	OriginKeyRSA = "origin-rsa"
+	OriginKeyECC = "origin-ecc"
+)
+
+//Certificate kinds reported by ListExpiries
+const (
+	CertificateCustom    = "custom"
+	CertificateDedicated = "dedicated"
+	CertificateOrigin    = "origin"
+)
+
+//originValidities are the validities in days origin certificates accept
+var originValidities = []int{7, 30, 90, 365, 730, 1095, 5475}
+
+//CustomCertificate is a certificate uploaded to a zone
+type CustomCertificate struct {
+	ID           string     `json:"id"`
+	Hosts        []string   `json:"hosts"`
+	Issuer       string     `json:"issuer,omitempty"`
+	Signature    string     `json:"signature,omitempty"`
+	Status       string     `json:"status,omitempty"`
+	BundleMethod string     `json:"bundle_method,omitempty"`
+	Priority     int        `json:"priority,omitempty"`
+	UploadedOn   *time.Time `json:"uploaded_on,omitempty"`
+	ModifiedOn   *time.Time `json:"modified_on,omitempty"`
+	ExpiresOn    *time.Time `json:"expires_on,omitempty"`
+}
+
+//CustomCertificateBody uploads a certificate and its PEM encoded private key
+type CustomCertificateBody struct {
+	Certificate  string `json:"certificate"`
+	PrivateKey   string `json:"private_key"`
+	BundleMethod string `json:"bundle_method,omitempty"`
+}
+
+//CertificatePack is a dedicated certificate ordered for the zone. It holds
+//one certificate per signature algorithm.
+type CertificatePack struct {
+	ID           string            `json:"id"`
+	Type         string            `json:"type"`
+	Hosts        []string          `json:"hosts"`
+	Status       string            `json:"status,omitempty"`
+	Certificates []PackCertificate `json:"certificates,omitempty"`
+}
+
+//PackCertificate ...
+type PackCertificate struct {
+	ID        string     `json:"id"`
+	Hosts     []string   `json:"hosts"`
+	Issuer    string     `json:"issuer,omitempty"`
+	Signature string     `json:"signature,omitempty"`
+	Status    string     `json:"status,omitempty"`
+	ExpiresOn *time.Time `json:"expires_on,omitempty"`
+}
+
+//OrderCertificateBody ...
+type OrderCertificateBody struct {
+	Type  string   `json:"type"`
+	Hosts []string `json:"hosts"`
+}
+
+//OriginCertificate is a certificate signed by CIS to secure the connection
+//between CIS and the origin
+type OriginCertificate struct {
+	ID                string     `json:"id"`
+	Certificate       string     `json:"certificate"`
+	Hostnames         []string   `json:"hostnames"`
+	RequestType       string     `json:"request_type"`
+	RequestedValidity int        `json:"requested_validity"`
+	CSR               string     `json:"csr,omitempty"`
+	ExpiresOn         *time.Time `json:"expires_on,omitempty"`
+}
+
+//OriginCertificateBody requests an origin certificate for a CSR.
+//RequestedValidity is in days.
+type OriginCertificateBody struct {
+	CSR               string   `json:"csr"`
+	Hostnames         []string `json:"hostnames"`
+	RequestType       string   `json:"request_type"`
+	RequestedValidity int      `json:"requested_validity"`
+}
+
+//CustomCertificateResults ...
+type CustomCertificateResults struct {
+	Certificates []CustomCertificate `json:"result"`
+	ResultsInfo  ResultsCount        `json:"result_info"`
+	Success      bool                `json:"success"`
+	Errors       []Error             `json:"errors"`
+}
+
+//CustomCertificateResult ...
+type CustomCertificateResult struct {
+	Certificate CustomCertificate `json:"result"`
+	Success     bool              `json:"success"`
+	Errors      []Error           `json:"errors"`
+	Messages    []string          `json:"messages"`
+}
+
+//CertificatePackResults ...
+type CertificatePackResults struct {
+	Packs       []CertificatePack `json:"result"`
+	ResultsInfo ResultsCount      `json:"result_info"`
+	Success     bool              `json:"success"`
+	Errors      []Error           `json:"errors"`
+}
+
+//CertificatePackResult ...
+type CertificatePackResult struct {
+	Pack     CertificatePack `json:"result"`
+	Success  bool            `json:"success"`
+	Errors   []Error         `json:"errors"`
+	Messages []string        `json:"messages"`
+}
+
+//OriginCertificateResults ...
+type OriginCertificateResults struct {
+	Certificates []OriginCertificate `json:"result"`
+	Success      bool                `json:"success"`
+	Errors       []Error             `json:"errors"`
+}
+
+//OriginCertificateResult ...
+type OriginCertificateResult struct {
+	Certificate OriginCertificate `json:"result"`
+	Success     bool              `json:"success"`
+	Errors      []Error           `json:"errors"`
+	Messages    []string          `json:"messages"`
+}
+
+//CertificateExpiry tells when a certificate of a zone expires
+type CertificateExpiry struct {
+	//Kind is CertificateCustom, CertificateDedicated or CertificateOrigin
+	Kind      string
+	ID        string
+	Hosts     []string
+	ExpiresOn time.Time
+}
+
+//Remaining returns the time left before expiry, negative once expired
+func (e CertificateExpiry) Remaining(now time.Time) time.Duration {
+	return e.ExpiresOn.Sub(now)
+}
+
+//ExpiringWithin returns the certificates expiring before now+d, including
+//expired ones, soonest first
+func ExpiringWithin(expiries []CertificateExpiry, d time.Duration, now time.Time) []CertificateExpiry {
+	expiring := []CertificateExpiry{}
+	for _, e := range expiries {
+		if e.Remaining(now) < d {
+			expiring = append(expiring, e)
+		}
+	}
+	sort.SliceStable(expiring, func(i, j int) bool { return expiring[i].ExpiresOn.Before(expiring[j].ExpiresOn) })
+	return expiring
+}
+
+//ValidateCustomCertificate checks that the certificate and key are PEM
+//encoded, belong together and that the certificate has not expired. It
+//returns the parsed leaf certificate.
+func ValidateCustomCertificate(body CustomCertificateBody, now time.Time) (*x509.Certificate, error) {
+	switch body.BundleMethod {
+	case "", BundleUbiquitous, BundleOptimal, BundleForce:
+	default:
+		return nil, bmxerror.New(ErrCodeInvalidCertificate, fmt.Sprintf("Unknown bundle method %q", body.BundleMethod))
+	}
+	pair, err := tls.X509KeyPair([]byte(body.Certificate), []byte(body.PrivateKey))
+	if err != nil {
+		return nil, bmxerror.New(ErrCodeInvalidCertificate, fmt.Sprintf("Invalid certificate or key: %v", err))
+	}
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, bmxerror.New(ErrCodeInvalidCertificate, fmt.Sprintf("Invalid certificate: %v", err))
+	}
+	if now.After(leaf.NotAfter) {
+		return nil, bmxerror.New(ErrCodeInvalidCertificate,
+			fmt.Sprintf("The certificate expired on %s", leaf.NotAfter.Format(time.RFC3339)))
+	}
+	return leaf, nil
+}
+
+//NewOriginCertificateRequest generates a private key and a CSR for
+//hostnames. The key stays local; keep keyPEM to install on the origin with
+//the certificate CIS returns.
+func NewOriginCertificateRequest(hostnames []string, requestType string, validityDays int) (body OriginCertificateBody, keyPEM string, err error) {
+	if len(hostnames) == 0 {
+		return body, "", bmxerror.New(ErrCodeInvalidCertificate, "Origin certificates need at least one hostname")
+	}
+	validity := false
+	for _, v := range originValidities {
+		validity = validity || v == validityDays
+	}
+	if !validity {
+		return body, "", bmxerror.New(ErrCodeInvalidCertificate,
+			fmt.Sprintf("Validity must be one of %v days, not %d", originValidities, validityDays))
+	}
+	var key interface{}
+	switch requestType {
+	case OriginKeyRSA:
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	case OriginKeyECC:
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	default:
+		return body, "", bmxerror.New(ErrCodeInvalidCertificate, fmt.Sprintf("Unknown request type %q", requestType))
+	}
+	if err != nil {
+		return body, "", err
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: hostnames[0]},
+		DNSNames: hostnames,
+	}, key)
+	if err != nil {
+		return body, "", err
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return body, "", err
+	}
+	body = OriginCertificateBody{
+		CSR:               string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})),
+		Hostnames:         hostnames,
+		RequestType:       requestType,
+		RequestedValidity: validityDays,
+	}
+	return body, string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})), nil
+}
+
+//Certificates manages the TLS certificates of a zone
+type Certificates interface {
+	ListCustomCertificates(cisID string, zoneID string) ([]CustomCertificate, error)
+	//UploadCustomCertificate validates the certificate locally first
+	UploadCustomCertificate(cisID string, zoneID string, body CustomCertificateBody) (*CustomCertificate, error)
+	DeleteCustomCertificate(cisID string, zoneID string, certificateID string) error
+	ListCertificatePacks(cisID string, zoneID string) ([]CertificatePack, error)
+	OrderCertificate(cisID string, zoneID string, hosts []string) (*CertificatePack, error)
+	DeleteCertificatePack(cisID string, zoneID string, packID string) error
+	ListOriginCertificates(cisID string, zoneID string) ([]OriginCertificate, error)
+	//CreateOriginCertificate signs a CSR, see NewOriginCertificateRequest
+	CreateOriginCertificate(cisID string, zoneID string, body OriginCertificateBody) (*OriginCertificate, error)
+	RevokeOriginCertificate(cisID string, zoneID string, certificateID string) error
+	//ListExpiries returns the expiry of every certificate of the zone
+	ListExpiries(cisID string, zoneID string) ([]CertificateExpiry, error)
+}
+
+type certificates struct {
+	client *client.Client
+}
+
+func newCertificatesAPI(c *client.Client) Certificates {
+	return &certificates{
+		client: c,
+	}
+}
+
+func (r *certificates) ListCustomCertificates(cisID string, zoneID string) ([]CustomCertificate, error) {
+	var certificates []CustomCertificate
+	rawURL := fmt.Sprintf("/v1/%s/zones/%s/custom_certificates?page=1", cisID, zoneID)
+	if _, err := r.client.GetPaginated(rawURL, NewDNSPaginatedResources(CustomCertificate{}), func(resource interface{}) bool {
+		if c, ok := resource.(CustomCertificate); ok {
+			certificates = append(certificates, c)
+			return true
+		}
+		return false
+	}); err != nil {
+		return nil, err
+	}
+	return certificates, nil
+}
+
+func (r *certificates) UploadCustomCertificate(cisID string, zoneID string, body CustomCertificateBody) (*CustomCertificate, error) {
+	if _, err := ValidateCustomCertificate(body, time.Now()); err != nil {
+		return nil, err
+	}
+	result := CustomCertificateResult{}
+	rawURL := fmt.Sprintf("/v1/%s/zones/%s/custom_certificates", cisID, zoneID)
+	_, err := r.client.Post(rawURL, &body, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result.Certificate, nil
+}
+
+func (r *certificates) DeleteCustomCertificate(cisID string, zoneID string, certificateID string) error {
+	rawURL := fmt.Sprintf("/v1/%s/zones/%s/custom_certificates/%s", cisID, zoneID, certificateID)
+	_, err := r.client.Delete(rawURL)
+	return err
+}
+
+func (r *certificates) ListCertificatePacks(cisID string, zoneID string) ([]CertificatePack, error) {
+	var packs []CertificatePack
+	rawURL := fmt.Sprintf("/v1/%s/zones/%s/ssl/certificate_packs?page=1", cisID, zoneID)
+	if _, err := r.client.GetPaginated(rawURL, NewDNSPaginatedResources(CertificatePack{}), func(resource interface{}) bool {
+		if p, ok := resource.(CertificatePack); ok {
+			packs = append(packs, p)
+			return true
+		}
+		return false
+	}); err != nil {
+		return nil, err
+	}
+	return packs, nil
+}
+
+func (r *certificates) OrderCertificate(cisID string, zoneID string, hosts []string) (*CertificatePack, error) {
+	if len(hosts) == 0 {
+		return nil, bmxerror.New(ErrCodeInvalidCertificate, "Dedicated certificates need at least one host")
+	}
+	for _, h := range hosts {
+		if err := validateName(h); err != nil || h == "@" {
+			return nil, bmxerror.New(ErrCodeInvalidCertificate, fmt.Sprintf("%q is not a host name", h))
+		}
+	}
+	result := CertificatePackResult{}
+	rawURL := fmt.Sprintf("/v1/%s/zones/%s/ssl/certificate_packs", cisID, zoneID)
+	_, err := r.client.Post(rawURL, &OrderCertificateBody{Type: CertificateDedicated, Hosts: hosts}, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result.Pack, nil
+}
+
+func (r *certificates) DeleteCertificatePack(cisID string, zoneID string, packID string) error {
+	rawURL := fmt.Sprintf("/v1/%s/zones/%s/ssl/certificate_packs/%s", cisID, zoneID, packID)
+	_, err := r.client.Delete(rawURL)
+	return err
+}
+
+func (r *certificates) ListOriginCertificates(cisID string, zoneID string) ([]OriginCertificate, error) {
+	result := OriginCertificateResults{}
+	rawURL := fmt.Sprintf("/v1/%s/zones/%s/origin_certificates", cisID, zoneID)
+	_, err := r.client.Get(rawURL, &result, nil)
+	if err != nil {
+		return nil, err
+	}
+	return result.Certificates, nil
+}
+
+func (r *certificates) CreateOriginCertificate(cisID string, zoneID string, body OriginCertificateBody) (*OriginCertificate, error) {
+	block, _ := pem.Decode([]byte(body.CSR))
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, bmxerror.New(ErrCodeInvalidCertificate, "The CSR is not a PEM encoded certificate request")
+	}
+	result := OriginCertificateResult{}
+	rawURL := fmt.Sprintf("/v1/%s/zones/%s/origin_certificates", cisID, zoneID)
+	_, err := r.client.Post(rawURL, &body, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result.Certificate, nil
+}
+
+func (r *certificates) RevokeOriginCertificate(cisID string, zoneID string, certificateID string) error {
+	rawURL := fmt.Sprintf("/v1/%s/zones/%s/origin_certificates/%s", cisID, zoneID, certificateID)
+	_, err := r.client.Delete(rawURL)
+	return err
+}
+
+func (r *certificates) ListExpiries(cisID string, zoneID string) ([]CertificateExpiry, error) {
+	expiries := []CertificateExpiry{}
+	custom, err := r.ListCustomCertificates(cisID, zoneID)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range custom {
+		if c.ExpiresOn != nil {
+			expiries = append(expiries, CertificateExpiry{Kind: CertificateCustom, ID: c.ID, Hosts: c.Hosts, ExpiresOn: *c.ExpiresOn})
+		}
+	}
+	packs, err := r.ListCertificatePacks(cisID, zoneID)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range packs {
+		for _, c := range p.Certificates {
+			if c.ExpiresOn != nil {
+				expiries = append(expiries, CertificateExpiry{Kind: CertificateDedicated, ID: p.ID, Hosts: c.Hosts, ExpiresOn: *c.ExpiresOn})
+			}
+		}
+	}
+	origin, err := r.ListOriginCertificates(cisID, zoneID)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range origin {
+		if c.ExpiresOn != nil {
+			expiries = append(expiries, CertificateExpiry{Kind: CertificateOrigin, ID: c.ID, Hosts: c.Hostnames, ExpiresOn: *c.ExpiresOn})
+		}
+	}
+	return expiries, nil
+}
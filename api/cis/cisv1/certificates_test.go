@@ -0,0 +1,110 @@
+package cisv1
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+func selfSigned(notAfter time.Time) (certPEM, keyPEM string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "www.example.com"},
+		DNSNames:     []string{"www.example.com"},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).NotTo(HaveOccurred())
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	Expect(err).NotTo(HaveOccurred())
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
+
+var _ = Describe("Certificates", func() {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	It("should validate custom certificates locally", func() {
+		cert, key := selfSigned(now.Add(90 * 24 * time.Hour))
+		leaf, err := ValidateCustomCertificate(CustomCertificateBody{Certificate: cert, PrivateKey: key}, now)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(leaf.DNSNames).To(ConsistOf("www.example.com"))
+
+		_, otherKey := selfSigned(now.Add(90 * 24 * time.Hour))
+		_, err = ValidateCustomCertificate(CustomCertificateBody{Certificate: cert, PrivateKey: otherKey}, now)
+		Expect(err).To(HaveOccurred())
+
+		expired, expiredKey := selfSigned(now.Add(-time.Hour))
+		_, err = ValidateCustomCertificate(CustomCertificateBody{Certificate: expired, PrivateKey: expiredKey}, now)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("expired"))
+
+		_, err = ValidateCustomCertificate(CustomCertificateBody{Certificate: cert, PrivateKey: key, BundleMethod: "best"}, now)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should generate origin certificate requests", func() {
+		body, keyPEM, err := NewOriginCertificateRequest([]string{"example.com", "*.example.com"}, OriginKeyECC, 365)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(keyPEM).To(ContainSubstring("PRIVATE KEY"))
+		block, _ := pem.Decode([]byte(body.CSR))
+		Expect(block).NotTo(BeNil())
+		csr, err := x509.ParseCertificateRequest(block.Bytes)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(csr.CheckSignature()).To(Succeed())
+		Expect(csr.DNSNames).To(Equal([]string{"example.com", "*.example.com"}))
+
+		_, _, err = NewOriginCertificateRequest([]string{"example.com"}, OriginKeyRSA, 100)
+		Expect(err).To(HaveOccurred())
+		_, _, err = NewOriginCertificateRequest(nil, OriginKeyRSA, 365)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should report certificate expiry", func() {
+		server := ghttp.NewServer()
+		defer server.Close()
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodGet, "/v1/crn/zones/zone/custom_certificates", "page=1"),
+				ghttp.RespondWith(http.StatusOK, `{"result": [{"id": "c0", "hosts": ["api.example.com"], "expires_on": "2027-01-20T00:00:00Z"}],
+					"result_info": {"page": 1, "total_pages": 2}, "success": true}`),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodGet, "/v1/crn/zones/zone/custom_certificates", "page=2"),
+				ghttp.RespondWith(http.StatusOK, `{"result": [{"id": "c1", "hosts": ["www.example.com"], "expires_on": "2026-01-20T00:00:00Z"}],
+					"result_info": {"page": 2, "total_pages": 2}, "success": true}`),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodGet, "/v1/crn/zones/zone/ssl/certificate_packs", "page=1"),
+				ghttp.RespondWith(http.StatusOK, `{"result": [{"id": "p1", "type": "dedicated", "hosts": ["example.com"], "certificates": [
+					{"id": "p1-rsa", "hosts": ["example.com"], "expires_on": "2026-06-01T00:00:00Z"},
+					{"id": "p1-ecc", "hosts": ["example.com"], "expires_on": "2025-12-30T00:00:00Z"}]}], "success": true}`),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodGet, "/v1/crn/zones/zone/origin_certificates"),
+				ghttp.RespondWith(http.StatusOK, `{"result": [{"id": "o1", "hostnames": ["origin.example.com"], "expires_on": "2040-01-01T00:00:00Z"}], "success": true}`),
+			),
+		)
+		expiries, err := newCertificatesAPI(newTestClient(server.URL())).ListExpiries("crn", "zone")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(expiries).To(HaveLen(5))
+		expiring := ExpiringWithin(expiries, 30*24*time.Hour, now)
+		Expect(expiring).To(HaveLen(2))
+		Expect(expiring[0].Kind).To(Equal(CertificateDedicated))
+		Expect(expiring[0].Remaining(now)).To(BeNumerically("<", 0))
+		Expect(expiring[1].ID).To(Equal("c1"))
+	})
+})
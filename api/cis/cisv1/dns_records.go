@@ -0,0 +1,392 @@
+package cisv1
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+)
+
+//ErrCodeInvalidDnsRecord ...
+const ErrCodeInvalidDnsRecord = "InvalidDnsRecord"
+
+//DNS record types
+const (
+	DnsTypeA     = "A"
+	DnsTypeAAAA  = "AAAA"
+	DnsTypeCNAME = "CNAME"
+	DnsTypeNS    = "NS"
+	DnsTypeMX    = "MX"
+	DnsTypeTXT   = "TXT"
+	DnsTypeSRV   = "SRV"
+	DnsTypeCAA   = "CAA"
+	DnsTypeLOC   = "LOC"
+	DnsTypePTR   = "PTR"
+	DnsTypeSPF   = "SPF"
+)
+
+//TTL limits. AutoTTL lets CIS choose the TTL.
+const (
+	AutoTTL = 1
+	MinTTL  = 120
+	MaxTTL  = 86400
+)
+
+//SRVData is the data of SRV records. Name is the domain the service is
+//offered for.
+type SRVData struct {
+	Service  string `json:"service"`
+	Proto    string `json:"proto"`
+	Name     string `json:"name"`
+	Priority int    `json:"priority"`
+	Weight   int    `json:"weight"`
+	Port     int    `json:"port"`
+	Target   string `json:"target"`
+}
+
+//CAAData is the data of CAA records. Tag is issue, issuewild or iodef.
+type CAAData struct {
+	Flags int    `json:"flags"`
+	Tag   string `json:"tag"`
+	Value string `json:"value"`
+}
+
+//LOCData is the data of LOC records. Directions are N or S for latitudes
+//and E or W for longitudes; sizes and altitude are in meters.
+type LOCData struct {
+	LatDegrees    int     `json:"lat_degrees"`
+	LatMinutes    int     `json:"lat_minutes"`
+	LatSeconds    float64 `json:"lat_seconds"`
+	LatDirection  string  `json:"lat_direction"`
+	LongDegrees   int     `json:"long_degrees"`
+	LongMinutes   int     `json:"long_minutes"`
+	LongSeconds   float64 `json:"long_seconds"`
+	LongDirection string  `json:"long_direction"`
+	Altitude      float64 `json:"altitude"`
+	Size          float64 `json:"size"`
+	PrecisionHorz float64 `json:"precision_horz"`
+	PrecisionVert float64 `json:"precision_vert"`
+}
+
+//UnmarshalJSON decodes Data into SRVData, CAAData or LOCData according to
+//the record type
+func (r *DnsRecord) UnmarshalJSON(b []byte) error {
+	type plain DnsRecord
+	aux := struct {
+		*plain
+		Data json.RawMessage `json:"data,omitempty"`
+	}{plain: (*plain)(r)}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+	data, err := decodeDnsData(r.DnsType, aux.Data)
+	if err != nil {
+		return err
+	}
+	r.Data = data
+	return nil
+}
+
+func decodeDnsData(dnsType string, raw []byte) (interface{}, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var err error
+	switch dnsType {
+	case DnsTypeSRV:
+		d := SRVData{}
+		err = json.Unmarshal(raw, &d)
+		return d, err
+	case DnsTypeCAA:
+		d := CAAData{}
+		err = json.Unmarshal(raw, &d)
+		return d, err
+	case DnsTypeLOC:
+		d := LOCData{}
+		err = json.Unmarshal(raw, &d)
+		return d, err
+	}
+	//Numbers are kept as json.Number, as ListDns has always decoded them
+	var d interface{}
+	dc := json.NewDecoder(bytes.NewReader(raw))
+	dc.UseNumber()
+	err = dc.Decode(&d)
+	return d, err
+}
+
+//convertData copies data, whatever its Go type, into out
+func convertData(data interface{}, out interface{}) error {
+	switch d := data.(type) {
+	case nil:
+		return fmt.Errorf("record has no data")
+	case SRVData:
+		*out.(*SRVData) = d
+		return nil
+	case CAAData:
+		*out.(*CAAData) = d
+		return nil
+	case LOCData:
+		*out.(*LOCData) = d
+		return nil
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, out)
+}
+
+func typedData(dnsType, want string, data interface{}, out interface{}) error {
+	if dnsType != want {
+		return bmxerror.New(ErrCodeInvalidDnsRecord, fmt.Sprintf("%s record has no %s data", dnsType, want))
+	}
+	if err := convertData(data, out); err != nil {
+		return bmxerror.New(ErrCodeInvalidDnsRecord, fmt.Sprintf("Invalid %s data: %v", want, err))
+	}
+	return nil
+}
+
+//SRV returns the data of an SRV record
+func (r DnsRecord) SRV() (SRVData, error) {
+	d := SRVData{}
+	return d, typedData(r.DnsType, DnsTypeSRV, r.Data, &d)
+}
+
+//CAA returns the data of a CAA record
+func (r DnsRecord) CAA() (CAAData, error) {
+	d := CAAData{}
+	return d, typedData(r.DnsType, DnsTypeCAA, r.Data, &d)
+}
+
+//LOC returns the data of a LOC record
+func (r DnsRecord) LOC() (LOCData, error) {
+	d := LOCData{}
+	return d, typedData(r.DnsType, DnsTypeLOC, r.Data, &d)
+}
+
+//SRV returns the data of an SRV record
+func (b DnsBody) SRV() (SRVData, error) {
+	d := SRVData{}
+	return d, typedData(b.DnsType, DnsTypeSRV, b.Data, &d)
+}
+
+//CAA returns the data of a CAA record
+func (b DnsBody) CAA() (CAAData, error) {
+	d := CAAData{}
+	return d, typedData(b.DnsType, DnsTypeCAA, b.Data, &d)
+}
+
+//LOC returns the data of a LOC record
+func (b DnsBody) LOC() (LOCData, error) {
+	d := LOCData{}
+	return d, typedData(b.DnsType, DnsTypeLOC, b.Data, &d)
+}
+
+//Body returns the fields of the record that can be sent back with
+//CreateDns or UpdateDns
+func (r DnsRecord) Body() DnsBody {
+	return DnsBody{
+		Name:     r.Name,
+		DnsType:  r.DnsType,
+		Content:  r.Content,
+		Priority: r.Priority,
+		Data:     r.Data,
+		Proxied:  r.Proxied,
+		Ttl:      r.Ttl,
+	}
+}
+
+//Record returns the body as a record without an ID, to compare or format
+//it like live records
+func (b DnsBody) Record() DnsRecord {
+	return DnsRecord{
+		Name:     b.Name,
+		DnsType:  b.DnsType,
+		Content:  b.Content,
+		Priority: b.Priority,
+		Data:     b.Data,
+		Proxied:  b.Proxied,
+		Ttl:      b.Ttl,
+	}
+}
+
+//NewARecord ...
+func NewARecord(name, ip string, ttl int, proxied bool) (DnsBody, error) {
+	return validBody(DnsBody{Name: name, DnsType: DnsTypeA, Content: ip, Ttl: ttl, Proxied: proxied})
+}
+
+//NewAAAARecord ...
+func NewAAAARecord(name, ip string, ttl int, proxied bool) (DnsBody, error) {
+	return validBody(DnsBody{Name: name, DnsType: DnsTypeAAAA, Content: ip, Ttl: ttl, Proxied: proxied})
+}
+
+//NewCNAMERecord ...
+func NewCNAMERecord(name, target string, ttl int, proxied bool) (DnsBody, error) {
+	return validBody(DnsBody{Name: name, DnsType: DnsTypeCNAME, Content: target, Ttl: ttl, Proxied: proxied})
+}
+
+//NewNSRecord ...
+func NewNSRecord(name, nameServer string, ttl int) (DnsBody, error) {
+	return validBody(DnsBody{Name: name, DnsType: DnsTypeNS, Content: nameServer, Ttl: ttl})
+}
+
+//NewPTRRecord ...
+func NewPTRRecord(name, target string, ttl int) (DnsBody, error) {
+	return validBody(DnsBody{Name: name, DnsType: DnsTypePTR, Content: target, Ttl: ttl})
+}
+
+//NewMXRecord ...
+func NewMXRecord(name, mailServer string, priority, ttl int) (DnsBody, error) {
+	return validBody(DnsBody{Name: name, DnsType: DnsTypeMX, Content: mailServer, Priority: priority, Ttl: ttl})
+}
+
+//NewTXTRecord ...
+func NewTXTRecord(name, text string, ttl int) (DnsBody, error) {
+	return validBody(DnsBody{Name: name, DnsType: DnsTypeTXT, Content: text, Ttl: ttl})
+}
+
+//NewSRVRecord returns an SRV record named _service._proto.name
+func NewSRVRecord(data SRVData, ttl int) (DnsBody, error) {
+	return validBody(DnsBody{
+		Name:     fmt.Sprintf("%s.%s.%s", data.Service, data.Proto, data.Name),
+		DnsType:  DnsTypeSRV,
+		Priority: data.Priority,
+		Data:     data,
+		Ttl:      ttl,
+	})
+}
+
+//NewCAARecord ...
+func NewCAARecord(name string, data CAAData, ttl int) (DnsBody, error) {
+	return validBody(DnsBody{Name: name, DnsType: DnsTypeCAA, Data: data, Ttl: ttl})
+}
+
+//NewLOCRecord ...
+func NewLOCRecord(name string, data LOCData, ttl int) (DnsBody, error) {
+	return validBody(DnsBody{Name: name, DnsType: DnsTypeLOC, Data: data, Ttl: ttl})
+}
+
+func validBody(b DnsBody) (DnsBody, error) {
+	if b.Ttl == 0 {
+		b.Ttl = AutoTTL
+	}
+	return b, b.Validate()
+}
+
+//Validate checks the name, TTL and data of the record before it is sent.
+//Names may be relative to the zone, and @ is the zone apex.
+func (b DnsBody) Validate() error {
+	problems := []string{}
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+	if err := validateName(b.Name); err != nil {
+		add("%v", err)
+	}
+	if b.Ttl != 0 && b.Ttl != AutoTTL && (b.Ttl < MinTTL || b.Ttl > MaxTTL) {
+		add("TTL %d must be %d for automatic or between %d and %d", b.Ttl, AutoTTL, MinTTL, MaxTTL)
+	}
+	if b.Proxied && b.DnsType != DnsTypeA && b.DnsType != DnsTypeAAAA && b.DnsType != DnsTypeCNAME {
+		add("%s records cannot be proxied", b.DnsType)
+	}
+	switch b.DnsType {
+	case DnsTypeA, DnsTypeAAAA:
+		ip := net.ParseIP(b.Content)
+		if ip == nil || strings.Contains(b.Content, ":") != (b.DnsType == DnsTypeAAAA) {
+			add("%q is not an IPv%s address", b.Content, map[string]string{DnsTypeA: "4", DnsTypeAAAA: "6"}[b.DnsType])
+		}
+	case DnsTypeCNAME, DnsTypeNS, DnsTypePTR, DnsTypeMX:
+		if err := validateName(b.Content); err != nil || b.Content == "@" {
+			add("%q is not a valid target", b.Content)
+		}
+		if b.DnsType == DnsTypeMX && (b.Priority < 0 || b.Priority > 65535) {
+			add("MX priority %d must be between 0 and 65535", b.Priority)
+		}
+	case DnsTypeTXT, DnsTypeSPF:
+		if b.Content == "" {
+			add("%s records need content", b.DnsType)
+		}
+	case DnsTypeSRV:
+		d, err := b.SRV()
+		if err != nil {
+			add("%v", err)
+			break
+		}
+		if !strings.HasPrefix(d.Service, "_") || !strings.HasPrefix(d.Proto, "_") {
+			add("SRV service %q and protocol %q must start with _", d.Service, d.Proto)
+		}
+		for field, v := range map[string]int{"priority": d.Priority, "weight": d.Weight, "port": d.Port} {
+			if v < 0 || v > 65535 {
+				add("SRV %s %d must be between 0 and 65535", field, v)
+			}
+		}
+		if err := validateName(d.Target); err != nil {
+			add("%q is not a valid SRV target", d.Target)
+		}
+	case DnsTypeCAA:
+		d, err := b.CAA()
+		if err != nil {
+			add("%v", err)
+			break
+		}
+		if d.Flags < 0 || d.Flags > 255 {
+			add("CAA flags %d must be between 0 and 255", d.Flags)
+		}
+		if d.Tag != "issue" && d.Tag != "issuewild" && d.Tag != "iodef" {
+			add("CAA tag %q must be issue, issuewild or iodef", d.Tag)
+		}
+	case DnsTypeLOC:
+		d, err := b.LOC()
+		if err != nil {
+			add("%v", err)
+			break
+		}
+		if d.LatDegrees < 0 || d.LatDegrees > 90 || d.LongDegrees < 0 || d.LongDegrees > 180 {
+			add("LOC degrees are out of range")
+		}
+		if d.LatMinutes < 0 || d.LatMinutes > 59 || d.LongMinutes < 0 || d.LongMinutes > 59 ||
+			d.LatSeconds < 0 || d.LatSeconds >= 60 || d.LongSeconds < 0 || d.LongSeconds >= 60 {
+			add("LOC minutes and seconds must be below 60")
+		}
+		if (d.LatDirection != "N" && d.LatDirection != "S") || (d.LongDirection != "E" && d.LongDirection != "W") {
+			add("LOC directions must be N or S and E or W")
+		}
+	case "":
+		add("the record type is required")
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return bmxerror.New(ErrCodeInvalidDnsRecord,
+		fmt.Sprintf("Invalid %s record %s: %s", b.DnsType, b.Name, strings.Join(problems, "; ")))
+}
+
+//validateName checks a domain name. A leading * label is allowed, and
+//labels may contain _ as in service and DKIM names.
+func validateName(name string) error {
+	if name == "@" {
+		return nil
+	}
+	name = strings.TrimSuffix(name, ".")
+	if name == "" || len(name) > 253 {
+		return fmt.Errorf("name %q must have between 1 and 253 characters", name)
+	}
+	for i, label := range strings.Split(name, ".") {
+		if label == "*" && i == 0 {
+			continue
+		}
+		if label == "" || len(label) > 63 {
+			return fmt.Errorf("name %q has an empty or too long label", name)
+		}
+		for j, c := range label {
+			ok := c == '-' || c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+			if !ok || (c == '-' && (j == 0 || j == len(label)-1)) {
+				return fmt.Errorf("name %q has an invalid label %q", name, label)
+			}
+		}
+	}
+	return nil
+}
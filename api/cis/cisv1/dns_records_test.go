@@ -0,0 +1,124 @@
+package cisv1
+
+import (
+	"encoding/json"
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("DnsRecords", func() {
+	Describe("constructors", func() {
+		It("should build valid records", func() {
+			srv, err := NewSRVRecord(SRVData{
+				Service: "_sip", Proto: "_tcp", Name: "example.com",
+				Priority: 10, Weight: 5, Port: 5060, Target: "sip.example.com",
+			}, 0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(srv.Name).To(Equal("_sip._tcp.example.com"))
+			Expect(srv.Ttl).To(Equal(AutoTTL))
+			Expect(srv.Priority).To(Equal(10))
+
+			mx, err := NewMXRecord("@", "mail.example.com", 10, 3600)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(mx.Priority).To(Equal(10))
+
+			_, err = NewCAARecord("example.com", CAAData{Tag: "issue", Value: "letsencrypt.org"}, 300)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = NewLOCRecord("office.example.com", LOCData{
+				LatDegrees: 52, LatMinutes: 22, LatSeconds: 23, LatDirection: "N",
+				LongDegrees: 4, LongMinutes: 53, LongSeconds: 32, LongDirection: "E",
+				Altitude: -2, Size: 1, PrecisionHorz: 10000, PrecisionVert: 10,
+			}, 0)
+			Expect(err).NotTo(HaveOccurred())
+		})
+		It("should reject invalid records", func() {
+			_, err := NewARecord("www.example.com", "2001:db8::1", 300, false)
+			Expect(err).To(HaveOccurred())
+
+			_, err = NewTXTRecord("-bad-.example.com", "text", 60)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("invalid label"))
+			Expect(err.Error()).To(ContainSubstring("TTL 60"))
+
+			_, err = NewMXRecord("example.com", "mail.example.com", 70000, 0)
+			Expect(err).To(HaveOccurred())
+
+			_, err = NewCAARecord("example.com", CAAData{Tag: "issuer", Value: "x"}, 0)
+			Expect(err).To(HaveOccurred())
+
+			_, err = NewSRVRecord(SRVData{Service: "sip", Proto: "_tcp", Name: "example.com", Target: "sip.example.com"}, 0)
+			Expect(err).To(HaveOccurred())
+
+			_, err = NewTXTRecord("example.com", "text", 0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(DnsBody{Name: "example.com", DnsType: DnsTypeTXT, Content: "x", Proxied: true}.Validate()).To(HaveOccurred())
+		})
+		It("should allow wildcards and underscores", func() {
+			Expect(DnsBody{Name: "*.example.com", DnsType: DnsTypeA, Content: "192.0.2.1"}.Validate()).To(Succeed())
+			Expect(DnsBody{Name: "_dmarc.example.com", DnsType: DnsTypeTXT, Content: "v=DMARC1"}.Validate()).To(Succeed())
+		})
+	})
+
+	Describe("decoding", func() {
+		It("should decode data into the typed struct of the record type", func() {
+			records := []DnsRecord{}
+			Expect(json.Unmarshal([]byte(`[
+				{"id": "1", "type": "SRV", "name": "_sip._tcp.example.com",
+				 "data": {"service": "_sip", "proto": "_tcp", "name": "example.com", "priority": 10, "weight": 5, "port": 5060, "target": "sip.example.com"}},
+				{"id": "2", "type": "CAA", "name": "example.com", "data": {"flags": 0, "tag": "issue", "value": "letsencrypt.org"}},
+				{"id": "3", "type": "A", "name": "example.com", "content": "192.0.2.1"}
+			]`), &records)).To(Succeed())
+			Expect(records[0].Data).To(BeAssignableToTypeOf(SRVData{}))
+			srv, err := records[0].SRV()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(srv.Port).To(Equal(5060))
+			caa, err := records[1].CAA()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(caa.Value).To(Equal("letsencrypt.org"))
+			Expect(records[2].Data).To(BeNil())
+			_, err = records[2].SRV()
+			Expect(err).To(HaveOccurred())
+		})
+		It("should convert hand built maps", func() {
+			body := DnsBody{DnsType: DnsTypeCAA, Data: map[string]interface{}{"flags": 128, "tag": "iodef", "value": "mailto:sec@example.com"}}
+			caa, err := body.CAA()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(caa.Flags).To(Equal(128))
+		})
+	})
+
+	Describe("ListDns", func() {
+		var server *ghttp.Server
+		BeforeEach(func() {
+			server = ghttp.NewServer()
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodGet, "/v1/crn/zones/zone/dns_records"),
+					ghttp.RespondWith(http.StatusOK, `{
+						"result": [{"id": "1", "type": "LOC", "name": "office.example.com",
+							"data": {"lat_degrees": 52, "lat_minutes": 22, "lat_seconds": 23.5, "lat_direction": "N",
+								"long_degrees": 4, "long_minutes": 53, "long_seconds": 32, "long_direction": "E",
+								"altitude": 0, "size": 1, "precision_horz": 10000, "precision_vert": 10}}],
+						"result_info": {"page": 1, "total_pages": 1},
+						"success": true
+					}`),
+				),
+			)
+		})
+		AfterEach(func() {
+			server.Close()
+		})
+		It("should return typed data", func() {
+			records, err := newDns(server.URL()).ListDns("crn", "zone")
+			Expect(err).NotTo(HaveOccurred())
+			loc, err := records[0].LOC()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(loc.LatSeconds).To(Equal(23.5))
+			Expect(records[0].Body().Validate()).To(Succeed())
+		})
+	})
+})
@@ -0,0 +1,399 @@
+package cisv1
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+)
+
+//ErrCodeInvalidExpression ...
+const ErrCodeInvalidExpression = "InvalidFilterExpression"
+
+//Field types of filter expressions
+const (
+	FieldString = "string"
+	FieldInt    = "int"
+	FieldIP     = "ip"
+	FieldBool   = "bool"
+)
+
+//ExpressionFields are the fields whose operators and values are type
+//checked. Other fields, such as http.request.headers, are passed through.
+var ExpressionFields = map[string]string{
+	"cf.client.bot":                   FieldBool,
+	"cf.edge.server_ip":               FieldIP,
+	"cf.edge.server_port":             FieldInt,
+	"cf.threat_score":                 FieldInt,
+	"http.cookie":                     FieldString,
+	"http.host":                       FieldString,
+	"http.referer":                    FieldString,
+	"http.request.full_uri":           FieldString,
+	"http.request.method":             FieldString,
+	"http.request.uri":                FieldString,
+	"http.request.uri.path":           FieldString,
+	"http.request.uri.query":          FieldString,
+	"http.request.version":            FieldString,
+	"http.user_agent":                 FieldString,
+	"http.x_forwarded_for":            FieldString,
+	"ip.src":                          FieldIP,
+	"ip.geoip.asnum":                  FieldInt,
+	"ip.geoip.continent":              FieldString,
+	"ip.geoip.country":                FieldString,
+	"ip.geoip.is_in_european_union":   FieldBool,
+	"ip.geoip.subdivision_1_iso_code": FieldString,
+	"ssl":                             FieldBool,
+}
+
+//expressionFunctions take and return a string field. Calls to other
+//functions, such as starts_with, len or any, are passed through.
+var expressionFunctions = map[string]bool{
+	"lower":      true,
+	"upper":      true,
+	"url_decode": true,
+}
+
+//comparison operators, by the field types accepting them
+var expressionOperators = map[string][]string{
+	"eq": {FieldString, FieldInt, FieldIP, FieldBool}, "==": {FieldString, FieldInt, FieldIP, FieldBool},
+	"ne": {FieldString, FieldInt, FieldIP, FieldBool}, "!=": {FieldString, FieldInt, FieldIP, FieldBool},
+	"lt": {FieldInt}, "<": {FieldInt},
+	"le": {FieldInt}, "<=": {FieldInt},
+	"gt": {FieldInt}, ">": {FieldInt},
+	"ge": {FieldInt}, ">=": {FieldInt},
+	"contains": {FieldString},
+	"matches":  {FieldString}, "~": {FieldString},
+	"in": {FieldString, FieldInt, FieldIP},
+}
+
+type exprToken struct {
+	kind  string //ident, string, number, op, punct, eof
+	value string
+	pos   int
+}
+
+type exprParser struct {
+	expr   string
+	tokens []exprToken
+	i      int
+	//calls counts the function calls whose arguments are being read
+	calls int
+}
+
+//ValidateExpression checks the syntax of a firewall filter expression, such
+//as
+//
+//	(http.request.uri.path contains "/admin" and not ip.src in {192.0.2.0/24})
+//
+//Operators and value types are checked locally for ExpressionFields. Other
+//fields, which may be indexed as in http.request.headers["x-api-key"], are
+//accepted with any operator and literal value, and so are the results of
+//functions other than lower, upper and url_decode, whose arguments are only
+//checked as values or expressions; the API remains the final judge.
+func ValidateExpression(expr string) error {
+	if strings.TrimSpace(expr) == "" {
+		return bmxerror.New(ErrCodeInvalidExpression, "The expression is empty")
+	}
+	tokens, err := lexExpression(expr)
+	if err != nil {
+		return err
+	}
+	p := &exprParser{expr: expr, tokens: tokens}
+	if err := p.parseOr(); err != nil {
+		return err
+	}
+	if t := p.peek(); t.kind != "eof" {
+		return p.errorf(t, "unexpected %q", t.value)
+	}
+	return nil
+}
+
+func lexExpression(expr string) ([]exprToken, error) {
+	tokens := []exprToken{}
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"':
+			start := i
+			var b strings.Builder
+			i++
+			for ; i < len(expr) && expr[i] != '"'; i++ {
+				if expr[i] == '\\' && i+1 < len(expr) {
+					i++
+				}
+				b.WriteByte(expr[i])
+			}
+			if i == len(expr) {
+				return nil, bmxerror.New(ErrCodeInvalidExpression, fmt.Sprintf("Unterminated string at %d", start+1))
+			}
+			i++
+			tokens = append(tokens, exprToken{kind: "string", value: b.String(), pos: start})
+		case strings.IndexByte("(){}[],", c) >= 0:
+			tokens = append(tokens, exprToken{kind: "punct", value: string(c), pos: i})
+			i++
+		case strings.IndexByte("=!<>~&|^", c) >= 0:
+			start := i
+			for i < len(expr) && strings.IndexByte("=!<>~&|^", expr[i]) >= 0 && i-start < 2 {
+				i++
+			}
+			op := expr[start:i]
+			switch op {
+			case "==", "!=", "<", "<=", ">", ">=", "~", "&&", "||", "^^", "!":
+			default:
+				//a lone ! followed by another operator character, such as !(
+				if op[0] == '!' {
+					op, i = "!", start+1
+					break
+				}
+				return nil, bmxerror.New(ErrCodeInvalidExpression, fmt.Sprintf("Unknown operator %q at %d", op, start+1))
+			}
+			tokens = append(tokens, exprToken{kind: "op", value: op, pos: start})
+		default:
+			start := i
+			for i < len(expr) && strings.IndexByte(" \t\n\r\"(){}[],=!<>~&|^", expr[i]) < 0 {
+				i++
+			}
+			word := expr[start:i]
+			kind := "ident"
+			if _, err := strconv.Atoi(word); err == nil {
+				kind = "number"
+			} else if isIPLiteral(word) {
+				kind = "ip"
+			}
+			tokens = append(tokens, exprToken{kind: kind, value: word, pos: start})
+		}
+	}
+	return append(tokens, exprToken{kind: "eof", value: "end of expression", pos: len(expr)}), nil
+}
+
+func isIPLiteral(s string) bool {
+	if net.ParseIP(s) != nil {
+		return true
+	}
+	_, _, err := net.ParseCIDR(s)
+	return err == nil
+}
+
+func (p *exprParser) peek() exprToken {
+	return p.tokens[p.i]
+}
+
+func (p *exprParser) next() exprToken {
+	t := p.tokens[p.i]
+	if t.kind != "eof" {
+		p.i++
+	}
+	return t
+}
+
+func (p *exprParser) accept(values ...string) bool {
+	t := p.peek()
+	if t.kind != "ident" && t.kind != "op" {
+		return false
+	}
+	for _, v := range values {
+		if t.value == v {
+			p.i++
+			return true
+		}
+	}
+	return false
+}
+
+func (p *exprParser) errorf(t exprToken, format string, args ...interface{}) error {
+	return bmxerror.New(ErrCodeInvalidExpression, fmt.Sprintf("At %d: %s", t.pos+1, fmt.Sprintf(format, args...)))
+}
+
+func (p *exprParser) parseOr() error {
+	return p.parseBinary([]string{"or", "||"}, func() error {
+		return p.parseBinary([]string{"xor", "^^"}, func() error {
+			return p.parseBinary([]string{"and", "&&"}, p.parseUnary)
+		})
+	})
+}
+
+func (p *exprParser) parseBinary(ops []string, operand func() error) error {
+	if err := operand(); err != nil {
+		return err
+	}
+	for p.accept(ops...) {
+		if err := operand(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *exprParser) parseUnary() error {
+	if p.accept("not", "!") {
+		return p.parseUnary()
+	}
+	t := p.next()
+	if t.kind == "punct" && t.value == "(" {
+		if err := p.parseOr(); err != nil {
+			return err
+		}
+		if c := p.next(); c.value != ")" {
+			return p.errorf(c, "expected ) but found %q", c.value)
+		}
+		return nil
+	}
+	return p.parseComparison(t)
+}
+
+func (p *exprParser) parseComparison(t exprToken) error {
+	fieldType, err := p.parseField(t)
+	if err != nil {
+		return err
+	}
+	op := p.peek()
+	allowed, ok := expressionOperators[op.value]
+	if !ok || (op.kind != "ident" && op.kind != "op") {
+		if fieldType == FieldBool || fieldType == "" {
+			return nil
+		}
+		//fields of any type can be passed to functions
+		if p.calls > 0 && op.kind == "punct" && (op.value == "," || op.value == ")") {
+			return nil
+		}
+		return p.errorf(op, "expected an operator after %s but found %q", t.value, op.value)
+	}
+	p.i++
+	if fieldType != "" && !containsString(allowed, fieldType) {
+		return p.errorf(op, "%s cannot be used with %s fields", op.value, fieldType)
+	}
+	if op.value == "in" {
+		return p.parseSet(fieldType)
+	}
+	if op.value == "matches" || op.value == "~" {
+		v := p.next()
+		if v.kind != "string" {
+			return p.errorf(v, "matches needs a quoted regular expression")
+		}
+		return nil
+	}
+	return p.parseValue(fieldType)
+}
+
+//parseField reads a field or a function call, either of which may be
+//indexed, and returns its type
+func (p *exprParser) parseField(t exprToken) (string, error) {
+	if t.kind != "ident" {
+		return "", p.errorf(t, "expected a field but found %q", t.value)
+	}
+	//unknown fields have no type and are not checked
+	fieldType := ExpressionFields[t.value]
+	switch o := p.peek(); {
+	case expressionFunctions[t.value]:
+		if o := p.next(); o.value != "(" {
+			return "", p.errorf(o, "expected ( after %s", t.value)
+		}
+		arg := p.next()
+		argType, err := p.parseField(arg)
+		if err != nil {
+			return "", err
+		}
+		if argType != FieldString && argType != "" {
+			return "", p.errorf(arg, "%s takes a string field", t.value)
+		}
+		if c := p.next(); c.value != ")" {
+			return "", p.errorf(c, "expected ) but found %q", c.value)
+		}
+		fieldType = FieldString
+	case o.kind == "punct" && o.value == "(":
+		p.next()
+		if err := p.parseArguments(t); err != nil {
+			return "", err
+		}
+		fieldType = ""
+	}
+	for p.peek().value == "[" {
+		p.next()
+		if i := p.next(); i.kind != "string" && i.kind != "number" && i.value != "*" {
+			return "", p.errorf(i, "expected a key or an index but found %q", i.value)
+		}
+		if c := p.next(); c.value != "]" {
+			return "", p.errorf(c, "expected ] but found %q", c.value)
+		}
+	}
+	return fieldType, nil
+}
+
+//parseArguments reads the comma separated arguments of a call to fn up to
+//the closing parenthesis. Literals are accepted as they are, anything else
+//must be an expression.
+func (p *exprParser) parseArguments(fn exprToken) error {
+	if c := p.peek(); c.kind == "punct" && c.value == ")" {
+		p.next()
+		return nil
+	}
+	p.calls++
+	defer func() { p.calls-- }()
+	for {
+		switch a := p.peek(); a.kind {
+		case "string", "number", "ip":
+			p.next()
+		default:
+			if err := p.parseOr(); err != nil {
+				return err
+			}
+		}
+		c := p.next()
+		if c.kind == "punct" && c.value == ")" {
+			return nil
+		}
+		if c.kind != "punct" || c.value != "," {
+			return p.errorf(c, "expected , or ) in the arguments of %s but found %q", fn.value, c.value)
+		}
+	}
+}
+
+func (p *exprParser) parseValue(fieldType string) error {
+	v := p.next()
+	switch fieldType {
+	case FieldString:
+		if v.kind != "string" {
+			return p.errorf(v, "expected a quoted string but found %q", v.value)
+		}
+	case FieldInt:
+		if v.kind != "number" {
+			return p.errorf(v, "expected a number but found %q", v.value)
+		}
+	case FieldIP:
+		if v.kind != "ip" {
+			return p.errorf(v, "expected an IP address or CIDR but found %q", v.value)
+		}
+	case FieldBool:
+		if v.value != "true" && v.value != "false" {
+			return p.errorf(v, "expected true or false but found %q", v.value)
+		}
+	default:
+		if v.kind != "string" && v.kind != "number" && v.kind != "ip" && v.value != "true" && v.value != "false" {
+			return p.errorf(v, "expected a value but found %q", v.value)
+		}
+	}
+	return nil
+}
+
+func (p *exprParser) parseSet(fieldType string) error {
+	if o := p.next(); o.value != "{" {
+		return p.errorf(o, "expected { after in")
+	}
+	if p.peek().value == "}" {
+		return p.errorf(p.peek(), "the set is empty")
+	}
+	for p.peek().value != "}" {
+		if p.peek().kind == "eof" {
+			return p.errorf(p.peek(), "expected }")
+		}
+		if err := p.parseValue(fieldType); err != nil {
+			return err
+		}
+	}
+	p.next()
+	return nil
+}
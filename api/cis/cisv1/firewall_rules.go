@@ -0,0 +1,247 @@
+package cisv1
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+	"github.com/IBM-Cloud/bluemix-go/client"
+)
+
+//ErrCodeInvalidFirewallRule ...
+const ErrCodeInvalidFirewallRule = "InvalidFirewallRule"
+
+//Firewall rule actions
+const (
+	FirewallActionAllow       = "allow"
+	FirewallActionBlock       = "block"
+	FirewallActionChallenge   = "challenge"
+	FirewallActionJSChallenge = "js_challenge"
+	FirewallActionLog         = "log"
+	FirewallActionBypass      = "bypass"
+)
+
+//FirewallFilter is an expression matching requests, see ValidateExpression
+type FirewallFilter struct {
+	ID          string `json:"id,omitempty"`
+	Expression  string `json:"expression,omitempty"`
+	Paused      bool   `json:"paused"`
+	Description string `json:"description,omitempty"`
+	Ref         string `json:"ref,omitempty"`
+}
+
+//FirewallRule applies an action to the requests matched by a filter. On
+//create the filter is referenced by ID.
+type FirewallRule struct {
+	ID          string         `json:"id,omitempty"`
+	Filter      FirewallFilter `json:"filter"`
+	Action      string         `json:"action"`
+	Priority    int            `json:"priority,omitempty"`
+	Paused      bool           `json:"paused"`
+	Description string         `json:"description,omitempty"`
+	//Products are bypassed when Action is bypass, such as waf or rateLimit
+	Products   []string   `json:"products,omitempty"`
+	CreatedOn  *time.Time `json:"created_on,omitempty"`
+	ModifiedOn *time.Time `json:"modified_on,omitempty"`
+}
+
+//FirewallFilterResults ...
+type FirewallFilterResults struct {
+	Filters     []FirewallFilter `json:"result"`
+	ResultsInfo ResultsCount     `json:"result_info"`
+	Success     bool             `json:"success"`
+	Errors      []Error          `json:"errors"`
+}
+
+//FirewallFilterResult ...
+type FirewallFilterResult struct {
+	Filter   FirewallFilter `json:"result"`
+	Success  bool           `json:"success"`
+	Errors   []Error        `json:"errors"`
+	Messages []string       `json:"messages"`
+}
+
+//FirewallRuleResults ...
+type FirewallRuleResults struct {
+	Rules       []FirewallRule `json:"result"`
+	ResultsInfo ResultsCount   `json:"result_info"`
+	Success     bool           `json:"success"`
+	Errors      []Error        `json:"errors"`
+}
+
+//FirewallRuleResult ...
+type FirewallRuleResult struct {
+	Rule     FirewallRule `json:"result"`
+	Success  bool         `json:"success"`
+	Errors   []Error      `json:"errors"`
+	Messages []string     `json:"messages"`
+}
+
+//Validate checks the action and, when the filter is inlined, its expression
+func (r FirewallRule) Validate() error {
+	switch r.Action {
+	case FirewallActionAllow, FirewallActionBlock, FirewallActionChallenge, FirewallActionJSChallenge, FirewallActionLog:
+		if len(r.Products) > 0 {
+			return bmxerror.New(ErrCodeInvalidFirewallRule, "Products can only be set on bypass rules")
+		}
+	case FirewallActionBypass:
+		if len(r.Products) == 0 {
+			return bmxerror.New(ErrCodeInvalidFirewallRule, "Bypass rules need the products to bypass")
+		}
+	default:
+		return bmxerror.New(ErrCodeInvalidFirewallRule, fmt.Sprintf("Unknown firewall rule action %q", r.Action))
+	}
+	if r.Filter.ID == "" && r.Filter.Expression == "" {
+		return bmxerror.New(ErrCodeInvalidFirewallRule, "The rule has no filter")
+	}
+	if r.Filter.Expression != "" {
+		return ValidateExpression(r.Filter.Expression)
+	}
+	return nil
+}
+
+//FirewallRules manages firewall rules and the filters they use. Create
+//methods validate their input before calling the API.
+type FirewallRules interface {
+	ListFilters(cisID string, zoneID string) ([]FirewallFilter, error)
+	GetFilter(cisID string, zoneID string, filterID string) (*FirewallFilter, error)
+	CreateFilters(cisID string, zoneID string, filters []FirewallFilter) ([]FirewallFilter, error)
+	UpdateFilter(cisID string, zoneID string, filterID string, filter FirewallFilter) (*FirewallFilter, error)
+	DeleteFilter(cisID string, zoneID string, filterID string) error
+
+	ListFirewallRules(cisID string, zoneID string) ([]FirewallRule, error)
+	GetFirewallRule(cisID string, zoneID string, ruleID string) (*FirewallRule, error)
+	CreateFirewallRules(cisID string, zoneID string, rules []FirewallRule) ([]FirewallRule, error)
+	UpdateFirewallRule(cisID string, zoneID string, ruleID string, rule FirewallRule) (*FirewallRule, error)
+	DeleteFirewallRule(cisID string, zoneID string, ruleID string) error
+}
+
+type firewallRules struct {
+	client *client.Client
+}
+
+func newFirewallRulesAPI(c *client.Client) FirewallRules {
+	return &firewallRules{
+		client: c,
+	}
+}
+
+func (r *firewallRules) ListFilters(cisID string, zoneID string) ([]FirewallFilter, error) {
+	var filters []FirewallFilter
+	rawURL := fmt.Sprintf("/v1/%s/zones/%s/filters?page=1", cisID, zoneID)
+	if _, err := r.client.GetPaginated(rawURL, NewDNSPaginatedResources(FirewallFilter{}), func(resource interface{}) bool {
+		if f, ok := resource.(FirewallFilter); ok {
+			filters = append(filters, f)
+			return true
+		}
+		return false
+	}); err != nil {
+		return nil, err
+	}
+	return filters, nil
+}
+
+func (r *firewallRules) GetFilter(cisID string, zoneID string, filterID string) (*FirewallFilter, error) {
+	result := FirewallFilterResult{}
+	rawURL := fmt.Sprintf("/v1/%s/zones/%s/filters/%s", cisID, zoneID, filterID)
+	_, err := r.client.Get(rawURL, &result, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &result.Filter, nil
+}
+
+func (r *firewallRules) CreateFilters(cisID string, zoneID string, filters []FirewallFilter) ([]FirewallFilter, error) {
+	for _, f := range filters {
+		if err := ValidateExpression(f.Expression); err != nil {
+			return nil, err
+		}
+	}
+	result := FirewallFilterResults{}
+	rawURL := fmt.Sprintf("/v1/%s/zones/%s/filters", cisID, zoneID)
+	_, err := r.client.Post(rawURL, &filters, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result.Filters, nil
+}
+
+func (r *firewallRules) UpdateFilter(cisID string, zoneID string, filterID string, filter FirewallFilter) (*FirewallFilter, error) {
+	if err := ValidateExpression(filter.Expression); err != nil {
+		return nil, err
+	}
+	filter.ID = filterID
+	result := FirewallFilterResult{}
+	rawURL := fmt.Sprintf("/v1/%s/zones/%s/filters/%s", cisID, zoneID, filterID)
+	_, err := r.client.Put(rawURL, &filter, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result.Filter, nil
+}
+
+func (r *firewallRules) DeleteFilter(cisID string, zoneID string, filterID string) error {
+	rawURL := fmt.Sprintf("/v1/%s/zones/%s/filters/%s", cisID, zoneID, filterID)
+	_, err := r.client.Delete(rawURL)
+	return err
+}
+
+func (r *firewallRules) ListFirewallRules(cisID string, zoneID string) ([]FirewallRule, error) {
+	var rules []FirewallRule
+	rawURL := fmt.Sprintf("/v1/%s/zones/%s/firewall/rules?page=1", cisID, zoneID)
+	if _, err := r.client.GetPaginated(rawURL, NewDNSPaginatedResources(FirewallRule{}), func(resource interface{}) bool {
+		if rule, ok := resource.(FirewallRule); ok {
+			rules = append(rules, rule)
+			return true
+		}
+		return false
+	}); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (r *firewallRules) GetFirewallRule(cisID string, zoneID string, ruleID string) (*FirewallRule, error) {
+	result := FirewallRuleResult{}
+	rawURL := fmt.Sprintf("/v1/%s/zones/%s/firewall/rules/%s", cisID, zoneID, ruleID)
+	_, err := r.client.Get(rawURL, &result, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &result.Rule, nil
+}
+
+func (r *firewallRules) CreateFirewallRules(cisID string, zoneID string, rules []FirewallRule) ([]FirewallRule, error) {
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	result := FirewallRuleResults{}
+	rawURL := fmt.Sprintf("/v1/%s/zones/%s/firewall/rules", cisID, zoneID)
+	_, err := r.client.Post(rawURL, &rules, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result.Rules, nil
+}
+
+func (r *firewallRules) UpdateFirewallRule(cisID string, zoneID string, ruleID string, rule FirewallRule) (*FirewallRule, error) {
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+	rule.ID = ruleID
+	result := FirewallRuleResult{}
+	rawURL := fmt.Sprintf("/v1/%s/zones/%s/firewall/rules/%s", cisID, zoneID, ruleID)
+	_, err := r.client.Put(rawURL, &rule, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result.Rule, nil
+}
+
+func (r *firewallRules) DeleteFirewallRule(cisID string, zoneID string, ruleID string) error {
+	rawURL := fmt.Sprintf("/v1/%s/zones/%s/firewall/rules/%s", cisID, zoneID, ruleID)
+	_, err := r.client.Delete(rawURL)
+	return err
+}
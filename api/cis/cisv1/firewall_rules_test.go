@@ -0,0 +1,113 @@
+package cisv1
+
+import (
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	bluemix "github.com/IBM-Cloud/bluemix-go"
+	"github.com/IBM-Cloud/bluemix-go/client"
+	bluemixHttp "github.com/IBM-Cloud/bluemix-go/http"
+	"github.com/IBM-Cloud/bluemix-go/session"
+)
+
+func newTestClient(url string) *client.Client {
+	sess, err := session.New()
+	Expect(err).NotTo(HaveOccurred())
+	conf := sess.Config.Copy()
+	conf.HTTPClient = bluemixHttp.NewHTTPClient(conf)
+	conf.Endpoint = &url
+	return &client.Client{Config: conf, ServiceName: bluemix.CisService}
+}
+
+var _ = Describe("FirewallRules", func() {
+	Describe("ValidateExpression", func() {
+		It("should accept valid expressions", func() {
+			for _, expr := range []string{
+				`http.request.uri.path contains "/admin"`,
+				`(ip.geoip.country in {"CN" "RU"} and not cf.client.bot) or cf.threat_score gt 50`,
+				`ip.src in {192.0.2.0/24 2001:db8::/32} && !ssl`,
+				`lower(http.user_agent) matches "curl.*"`,
+				`http.request.method eq "POST" xor ip.geoip.asnum == 64496`,
+				`ssl`,
+				`http.request.headers["x-api-key"][0] eq "secret" or cf.bot_management.score lt 30`,
+				`http.request.uri.args["id"][0] in {"1" "2"} and not cf.bot_management.verified_bot`,
+				`lower(http.request.headers["user-agent"][0]) contains "bot"`,
+				`starts_with(http.request.uri.path, "/api") and not ends_with(http.host, ".internal")`,
+				`len(http.request.body.raw) gt 1024`,
+				`any(http.request.headers.names[*] == "x-debug")`,
+				`any(lower(http.request.headers.values[*])[*] contains "curl")`,
+			} {
+				Expect(ValidateExpression(expr)).To(Succeed(), expr)
+			}
+		})
+		It("should reject invalid expressions", func() {
+			for expr, message := range map[string]string{
+				``:                                       "empty",
+				`http.request.uri.path contains "/admin`: "Unterminated string",
+				`http.request.path eq ip.src`:            "expected a value",
+				`http.request.headers["a" eq "b"`:        "expected ]",
+				`cf.threat_score contains "1"`:           "contains cannot be used with int fields",
+				`ip.src eq "192.0.2.1"`:                  "expected an IP address",
+				`(http.host eq "example.com"`:            "expected )",
+				`http.host eq "a" and`:                   "expected a field",
+				`http.host "a"`:                          "expected an operator",
+				`ip.geoip.country in {}`:                 "the set is empty",
+				`http.host eq "a" http.host eq "b"`:      "unexpected",
+				`lower(cf.threat_score) eq "1"`:          "lower takes a string field",
+				`http.request.uri matches /admin/`:       "quoted regular expression",
+				`starts_with(http.host, "a" "b")`:        "expected , or ) in the arguments of starts_with",
+				`any(http.host eq)`:                      "expected a quoted string",
+			} {
+				err := ValidateExpression(expr)
+				Expect(err).To(HaveOccurred(), expr)
+				Expect(err.Error()).To(ContainSubstring(message), expr)
+			}
+		})
+	})
+
+	Describe("API", func() {
+		var server *ghttp.Server
+		BeforeEach(func() {
+			server = ghttp.NewServer()
+		})
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should create filters and rules", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodPost, "/v1/crn/zones/zone/filters"),
+					ghttp.VerifyJSON(`[{"expression": "ip.src eq 192.0.2.1", "paused": false}]`),
+					ghttp.RespondWith(http.StatusOK, `{"result": [{"id": "f1", "expression": "ip.src eq 192.0.2.1", "paused": false}], "success": true, "errors": []}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodPost, "/v1/crn/zones/zone/firewall/rules"),
+					ghttp.VerifyJSON(`[{"filter": {"id": "f1", "paused": false}, "action": "block", "paused": false}]`),
+					ghttp.RespondWith(http.StatusOK, `{"result": [{"id": "r1", "filter": {"id": "f1", "expression": "ip.src eq 192.0.2.1"}, "action": "block"}], "success": true, "errors": []}`),
+				),
+			)
+			api := newFirewallRulesAPI(newTestClient(server.URL()))
+			filters, err := api.CreateFilters("crn", "zone", []FirewallFilter{{Expression: "ip.src eq 192.0.2.1"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(filters[0].ID).To(Equal("f1"))
+			rules, err := api.CreateFirewallRules("crn", "zone", []FirewallRule{{Filter: FirewallFilter{ID: "f1"}, Action: FirewallActionBlock}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rules[0].Filter.Expression).To(Equal("ip.src eq 192.0.2.1"))
+		})
+
+		It("should validate rules before sending them", func() {
+			api := newFirewallRulesAPI(newTestClient(server.URL()))
+			_, err := api.CreateFirewallRules("crn", "zone", []FirewallRule{{Filter: FirewallFilter{ID: "f1"}, Action: "deny"}})
+			Expect(err).To(HaveOccurred())
+			_, err = api.CreateFirewallRules("crn", "zone", []FirewallRule{{Filter: FirewallFilter{ID: "f1"}, Action: FirewallActionBypass}})
+			Expect(err).To(HaveOccurred())
+			_, err = api.CreateFilters("crn", "zone", []FirewallFilter{{Expression: "ip.src eq"}})
+			Expect(err).To(HaveOccurred())
+			Expect(server.ReceivedRequests()).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,343 @@
+package cisv1
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+	"github.com/IBM-Cloud/bluemix-go/client"
+)
+
+//ErrCodeInvalidPageRule ...
+const ErrCodeInvalidPageRule = "InvalidPageRule"
+
+//Page rule statuses
+const (
+	PageRuleActive   = "active"
+	PageRuleDisabled = "disabled"
+)
+
+//Page rule action IDs
+const (
+	PageActionAlwaysOnline            = "always_online"
+	PageActionAlwaysUseHTTPS          = "always_use_https"
+	PageActionAutomaticHTTPSRewrites  = "automatic_https_rewrites"
+	PageActionBrowserCacheTTL         = "browser_cache_ttl"
+	PageActionBrowserCheck            = "browser_check"
+	PageActionCacheLevel              = "cache_level"
+	PageActionDisableApps             = "disable_apps"
+	PageActionDisablePerformance      = "disable_performance"
+	PageActionDisableSecurity         = "disable_security"
+	PageActionEdgeCacheTTL            = "edge_cache_ttl"
+	PageActionEmailObfuscation        = "email_obfuscation"
+	PageActionForwardingURL           = "forwarding_url"
+	PageActionIPGeolocation           = "ip_geolocation"
+	PageActionMinify                  = "minify"
+	PageActionOpportunisticEncryption = "opportunistic_encryption"
+	PageActionSecurityLevel           = "security_level"
+	PageActionServerSideExclude       = "server_side_exclude"
+	PageActionSSL                     = "ssl"
+	PageActionWAF                     = "waf"
+)
+
+//pageActionValues lists the values of actions taking one of a fixed set of
+//strings
+var pageActionValues = map[string][]string{
+	PageActionAlwaysOnline:            onOff,
+	PageActionAutomaticHTTPSRewrites:  onOff,
+	PageActionBrowserCheck:            onOff,
+	PageActionEmailObfuscation:        onOff,
+	PageActionIPGeolocation:           onOff,
+	PageActionOpportunisticEncryption: onOff,
+	PageActionServerSideExclude:       onOff,
+	PageActionWAF:                     onOff,
+	PageActionCacheLevel:              {"bypass", "basic", "simplified", "aggressive", "cache_everything"},
+	PageActionSecurityLevel:           {"essentially_off", "low", "medium", "high", "under_attack"},
+	PageActionSSL:                     {"off", "flexible", "full", "strict"},
+}
+
+//PageRuleConstraint ...
+type PageRuleConstraint struct {
+	Operator string `json:"operator"`
+	Value    string `json:"value"`
+}
+
+//PageRuleTarget selects the URLs a page rule applies to
+type PageRuleTarget struct {
+	Target     string             `json:"target"`
+	Constraint PageRuleConstraint `json:"constraint"`
+}
+
+//ForwardingURL is the value of forwarding_url actions. StatusCode is 301 or
+//302.
+type ForwardingURL struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code"`
+}
+
+//PageRuleAction is one setting applied by a page rule. Value is nil for
+//actions without a value, a ForwardingURL, a Minify, an int for TTLs and a
+//string otherwise; reads decode it into the same types.
+type PageRuleAction struct {
+	ID    string      `json:"id"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+//PageRule ...
+type PageRule struct {
+	ID         string           `json:"id,omitempty"`
+	Targets    []PageRuleTarget `json:"targets"`
+	Actions    []PageRuleAction `json:"actions"`
+	Priority   int              `json:"priority,omitempty"`
+	Status     string           `json:"status,omitempty"`
+	CreatedOn  *time.Time       `json:"created_on,omitempty"`
+	ModifiedOn *time.Time       `json:"modified_on,omitempty"`
+}
+
+//PageRuleResults ...
+type PageRuleResults struct {
+	PageRules []PageRule `json:"result"`
+	Success   bool       `json:"success"`
+	Errors    []Error    `json:"errors"`
+	Messages  []string   `json:"messages"`
+}
+
+//PageRuleResult ...
+type PageRuleResult struct {
+	PageRule PageRule `json:"result"`
+	Success  bool     `json:"success"`
+	Errors   []Error  `json:"errors"`
+	Messages []string `json:"messages"`
+}
+
+//URLTarget matches URLs against pattern, where * is a wildcard
+func URLTarget(pattern string) PageRuleTarget {
+	return PageRuleTarget{Target: "url", Constraint: PageRuleConstraint{Operator: "matches", Value: pattern}}
+}
+
+//ForwardingURLAction redirects to url
+func ForwardingURLAction(url string, statusCode int) PageRuleAction {
+	return PageRuleAction{ID: PageActionForwardingURL, Value: ForwardingURL{URL: url, StatusCode: statusCode}}
+}
+
+//AlwaysUseHTTPSAction ...
+func AlwaysUseHTTPSAction() PageRuleAction {
+	return PageRuleAction{ID: PageActionAlwaysUseHTTPS}
+}
+
+//DisableSecurityAction ...
+func DisableSecurityAction() PageRuleAction {
+	return PageRuleAction{ID: PageActionDisableSecurity}
+}
+
+//CacheLevelAction ...
+func CacheLevelAction(level string) PageRuleAction {
+	return PageRuleAction{ID: PageActionCacheLevel, Value: level}
+}
+
+//EdgeCacheTTLAction ...
+func EdgeCacheTTLAction(seconds int) PageRuleAction {
+	return PageRuleAction{ID: PageActionEdgeCacheTTL, Value: seconds}
+}
+
+//BrowserCacheTTLAction ...
+func BrowserCacheTTLAction(seconds int) PageRuleAction {
+	return PageRuleAction{ID: PageActionBrowserCacheTTL, Value: seconds}
+}
+
+//SSLAction ...
+func SSLAction(mode string) PageRuleAction {
+	return PageRuleAction{ID: PageActionSSL, Value: mode}
+}
+
+//SecurityLevelAction ...
+func SecurityLevelAction(level string) PageRuleAction {
+	return PageRuleAction{ID: PageActionSecurityLevel, Value: level}
+}
+
+//MinifyAction ...
+func MinifyAction(m Minify) PageRuleAction {
+	return PageRuleAction{ID: PageActionMinify, Value: m}
+}
+
+//ToggleAction turns an on/off action such as always_online on or off
+func ToggleAction(id string, on bool) PageRuleAction {
+	if on {
+		return PageRuleAction{ID: id, Value: SettingOn}
+	}
+	return PageRuleAction{ID: id, Value: SettingOff}
+}
+
+//UnmarshalJSON decodes the value into the type matching the action ID
+func (a *PageRuleAction) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		ID    string          `json:"id"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	a.ID, a.Value = raw.ID, nil
+	if len(raw.Value) == 0 || string(raw.Value) == "null" {
+		return nil
+	}
+	var err error
+	switch raw.ID {
+	case PageActionForwardingURL:
+		v := ForwardingURL{}
+		err = json.Unmarshal(raw.Value, &v)
+		a.Value = v
+	case PageActionMinify:
+		v := Minify{}
+		err = json.Unmarshal(raw.Value, &v)
+		a.Value = v
+	case PageActionBrowserCacheTTL, PageActionEdgeCacheTTL:
+		var v int
+		err = json.Unmarshal(raw.Value, &v)
+		a.Value = v
+	default:
+		var v interface{}
+		err = json.Unmarshal(raw.Value, &v)
+		a.Value = v
+	}
+	return err
+}
+
+//Validate checks the rule locally before it is sent
+func (p PageRule) Validate() error {
+	if len(p.Targets) != 1 || p.Targets[0].Constraint.Value == "" {
+		return bmxerror.New(ErrCodeInvalidPageRule, "A page rule needs exactly one URL target")
+	}
+	if p.Status != "" && p.Status != PageRuleActive && p.Status != PageRuleDisabled {
+		return bmxerror.New(ErrCodeInvalidPageRule, fmt.Sprintf("Unknown page rule status %q", p.Status))
+	}
+	if len(p.Actions) == 0 {
+		return bmxerror.New(ErrCodeInvalidPageRule, "A page rule needs at least one action")
+	}
+	seen := map[string]bool{}
+	for _, a := range p.Actions {
+		if seen[a.ID] {
+			return bmxerror.New(ErrCodeInvalidPageRule, fmt.Sprintf("Action %s is set twice", a.ID))
+		}
+		seen[a.ID] = true
+		if (a.ID == PageActionForwardingURL || a.ID == PageActionAlwaysUseHTTPS) && len(p.Actions) > 1 {
+			return bmxerror.New(ErrCodeInvalidPageRule, fmt.Sprintf("Action %s cannot be combined with other actions", a.ID))
+		}
+		if err := a.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a PageRuleAction) validate() error {
+	invalid := func(format string, args ...interface{}) error {
+		return bmxerror.New(ErrCodeInvalidPageRule, fmt.Sprintf("Action %s: ", a.ID)+fmt.Sprintf(format, args...))
+	}
+	switch a.ID {
+	case PageActionForwardingURL:
+		f, ok := a.Value.(ForwardingURL)
+		if !ok {
+			return invalid("value must be a ForwardingURL")
+		}
+		if f.URL == "" {
+			return invalid("the URL is empty")
+		}
+		if f.StatusCode != 301 && f.StatusCode != 302 {
+			return invalid("status code must be 301 or 302, not %d", f.StatusCode)
+		}
+	case PageActionAlwaysUseHTTPS, PageActionDisableApps, PageActionDisablePerformance, PageActionDisableSecurity:
+		if a.Value != nil {
+			return invalid("takes no value")
+		}
+	case PageActionBrowserCacheTTL, PageActionEdgeCacheTTL:
+		if ttl, ok := a.Value.(int); !ok || ttl < 0 {
+			return invalid("value must be a number of seconds")
+		}
+	case PageActionMinify:
+		if err := ValidateSetting(SettingMinify, a.Value); err != nil {
+			return invalid("%v", err)
+		}
+	default:
+		values, ok := pageActionValues[a.ID]
+		if !ok {
+			return nil
+		}
+		if s, _ := a.Value.(string); !containsString(values, s) {
+			return invalid("value must be one of %v", values)
+		}
+	}
+	return nil
+}
+
+//PageRules ...
+type PageRules interface {
+	ListPageRules(cisID string, zoneID string) ([]PageRule, error)
+	GetPageRule(cisID string, zoneID string, pageRuleID string) (*PageRule, error)
+	CreatePageRule(cisID string, zoneID string, pageRule PageRule) (*PageRule, error)
+	UpdatePageRule(cisID string, zoneID string, pageRuleID string, pageRule PageRule) (*PageRule, error)
+	DeletePageRule(cisID string, zoneID string, pageRuleID string) error
+}
+
+type pageRules struct {
+	client *client.Client
+}
+
+func newPageRulesAPI(c *client.Client) PageRules {
+	return &pageRules{
+		client: c,
+	}
+}
+
+func (r *pageRules) ListPageRules(cisID string, zoneID string) ([]PageRule, error) {
+	result := PageRuleResults{}
+	rawURL := fmt.Sprintf("/v1/%s/zones/%s/pagerules", cisID, zoneID)
+	_, err := r.client.Get(rawURL, &result, nil)
+	if err != nil {
+		return nil, err
+	}
+	return result.PageRules, nil
+}
+
+func (r *pageRules) GetPageRule(cisID string, zoneID string, pageRuleID string) (*PageRule, error) {
+	result := PageRuleResult{}
+	rawURL := fmt.Sprintf("/v1/%s/zones/%s/pagerules/%s", cisID, zoneID, pageRuleID)
+	_, err := r.client.Get(rawURL, &result, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &result.PageRule, nil
+}
+
+func (r *pageRules) CreatePageRule(cisID string, zoneID string, pageRule PageRule) (*PageRule, error) {
+	if err := pageRule.Validate(); err != nil {
+		return nil, err
+	}
+	result := PageRuleResult{}
+	rawURL := fmt.Sprintf("/v1/%s/zones/%s/pagerules", cisID, zoneID)
+	_, err := r.client.Post(rawURL, &pageRule, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result.PageRule, nil
+}
+
+func (r *pageRules) UpdatePageRule(cisID string, zoneID string, pageRuleID string, pageRule PageRule) (*PageRule, error) {
+	if err := pageRule.Validate(); err != nil {
+		return nil, err
+	}
+	result := PageRuleResult{}
+	rawURL := fmt.Sprintf("/v1/%s/zones/%s/pagerules/%s", cisID, zoneID, pageRuleID)
+	_, err := r.client.Put(rawURL, &pageRule, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result.PageRule, nil
+}
+
+func (r *pageRules) DeletePageRule(cisID string, zoneID string, pageRuleID string) error {
+	rawURL := fmt.Sprintf("/v1/%s/zones/%s/pagerules/%s", cisID, zoneID, pageRuleID)
+	_, err := r.client.Delete(rawURL)
+	return err
+}
@@ -0,0 +1,80 @@
+package cisv1
+
+import (
+	"encoding/json"
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("PageRules", func() {
+	It("should validate rules", func() {
+		Expect(PageRule{
+			Targets: []PageRuleTarget{URLTarget("*example.com/static/*")},
+			Actions: []PageRuleAction{CacheLevelAction("cache_everything"), EdgeCacheTTLAction(7200), ToggleAction(PageActionAlwaysOnline, true)},
+		}.Validate()).To(Succeed())
+		Expect(PageRule{
+			Targets: []PageRuleTarget{URLTarget("example.com/old")},
+			Actions: []PageRuleAction{ForwardingURLAction("https://example.com/new", 301)},
+		}.Validate()).To(Succeed())
+
+		for _, p := range []PageRule{
+			{Actions: []PageRuleAction{AlwaysUseHTTPSAction()}},
+			{Targets: []PageRuleTarget{URLTarget("a")}},
+			{Targets: []PageRuleTarget{URLTarget("a")}, Actions: []PageRuleAction{ForwardingURLAction("https://b", 307)}},
+			{Targets: []PageRuleTarget{URLTarget("a")}, Actions: []PageRuleAction{AlwaysUseHTTPSAction(), SSLAction("full")}},
+			{Targets: []PageRuleTarget{URLTarget("a")}, Actions: []PageRuleAction{SSLAction("strict"), SSLAction("full")}},
+			{Targets: []PageRuleTarget{URLTarget("a")}, Actions: []PageRuleAction{CacheLevelAction("everything")}},
+			{Targets: []PageRuleTarget{URLTarget("a")}, Actions: []PageRuleAction{MinifyAction(Minify{CSS: "on"})}},
+			{Targets: []PageRuleTarget{URLTarget("a")}, Actions: []PageRuleAction{SSLAction("full")}, Status: "paused"},
+		} {
+			Expect(p.Validate()).NotTo(Succeed())
+		}
+	})
+
+	It("should decode typed action values", func() {
+		rule := PageRule{}
+		Expect(json.Unmarshal([]byte(`{
+			"id": "p1",
+			"targets": [{"target": "url", "constraint": {"operator": "matches", "value": "*example.com/*"}}],
+			"actions": [
+				{"id": "forwarding_url", "value": {"url": "https://example.com", "status_code": 302}},
+				{"id": "browser_cache_ttl", "value": 14400},
+				{"id": "minify", "value": {"css": "on", "html": "off", "js": "on"}},
+				{"id": "ssl", "value": "full"},
+				{"id": "always_use_https"}
+			],
+			"status": "active"
+		}`), &rule)).To(Succeed())
+		Expect(rule.Actions[0].Value).To(Equal(ForwardingURL{URL: "https://example.com", StatusCode: 302}))
+		Expect(rule.Actions[1].Value).To(Equal(14400))
+		Expect(rule.Actions[2].Value).To(Equal(Minify{CSS: "on", HTML: "off", JS: "on"}))
+		Expect(rule.Actions[3].Value).To(Equal("full"))
+		Expect(rule.Actions[4].Value).To(BeNil())
+	})
+
+	It("should create page rules", func() {
+		server := ghttp.NewServer()
+		defer server.Close()
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodPost, "/v1/crn/zones/zone/pagerules"),
+				ghttp.VerifyJSON(`{
+					"targets": [{"target": "url", "constraint": {"operator": "matches", "value": "example.com/old"}}],
+					"actions": [{"id": "forwarding_url", "value": {"url": "https://example.com/new", "status_code": 301}}],
+					"status": "active"
+				}`),
+				ghttp.RespondWith(http.StatusOK, `{"result": {"id": "p1", "targets": [], "actions": [{"id": "forwarding_url", "value": {"url": "https://example.com/new", "status_code": 301}}], "status": "active"}, "success": true, "errors": []}`),
+			),
+		)
+		rule, err := newPageRulesAPI(newTestClient(server.URL())).CreatePageRule("crn", "zone", PageRule{
+			Targets: []PageRuleTarget{URLTarget("example.com/old")},
+			Actions: []PageRuleAction{ForwardingURLAction("https://example.com/new", 301)},
+			Status:  PageRuleActive,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rule.ID).To(Equal("p1"))
+	})
+})
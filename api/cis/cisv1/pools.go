@@ -58,6 +58,36 @@ type PoolBody struct {
 	NotEmail     string   `json:"notification_email,omitempty"`
 }
 
+//PoolHealth is the health of the origins of a pool as seen from each check
+//region
+type PoolHealth struct {
+	PoolId    string                  `json:"pool_id"`
+	PopHealth map[string]RegionHealth `json:"pop_health"`
+}
+
+//RegionHealth ...
+type RegionHealth struct {
+	Healthy bool `json:"healthy"`
+	//Origins maps origin addresses to their health, one map per origin
+	Origins []map[string]OriginHealth `json:"origins"`
+}
+
+//OriginHealth ...
+type OriginHealth struct {
+	Healthy       bool   `json:"healthy"`
+	Rtt           string `json:"rtt,omitempty"`
+	FailureReason string `json:"failure_reason,omitempty"`
+	ResponseCode  int    `json:"response_code,omitempty"`
+}
+
+//PoolHealthResult ...
+type PoolHealthResult struct {
+	PoolHealth PoolHealth `json:"result"`
+	Success    bool       `json:"success"`
+	Errors     []Error    `json:"errors"`
+	Messages   []string   `json:"messages"`
+}
+
 type PoolDelete struct {
 	Result struct {
 		PoolId string
@@ -73,6 +103,7 @@ type Pools interface {
 	CreatePool(cisId string, poolBody PoolBody) (*Pool, error)
 	DeletePool(cisId string, poolId string) error
 	UpdatePool(cisId string, poolId string, poolBody PoolBody) (*Pool, error)
+	GetPoolHealth(cisId string, poolId string) (*PoolHealth, error)
 }
 
 type pools struct {
@@ -133,3 +164,13 @@ func (r *pools) UpdatePool(cisId string, poolId string, poolBody PoolBody) (*Poo
 	}
 	return &poolResult.Pool, nil
 }
+
+func (r *pools) GetPoolHealth(cisId string, poolId string) (*PoolHealth, error) {
+	healthResult := PoolHealthResult{}
+	rawURL := fmt.Sprintf("/v1/%s/load_balancers/pools/%s/health", cisId, poolId)
+	_, err := r.client.Get(rawURL, &healthResult, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &healthResult.PoolHealth, nil
+}
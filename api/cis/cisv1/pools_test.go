@@ -535,6 +535,47 @@ var _ = Describe("Pools", func() {
 			})
 		})
 	})
+
+	Describe("GetPoolHealth", func() {
+		Context("When read of pool health is successful", func() {
+			BeforeEach(func() {
+				server = ghttp.NewServer()
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest(http.MethodGet, "/v1/crn:v1:staging:public:iam::::apikey:ApiKey-62fefdd1-4557-4c7d-8a1c-f6da7ee2ff3a/load_balancers/pools/4112ba6c2974ec43886f90736968e838/health"),
+						ghttp.RespondWith(http.StatusOK, `
+                            {
+                              "result": {
+                                "pool_id": "4112ba6c2974ec43886f90736968e838",
+                                "pop_health": {
+                                  "EEU": {
+                                    "healthy": true,
+                                    "origins": [
+                                      {"150.0.0.1": {"healthy": true, "rtt": "66ms", "failure_reason": "No failures", "response_code": 200}},
+                                      {"150.0.0.2": {"healthy": false, "rtt": "0s", "failure_reason": "TCP connection failed", "response_code": 0}}
+                                    ]
+                                  }
+                                }
+                              },
+                              "success": true,
+                              "errors": [],
+                              "messages": []
+                            }
+                        `),
+					),
+				)
+			})
+
+			It("should return pool health", func() {
+				target := "crn:v1:staging:public:iam::::apikey:ApiKey-62fefdd1-4557-4c7d-8a1c-f6da7ee2ff3a"
+				poolId := "4112ba6c2974ec43886f90736968e838"
+				health, err := newPool(server.URL()).GetPoolHealth(target, poolId)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(health.PopHealth).To(HaveKey("EEU"))
+				Expect(health.PopHealth["EEU"].Origins[1]["150.0.0.2"].Healthy).To(BeFalse())
+			})
+		})
+	})
 })
 
 func newPool(url string) Pools {
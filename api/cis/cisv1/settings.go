@@ -24,9 +24,16 @@ type SettingsBody struct {
 	Value string `json:"value"`
 }
 
+//Settings ...
+//
+//GetSetting and UpdateSetting only handle settings with string values. The
+//Value methods handle every setting, see Setting and ValidateSetting.
 type Settings interface {
 	GetSetting(cisId string, zoneId string, setting string) (*SettingsResObj, error)
 	UpdateSetting(cisId string, zoneId string, setting string, settingsBody SettingsBody) (*SettingsResObj, error)
+	ListSettings(cisId string, zoneId string) ([]Setting, error)
+	GetSettingValue(cisId string, zoneId string, setting string) (*Setting, error)
+	UpdateSettingValue(cisId string, zoneId string, setting string, value interface{}) (*Setting, error)
 }
 
 type settings struct {
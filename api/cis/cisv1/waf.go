@@ -0,0 +1,226 @@
+package cisv1
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+	"github.com/IBM-Cloud/bluemix-go/client"
+)
+
+//ErrCodeInvalidWafMode ...
+const ErrCodeInvalidWafMode = "InvalidWafMode"
+
+//WAF group modes
+const (
+	WafGroupOn  = "on"
+	WafGroupOff = "off"
+)
+
+//WafPackage is a set of WAF rule groups, such as the OWASP ModSecurity
+//Core Rule Set. Sensitivity and ActionMode only apply to anomaly packages.
+type WafPackage struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	Description   string `json:"description,omitempty"`
+	DetectionMode string `json:"detection_mode"`
+	ZoneID        string `json:"zone_id,omitempty"`
+	Status        string `json:"status,omitempty"`
+	Sensitivity   string `json:"sensitivity,omitempty"`
+	ActionMode    string `json:"action_mode,omitempty"`
+}
+
+//WafPackageBody ...
+type WafPackageBody struct {
+	Sensitivity string `json:"sensitivity,omitempty"`
+	ActionMode  string `json:"action_mode,omitempty"`
+}
+
+//WafGroup is a group of WAF rules that is turned on or off as a whole
+type WafGroup struct {
+	ID                 string `json:"id"`
+	Name               string `json:"name"`
+	Description        string `json:"description,omitempty"`
+	Mode               string `json:"mode"`
+	PackageID          string `json:"package_id"`
+	RulesCount         int    `json:"rules_count"`
+	ModifiedRulesCount int    `json:"modified_rules_count"`
+}
+
+//WafRule is a single WAF rule. Mode is one of AllowedModes.
+type WafRule struct {
+	ID           string   `json:"id"`
+	Description  string   `json:"description,omitempty"`
+	Priority     string   `json:"priority,omitempty"`
+	PackageID    string   `json:"package_id"`
+	Group        WafGroup `json:"group"`
+	Mode         string   `json:"mode"`
+	DefaultMode  string   `json:"default_mode,omitempty"`
+	AllowedModes []string `json:"allowed_modes"`
+}
+
+//WafModeBody ...
+type WafModeBody struct {
+	Mode string `json:"mode"`
+}
+
+//WafPackageResults ...
+type WafPackageResults struct {
+	Packages    []WafPackage `json:"result"`
+	ResultsInfo ResultsCount `json:"result_info"`
+	Success     bool         `json:"success"`
+	Errors      []Error      `json:"errors"`
+}
+
+//WafPackageResult ...
+type WafPackageResult struct {
+	Package  WafPackage `json:"result"`
+	Success  bool       `json:"success"`
+	Errors   []Error    `json:"errors"`
+	Messages []string   `json:"messages"`
+}
+
+//WafGroupResult ...
+type WafGroupResult struct {
+	Group    WafGroup `json:"result"`
+	Success  bool     `json:"success"`
+	Errors   []Error  `json:"errors"`
+	Messages []string `json:"messages"`
+}
+
+//WafRuleResult ...
+type WafRuleResult struct {
+	Rule     WafRule  `json:"result"`
+	Success  bool     `json:"success"`
+	Errors   []Error  `json:"errors"`
+	Messages []string `json:"messages"`
+}
+
+//AllowsMode reports whether mode is one of the allowed modes of the rule
+func (r WafRule) AllowsMode(mode string) bool {
+	return containsString(r.AllowedModes, mode)
+}
+
+//Waf lists WAF packages, groups and rules and changes their modes
+type Waf interface {
+	ListWafPackages(cisID string, zoneID string) ([]WafPackage, error)
+	GetWafPackage(cisID string, zoneID string, packageID string) (*WafPackage, error)
+	UpdateWafPackage(cisID string, zoneID string, packageID string, body WafPackageBody) (*WafPackage, error)
+	ListWafGroups(cisID string, zoneID string, packageID string) ([]WafGroup, error)
+	UpdateWafGroup(cisID string, zoneID string, packageID string, groupID string, mode string) (*WafGroup, error)
+	ListWafRules(cisID string, zoneID string, packageID string) ([]WafRule, error)
+	GetWafRule(cisID string, zoneID string, packageID string, ruleID string) (*WafRule, error)
+	//UpdateWafRule reads the rule first and fails if mode is not allowed
+	UpdateWafRule(cisID string, zoneID string, packageID string, ruleID string, mode string) (*WafRule, error)
+}
+
+type waf struct {
+	client *client.Client
+}
+
+func newWafAPI(c *client.Client) Waf {
+	return &waf{
+		client: c,
+	}
+}
+
+func (r *waf) ListWafPackages(cisID string, zoneID string) ([]WafPackage, error) {
+	result := WafPackageResults{}
+	rawURL := fmt.Sprintf("/v1/%s/zones/%s/firewall/waf/packages", cisID, zoneID)
+	_, err := r.client.Get(rawURL, &result, nil)
+	if err != nil {
+		return nil, err
+	}
+	return result.Packages, nil
+}
+
+func (r *waf) GetWafPackage(cisID string, zoneID string, packageID string) (*WafPackage, error) {
+	result := WafPackageResult{}
+	rawURL := fmt.Sprintf("/v1/%s/zones/%s/firewall/waf/packages/%s", cisID, zoneID, packageID)
+	_, err := r.client.Get(rawURL, &result, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &result.Package, nil
+}
+
+func (r *waf) UpdateWafPackage(cisID string, zoneID string, packageID string, body WafPackageBody) (*WafPackage, error) {
+	result := WafPackageResult{}
+	rawURL := fmt.Sprintf("/v1/%s/zones/%s/firewall/waf/packages/%s", cisID, zoneID, packageID)
+	_, err := r.client.Patch(rawURL, &body, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result.Package, nil
+}
+
+func (r *waf) ListWafGroups(cisID string, zoneID string, packageID string) ([]WafGroup, error) {
+	var groups []WafGroup
+	rawURL := fmt.Sprintf("/v1/%s/zones/%s/firewall/waf/packages/%s/groups?page=1&per_page=100", cisID, zoneID, packageID)
+	if _, err := r.client.GetPaginated(rawURL, NewDNSPaginatedResources(WafGroup{}), func(resource interface{}) bool {
+		if g, ok := resource.(WafGroup); ok {
+			groups = append(groups, g)
+			return true
+		}
+		return false
+	}); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+func (r *waf) UpdateWafGroup(cisID string, zoneID string, packageID string, groupID string, mode string) (*WafGroup, error) {
+	if mode != WafGroupOn && mode != WafGroupOff {
+		return nil, bmxerror.New(ErrCodeInvalidWafMode, fmt.Sprintf("WAF groups are on or off, not %q", mode))
+	}
+	result := WafGroupResult{}
+	rawURL := fmt.Sprintf("/v1/%s/zones/%s/firewall/waf/packages/%s/groups/%s", cisID, zoneID, packageID, groupID)
+	_, err := r.client.Patch(rawURL, &WafModeBody{Mode: mode}, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result.Group, nil
+}
+
+func (r *waf) ListWafRules(cisID string, zoneID string, packageID string) ([]WafRule, error) {
+	var rules []WafRule
+	rawURL := fmt.Sprintf("/v1/%s/zones/%s/firewall/waf/packages/%s/rules?page=1&per_page=100", cisID, zoneID, packageID)
+	if _, err := r.client.GetPaginated(rawURL, NewDNSPaginatedResources(WafRule{}), func(resource interface{}) bool {
+		if rule, ok := resource.(WafRule); ok {
+			rules = append(rules, rule)
+			return true
+		}
+		return false
+	}); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (r *waf) GetWafRule(cisID string, zoneID string, packageID string, ruleID string) (*WafRule, error) {
+	result := WafRuleResult{}
+	rawURL := fmt.Sprintf("/v1/%s/zones/%s/firewall/waf/packages/%s/rules/%s", cisID, zoneID, packageID, ruleID)
+	_, err := r.client.Get(rawURL, &result, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &result.Rule, nil
+}
+
+func (r *waf) UpdateWafRule(cisID string, zoneID string, packageID string, ruleID string, mode string) (*WafRule, error) {
+	rule, err := r.GetWafRule(cisID, zoneID, packageID, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	if !rule.AllowsMode(mode) {
+		return nil, bmxerror.New(ErrCodeInvalidWafMode,
+			fmt.Sprintf("WAF rule %s does not allow mode %q, use one of %s", ruleID, mode, strings.Join(rule.AllowedModes, ", ")))
+	}
+	result := WafRuleResult{}
+	rawURL := fmt.Sprintf("/v1/%s/zones/%s/firewall/waf/packages/%s/rules/%s", cisID, zoneID, packageID, ruleID)
+	_, err = r.client.Patch(rawURL, &WafModeBody{Mode: mode}, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result.Rule, nil
+}
@@ -0,0 +1,60 @@
+package cisv1
+
+import (
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Waf", func() {
+	var server *ghttp.Server
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+	})
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should page through WAF rules and check modes", func() {
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodGet, "/v1/crn/zones/zone/firewall/waf/packages/pkg/rules", "page=1&per_page=100"),
+				ghttp.RespondWith(http.StatusOK, `{"result": [{"id": "100000", "mode": "on", "allowed_modes": ["on", "off"]}], "result_info": {"page": 1, "total_pages": 2}, "success": true}`),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodGet, "/v1/crn/zones/zone/firewall/waf/packages/pkg/rules", "page=2&per_page=100"),
+				ghttp.RespondWith(http.StatusOK, `{"result": [{"id": "958000", "mode": "default", "allowed_modes": ["default", "disable", "simulate", "block", "challenge"]}], "result_info": {"page": 2, "total_pages": 2}, "success": true}`),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodGet, "/v1/crn/zones/zone/firewall/waf/packages/pkg/rules/100000"),
+				ghttp.RespondWith(http.StatusOK, `{"result": {"id": "100000", "mode": "on", "allowed_modes": ["on", "off"]}, "success": true}`),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodGet, "/v1/crn/zones/zone/firewall/waf/packages/pkg/rules/100000"),
+				ghttp.RespondWith(http.StatusOK, `{"result": {"id": "100000", "mode": "on", "allowed_modes": ["on", "off"]}, "success": true}`),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodPatch, "/v1/crn/zones/zone/firewall/waf/packages/pkg/rules/100000"),
+				ghttp.VerifyJSON(`{"mode": "off"}`),
+				ghttp.RespondWith(http.StatusOK, `{"result": {"id": "100000", "mode": "off", "allowed_modes": ["on", "off"]}, "success": true}`),
+			),
+		)
+		api := newWafAPI(newTestClient(server.URL()))
+		rules, err := api.ListWafRules("crn", "zone", "pkg")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rules).To(HaveLen(2))
+		Expect(rules[1].AllowsMode("simulate")).To(BeTrue())
+
+		_, err = api.UpdateWafRule("crn", "zone", "pkg", "100000", "block")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("use one of on, off"))
+		rule, err := api.UpdateWafRule("crn", "zone", "pkg", "100000", "off")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rule.Mode).To(Equal("off"))
+
+		_, err = api.UpdateWafGroup("crn", "zone", "pkg", "group", "block")
+		Expect(err).To(HaveOccurred())
+	})
+})
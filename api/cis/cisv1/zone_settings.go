@@ -0,0 +1,325 @@
+package cisv1
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+)
+
+//ErrCodeInvalidSetting ...
+const ErrCodeInvalidSetting = "InvalidSetting"
+
+//Setting names
+const (
+	SettingAlwaysUseHTTPS          = "always_use_https"
+	SettingAutomaticHTTPSRewrites  = "automatic_https_rewrites"
+	SettingBrowserCheck            = "browser_check"
+	SettingBrotli                  = "brotli"
+	SettingBrowserCacheTTL         = "browser_cache_ttl"
+	SettingCacheLevel              = "cache_level"
+	SettingChallengeTTL            = "challenge_ttl"
+	SettingCiphers                 = "ciphers"
+	SettingCNAMEFlattening         = "cname_flattening"
+	SettingHotlinkProtection       = "hotlink_protection"
+	SettingHTTP2                   = "http2"
+	SettingImageLoadOptimization   = "image_load_optimization"
+	SettingImageSizeOptimization   = "image_size_optimization"
+	SettingIPGeolocation           = "ip_geolocation"
+	SettingIPv6                    = "ipv6"
+	SettingMaxUpload               = "max_upload"
+	SettingMinTLSVersion           = "min_tls_version"
+	SettingMinify                  = "minify"
+	SettingMobileRedirect          = "mobile_redirect"
+	SettingOpportunisticEncryption = "opportunistic_encryption"
+	SettingOriginErrorPagePassThru = "origin_error_page_pass_thru"
+	SettingPrefetchPreload         = "prefetch_preload"
+	SettingPseudoIPv4              = "pseudo_ipv4"
+	SettingResponseBuffering       = "response_buffering"
+	SettingScriptLoadOptimization  = "script_load_optimization"
+	SettingSecurityHeader          = "security_header"
+	SettingServerSideExclude       = "server_side_exclude"
+	SettingSSL                     = "ssl"
+	SettingTLSClientAuth           = "tls_client_auth"
+	SettingTrueClientIPHeader      = "true_client_ip_header"
+	SettingWAF                     = "waf"
+	SettingWebsockets              = "websockets"
+)
+
+//Values of on/off settings
+const (
+	SettingOn  = "on"
+	SettingOff = "off"
+)
+
+//Minify is the value of the minify setting. Each field is on or off.
+type Minify struct {
+	CSS  string `json:"css"`
+	HTML string `json:"html"`
+	JS   string `json:"js"`
+}
+
+//SecurityHeader is the value of the security_header setting
+type SecurityHeader struct {
+	StrictTransportSecurity StrictTransportSecurity `json:"strict_transport_security"`
+}
+
+//StrictTransportSecurity configures the HSTS header. MaxAge is in seconds.
+type StrictTransportSecurity struct {
+	Enabled           bool `json:"enabled"`
+	MaxAge            int  `json:"max_age"`
+	IncludeSubdomains bool `json:"include_subdomains"`
+	Preload           bool `json:"preload"`
+	Nosniff           bool `json:"nosniff"`
+}
+
+//MobileRedirect is the value of the mobile_redirect setting
+type MobileRedirect struct {
+	Status          string `json:"status"`
+	MobileSubdomain string `json:"mobile_subdomain"`
+	StripURI        bool   `json:"strip_uri"`
+}
+
+//Setting is a zone setting with its value kept as returned by the API. Use
+//the typed accessors or Decode to read it.
+type Setting struct {
+	ID           string          `json:"id"`
+	Value        json.RawMessage `json:"value"`
+	Editable     bool            `json:"editable"`
+	ModifiedDate string          `json:"modified_on,omitempty"`
+}
+
+//ListSettingsResult ...
+type ListSettingsResult struct {
+	Result   []Setting `json:"result"`
+	Success  bool      `json:"success"`
+	Errors   []Error   `json:"errors"`
+	Messages []string  `json:"messages"`
+}
+
+//SettingResult ...
+type SettingResult struct {
+	Result   Setting  `json:"result"`
+	Success  bool     `json:"success"`
+	Errors   []Error  `json:"errors"`
+	Messages []string `json:"messages"`
+}
+
+//SettingValueBody is the body of UpdateSettingValue
+type SettingValueBody struct {
+	Value interface{} `json:"value"`
+}
+
+//Decode unmarshals the value into v
+func (s Setting) Decode(v interface{}) error {
+	if err := json.Unmarshal(s.Value, v); err != nil {
+		return bmxerror.New(ErrCodeInvalidSetting, fmt.Sprintf("Value of %s is not a %T: %v", s.ID, v, err))
+	}
+	return nil
+}
+
+//StringValue returns the value of on/off and other string settings
+func (s Setting) StringValue() (string, error) {
+	var v string
+	err := s.Decode(&v)
+	return v, err
+}
+
+//IntValue returns the value of integer settings such as challenge_ttl
+func (s Setting) IntValue() (int, error) {
+	var v int
+	err := s.Decode(&v)
+	return v, err
+}
+
+//ListValue returns the value of list settings such as ciphers
+func (s Setting) ListValue() ([]string, error) {
+	v := []string{}
+	err := s.Decode(&v)
+	return v, err
+}
+
+//Minify ...
+func (s Setting) Minify() (Minify, error) {
+	v := Minify{}
+	err := s.Decode(&v)
+	return v, err
+}
+
+//SecurityHeader ...
+func (s Setting) SecurityHeader() (SecurityHeader, error) {
+	v := SecurityHeader{}
+	err := s.Decode(&v)
+	return v, err
+}
+
+//MobileRedirect ...
+func (s Setting) MobileRedirect() (MobileRedirect, error) {
+	v := MobileRedirect{}
+	err := s.Decode(&v)
+	return v, err
+}
+
+//SameValue reports whether both settings hold the same value, regardless of
+//formatting and key order
+func (s Setting) SameValue(other Setting) bool {
+	var a, b interface{}
+	if json.Unmarshal(s.Value, &a) != nil || json.Unmarshal(other.Value, &b) != nil {
+		return bytes.Equal(s.Value, other.Value)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+//settingSpec describes the values a setting accepts. Settings without a spec
+//are sent as given.
+type settingSpec struct {
+	typ    reflect.Type
+	values []string
+	ints   []int
+}
+
+var (
+	stringType = reflect.TypeOf("")
+	intType    = reflect.TypeOf(0)
+	listType   = reflect.TypeOf([]string{})
+	onOff      = []string{SettingOn, SettingOff}
+)
+
+func toggle() settingSpec {
+	return settingSpec{typ: stringType, values: onOff}
+}
+
+var settingSpecs = map[string]settingSpec{
+	SettingAlwaysUseHTTPS:          toggle(),
+	SettingAutomaticHTTPSRewrites:  toggle(),
+	SettingBrowserCheck:            toggle(),
+	SettingBrotli:                  toggle(),
+	SettingHotlinkProtection:       toggle(),
+	SettingHTTP2:                   toggle(),
+	SettingImageLoadOptimization:   toggle(),
+	SettingIPGeolocation:           toggle(),
+	SettingIPv6:                    toggle(),
+	SettingOpportunisticEncryption: toggle(),
+	SettingOriginErrorPagePassThru: toggle(),
+	SettingPrefetchPreload:         toggle(),
+	SettingResponseBuffering:       toggle(),
+	SettingScriptLoadOptimization:  toggle(),
+	SettingServerSideExclude:       toggle(),
+	SettingTLSClientAuth:           toggle(),
+	SettingTrueClientIPHeader:      toggle(),
+	SettingWAF:                     toggle(),
+	SettingWebsockets:              toggle(),
+	SettingImageSizeOptimization:   {typ: stringType, values: []string{"off", "lossless", "lossy"}},
+	SettingCNAMEFlattening:         {typ: stringType, values: []string{"flatten_at_root", "flatten_all", "flatten_none"}},
+	SettingMinTLSVersion:           {typ: stringType, values: []string{"1.0", "1.1", "1.2", "1.3"}},
+	SettingPseudoIPv4:              {typ: stringType, values: []string{"off", "add_header", "overwrite_header"}},
+	SettingSSL:                     {typ: stringType, values: []string{"off", "flexible", "full", "strict", "origin_pull"}},
+	SettingCacheLevel:              {typ: stringType, values: []string{"basic", "simplified", "aggressive"}},
+	SettingBrowserCacheTTL: {typ: intType, ints: []int{0, 30, 60, 300, 1200, 1800, 3600, 7200, 10800, 14400,
+		18000, 28800, 43200, 57600, 72000, 86400, 172800, 259200, 345600, 432000, 691200, 1382400, 2073600,
+		2678400, 5356800, 16070400, 31536000}},
+	SettingChallengeTTL: {typ: intType, ints: []int{300, 900, 1800, 2700, 3600, 7200, 10800, 14400, 28800,
+		57600, 86400, 604800, 2592000, 31536000}},
+	SettingMaxUpload: {typ: intType, ints: []int{100, 125, 150, 175, 200, 225, 250, 275, 300, 325, 350, 375,
+		400, 425, 450, 475, 500}},
+	SettingCiphers:        {typ: listType},
+	SettingMinify:         {typ: reflect.TypeOf(Minify{})},
+	SettingSecurityHeader: {typ: reflect.TypeOf(SecurityHeader{})},
+	SettingMobileRedirect: {typ: reflect.TypeOf(MobileRedirect{})},
+}
+
+//ValidateSetting checks value against the type and allowed values of the
+//setting. value may be a Go value or a json.RawMessage. Unknown settings,
+//and fields of object values that are not known, are not checked.
+func ValidateSetting(setting string, value interface{}) error {
+	spec, ok := settingSpecs[setting]
+	if !ok {
+		return nil
+	}
+	raw, ok := value.(json.RawMessage)
+	if !ok {
+		var err error
+		if raw, err = json.Marshal(value); err != nil {
+			return bmxerror.New(ErrCodeInvalidSetting, fmt.Sprintf("Value of %s cannot be encoded: %v", setting, err))
+		}
+	}
+	v := reflect.New(spec.typ)
+	if err := json.Unmarshal(raw, v.Interface()); err != nil {
+		return bmxerror.New(ErrCodeInvalidSetting, fmt.Sprintf("Value of %s must be a %s: %v", setting, spec.typ, err))
+	}
+	switch x := v.Elem().Interface().(type) {
+	case string:
+		if len(spec.values) > 0 && !containsString(spec.values, x) {
+			return bmxerror.New(ErrCodeInvalidSetting,
+				fmt.Sprintf("Value %q of %s must be one of %s", x, setting, strings.Join(spec.values, ", ")))
+		}
+	case int:
+		if i := sort.SearchInts(spec.ints, x); len(spec.ints) > 0 && (i == len(spec.ints) || spec.ints[i] != x) {
+			return bmxerror.New(ErrCodeInvalidSetting, fmt.Sprintf("Value %d of %s must be one of %v", x, setting, spec.ints))
+		}
+	case Minify:
+		for _, m := range []string{x.CSS, x.HTML, x.JS} {
+			if !containsString(onOff, m) {
+				return bmxerror.New(ErrCodeInvalidSetting, fmt.Sprintf("Minify values must be on or off, not %q", m))
+			}
+		}
+	case SecurityHeader:
+		if x.StrictTransportSecurity.MaxAge < 0 {
+			return bmxerror.New(ErrCodeInvalidSetting, "The HSTS max age cannot be negative")
+		}
+	case MobileRedirect:
+		if !containsString(onOff, x.Status) {
+			return bmxerror.New(ErrCodeInvalidSetting, fmt.Sprintf("Mobile redirect status must be on or off, not %q", x.Status))
+		}
+		if x.Status == SettingOn && x.MobileSubdomain == "" {
+			return bmxerror.New(ErrCodeInvalidSetting, "Mobile redirect needs a mobile subdomain")
+		}
+	}
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *settings) ListSettings(cisId string, zoneId string) ([]Setting, error) {
+	result := ListSettingsResult{}
+	rawURL := fmt.Sprintf("/v1/%s/zones/%s/settings", cisId, zoneId)
+	_, err := r.client.Get(rawURL, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result.Result, nil
+}
+
+func (r *settings) GetSettingValue(cisId string, zoneId string, setting string) (*Setting, error) {
+	result := SettingResult{}
+	rawURL := fmt.Sprintf("/v1/%s/zones/%s/settings/%s", cisId, zoneId, setting)
+	_, err := r.client.Get(rawURL, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result.Result, nil
+}
+
+func (r *settings) UpdateSettingValue(cisId string, zoneId string, setting string, value interface{}) (*Setting, error) {
+	if err := ValidateSetting(setting, value); err != nil {
+		return nil, err
+	}
+	result := SettingResult{}
+	rawURL := fmt.Sprintf("/v1/%s/zones/%s/settings/%s", cisId, zoneId, setting)
+	_, err := r.client.Patch(rawURL, &SettingValueBody{Value: value}, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result.Result, nil
+}
@@ -0,0 +1,113 @@
+package cisv1
+
+import (
+	"encoding/json"
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("ZoneSettings", func() {
+	var server *ghttp.Server
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+	})
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("ListSettings", func() {
+		It("should return settings of every value type", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodGet, "/v1/crn/zones/zone/settings"),
+					ghttp.RespondWith(http.StatusOK, `{
+						"result": [
+							{"id": "min_tls_version", "value": "1.2", "editable": true},
+							{"id": "challenge_ttl", "value": 1800, "editable": true},
+							{"id": "ciphers", "value": ["ECDHE-RSA-AES128-GCM-SHA256"], "editable": true},
+							{"id": "minify", "value": {"css": "on", "html": "off", "js": "on"}, "editable": true},
+							{"id": "security_header", "value": {"strict_transport_security": {"enabled": true, "max_age": 86400, "include_subdomains": true, "preload": true, "nosniff": true}}, "editable": false}
+						],
+						"success": true
+					}`),
+				),
+			)
+			settings, err := newSetting(server.URL()).ListSettings("crn", "zone")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(settings).To(HaveLen(5))
+			tls, err := settings[0].StringValue()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tls).To(Equal("1.2"))
+			ttl, err := settings[1].IntValue()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ttl).To(Equal(1800))
+			ciphers, err := settings[2].ListValue()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ciphers).To(ConsistOf("ECDHE-RSA-AES128-GCM-SHA256"))
+			minify, err := settings[3].Minify()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(minify.HTML).To(Equal(SettingOff))
+			header, err := settings[4].SecurityHeader()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(header.StrictTransportSecurity.MaxAge).To(Equal(86400))
+			Expect(header.StrictTransportSecurity.Preload).To(BeTrue())
+			Expect(ValidateSetting(SettingSecurityHeader, settings[4].Value)).To(Succeed())
+			//the typed value encodes back to what the API returned
+			encoded, err := json.Marshal(header)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(settings[4].SameValue(Setting{Value: encoded})).To(BeTrue())
+			_, err = settings[3].StringValue()
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("UpdateSettingValue", func() {
+		It("should send typed values", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodPatch, "/v1/crn/zones/zone/settings/minify"),
+					ghttp.VerifyJSON(`{"value": {"css": "on", "html": "on", "js": "off"}}`),
+					ghttp.RespondWith(http.StatusOK, `{
+						"result": {"id": "minify", "value": {"css": "on", "html": "on", "js": "off"}, "editable": true},
+						"success": true
+					}`),
+				),
+			)
+			setting, err := newSetting(server.URL()).UpdateSettingValue("crn", "zone", SettingMinify,
+				Minify{CSS: SettingOn, HTML: SettingOn, JS: SettingOff})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(setting.ID).To(Equal(SettingMinify))
+		})
+		It("should reject invalid values without calling the API", func() {
+			_, err := newSetting(server.URL()).UpdateSettingValue("crn", "zone", SettingChallengeTTL, 1000)
+			Expect(err).To(HaveOccurred())
+			Expect(server.ReceivedRequests()).To(BeEmpty())
+		})
+	})
+
+	Describe("ValidateSetting", func() {
+		It("should check types and allowed values", func() {
+			Expect(ValidateSetting(SettingMinTLSVersion, "1.3")).To(Succeed())
+			Expect(ValidateSetting(SettingMinTLSVersion, "1.4")).NotTo(Succeed())
+			Expect(ValidateSetting(SettingAlwaysUseHTTPS, true)).NotTo(Succeed())
+			Expect(ValidateSetting(SettingChallengeTTL, 31536000)).To(Succeed())
+			Expect(ValidateSetting(SettingChallengeTTL, 40000000)).NotTo(Succeed())
+			Expect(ValidateSetting(SettingMaxUpload, json.RawMessage(`125`))).To(Succeed())
+			Expect(ValidateSetting(SettingMinify, map[string]string{"css": "on", "html": "on", "js": "yes"})).NotTo(Succeed())
+			Expect(ValidateSetting(SettingMinify, map[string]string{"css": "on", "html": "on", "js": "on", "svg": "on"})).To(Succeed())
+			Expect(ValidateSetting(SettingMobileRedirect, MobileRedirect{Status: SettingOn})).NotTo(Succeed())
+			Expect(ValidateSetting(SettingCiphers, []string{"AES128-SHA"})).To(Succeed())
+			Expect(ValidateSetting("unknown_setting", 42)).To(Succeed())
+		})
+	})
+
+	It("should compare values regardless of formatting", func() {
+		a := Setting{Value: json.RawMessage(`{"css":"on","html":"on","js":"off"}`)}
+		b := Setting{Value: json.RawMessage(`{ "js": "off", "html": "on", "css": "on" }`)}
+		Expect(a.SameValue(b)).To(BeTrue())
+		Expect(a.SameValue(Setting{Value: json.RawMessage(`"on"`)})).To(BeFalse())
+	})
+})
@@ -0,0 +1,569 @@
+//Package dnssync keeps the records of a CIS zone in line with a desired
+//record set, such as a file kept in Git. Only records marked as owned are
+//ever changed or deleted.
+package dnssync
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	yaml "github.com/ghodss/yaml"
+
+	"github.com/IBM-Cloud/bluemix-go/api/cis/cisv1"
+	"github.com/IBM-Cloud/bluemix-go/api/cis/zonefile"
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+)
+
+//ErrCodeInvalidDesiredState ...
+const ErrCodeInvalidDesiredState = "InvalidDesiredState"
+
+//ErrCodeSyncIncomplete ...
+const ErrCodeSyncIncomplete = "SyncIncomplete"
+
+//Operation actions
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+//DefaultMarkerPrefix is prepended to a record name to form the name of its
+//ownership marker
+const DefaultMarkerPrefix = "_managed-by."
+
+//wildcardLabel replaces the * of a wildcard name in its marker name, as *
+//is only valid as the leftmost label
+const wildcardLabel = "_wildcard"
+
+//DesiredState is the record set of a zone. Names may be relative to Zone,
+//with @ for the apex.
+//
+//	zone: example.com
+//	owner: dns-repo
+//	records:
+//	- name: www
+//	  type: CNAME
+//	  content: example.com
+//	  proxied: true
+//	- name: "@"
+//	  type: A
+//	  content: 192.0.2.1
+type DesiredState struct {
+	Zone    string          `json:"zone"`
+	Owner   string          `json:"owner"`
+	Records []cisv1.DnsBody `json:"records"`
+}
+
+//LoadDesiredState reads a desired state from a YAML or JSON file
+func LoadDesiredState(path string) (DesiredState, error) {
+	state := DesiredState{}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return state, err
+	}
+	if err := yaml.Unmarshal(data, &state); err != nil {
+		return state, err
+	}
+	return state, state.Normalize()
+}
+
+//Normalize makes record names fully qualified and defaults the TTL to
+//automatic
+func (s *DesiredState) Normalize() error {
+	zone := normalizeName(s.Zone)
+	if zone == "" {
+		return bmxerror.New(ErrCodeInvalidDesiredState, "The desired state has no zone")
+	}
+	s.Zone = zone
+	for i, r := range s.Records {
+		if r.DnsType == "" {
+			return bmxerror.New(ErrCodeInvalidDesiredState, fmt.Sprintf("Record %d has no type", i+1))
+		}
+		name := normalizeName(r.Name)
+		switch {
+		case name == "" || name == "@":
+			name = zone
+		case strings.HasSuffix(r.Name, "."), name == zone, strings.HasSuffix(name, "."+zone):
+		default:
+			name = name + "." + zone
+		}
+		s.Records[i].Name = name
+		s.Records[i].DnsType = strings.ToUpper(r.DnsType)
+		if r.Ttl == 0 {
+			s.Records[i].Ttl = cisv1.AutoTTL
+		}
+	}
+	return nil
+}
+
+//Operation is one change to a record. Record is the desired record, empty
+//for deletes; Existing is the live record, nil for creates.
+type Operation struct {
+	Action   string
+	Record   cisv1.DnsBody
+	Existing *cisv1.DnsRecord
+	//Changes describes the fields an update changes
+	Changes []string
+	//Marker is set for operations on ownership markers
+	Marker bool
+}
+
+//Conflict is a desired record that would change a record not owned by the
+//reconciler
+type Conflict struct {
+	Record   cisv1.DnsBody
+	Existing cisv1.DnsRecord
+	Reason   string
+}
+
+//Plan lists the operations needed to reach the desired state
+type Plan struct {
+	Operations []Operation
+	Conflicts  []Conflict
+	//Unmanaged counts the live records left alone because they are not
+	//owned
+	Unmanaged int
+}
+
+//Count returns the number of operations of action
+func (p Plan) Count(action string) int {
+	n := 0
+	for _, op := range p.Operations {
+		if op.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+//Reconciler plans and applies record changes for one owner. Ownership is
+//recorded per name in a TXT marker named MarkerPrefix + name, holding
+//"managed-by=<owner> types=<types>". The marker of *.example.com is named
+//MarkerPrefix + "_wildcard.example.com". Only records whose name and type
+//are owned are updated or deleted.
+type Reconciler struct {
+	Dns          cisv1.Dns
+	Owner        string
+	MarkerPrefix string
+	//Adopt takes ownership of unowned live records of the desired names
+	//and types instead of reporting them as conflicts
+	Adopt bool
+}
+
+//NewReconciler ...
+func NewReconciler(dns cisv1.Dns, owner string) *Reconciler {
+	return &Reconciler{Dns: dns, Owner: owner, MarkerPrefix: DefaultMarkerPrefix}
+}
+
+//marker is the ownership marker of a name
+type marker struct {
+	record cisv1.DnsRecord
+	owner  string
+	//types are the owned types, nil when the marker owns every type
+	types map[string]bool
+}
+
+func (m marker) owns(rrtype string) bool {
+	return m.types == nil || m.types[rrtype]
+}
+
+func parseMarker(rec cisv1.DnsRecord) (marker, bool) {
+	m := marker{record: rec}
+	for _, field := range strings.Fields(rec.Content) {
+		switch {
+		case strings.HasPrefix(field, "managed-by="):
+			m.owner = strings.TrimPrefix(field, "managed-by=")
+		case strings.HasPrefix(field, "types="):
+			m.types = map[string]bool{}
+			for _, t := range strings.Split(strings.TrimPrefix(field, "types="), ",") {
+				m.types[strings.ToUpper(t)] = true
+			}
+		}
+	}
+	return m, m.owner != ""
+}
+
+func (r *Reconciler) markerContent(types map[string]bool) string {
+	list := make([]string, 0, len(types))
+	for t := range types {
+		list = append(list, t)
+	}
+	sort.Strings(list)
+	return fmt.Sprintf("managed-by=%s types=%s", r.Owner, strings.Join(list, ","))
+}
+
+//group is the desired and live records of one name and type
+type group struct {
+	name, rrtype string
+	desired      []cisv1.DnsBody
+	live         []cisv1.DnsRecord
+}
+
+//Plan compares the desired records, with fully qualified names, with the
+//live records of the zone
+func (r *Reconciler) Plan(cisID, zoneID string, desired []cisv1.DnsBody) (Plan, error) {
+	plan := Plan{Operations: []Operation{}, Conflicts: []Conflict{}}
+	live, err := r.Dns.ListDns(cisID, zoneID)
+	if err != nil {
+		return plan, err
+	}
+
+	markers := map[string]marker{}
+	groups := map[string]*group{}
+	byName := map[string][]*group{}
+	order := []string{}
+	groupOf := func(name, rrtype string) *group {
+		key := name + " " + rrtype
+		g, ok := groups[key]
+		if !ok {
+			g = &group{name: name, rrtype: rrtype}
+			groups[key] = g
+			byName[name] = append(byName[name], g)
+			order = append(order, key)
+		}
+		return g
+	}
+	for _, rec := range live {
+		name := normalizeName(rec.Name)
+		if rec.DnsType == "TXT" && strings.HasPrefix(name, r.MarkerPrefix) {
+			if m, ok := parseMarker(rec); ok {
+				markers[r.markedName(name)] = m
+				continue
+			}
+		}
+		g := groupOf(name, rec.DnsType)
+		g.live = append(g.live, rec)
+	}
+	for _, body := range desired {
+		body.Name = normalizeName(body.Name)
+		body.DnsType = strings.ToUpper(body.DnsType)
+		if body.Ttl == 0 {
+			body.Ttl = cisv1.AutoTTL
+		}
+		g := groupOf(body.Name, body.DnsType)
+		g.desired = append(g.desired, body)
+	}
+	sort.Strings(order)
+
+	owned := func(name, rrtype string) bool {
+		m, ok := markers[name]
+		return ok && m.owner == r.Owner && m.owns(rrtype)
+	}
+	conflict := func(g *group, existing cisv1.DnsRecord, reason string) {
+		for _, body := range g.desired {
+			plan.Conflicts = append(plan.Conflicts, Conflict{Record: body, Existing: existing, Reason: reason})
+		}
+	}
+	//claims are the types each name will be owned for
+	claims := map[string]map[string]bool{}
+	for _, key := range order {
+		g := groups[key]
+		if len(g.desired) == 0 {
+			if owned(g.name, g.rrtype) {
+				for i := range g.live {
+					plan.Operations = append(plan.Operations, Operation{Action: ActionDelete, Existing: &g.live[i]})
+				}
+			} else {
+				plan.Unmanaged += len(g.live)
+			}
+			continue
+		}
+		if m, ok := markers[g.name]; ok && m.owner != r.Owner {
+			conflict(g, m.record, fmt.Sprintf("%s is managed by %s", g.name, m.owner))
+			plan.Unmanaged += len(g.live)
+			continue
+		}
+		if !owned(g.name, g.rrtype) && len(g.live) > 0 && !r.Adopt {
+			conflict(g, g.live[0], fmt.Sprintf("%s %s exists and is not managed by %s", g.rrtype, g.name, r.Owner))
+			plan.Unmanaged += len(g.live)
+			continue
+		}
+		if other, ok := r.cnameConflict(g, byName[g.name], owned); ok {
+			conflict(g, other, fmt.Sprintf("%s %s cannot coexist with %s %s", g.rrtype, g.name, other.DnsType, other.Name))
+			continue
+		}
+		if claims[g.name] == nil {
+			claims[g.name] = map[string]bool{}
+		}
+		claims[g.name][g.rrtype] = true
+		plan.Operations = append(plan.Operations, match(g)...)
+	}
+	plan.Operations = append(plan.Operations, r.markerOperations(markers, claims)...)
+	sortOperations(plan.Operations)
+	return plan, nil
+}
+
+//markerOperations creates, updates or deletes the markers of our names so
+//they list the claimed types
+func (r *Reconciler) markerOperations(markers map[string]marker, claims map[string]map[string]bool) []Operation {
+	ops := []Operation{}
+	for name, types := range claims {
+		body := cisv1.DnsBody{Name: r.markerName(name), DnsType: "TXT", Content: r.markerContent(types), Ttl: cisv1.AutoTTL}
+		m, ok := markers[name]
+		switch {
+		case !ok:
+			ops = append(ops, Operation{Action: ActionCreate, Record: body, Marker: true})
+		case m.record.Content != body.Content:
+			existing := m.record
+			ops = append(ops, Operation{Action: ActionUpdate, Record: body, Existing: &existing, Marker: true,
+				Changes: []string{fmt.Sprintf("content %q -> %q", m.record.Content, body.Content)}})
+		}
+	}
+	for name, m := range markers {
+		if _, ok := claims[name]; !ok && m.owner == r.Owner {
+			existing := m.record
+			ops = append(ops, Operation{Action: ActionDelete, Existing: &existing, Marker: true})
+		}
+	}
+	return ops
+}
+
+func (r *Reconciler) markerName(name string) string {
+	if name == "*" || strings.HasPrefix(name, "*.") {
+		name = wildcardLabel + name[1:]
+	}
+	return r.MarkerPrefix + name
+}
+
+//markedName returns the name a marker is named after
+func (r *Reconciler) markedName(markerName string) string {
+	name := strings.TrimPrefix(markerName, r.MarkerPrefix)
+	if name == wildcardLabel || strings.HasPrefix(name, wildcardLabel+".") {
+		name = "*" + strings.TrimPrefix(name, wildcardLabel)
+	}
+	return name
+}
+
+//cnameConflict returns a record sharing its name with the records of g when
+//one of them is a CNAME. Owned records that are about to be deleted do not
+//conflict.
+func (r *Reconciler) cnameConflict(g *group, sameName []*group, owned func(string, string) bool) (cisv1.DnsRecord, bool) {
+	for _, other := range sameName {
+		if other == g || (g.rrtype != "CNAME" && other.rrtype != "CNAME") {
+			continue
+		}
+		if len(other.desired) == 0 && owned(other.name, other.rrtype) {
+			continue
+		}
+		if len(other.live) > 0 {
+			return other.live[0], true
+		}
+		if len(other.desired) > 0 {
+			return other.desired[0].Record(), true
+		}
+	}
+	return cisv1.DnsRecord{}, false
+}
+
+//match pairs the desired and live records of a group. Records with the same
+//content are kept, updating their TTL or proxied flag when needed. The
+//remaining ones are paired in order and updated in place, and whatever is
+//left is created or deleted.
+func match(g *group) []Operation {
+	ops := []Operation{}
+	used := make([]bool, len(g.live))
+	unmatched := []cisv1.DnsBody{}
+	for _, body := range g.desired {
+		key := zonefile.RecordKey(body.Record())
+		found := -1
+		for i, rec := range g.live {
+			if !used[i] && zonefile.RecordKey(rec) == key {
+				found = i
+				break
+			}
+		}
+		if found < 0 {
+			unmatched = append(unmatched, body)
+			continue
+		}
+		used[found] = true
+		if changes := diff(body, g.live[found]); len(changes) > 0 {
+			ops = append(ops, Operation{Action: ActionUpdate, Record: body, Existing: &g.live[found], Changes: changes})
+		}
+	}
+	for _, body := range unmatched {
+		found := -1
+		for i := range g.live {
+			if !used[i] {
+				found = i
+				break
+			}
+		}
+		if found < 0 {
+			ops = append(ops, Operation{Action: ActionCreate, Record: body})
+			continue
+		}
+		used[found] = true
+		ops = append(ops, Operation{Action: ActionUpdate, Record: body, Existing: &g.live[found], Changes: diff(body, g.live[found])})
+	}
+	for i := range g.live {
+		if !used[i] {
+			ops = append(ops, Operation{Action: ActionDelete, Existing: &g.live[i]})
+		}
+	}
+	return ops
+}
+
+func diff(body cisv1.DnsBody, live cisv1.DnsRecord) []string {
+	changes := []string{}
+	if a, b := zonefile.RecordKey(live), zonefile.RecordKey(body.Record()); a != b {
+		was, _ := zonefile.RData(live)
+		now, _ := zonefile.RData(body.Record())
+		changes = append(changes, fmt.Sprintf("content %s -> %s", was, now))
+	}
+	if body.Ttl != live.Ttl {
+		changes = append(changes, fmt.Sprintf("ttl %s -> %s", ttlString(live.Ttl), ttlString(body.Ttl)))
+	}
+	if body.Proxied != live.Proxied {
+		changes = append(changes, fmt.Sprintf("proxied %t -> %t", live.Proxied, body.Proxied))
+	}
+	return changes
+}
+
+func ttlString(ttl int) string {
+	if ttl == cisv1.AutoTTL {
+		return "auto"
+	}
+	return fmt.Sprint(ttl)
+}
+
+//sortOperations orders deletes first, so CNAMEs can replace other records,
+//then updates and creates. Marker deletes go last and marker creates go
+//before the other creates.
+func sortOperations(ops []Operation) {
+	rank := func(op Operation) int {
+		switch {
+		case op.Action == ActionDelete && op.Marker:
+			return 4
+		case op.Action == ActionDelete:
+			return 0
+		case op.Action == ActionUpdate:
+			return 1
+		case op.Marker:
+			return 2
+		}
+		return 3
+	}
+	sort.SliceStable(ops, func(i, j int) bool {
+		if rank(ops[i]) != rank(ops[j]) {
+			return rank(ops[i]) < rank(ops[j])
+		}
+		return ops[i].name() < ops[j].name()
+	})
+}
+
+func (op Operation) name() string {
+	if op.Existing != nil {
+		return normalizeName(op.Existing.Name)
+	}
+	return op.Record.Name
+}
+
+func normalizeName(name string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".")
+}
+
+//Summary is the outcome of Sync
+type Summary struct {
+	DryRun  bool
+	Plan    Plan
+	Created int
+	Updated int
+	Deleted int
+	//Errors maps the Key of the failed operations to their error
+	Errors map[string]error
+}
+
+//Sync plans the changes and applies them unless dryRun is set
+func (r *Reconciler) Sync(cisID, zoneID string, desired []cisv1.DnsBody, dryRun bool) (Summary, error) {
+	plan, err := r.Plan(cisID, zoneID, desired)
+	if err != nil {
+		return Summary{}, err
+	}
+	summary := Summary{DryRun: dryRun, Plan: plan, Errors: map[string]error{}}
+	if dryRun {
+		return summary, nil
+	}
+	err = r.Apply(cisID, zoneID, plan, &summary)
+	return summary, err
+}
+
+//Apply runs the operations of plan in order. Failed operations are
+//recorded in summary and do not stop the others; they are reported as an
+//ErrCodeSyncIncomplete error at the end.
+func (r *Reconciler) Apply(cisID, zoneID string, plan Plan, summary *Summary) error {
+	if summary.Errors == nil {
+		summary.Errors = map[string]error{}
+	}
+	for _, op := range plan.Operations {
+		var err error
+		switch op.Action {
+		case ActionCreate:
+			if _, err = r.Dns.CreateDns(cisID, zoneID, op.Record); err == nil {
+				summary.Created++
+			}
+		case ActionUpdate:
+			if _, err = r.Dns.UpdateDns(cisID, zoneID, op.Existing.Id, op.Record); err == nil {
+				summary.Updated++
+			}
+		case ActionDelete:
+			if err = r.Dns.DeleteDns(cisID, zoneID, op.Existing.Id); err == nil {
+				summary.Deleted++
+			}
+		}
+		if err != nil {
+			summary.Errors[op.Key()] = err
+		}
+	}
+	if len(summary.Errors) > 0 {
+		return bmxerror.New(ErrCodeSyncIncomplete,
+			fmt.Sprintf("%d of %d operations failed", len(summary.Errors), len(plan.Operations)))
+	}
+	return nil
+}
+
+//Key identifies the operation in Summary.Errors: the ID of the live record,
+//or the description of a create
+func (op Operation) Key() string {
+	if op.Existing != nil {
+		return op.Existing.Id
+	}
+	return op.String()
+}
+
+//String describes the operation in one line
+func (op Operation) String() string {
+	switch op.Action {
+	case ActionCreate:
+		data, _ := zonefile.RData(op.Record.Record())
+		return fmt.Sprintf("+ %s %s %s", op.Record.Name, op.Record.DnsType, data)
+	case ActionUpdate:
+		return fmt.Sprintf("~ %s %s (%s)", op.Record.Name, op.Record.DnsType, strings.Join(op.Changes, ", "))
+	}
+	data, _ := zonefile.RData(*op.Existing)
+	return fmt.Sprintf("- %s %s %s", normalizeName(op.Existing.Name), op.Existing.DnsType, data)
+}
+
+//Print writes the plan and, unless it was a dry run, the outcome
+func (s Summary) Print(w io.Writer) {
+	for _, op := range s.Plan.Operations {
+		fmt.Fprintln(w, op)
+	}
+	for _, c := range s.Plan.Conflicts {
+		fmt.Fprintf(w, "! %s %s: %s\n", c.Record.Name, c.Record.DnsType, c.Reason)
+	}
+	if s.DryRun {
+		fmt.Fprintf(w, "Dry run: %d to create, %d to update, %d to delete, %d unmanaged records left alone\n",
+			s.Plan.Count(ActionCreate), s.Plan.Count(ActionUpdate), s.Plan.Count(ActionDelete), s.Plan.Unmanaged)
+		return
+	}
+	for _, op := range s.Plan.Operations {
+		if err, ok := s.Errors[op.Key()]; ok {
+			fmt.Fprintf(w, "failed %s: %v\n", op, err)
+		}
+	}
+	fmt.Fprintf(w, "%d created, %d updated, %d deleted, %d failed\n", s.Created, s.Updated, s.Deleted, len(s.Errors))
+}
@@ -0,0 +1,13 @@
+package dnssync_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestDnssync(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Dnssync Suite")
+}
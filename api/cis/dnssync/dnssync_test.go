@@ -0,0 +1,219 @@
+package dnssync
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/IBM-Cloud/bluemix-go/api/cis/cisv1"
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type fakeDns struct {
+	cisv1.Dns
+	records []cisv1.DnsRecord
+	calls   []string
+	fail    string
+}
+
+func (f *fakeDns) ListDns(cisID, zoneID string) ([]cisv1.DnsRecord, error) {
+	return f.records, nil
+}
+
+func (f *fakeDns) CreateDns(cisID, zoneID string, body cisv1.DnsBody) (*cisv1.DnsRecord, error) {
+	if body.Name == f.fail {
+		return nil, errors.New("rejected")
+	}
+	f.calls = append(f.calls, "create "+body.Name+" "+body.DnsType+" "+body.Content)
+	return &cisv1.DnsRecord{Name: body.Name}, nil
+}
+
+func (f *fakeDns) UpdateDns(cisID, zoneID, id string, body cisv1.DnsBody) (*cisv1.DnsRecord, error) {
+	if body.Name == f.fail {
+		return nil, errors.New("rejected")
+	}
+	f.calls = append(f.calls, "update "+id+" "+body.Content)
+	return &cisv1.DnsRecord{Id: id}, nil
+}
+
+func (f *fakeDns) DeleteDns(cisID, zoneID, id string) error {
+	f.calls = append(f.calls, "delete "+id)
+	return nil
+}
+
+func a(id, name, ip string, ttl int) cisv1.DnsRecord {
+	return cisv1.DnsRecord{Id: id, Name: name, DnsType: "A", Content: ip, Ttl: ttl}
+}
+
+func owner(id, name, content string) cisv1.DnsRecord {
+	return cisv1.DnsRecord{Id: id, Name: DefaultMarkerPrefix + name, DnsType: "TXT", Content: content, Ttl: 1}
+}
+
+var _ = Describe("Reconciler", func() {
+	var dns *fakeDns
+	BeforeEach(func() {
+		dns = &fakeDns{records: []cisv1.DnsRecord{
+			owner("m1", "www.example.com", "managed-by=git types=A"),
+			a("1", "www.example.com", "192.0.2.1", 1),
+			a("2", "www.example.com", "192.0.2.2", 1),
+			a("3", "www.example.com", "192.0.2.3", 1),
+			owner("m2", "old.example.com", "managed-by=git types=CNAME"),
+			{Id: "4", Name: "old.example.com", DnsType: "CNAME", Content: "legacy.example.net", Ttl: 1},
+			a("5", "manual.example.com", "192.0.2.50", 1),
+			{Id: "6", Name: "www.example.com", DnsType: "TXT", Content: "verification=abc", Ttl: 1},
+			owner("m3", "team.example.com", "managed-by=other types=A"),
+		}}
+	})
+
+	It("should plan changes for owned records only", func() {
+		plan, err := NewReconciler(dns, "git").Plan("cis", "zone", []cisv1.DnsBody{
+			{Name: "www.example.com", DnsType: "A", Content: "192.0.2.1", Ttl: 300},
+			{Name: "www.example.com", DnsType: "A", Content: "192.0.2.3", Ttl: 1, Proxied: true},
+			{Name: "www.example.com", DnsType: "A", Content: "192.0.2.4"},
+			{Name: "api.example.com.", DnsType: "CNAME", Content: "www.example.com"},
+			{Name: "manual.example.com", DnsType: "A", Content: "192.0.2.51"},
+			{Name: "team.example.com", DnsType: "A", Content: "192.0.2.60"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		lines := []string{}
+		for _, op := range plan.Operations {
+			lines = append(lines, op.String())
+		}
+		Expect(lines).To(Equal([]string{
+			"- old.example.com CNAME legacy.example.net.",
+			"~ www.example.com A (ttl auto -> 300)",
+			"~ www.example.com A (proxied false -> true)",
+			"~ www.example.com A (content 192.0.2.2 -> 192.0.2.4)",
+			`+ _managed-by.api.example.com TXT "managed-by=git types=CNAME"`,
+			"+ api.example.com CNAME www.example.com.",
+			`- _managed-by.old.example.com TXT "managed-by=git types=CNAME"`,
+		}))
+		Expect(plan.Conflicts).To(HaveLen(2))
+		Expect(plan.Conflicts[0].Existing.Id).To(Equal("5"))
+		Expect(plan.Conflicts[1].Reason).To(ContainSubstring("managed by other"))
+		Expect(plan.Unmanaged).To(Equal(2))
+	})
+
+	It("should adopt unowned records when asked", func() {
+		r := NewReconciler(dns, "git")
+		r.Adopt = true
+		plan, err := r.Plan("cis", "zone", []cisv1.DnsBody{
+			{Name: "www.example.com", DnsType: "A", Content: "192.0.2.1"},
+			{Name: "www.example.com", DnsType: "TXT", Content: "verification=abc"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Conflicts).To(BeEmpty())
+		Expect(plan.Count(ActionDelete)).To(Equal(4))
+		var update Operation
+		for _, op := range plan.Operations {
+			if op.Marker && op.Action == ActionUpdate {
+				update = op
+			}
+		}
+		Expect(update.Existing.Id).To(Equal("m1"))
+		Expect(update.Record.Content).To(Equal("managed-by=git types=A,TXT"))
+	})
+
+	It("should refuse a CNAME next to unowned records", func() {
+		plan, err := NewReconciler(dns, "git").Plan("cis", "zone", []cisv1.DnsBody{
+			{Name: "old.example.com", DnsType: "CNAME", Content: "legacy.example.net"},
+			{Name: "www.example.com", DnsType: "CNAME", Content: "example.com"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Conflicts).To(HaveLen(1))
+		Expect(plan.Conflicts[0].Record.Name).To(Equal("www.example.com"))
+		Expect(plan.Count(ActionCreate)).To(Equal(0))
+	})
+
+	It("should apply the plan unless it is a dry run", func() {
+		r := NewReconciler(dns, "git")
+		desired := []cisv1.DnsBody{{Name: "www.example.com", DnsType: "A", Content: "192.0.2.1"}}
+		summary, err := r.Sync("cis", "zone", desired, true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(dns.calls).To(BeEmpty())
+		var out bytes.Buffer
+		summary.Print(&out)
+		Expect(out.String()).To(ContainSubstring("Dry run: 0 to create, 0 to update, 4 to delete, 2 unmanaged records left alone"))
+
+		summary, err = r.Sync("cis", "zone", desired, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(dns.calls).To(Equal([]string{"delete 4", "delete 2", "delete 3", "delete m2"}))
+		Expect(summary.Deleted).To(Equal(4))
+		Expect(summary.Errors).To(BeEmpty())
+	})
+
+	It("should report each failed operation", func() {
+		dns.fail = "www.example.com"
+		summary, err := NewReconciler(dns, "git").Sync("cis", "zone", []cisv1.DnsBody{
+			{Name: "www.example.com", DnsType: "A", Content: "192.0.2.1", Ttl: 300},
+			{Name: "www.example.com", DnsType: "A", Content: "192.0.2.2", Ttl: 300},
+			{Name: "www.example.com", DnsType: "A", Content: "192.0.2.3", Ttl: 300},
+		}, false)
+		Expect(err).To(HaveOccurred())
+		Expect(err.(bmxerror.Error).Code()).To(Equal(ErrCodeSyncIncomplete))
+		Expect(summary.Errors).To(HaveLen(3))
+		Expect(summary.Errors).To(HaveKey("1"))
+		Expect(summary.Errors).To(HaveKey("3"))
+
+		var out bytes.Buffer
+		summary.Print(&out)
+		Expect(out.String()).To(ContainSubstring("failed ~ www.example.com A (ttl auto -> 300): rejected"))
+		Expect(out.String()).To(ContainSubstring("0 created, 0 updated, 2 deleted, 3 failed"))
+	})
+
+	It("should name the markers of wildcards with a valid label", func() {
+		dns.records = append(dns.records, owner("m4", "*.dev.example.com", "managed-by=git types=A"))
+		dns.records[len(dns.records)-1].Name = DefaultMarkerPrefix + "_wildcard.dev.example.com"
+		plan, err := NewReconciler(dns, "git").Plan("cis", "zone", []cisv1.DnsBody{
+			{Name: "*.dev.example.com", DnsType: "A", Content: "192.0.2.70"},
+			{Name: "*.example.com", DnsType: "A", Content: "192.0.2.80"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		lines := []string{}
+		for _, op := range plan.Operations {
+			if op.Marker {
+				lines = append(lines, op.String())
+			}
+		}
+		Expect(lines).To(ConsistOf(
+			`+ _managed-by._wildcard.example.com TXT "managed-by=git types=A"`,
+			`- _managed-by.www.example.com TXT "managed-by=git types=A"`,
+			`- _managed-by.old.example.com TXT "managed-by=git types=CNAME"`,
+		))
+	})
+
+	Describe("LoadDesiredState", func() {
+		It("should qualify relative names", func() {
+			dir, err := ioutil.TempDir("", "dnssync")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(dir)
+			path := filepath.Join(dir, "dns.yaml")
+			Expect(ioutil.WriteFile(path, []byte(`zone: example.com
+owner: git
+records:
+- name: "@"
+  type: a
+  content: 192.0.2.1
+- name: www
+  type: CNAME
+  content: example.com
+  ttl: 300
+- name: other.example.org.
+  type: TXT
+  content: hello
+`), 0600)).To(Succeed())
+			state, err := LoadDesiredState(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state.Records).To(Equal([]cisv1.DnsBody{
+				{Name: "example.com", DnsType: "A", Content: "192.0.2.1", Ttl: 1},
+				{Name: "www.example.com", DnsType: "CNAME", Content: "example.com", Ttl: 300},
+				{Name: "other.example.org", DnsType: "TXT", Content: "hello", Ttl: 1},
+			}))
+		})
+	})
+})
@@ -0,0 +1,13 @@
+package glbsim_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestGlbsim(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Glbsim Suite")
+}
@@ -0,0 +1,157 @@
+package glbsim
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/IBM-Cloud/bluemix-go/api/cis/cisv1"
+)
+
+type fakeGlbs struct {
+	cisv1.Glbs
+	glb cisv1.Glb
+}
+
+func (f *fakeGlbs) GetGlb(cisID, zoneID, glbID string) (*cisv1.Glb, error) {
+	return &f.glb, nil
+}
+
+type fakePools struct {
+	cisv1.Pools
+	pools  map[string]cisv1.Pool
+	health map[string]cisv1.PoolHealth
+}
+
+func (f *fakePools) GetPool(cisID, poolID string) (*cisv1.Pool, error) {
+	p := f.pools[poolID]
+	return &p, nil
+}
+
+func (f *fakePools) GetPoolHealth(cisID, poolID string) (*cisv1.PoolHealth, error) {
+	h := f.health[poolID]
+	return &h, nil
+}
+
+type fakeMonitors struct {
+	cisv1.Monitors
+	calls int
+}
+
+func (f *fakeMonitors) GetMonitor(cisID, monitorID string) (*cisv1.Monitor, error) {
+	f.calls++
+	return &cisv1.Monitor{Id: monitorID, MonType: "HTTPS", Method: "GET", Path: "/health"}, nil
+}
+
+var _ = Describe("Glbsim", func() {
+	var (
+		pools    *fakePools
+		monitors *fakeMonitors
+		topology *Topology
+	)
+	BeforeEach(func() {
+		pools = &fakePools{
+			pools: map[string]cisv1.Pool{
+				"us": {Id: "us", Name: "us-pool", Enabled: true, MinOrigins: 2, Monitor: "m1", Origins: []cisv1.Origin{
+					{Name: "us-1", Address: "192.0.2.1", Enabled: true, Weight: 3},
+					{Name: "us-2", Address: "192.0.2.2", Enabled: true, Weight: 1},
+					{Name: "us-3", Address: "192.0.2.3", Enabled: false, Weight: 1},
+				}},
+				"eu": {Id: "eu", Name: "eu-pool", Enabled: true, Monitor: "m1", Origins: []cisv1.Origin{
+					{Name: "eu-1", Address: "198.51.100.1", Enabled: true},
+					{Name: "eu-2", Address: "198.51.100.2", Enabled: true},
+				}},
+				"dr": {Id: "dr", Name: "dr-pool", Enabled: true, Origins: []cisv1.Origin{
+					{Name: "dr-1", Address: "203.0.113.1", Enabled: true, Weight: 1},
+				}},
+			},
+			health: map[string]cisv1.PoolHealth{
+				"eu": {PoolId: "eu", PopHealth: map[string]cisv1.RegionHealth{
+					"WEU": {Healthy: true, Origins: []map[string]cisv1.OriginHealth{
+						{"198.51.100.1": {Healthy: false, FailureReason: "HTTP timeout"}},
+						{"198.51.100.2": {Healthy: true}},
+					}},
+				}},
+			},
+		}
+		monitors = &fakeMonitors{}
+		loader := Loader{
+			Glbs: &fakeGlbs{glb: cisv1.Glb{
+				Name:         "www.example.com",
+				DefaultPools: []string{"us", "eu"},
+				FallbackPool: "dr",
+				RegionPools:  map[string][]string{"WEU": {"eu", "us"}},
+			}},
+			Pools:    pools,
+			Monitors: monitors,
+		}
+		var err error
+		topology, err = loader.Load("crn", "zone", "glb", true)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should resolve pools and monitors", func() {
+		Expect(topology.PoolIDs()).To(Equal([]string{"us", "eu", "dr"}))
+		Expect(topology.Pools["us"].MonitorDetails.Path).To(Equal("/health"))
+		Expect(topology.Pools["dr"].MonitorDetails).To(BeNil())
+		Expect(monitors.calls).To(Equal(1))
+		out := &bytes.Buffer{}
+		topology.Print(out)
+		Expect(out.String()).To(ContainSubstring("WEU: eu-pool, us-pool"))
+		Expect(out.String()).To(ContainSubstring("us-3 192.0.2.3 weight 1 disabled"))
+	})
+
+	It("should report unhealthy origins", func() {
+		reports := topology.HealthReport()
+		Expect(reports).To(HaveLen(3))
+		Expect(reports[1].Unhealthy).To(Equal(map[string][]string{"198.51.100.1": {"WEU: HTTP timeout"}}))
+	})
+
+	It("should split traffic by weight when everything is up", func() {
+		sim := topology.Simulate(Scenario{})
+		Expect(sim.Pool.ID).To(Equal("us"))
+		Expect(sim.Fallback).To(BeFalse())
+		Expect(sim.Origins).To(Equal([]OriginShare{
+			{Name: "us-1", Address: "192.0.2.1", Share: 0.75},
+			{Name: "us-2", Address: "192.0.2.2", Share: 0.25},
+		}))
+	})
+
+	It("should fail over when a pool drops below its minimum", func() {
+		sim := topology.Simulate(Scenario{Down: []string{"us-pool/us-2"}})
+		Expect(sim.Candidates[0].Reason).To(Equal("1 of 2 required origins healthy"))
+		Expect(sim.Pool.ID).To(Equal("eu"))
+		Expect(sim.Origins).To(HaveLen(2))
+		Expect(sim.Origins[0].Share).To(Equal(0.5))
+
+		sim = topology.Simulate(Scenario{Down: []string{"192.0.2.2"}, LiveHealth: true})
+		Expect(sim.Pool.ID).To(Equal("eu"))
+		Expect(sim.Origins).To(Equal([]OriginShare{{Name: "eu-2", Address: "198.51.100.2", Share: 1}}))
+	})
+
+	It("should use the fallback pool, even when it is down", func() {
+		sim := topology.Simulate(Scenario{Region: "WEU", Down: []string{"eu-1", "eu-2", "us-1"}})
+		Expect(sim.Candidates).To(HaveLen(2))
+		Expect(sim.Fallback).To(BeTrue())
+		Expect(sim.Degraded).To(BeFalse())
+		Expect(sim.Pool.Name).To(Equal("dr-pool"))
+
+		sim = topology.Simulate(Scenario{Region: "WEU", Down: []string{"eu-1", "eu-2", "us-1", "dr-1"}})
+		Expect(sim.Degraded).To(BeTrue())
+		Expect(sim.Origins).To(HaveLen(1))
+		out := &bytes.Buffer{}
+		sim.Print(out)
+		Expect(out.String()).To(ContainSubstring("skip eu-pool: 0 of 1 required origins healthy"))
+		Expect(out.String()).To(ContainSubstring("fallback dr-pool has no healthy origin"))
+	})
+
+	It("should simulate every region", func() {
+		sims := topology.SimulateAll(Scenario{Down: []string{"us-1"}})
+		Expect(sims).To(HaveLen(2))
+		Expect(sims[0].Region).To(Equal(""))
+		Expect(sims[0].Pool.ID).To(Equal("eu"))
+		Expect(sims[1].Region).To(Equal("WEU"))
+		Expect(sims[1].Pool.ID).To(Equal("eu"))
+	})
+})
@@ -0,0 +1,183 @@
+package glbsim
+
+import (
+	"fmt"
+	"io"
+)
+
+//Scenario describes the failures to simulate
+type Scenario struct {
+	//Region is a region with its own pools; other values, including "",
+	//use the default pools
+	Region string
+	//Down lists the origins to treat as down, by name or address. Prefix
+	//with "<pool name>/" to target the origin of a single pool.
+	Down []string
+	//LiveHealth also treats as down the origins that any check region
+	//reports unhealthy in the loaded health
+	LiveHealth bool
+}
+
+//PoolStatus is the state of a pool during a simulation
+type PoolStatus struct {
+	ID      string
+	Name    string
+	Healthy int
+	Minimum int
+	//Eligible pools have at least Minimum healthy origins and are enabled
+	Eligible bool
+	Reason   string
+}
+
+//OriginShare is the share of traffic an origin would receive
+type OriginShare struct {
+	Name    string
+	Address string
+	Share   float64
+}
+
+//Simulation is the outcome of Simulate
+type Simulation struct {
+	Region string
+	//Candidates are the pools tried, in order, up to the eligible one
+	Candidates []PoolStatus
+	//Pool serves the traffic; it is the fallback pool when no candidate is
+	//eligible
+	Pool     PoolStatus
+	Fallback bool
+	//Degraded is set when the serving pool has no healthy origin, so
+	//traffic goes to origins that are down
+	Degraded bool
+	Origins  []OriginShare
+}
+
+//Simulate returns the pool and origins that would serve s.Region. Pools are
+//tried in steering order; the first enabled one with enough healthy
+//origins serves, and the fallback pool serves when none does. Traffic is
+//split between healthy origins by weight.
+func (t *Topology) Simulate(s Scenario) Simulation {
+	down := map[string]bool{}
+	for _, d := range s.Down {
+		down[d] = true
+	}
+	sim := Simulation{Region: s.Region, Candidates: []PoolStatus{}}
+	for _, id := range t.poolsFor(s.Region) {
+		status := t.poolStatus(id, down, s.LiveHealth)
+		sim.Candidates = append(sim.Candidates, status)
+		if status.Eligible {
+			sim.Pool = status
+			sim.Origins = t.shares(id, down, s.LiveHealth)
+			return sim
+		}
+	}
+	sim.Fallback = true
+	sim.Pool = t.poolStatus(t.Glb.FallbackPool, down, s.LiveHealth)
+	sim.Origins = t.shares(t.Glb.FallbackPool, down, s.LiveHealth)
+	if len(sim.Origins) == 0 {
+		//the fallback pool receives traffic whatever its health
+		sim.Degraded = true
+		sim.Origins = t.shares(t.Glb.FallbackPool, nil, false)
+	}
+	return sim
+}
+
+//SimulateAll runs the scenario for the default pools, with Region "", and
+//for every region with its own pools
+func (t *Topology) SimulateAll(s Scenario) []Simulation {
+	sims := []Simulation{}
+	for _, region := range append([]string{""}, t.Regions()...) {
+		s.Region = region
+		sims = append(sims, t.Simulate(s))
+	}
+	return sims
+}
+
+func (t *Topology) poolStatus(id string, down map[string]bool, live bool) PoolStatus {
+	status := PoolStatus{ID: id, Name: t.poolName(id), Minimum: 1}
+	p, ok := t.Pools[id]
+	if !ok {
+		status.Reason = "pool not loaded"
+		return status
+	}
+	status.Minimum = minOrigins(p.Pool)
+	for _, o := range p.Origins {
+		if t.originUp(p, o.Name, o.Address, o.Enabled, down, live) {
+			status.Healthy++
+		}
+	}
+	switch {
+	case !p.Enabled:
+		status.Reason = "pool disabled"
+	case status.Healthy < status.Minimum:
+		status.Reason = fmt.Sprintf("%d of %d required origins healthy", status.Healthy, status.Minimum)
+	default:
+		status.Eligible = true
+	}
+	return status
+}
+
+func (t *Topology) originUp(p *Pool, name, address string, enabled bool, down map[string]bool, live bool) bool {
+	if !enabled || down[name] || down[address] || down[p.Name+"/"+name] || down[p.Name+"/"+address] {
+		return false
+	}
+	if live && p.Health != nil {
+		for _, region := range p.Health.PopHealth {
+			for _, origins := range region.Origins {
+				if h, ok := origins[address]; ok && !h.Healthy {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+func (t *Topology) shares(id string, down map[string]bool, live bool) []OriginShare {
+	p, ok := t.Pools[id]
+	if !ok {
+		return []OriginShare{}
+	}
+	shares := []OriginShare{}
+	total := 0
+	for _, o := range p.Origins {
+		if !t.originUp(p, o.Name, o.Address, o.Enabled, down, live) {
+			continue
+		}
+		shares = append(shares, OriginShare{Name: o.Name, Address: o.Address, Share: float64(o.Weight)})
+		total += o.Weight
+	}
+	for i := range shares {
+		if total == 0 {
+			//without weights traffic is spread evenly
+			shares[i].Share = 1 / float64(len(shares))
+		} else {
+			shares[i].Share /= float64(total)
+		}
+	}
+	return shares
+}
+
+//Print writes the pools tried and the origins serving the traffic
+func (s Simulation) Print(w io.Writer) {
+	region := s.Region
+	if region == "" {
+		region = "default"
+	}
+	fmt.Fprintf(w, "%s:\n", region)
+	for _, c := range s.Candidates {
+		if !c.Eligible {
+			fmt.Fprintf(w, "  skip %s: %s\n", c.Name, c.Reason)
+		}
+	}
+	switch {
+	case s.Degraded:
+		fmt.Fprintf(w, "  fallback %s has no healthy origin, traffic fails over to down origins\n", s.Pool.Name)
+	case s.Fallback:
+		fmt.Fprintf(w, "  fallback %s serves\n", s.Pool.Name)
+	default:
+		fmt.Fprintf(w, "  %s serves\n", s.Pool.Name)
+	}
+	for _, o := range s.Origins {
+		fmt.Fprintf(w, "    %s %s %.0f%%\n", o.Name, o.Address, o.Share*100)
+	}
+}
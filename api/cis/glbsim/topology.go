@@ -0,0 +1,207 @@
+//Package glbsim resolves a CIS global load balancer to its pools, origins
+//and monitors, and simulates which origins would serve a region when some
+//origins are down. It is meant to check a failover design before an
+//incident rather than during one.
+package glbsim
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/IBM-Cloud/bluemix-go/api/cis/cisv1"
+)
+
+//Pool is a pool of the topology with its monitor and, when loaded, its
+//health
+type Pool struct {
+	cisv1.Pool
+	//MonitorDetails is nil when the pool has no monitor
+	MonitorDetails *cisv1.Monitor
+	//Health is nil unless health was loaded
+	Health *cisv1.PoolHealth
+}
+
+//Topology is a load balancer resolved to its pools
+type Topology struct {
+	Glb cisv1.Glb
+	//Pools holds every pool the load balancer refers to, by ID
+	Pools map[string]*Pool
+}
+
+//Loader reads topologies from the CIS APIs
+type Loader struct {
+	Glbs     cisv1.Glbs
+	Pools    cisv1.Pools
+	Monitors cisv1.Monitors
+}
+
+//Load resolves glbID. Pool health is fetched when withHealth is set.
+//Monitors shared by several pools are read once.
+func (l Loader) Load(cisID, zoneID, glbID string, withHealth bool) (*Topology, error) {
+	glb, err := l.Glbs.GetGlb(cisID, zoneID, glbID)
+	if err != nil {
+		return nil, err
+	}
+	t := &Topology{Glb: *glb, Pools: map[string]*Pool{}}
+	monitors := map[string]*cisv1.Monitor{}
+	for _, id := range t.PoolIDs() {
+		pool, err := l.Pools.GetPool(cisID, id)
+		if err != nil {
+			return nil, fmt.Errorf("pool %s: %v", id, err)
+		}
+		p := &Pool{Pool: *pool}
+		if pool.Monitor != "" {
+			if _, ok := monitors[pool.Monitor]; !ok {
+				m, err := l.Monitors.GetMonitor(cisID, pool.Monitor)
+				if err != nil {
+					return nil, fmt.Errorf("monitor %s of pool %s: %v", pool.Monitor, id, err)
+				}
+				monitors[pool.Monitor] = m
+			}
+			p.MonitorDetails = monitors[pool.Monitor]
+		}
+		if withHealth {
+			if p.Health, err = l.Pools.GetPoolHealth(cisID, id); err != nil {
+				return nil, fmt.Errorf("health of pool %s: %v", id, err)
+			}
+		}
+		t.Pools[id] = p
+	}
+	return t, nil
+}
+
+//PoolIDs returns the IDs of the default, region and fallback pools, each
+//once
+func (t *Topology) PoolIDs() []string {
+	ids := []string{}
+	seen := map[string]bool{}
+	add := func(list ...string) {
+		for _, id := range list {
+			if id != "" && !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	add(t.Glb.DefaultPools...)
+	for _, region := range t.Regions() {
+		add(t.Glb.RegionPools[region]...)
+	}
+	add(t.Glb.FallbackPool)
+	return ids
+}
+
+//Regions returns the regions with their own pools, sorted
+func (t *Topology) Regions() []string {
+	regions := make([]string, 0, len(t.Glb.RegionPools))
+	for r := range t.Glb.RegionPools {
+		regions = append(regions, r)
+	}
+	sort.Strings(regions)
+	return regions
+}
+
+//poolsFor returns the pools steering would try for region, in order
+func (t *Topology) poolsFor(region string) []string {
+	if pools := t.Glb.RegionPools[region]; len(pools) > 0 {
+		return pools
+	}
+	return t.Glb.DefaultPools
+}
+
+func (t *Topology) poolName(id string) string {
+	if p, ok := t.Pools[id]; ok && p.Name != "" {
+		return p.Name
+	}
+	return id
+}
+
+//PoolReport summarizes the health of one pool
+type PoolReport struct {
+	ID      string
+	Name    string
+	Enabled bool
+	//Health is the overall health reported with the pool
+	Health string
+	//Unhealthy maps origin addresses to the check regions that report them
+	//down, with the failure reason
+	Unhealthy map[string][]string
+}
+
+//HealthReport summarizes the loaded health of every pool, in PoolIDs order
+func (t *Topology) HealthReport() []PoolReport {
+	reports := []PoolReport{}
+	for _, id := range t.PoolIDs() {
+		p, ok := t.Pools[id]
+		if !ok {
+			continue
+		}
+		r := PoolReport{ID: id, Name: p.Name, Enabled: p.Enabled, Health: p.Pool.Health, Unhealthy: map[string][]string{}}
+		if p.Health != nil {
+			regions := make([]string, 0, len(p.Health.PopHealth))
+			for region := range p.Health.PopHealth {
+				regions = append(regions, region)
+			}
+			sort.Strings(regions)
+			for _, region := range regions {
+				for _, origins := range p.Health.PopHealth[region].Origins {
+					for address, h := range origins {
+						if !h.Healthy {
+							r.Unhealthy[address] = append(r.Unhealthy[address], fmt.Sprintf("%s: %s", region, h.FailureReason))
+						}
+					}
+				}
+			}
+		}
+		reports = append(reports, r)
+	}
+	return reports
+}
+
+//Print writes the load balancer, its pools, their origins and monitors
+func (t *Topology) Print(w io.Writer) {
+	fmt.Fprintf(w, "%s (ttl %d, proxied %v)\n", t.Glb.Name, t.Glb.Ttl, t.Glb.Proxied)
+	names := func(ids []string) string {
+		out := make([]string, len(ids))
+		for i, id := range ids {
+			out[i] = t.poolName(id)
+		}
+		return strings.Join(out, ", ")
+	}
+	fmt.Fprintf(w, "  default: %s\n", names(t.Glb.DefaultPools))
+	for _, region := range t.Regions() {
+		fmt.Fprintf(w, "  %s: %s\n", region, names(t.Glb.RegionPools[region]))
+	}
+	fmt.Fprintf(w, "  fallback: %s\n", t.poolName(t.Glb.FallbackPool))
+	for _, id := range t.PoolIDs() {
+		p, ok := t.Pools[id]
+		if !ok {
+			continue
+		}
+		monitor := "no monitor"
+		if m := p.MonitorDetails; m != nil {
+			monitor = fmt.Sprintf("monitor %s %s %s", m.MonType, m.Method, m.Path)
+		}
+		state := "enabled"
+		if !p.Enabled {
+			state = "disabled"
+		}
+		fmt.Fprintf(w, "pool %s (%s, minimum %d origins, %s)\n", p.Name, state, minOrigins(p.Pool), monitor)
+		for _, o := range p.Origins {
+			state := ""
+			if !o.Enabled {
+				state = " disabled"
+			}
+			fmt.Fprintf(w, "  %s %s weight %d%s\n", o.Name, o.Address, o.Weight, state)
+		}
+	}
+}
+
+func minOrigins(p cisv1.Pool) int {
+	if p.MinOrigins < 1 {
+		return 1
+	}
+	return p.MinOrigins
+}
@@ -0,0 +1,164 @@
+package zonefile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/IBM-Cloud/bluemix-go/api/cis/cisv1"
+)
+
+//DefaultTTL is written as $TTL and applies to the name servers of the zone,
+//the only records written without a TTL
+const DefaultTTL = 300
+
+//Export writes the records of a CIS zone as a canonical zone file: names
+//relative to the zone, records sorted by name, type and data, and domain
+//names in the data fully qualified. The name servers of the zone are written
+//as apex NS records. Records with automatic TTL are written with a TTL of
+//cisv1.AutoTTL and proxied records carry a cf_tags comment, so Parse restores
+//both.
+func Export(w io.Writer, zone cisv1.Zone, records []cisv1.DnsRecord) error {
+	origin := strings.TrimSuffix(strings.ToLower(zone.Name), ".")
+	type line struct {
+		owner, ttl, rrtype, rdata, comment string
+		name                               string
+	}
+	lines := []line{}
+	hasApexNS := false
+	for _, r := range records {
+		data, err := RData(r)
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(strings.ToLower(r.Name), ".")
+		if r.DnsType == "NS" && name == origin {
+			hasApexNS = true
+		}
+		l := line{owner: relative(name, origin), rrtype: r.DnsType, rdata: data, name: name}
+		l.ttl = strconv.Itoa(cisv1.AutoTTL)
+		if r.Ttl > cisv1.AutoTTL {
+			l.ttl = strconv.Itoa(r.Ttl)
+		}
+		if r.Proxied {
+			l.comment = " ; " + proxiedTag
+		}
+		lines = append(lines, l)
+	}
+	if !hasApexNS {
+		for _, ns := range zone.NameServers {
+			lines = append(lines, line{owner: "@", rrtype: "NS", rdata: fqdn(ns), name: origin})
+		}
+	}
+	sort.SliceStable(lines, func(i, j int) bool {
+		a, b := lines[i], lines[j]
+		if (a.name == origin) != (b.name == origin) {
+			return a.name == origin
+		}
+		if a.name != b.name {
+			return a.name < b.name
+		}
+		if a.rrtype != b.rrtype {
+			return a.rrtype < b.rrtype
+		}
+		return a.rdata < b.rdata
+	})
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, ";; Zone %s exported from CIS\n", origin)
+	fmt.Fprintf(bw, "$ORIGIN %s.\n", origin)
+	fmt.Fprintf(bw, "$TTL %d\n", DefaultTTL)
+	for _, l := range lines {
+		fmt.Fprintf(bw, "%s\t%s\tIN\t%s\t%s%s\n", l.owner, l.ttl, l.rrtype, l.rdata, l.comment)
+	}
+	return bw.Flush()
+}
+
+//RData formats the data of a CIS record as in a zone file
+func RData(r cisv1.DnsRecord) (string, error) {
+	switch r.DnsType {
+	case "CNAME", "NS", "PTR":
+		return fqdn(r.Content), nil
+	case "MX":
+		return fmt.Sprintf("%d %s", r.Priority, fqdn(r.Content)), nil
+	case "TXT", "SPF":
+		return quoteTXT(r.Content), nil
+	case "SRV":
+		if r.Data != nil {
+			d, err := r.SRV()
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%d %d %d %s", d.Priority, d.Weight, d.Port, fqdn(d.Target)), nil
+		}
+		fields := strings.Fields(r.Content)
+		if len(fields) != 3 {
+			return "", fmt.Errorf("SRV record %s has invalid content %q", r.Name, r.Content)
+		}
+		return fmt.Sprintf("%d %s %s %s", r.Priority, fields[0], fields[1], fqdn(fields[2])), nil
+	case "CAA":
+		if r.Data != nil {
+			d, err := r.CAA()
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%d %s %s", d.Flags, d.Tag, quote(d.Value)), nil
+		}
+	}
+	return r.Content, nil
+}
+
+//relative returns name relative to origin, or fully qualified when it is
+//outside of it
+func relative(name, origin string) string {
+	switch {
+	case name == origin:
+		return "@"
+	case strings.HasSuffix(name, "."+origin):
+		return strings.TrimSuffix(name, "."+origin)
+	}
+	return fqdn(name)
+}
+
+func fqdn(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
+
+//quoteTXT quotes text, splitting it in strings of at most 255 characters
+func quoteTXT(text string) string {
+	if len(text) <= 255 {
+		return quote(text)
+	}
+	parts := []string{}
+	for len(text) > 255 {
+		parts = append(parts, quote(text[:255]))
+		text = text[255:]
+	}
+	parts = append(parts, quote(text))
+	return strings.Join(parts, " ")
+}
+
+func quote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < ' ' || c > '~':
+			fmt.Fprintf(&b, "\\%03d", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,130 @@
+package zonefile
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/IBM-Cloud/bluemix-go/api/cis/cisv1"
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+)
+
+//ErrCodeImportIncomplete ...
+const ErrCodeImportIncomplete = "ImportIncomplete"
+
+//Conflict is a record that cannot coexist with a record of the zone or an
+//earlier record of the file
+type Conflict struct {
+	Body     cisv1.DnsBody
+	Existing cisv1.DnsRecord
+	Reason   string
+}
+
+//Failure is a record the API refused
+type Failure struct {
+	Body cisv1.DnsBody
+	Err  error
+}
+
+//ImportResult describes what Import did, or would do in a dry run
+type ImportResult struct {
+	//Created are the records created. In a dry run they only have the
+	//fields of the zone file.
+	Created []cisv1.DnsRecord
+	//Unchanged are records already in the zone
+	Unchanged []cisv1.DnsBody
+	Conflicts []Conflict
+	Skipped   []Skipped
+	Failed    []Failure
+}
+
+//Importer creates the records of a zone file in a CIS zone
+type Importer struct {
+	Dns cisv1.Dns
+}
+
+//Import creates the records of zone that are missing from the CIS zone.
+//Records that already exist are left alone. A CNAME cannot share its name
+//with another record, so such records are reported as conflicts and not
+//created. API errors do not stop the import; they are collected in the
+//result and reported as an ErrCodeImportIncomplete error at the end.
+func (i Importer) Import(cisID, zoneID string, zone *Zone, dryRun bool) (ImportResult, error) {
+	bodies, skipped := zone.Bodies()
+	result := ImportResult{
+		Created:   []cisv1.DnsRecord{},
+		Unchanged: []cisv1.DnsBody{},
+		Conflicts: []Conflict{},
+		Skipped:   skipped,
+		Failed:    []Failure{},
+	}
+	existing, err := i.Dns.ListDns(cisID, zoneID)
+	if err != nil {
+		return result, err
+	}
+	present := map[string]bool{}
+	byName := map[string][]cisv1.DnsRecord{}
+	track := func(r cisv1.DnsRecord) {
+		present[RecordKey(r)] = true
+		name := strings.ToLower(r.Name)
+		byName[name] = append(byName[name], r)
+	}
+	for _, r := range existing {
+		track(r)
+	}
+
+	for _, body := range bodies {
+		record := body.Record()
+		if present[RecordKey(record)] {
+			result.Unchanged = append(result.Unchanged, body)
+			continue
+		}
+		if other, ok := cnameConflict(record, byName[strings.ToLower(body.Name)]); ok {
+			result.Conflicts = append(result.Conflicts, Conflict{
+				Body:     body,
+				Existing: other,
+				Reason:   fmt.Sprintf("%s %s cannot coexist with %s %s", body.DnsType, body.Name, other.DnsType, other.Name),
+			})
+			continue
+		}
+		if dryRun {
+			result.Created = append(result.Created, record)
+			track(record)
+			continue
+		}
+		created, err := i.Dns.CreateDns(cisID, zoneID, body)
+		if err != nil {
+			result.Failed = append(result.Failed, Failure{Body: body, Err: err})
+			continue
+		}
+		result.Created = append(result.Created, *created)
+		track(record)
+	}
+	if len(result.Failed) > 0 {
+		return result, bmxerror.New(ErrCodeImportIncomplete,
+			fmt.Sprintf("%d of %d records could not be created", len(result.Failed), len(bodies)))
+	}
+	return result, nil
+}
+
+//cnameConflict returns a record at the same name that cannot coexist with
+//r because one of them is a CNAME
+func cnameConflict(r cisv1.DnsRecord, sameName []cisv1.DnsRecord) (cisv1.DnsRecord, bool) {
+	for _, other := range sameName {
+		if r.DnsType == "CNAME" || other.DnsType == "CNAME" {
+			return other, true
+		}
+	}
+	return cisv1.DnsRecord{}, false
+}
+
+//RecordKey identifies a record by name, type and data, ignoring TTL and
+//proxying. Names and data other than text are compared without case.
+func RecordKey(r cisv1.DnsRecord) string {
+	data, err := RData(r)
+	if err != nil {
+		data = r.Content
+	}
+	if r.DnsType != "TXT" && r.DnsType != "CAA" {
+		data = strings.ToLower(data)
+	}
+	return strings.ToLower(strings.TrimSuffix(r.Name, ".")) + " " + r.DnsType + " " + data
+}
@@ -0,0 +1,150 @@
+package zonefile
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type token struct {
+	text   string
+	quoted bool
+}
+
+//entry is one logical line of a zone file. Parentheses join physical lines.
+type entry struct {
+	line   int
+	tokens []token
+	//continued is set when the line starts with whitespace, meaning the
+	//owner of the previous record is reused
+	continued bool
+	comment   string
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r'
+}
+
+//lex splits data into logical lines of tokens
+func lex(data string) ([]entry, error) {
+	entries := []entry{}
+	cur := entry{}
+	line := 1
+	depth := 0
+	atLineStart := true
+	flush := func() {
+		if len(cur.tokens) > 0 {
+			entries = append(entries, cur)
+		}
+		cur = entry{}
+		atLineStart = true
+	}
+
+	for i := 0; i < len(data); {
+		c := data[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+			if depth == 0 {
+				flush()
+			}
+		case isSpace(c):
+			if atLineStart && depth == 0 && len(cur.tokens) == 0 {
+				cur.continued = true
+			}
+			i++
+		case c == ';':
+			end := strings.IndexByte(data[i:], '\n')
+			if end < 0 {
+				end = len(data) - i
+			}
+			comment := strings.TrimSpace(data[i+1 : i+end])
+			if cur.comment != "" && comment != "" {
+				cur.comment += " "
+			}
+			cur.comment += comment
+			i += end
+		case c == '(':
+			depth++
+			i++
+		case c == ')':
+			if depth == 0 {
+				return nil, fmt.Errorf("line %d: unbalanced parenthesis", line)
+			}
+			depth--
+			i++
+		case c == '"':
+			text, n, err := quoted(data[i:])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+			cur.add(line, token{text: text, quoted: true})
+			atLineStart = false
+			i += n
+		default:
+			start := i
+			for i < len(data) && !isSpace(data[i]) && !strings.ContainsRune("\n;()\"", rune(data[i])) {
+				if data[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i > len(data) {
+				i = len(data)
+			}
+			cur.add(line, token{text: data[start:i]})
+			atLineStart = false
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("line %d: unbalanced parenthesis", line)
+	}
+	flush()
+	return entries, nil
+}
+
+func (e *entry) add(line int, t token) {
+	if len(e.tokens) == 0 {
+		e.line = line
+	}
+	e.tokens = append(e.tokens, t)
+}
+
+//quoted reads a quoted string at the start of s, resolving \X and \DDD
+//escapes. It returns the text and the number of bytes consumed.
+func quoted(s string) (string, int, error) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			return b.String(), i + 1, nil
+		case '\n':
+			return "", 0, fmt.Errorf("unterminated quoted string")
+		case '\\':
+			if i+3 < len(s) && isDigits(s[i+1:i+4]) {
+				n, _ := strconv.Atoi(s[i+1 : i+4])
+				if n > 255 {
+					return "", 0, fmt.Errorf("invalid escape \\%s", s[i+1:i+4])
+				}
+				b.WriteByte(byte(n))
+				i += 3
+			} else if i+1 < len(s) {
+				i++
+				b.WriteByte(s[i])
+			}
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated quoted string")
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return len(s) > 0
+}
@@ -0,0 +1,394 @@
+//Package zonefile converts between RFC 1035 zone files and CIS DNS records
+package zonefile
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/IBM-Cloud/bluemix-go/api/cis/cisv1"
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+)
+
+//ErrCodeInvalidZoneFile ...
+const ErrCodeInvalidZoneFile = "InvalidZoneFile"
+
+//proxiedTag marks proxied records in the comments of exported zone files
+const proxiedTag = "cf_tags=cf-proxied:true"
+
+//Types converted to CIS records
+var supportedTypes = map[string]bool{
+	"A": true, "AAAA": true, "CNAME": true, "NS": true, "MX": true,
+	"TXT": true, "SRV": true, "CAA": true, "PTR": true,
+}
+
+//Types recognized in zone files
+var knownTypes = map[string]bool{
+	"A": true, "AAAA": true, "CNAME": true, "NS": true, "MX": true,
+	"TXT": true, "SRV": true, "CAA": true, "PTR": true, "SOA": true,
+	"SPF": true, "LOC": true, "HINFO": true, "NAPTR": true, "DS": true,
+	"DNSKEY": true, "SSHFP": true, "TLSA": true, "CERT": true, "URI": true,
+}
+
+//SOA is the start of authority of a zone. CIS manages its own, so it is
+//kept for reference only.
+type SOA struct {
+	MName   string
+	RName   string
+	Serial  uint32
+	Refresh int
+	Retry   int
+	Expire  int
+	Minimum int
+}
+
+//Record is a resource record of a zone file. Names are fully qualified
+//without the trailing dot.
+type Record struct {
+	Line int
+	Name string
+	TTL  int
+	Type string
+	//RData are the data fields, with domain names made absolute and quoted
+	//strings unquoted
+	RData []string
+	//Proxied is read from the cf_tags comment written by Export
+	Proxied bool
+}
+
+//Zone is a parsed zone file
+type Zone struct {
+	Origin  string
+	SOA     *SOA
+	Records []Record
+}
+
+//ParseFile parses the zone file at path. origin is used until a $ORIGIN
+//directive and may be empty when the file sets one.
+func ParseFile(path, origin string) (*Zone, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f, origin)
+}
+
+//Parse reads a zone file. $INCLUDE and $GENERATE are not supported.
+func Parse(r io.Reader, origin string) (*Zone, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := lex(string(data))
+	if err != nil {
+		return nil, invalid(err.Error())
+	}
+	origin = strings.TrimSuffix(strings.ToLower(origin), ".")
+	zone := &Zone{Origin: origin, Records: []Record{}}
+	defaultTTL, lastTTL := -1, -1
+	lastOwner := ""
+
+	for _, e := range entries {
+		fail := func(format string, args ...interface{}) error {
+			return invalid(fmt.Sprintf("line %d: %s", e.line, fmt.Sprintf(format, args...)))
+		}
+		tokens := e.tokens
+		if first := tokens[0]; !first.quoted && !e.continued && strings.HasPrefix(first.text, "$") {
+			switch strings.ToUpper(first.text) {
+			case "$ORIGIN":
+				if len(tokens) < 2 {
+					return nil, fail("$ORIGIN needs a name")
+				}
+				origin = absolute(tokens[1].text, origin)
+				if zone.Origin == "" {
+					zone.Origin = origin
+				}
+			case "$TTL":
+				if len(tokens) < 2 {
+					return nil, fail("$TTL needs a value")
+				}
+				ttl, err := parseTTL(tokens[1].text)
+				if err != nil {
+					return nil, fail("%v", err)
+				}
+				defaultTTL = ttl
+			default:
+				return nil, fail("directive %s is not supported", first.text)
+			}
+			continue
+		}
+
+		rec := Record{Line: e.line, TTL: -1, Proxied: strings.Contains(e.comment, proxiedTag)}
+		if e.continued {
+			if lastOwner == "" {
+				return nil, fail("record has no owner name")
+			}
+			rec.Name = lastOwner
+		} else {
+			if origin == "" && tokens[0].text != "@" && !strings.HasSuffix(tokens[0].text, ".") {
+				return nil, fail("relative name %q without an origin", tokens[0].text)
+			}
+			rec.Name = absolute(tokens[0].text, origin)
+			tokens = tokens[1:]
+		}
+		//TTL and class may come in either order before the type
+	prefix:
+		for i := 0; i < 2 && len(tokens) > 0 && !tokens[0].quoted; i++ {
+			ttl, err := parseTTL(tokens[0].text)
+			switch {
+			case err == nil:
+				rec.TTL = ttl
+			case strings.EqualFold(tokens[0].text, "IN"):
+			case isClass(tokens[0].text):
+				return nil, fail("class %s is not supported", tokens[0].text)
+			default:
+				break prefix
+			}
+			tokens = tokens[1:]
+		}
+		if len(tokens) == 0 {
+			return nil, fail("record has no type")
+		}
+		rec.Type = strings.ToUpper(tokens[0].text)
+		if !knownTypes[rec.Type] {
+			return nil, fail("unknown record type %q", tokens[0].text)
+		}
+		for _, t := range tokens[1:] {
+			rec.RData = append(rec.RData, t.text)
+		}
+		if rec.TTL < 0 {
+			switch {
+			case defaultTTL >= 0:
+				rec.TTL = defaultTTL
+			case lastTTL >= 0:
+				rec.TTL = lastTTL
+			default:
+				rec.TTL = cisv1.AutoTTL
+			}
+		}
+		lastOwner, lastTTL = rec.Name, rec.TTL
+
+		if err := rec.normalize(origin); err != nil {
+			return nil, fail("%v", err)
+		}
+		if rec.Type == "SOA" {
+			soa, err := parseSOA(rec.RData)
+			if err != nil {
+				return nil, fail("%v", err)
+			}
+			zone.SOA = &soa
+			if defaultTTL < 0 {
+				defaultTTL = soa.Minimum
+			}
+		}
+		if supportedTypes[rec.Type] {
+			if _, err := rec.Body(); err != nil {
+				return nil, fail("%v", err)
+			}
+		}
+		zone.Records = append(zone.Records, rec)
+	}
+	return zone, nil
+}
+
+func invalid(message string) error {
+	return bmxerror.New(ErrCodeInvalidZoneFile, message)
+}
+
+//absolute makes name fully qualified relative to origin
+func absolute(name, origin string) string {
+	switch {
+	case name == "@":
+		return origin
+	case strings.HasSuffix(name, "."):
+		return strings.ToLower(strings.TrimSuffix(name, "."))
+	case origin == "":
+		return strings.ToLower(name)
+	}
+	return strings.ToLower(name) + "." + origin
+}
+
+func isClass(s string) bool {
+	switch strings.ToUpper(s) {
+	case "IN", "CH", "HS", "CS":
+		return true
+	}
+	return false
+}
+
+//parseTTL reads a TTL in seconds or with BIND units, such as 1h30m
+func parseTTL(s string) (int, error) {
+	if isDigits(s) {
+		return strconv.Atoi(s)
+	}
+	total, n := 0, -1
+	for _, c := range strings.ToLower(s) {
+		if c >= '0' && c <= '9' {
+			if n < 0 {
+				n = 0
+			}
+			n = n*10 + int(c-'0')
+			continue
+		}
+		unit := map[rune]int{'s': 1, 'm': 60, 'h': 3600, 'd': 86400, 'w': 604800}[c]
+		if unit == 0 || n < 0 {
+			return 0, fmt.Errorf("invalid TTL %q", s)
+		}
+		total += n * unit
+		n = -1
+	}
+	if n >= 0 {
+		return 0, fmt.Errorf("invalid TTL %q", s)
+	}
+	return total, nil
+}
+
+//rdataNames lists which data fields of a type are domain names
+var rdataNames = map[string][]int{
+	"CNAME": {0},
+	"NS":    {0},
+	"PTR":   {0},
+	"MX":    {1},
+	"SRV":   {3},
+	"SOA":   {0, 1},
+}
+
+//rdataCount is the number of data fields of fixed size types
+var rdataCount = map[string]int{
+	"A": 1, "AAAA": 1, "CNAME": 1, "NS": 1, "PTR": 1,
+	"MX": 2, "SRV": 4, "CAA": 3, "SOA": 7,
+}
+
+func (r *Record) normalize(origin string) error {
+	if n, ok := rdataCount[r.Type]; ok && len(r.RData) != n {
+		return fmt.Errorf("%s record needs %d data fields, got %d", r.Type, n, len(r.RData))
+	}
+	if len(r.RData) == 0 {
+		return fmt.Errorf("%s record has no data", r.Type)
+	}
+	for _, i := range rdataNames[r.Type] {
+		if r.RData[i] == "." {
+			continue
+		}
+		if origin == "" && !strings.HasSuffix(r.RData[i], ".") {
+			return fmt.Errorf("relative name %q without an origin", r.RData[i])
+		}
+		r.RData[i] = absolute(r.RData[i], origin)
+	}
+	return nil
+}
+
+func parseSOA(rdata []string) (SOA, error) {
+	soa := SOA{MName: rdata[0], RName: rdata[1]}
+	serial, err := strconv.ParseUint(rdata[2], 10, 32)
+	if err != nil {
+		return soa, fmt.Errorf("invalid SOA serial %q", rdata[2])
+	}
+	soa.Serial = uint32(serial)
+	for i, field := range []*int{&soa.Refresh, &soa.Retry, &soa.Expire, &soa.Minimum} {
+		if *field, err = parseTTL(rdata[3+i]); err != nil {
+			return soa, err
+		}
+	}
+	return soa, nil
+}
+
+//Body converts the record to a CIS DNS record
+func (r Record) Body() (cisv1.DnsBody, error) {
+	body := cisv1.DnsBody{Name: r.Name, DnsType: r.Type, Ttl: r.TTL, Proxied: r.Proxied}
+	if !supportedTypes[r.Type] {
+		return body, fmt.Errorf("CIS does not support %s records", r.Type)
+	}
+	number := func(i int, max uint64) (int, error) {
+		n, err := strconv.ParseUint(r.RData[i], 10, 64)
+		if err != nil || n > max {
+			return 0, fmt.Errorf("invalid %s field %q", r.Type, r.RData[i])
+		}
+		return int(n), nil
+	}
+	switch r.Type {
+	case "A", "AAAA":
+		ip := net.ParseIP(r.RData[0])
+		isV6 := strings.Contains(r.RData[0], ":")
+		if ip == nil || isV6 != (r.Type == "AAAA") {
+			return body, fmt.Errorf("invalid %s address %q", r.Type, r.RData[0])
+		}
+		body.Content = r.RData[0]
+	case "CNAME", "NS", "PTR":
+		body.Content = r.RData[0]
+	case "TXT":
+		body.Content = strings.Join(r.RData, "")
+	case "MX":
+		priority, err := number(0, 65535)
+		if err != nil {
+			return body, err
+		}
+		body.Priority = priority
+		body.Content = r.RData[1]
+	case "SRV":
+		labels := strings.SplitN(r.Name, ".", 3)
+		if len(labels) < 3 || !strings.HasPrefix(labels[0], "_") || !strings.HasPrefix(labels[1], "_") {
+			return body, fmt.Errorf("SRV name %q must be _service._proto.name", r.Name)
+		}
+		fields := make([]int, 3)
+		for i := range fields {
+			n, err := number(i, 65535)
+			if err != nil {
+				return body, err
+			}
+			fields[i] = n
+		}
+		body.Priority = fields[0]
+		body.Data = cisv1.SRVData{
+			Service:  labels[0],
+			Proto:    labels[1],
+			Name:     labels[2],
+			Priority: fields[0],
+			Weight:   fields[1],
+			Port:     fields[2],
+			Target:   r.RData[3],
+		}
+	case "CAA":
+		flags, err := number(0, 255)
+		if err != nil {
+			return body, err
+		}
+		body.Data = cisv1.CAAData{Flags: flags, Tag: r.RData[1], Value: r.RData[2]}
+	}
+	return body, nil
+}
+
+//Skipped is a record that is not imported
+type Skipped struct {
+	Record Record
+	Reason string
+}
+
+//Bodies returns the CIS records of the zone. SOA and apex NS records are
+//managed by CIS and skipped along with unsupported types.
+func (z *Zone) Bodies() ([]cisv1.DnsBody, []Skipped) {
+	bodies := []cisv1.DnsBody{}
+	skipped := []Skipped{}
+	for _, r := range z.Records {
+		switch {
+		case r.Type == "SOA":
+			skipped = append(skipped, Skipped{Record: r, Reason: "the SOA record is managed by CIS"})
+			continue
+		case r.Type == "NS" && r.Name == z.Origin:
+			skipped = append(skipped, Skipped{Record: r, Reason: "apex NS records are managed by CIS"})
+			continue
+		}
+		body, err := r.Body()
+		if err != nil {
+			skipped = append(skipped, Skipped{Record: r, Reason: err.Error()})
+			continue
+		}
+		bodies = append(bodies, body)
+	}
+	return bodies, skipped
+}
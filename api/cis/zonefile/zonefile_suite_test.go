@@ -0,0 +1,13 @@
+package zonefile_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestZonefile(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Zonefile Suite")
+}
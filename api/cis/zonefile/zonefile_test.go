@@ -0,0 +1,200 @@
+package zonefile
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+
+	"github.com/IBM-Cloud/bluemix-go/api/cis/cisv1"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const exampleZone = `$ORIGIN example.com.
+$TTL 1h
+@   IN  SOA ns1.example.com. hostmaster (
+            2024010101 ; serial
+            7200       ; refresh
+            3600       ; retry
+            1209600    ; expire
+            300 )      ; minimum
+    IN  NS  ns1.example.com.
+    IN  MX  10 mail
+    IN  A   192.0.2.1
+    IN  TXT "v=spf1 mx -all"
+    IN  CAA 0 issue "letsencrypt.org"
+www 300 IN CNAME @
+mail IN 600 A 192.0.2.10
+    AAAA 2001:db8::10
+_sip._tcp IN SRV 10 5 5060 sip.example.com.
+txt IN TXT "part one; " "part \"two\""
+$ORIGIN sub.example.com.
+app IN A 192.0.2.20 ; cf_tags=cf-proxied:true
+`
+
+type fakeDns struct {
+	cisv1.Dns
+	records []cisv1.DnsRecord
+	created []cisv1.DnsBody
+	fail    string
+}
+
+func (f *fakeDns) ListDns(cisID, zoneID string) ([]cisv1.DnsRecord, error) {
+	return f.records, nil
+}
+
+func (f *fakeDns) CreateDns(cisID, zoneID string, body cisv1.DnsBody) (*cisv1.DnsRecord, error) {
+	if body.Name == f.fail {
+		return nil, errors.New("rejected")
+	}
+	f.created = append(f.created, body)
+	record := body.Record()
+	record.Id = "record-" + body.Name
+	return &record, nil
+}
+
+var _ = Describe("Zonefile", func() {
+	Describe("Parse", func() {
+		It("should parse directives, relative names and multi-line records", func() {
+			zone, err := Parse(strings.NewReader(exampleZone), "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(zone.Origin).To(Equal("example.com"))
+			Expect(zone.SOA).To(Equal(&SOA{
+				MName: "ns1.example.com", RName: "hostmaster.example.com",
+				Serial: 2024010101, Refresh: 7200, Retry: 3600, Expire: 1209600, Minimum: 300,
+			}))
+			Expect(zone.Records).To(HaveLen(12))
+
+			bodies, skipped := zone.Bodies()
+			Expect(skipped).To(HaveLen(2))
+			Expect(skipped[0].Record.Type).To(Equal("SOA"))
+			Expect(skipped[1].Record.Type).To(Equal("NS"))
+			Expect(bodies).To(Equal([]cisv1.DnsBody{
+				{Name: "example.com", DnsType: "MX", Content: "mail.example.com", Priority: 10, Ttl: 3600},
+				{Name: "example.com", DnsType: "A", Content: "192.0.2.1", Ttl: 3600},
+				{Name: "example.com", DnsType: "TXT", Content: "v=spf1 mx -all", Ttl: 3600},
+				{Name: "example.com", DnsType: "CAA", Ttl: 3600, Data: cisv1.CAAData{Tag: "issue", Value: "letsencrypt.org"}},
+				{Name: "www.example.com", DnsType: "CNAME", Content: "example.com", Ttl: 300},
+				{Name: "mail.example.com", DnsType: "A", Content: "192.0.2.10", Ttl: 600},
+				{Name: "mail.example.com", DnsType: "AAAA", Content: "2001:db8::10", Ttl: 3600},
+				{Name: "_sip._tcp.example.com", DnsType: "SRV", Priority: 10, Ttl: 3600, Data: cisv1.SRVData{
+					Service: "_sip", Proto: "_tcp", Name: "example.com",
+					Priority: 10, Weight: 5, Port: 5060, Target: "sip.example.com",
+				}},
+				{Name: "txt.example.com", DnsType: "TXT", Content: `part one; part "two"`, Ttl: 3600},
+				{Name: "app.sub.example.com", DnsType: "A", Content: "192.0.2.20", Ttl: 3600, Proxied: true},
+			}))
+		})
+		It("should report errors with line numbers", func() {
+			_, err := Parse(strings.NewReader("$ORIGIN example.com.\nwww IN A 300.1.1.1\n"), "")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("line 2"))
+
+			_, err = Parse(strings.NewReader("www IN A 192.0.2.1\n"), "")
+			Expect(err).To(HaveOccurred())
+
+			_, err = Parse(strings.NewReader("@ IN SOA ns1 host ( 1 2 3 4 5\n"), "example.com")
+			Expect(err).To(HaveOccurred())
+
+			_, err = Parse(strings.NewReader("$INCLUDE other.zone\n"), "example.com")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should use the origin argument until $ORIGIN", func() {
+			zone, err := Parse(strings.NewReader("www 60 A 192.0.2.1\n"), "Example.com.")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(zone.Records[0].Name).To(Equal("www.example.com"))
+			Expect(zone.Records[0].TTL).To(Equal(60))
+		})
+	})
+
+	Describe("Export", func() {
+		records := []cisv1.DnsRecord{
+			{Name: "www.example.com", DnsType: "CNAME", Content: "example.com", Ttl: 1, Proxied: true},
+			{Name: "example.com", DnsType: "MX", Content: "mail.example.com", Priority: 10, Ttl: 3600},
+			{Name: "example.com", DnsType: "TXT", Content: `say "hi"`, Ttl: 1},
+			{Name: "_sip._tcp.example.com", DnsType: "SRV", Ttl: 1, Data: map[string]interface{}{
+				"priority": float64(10), "weight": float64(5), "port": float64(5060), "target": "sip.example.com",
+			}},
+			{Name: "other.org", DnsType: "A", Content: "192.0.2.1", Ttl: 120},
+		}
+		zone := cisv1.Zone{Name: "example.com", NameServers: []string{"ns1.cis.example.net"}}
+
+		It("should write a canonical zone file", func() {
+			var out bytes.Buffer
+			Expect(Export(&out, zone, records)).To(Succeed())
+			Expect(out.String()).To(Equal(`;; Zone example.com exported from CIS
+$ORIGIN example.com.
+$TTL 300
+@	3600	IN	MX	10 mail.example.com.
+@		IN	NS	ns1.cis.example.net.
+@	1	IN	TXT	"say \"hi\""
+_sip._tcp	1	IN	SRV	10 5 5060 sip.example.com.
+other.org.	120	IN	A	192.0.2.1
+www	1	IN	CNAME	example.com. ; cf_tags=cf-proxied:true
+`))
+		})
+		It("should parse back to the same records", func() {
+			var out bytes.Buffer
+			Expect(Export(&out, zone, records)).To(Succeed())
+			parsed, err := Parse(&out, "")
+			Expect(err).NotTo(HaveOccurred())
+			bodies, _ := parsed.Bodies()
+			Expect(bodies).To(HaveLen(5))
+			Expect(bodies[4]).To(Equal(cisv1.DnsBody{Name: "www.example.com", DnsType: "CNAME", Content: "example.com", Ttl: 1, Proxied: true}))
+			Expect(bodies[0].Ttl).To(Equal(3600))
+			Expect(bodies[1].Content).To(Equal(`say "hi"`))
+		})
+	})
+
+	Describe("RecordKey", func() {
+		It("should match the same data however it is held", func() {
+			live := cisv1.DnsRecord{Id: "1", Name: "_sip._tcp.Example.com.", DnsType: "SRV", Ttl: 300,
+				Data: cisv1.SRVData{Priority: 10, Weight: 5, Port: 5060, Target: "SIP.example.com"}}
+			backup := cisv1.DnsBody{Name: "_sip._tcp.example.com", DnsType: "SRV", Ttl: 1, Data: map[string]interface{}{
+				"priority": float64(10), "weight": float64(5), "port": float64(5060), "target": "sip.example.com",
+			}}
+			Expect(RecordKey(backup.Record())).To(Equal(RecordKey(live)))
+
+			backup.Data.(map[string]interface{})["port"] = float64(5061)
+			Expect(RecordKey(backup.Record())).NotTo(Equal(RecordKey(live)))
+		})
+	})
+
+	Describe("Import", func() {
+		var zone *Zone
+		BeforeEach(func() {
+			var err error
+			zone, err = Parse(strings.NewReader(exampleZone), "")
+			Expect(err).NotTo(HaveOccurred())
+		})
+		It("should create missing records and report conflicts", func() {
+			dns := &fakeDns{records: []cisv1.DnsRecord{
+				{Id: "1", Name: "example.com", DnsType: "A", Content: "192.0.2.1"},
+				{Id: "2", Name: "www.example.com", DnsType: "A", Content: "192.0.2.2"},
+				{Id: "3", Name: "mail.example.com", DnsType: "CNAME", Content: "mx.example.net"},
+			}}
+			result, err := Importer{Dns: dns}.Import("cis", "zone", zone, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Unchanged).To(HaveLen(1))
+			Expect(result.Conflicts).To(HaveLen(3))
+			Expect(result.Conflicts[0].Body.Name).To(Equal("www.example.com"))
+			Expect(result.Conflicts[1].Existing.Id).To(Equal("3"))
+			Expect(result.Created).To(HaveLen(6))
+			Expect(dns.created).To(HaveLen(6))
+			Expect(result.Skipped).To(HaveLen(2))
+		})
+		It("should only plan in a dry run and collect failures", func() {
+			dns := &fakeDns{fail: "txt.example.com"}
+			result, err := Importer{Dns: dns}.Import("cis", "zone", zone, true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Created).To(HaveLen(10))
+			Expect(dns.created).To(BeEmpty())
+
+			result, err = Importer{Dns: dns}.Import("cis", "zone", zone, false)
+			Expect(err).To(HaveOccurred())
+			Expect(result.Failed).To(HaveLen(1))
+			Expect(result.Created).To(HaveLen(9))
+		})
+	})
+})
@@ -0,0 +1,200 @@
+//Package zonesettings takes snapshots of the settings of a CIS zone and
+//restores them, to the same zone or to another one.
+package zonesettings
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"time"
+
+	"github.com/IBM-Cloud/bluemix-go/api/cis/cisv1"
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+)
+
+//ErrCodeRestoreIncomplete ...
+const ErrCodeRestoreIncomplete = "RestoreIncomplete"
+
+//Snapshot holds every setting of a zone at a point in time
+type Snapshot struct {
+	CisID    string          `json:"cis_id"`
+	ZoneID   string          `json:"zone_id"`
+	TakenAt  time.Time       `json:"taken_at"`
+	Settings []cisv1.Setting `json:"settings"`
+}
+
+//Take reads every setting of zoneID
+func Take(settings cisv1.Settings, cisID, zoneID string) (Snapshot, error) {
+	list, err := settings.ListSettings(cisID, zoneID)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return Snapshot{CisID: cisID, ZoneID: zoneID, TakenAt: time.Now().UTC(), Settings: list}, nil
+}
+
+//Load reads a snapshot written by Save
+func Load(path string) (Snapshot, error) {
+	snapshot := Snapshot{}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return snapshot, err
+	}
+	err = json.Unmarshal(data, &snapshot)
+	return snapshot, err
+}
+
+//Save writes the snapshot as indented JSON
+func (s Snapshot) Save(w io.Writer) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+//Change sets one setting back to its snapshot value
+type Change struct {
+	Setting string
+	From    json.RawMessage
+	To      json.RawMessage
+}
+
+//Plan lists the settings that differ between a snapshot and a zone
+type Plan struct {
+	Changes []Change
+	//ReadOnly lists differing settings that are not editable, in the
+	//snapshot or in the zone, typically because of the zone's plan
+	ReadOnly []string
+	//Missing lists snapshot settings the zone does not have
+	Missing []string
+}
+
+//Diff compares a snapshot with the live settings of a zone. Settings named
+//in skip are ignored.
+func Diff(snapshot Snapshot, live []cisv1.Setting, skip ...string) Plan {
+	plan := Plan{Changes: []Change{}, ReadOnly: []string{}, Missing: []string{}}
+	skipped := map[string]bool{}
+	for _, s := range skip {
+		skipped[s] = true
+	}
+	current := map[string]cisv1.Setting{}
+	for _, s := range live {
+		current[s.ID] = s
+	}
+	for _, want := range snapshot.Settings {
+		if skipped[want.ID] {
+			continue
+		}
+		have, ok := current[want.ID]
+		switch {
+		case !ok:
+			plan.Missing = append(plan.Missing, want.ID)
+		case want.SameValue(have):
+		case !want.Editable || !have.Editable:
+			plan.ReadOnly = append(plan.ReadOnly, want.ID)
+		default:
+			plan.Changes = append(plan.Changes, Change{Setting: want.ID, From: have.Value, To: want.Value})
+		}
+	}
+	sort.Slice(plan.Changes, func(i, j int) bool { return plan.Changes[i].Setting < plan.Changes[j].Setting })
+	sort.Strings(plan.ReadOnly)
+	sort.Strings(plan.Missing)
+	return plan
+}
+
+//Restorer applies snapshots to zones
+type Restorer struct {
+	Settings cisv1.Settings
+	//Skip names settings that are never changed
+	Skip []string
+}
+
+//NewRestorer ...
+func NewRestorer(settings cisv1.Settings) *Restorer {
+	return &Restorer{Settings: settings}
+}
+
+//Plan compares snapshot with the live settings of zoneID
+func (r *Restorer) Plan(cisID, zoneID string, snapshot Snapshot) (Plan, error) {
+	live, err := r.Settings.ListSettings(cisID, zoneID)
+	if err != nil {
+		return Plan{}, err
+	}
+	return Diff(snapshot, live, r.Skip...), nil
+}
+
+//Summary is the outcome of Restore
+type Summary struct {
+	DryRun  bool
+	Plan    Plan
+	Applied int
+	//Errors are keyed by setting
+	Errors map[string]error
+}
+
+//Restore brings the settings of zoneID in line with snapshot, which may
+//come from another zone. Only changed, editable settings are updated, and
+//nothing is updated when dryRun is set. Settings that fail to update are
+//recorded in the summary and reported as an ErrCodeRestoreIncomplete error
+//once the others are updated.
+func (r *Restorer) Restore(cisID, zoneID string, snapshot Snapshot, dryRun bool) (Summary, error) {
+	plan, err := r.Plan(cisID, zoneID, snapshot)
+	if err != nil {
+		return Summary{}, err
+	}
+	summary := Summary{DryRun: dryRun, Plan: plan, Errors: map[string]error{}}
+	if dryRun {
+		return summary, nil
+	}
+	for _, c := range plan.Changes {
+		if _, err := r.Settings.UpdateSettingValue(cisID, zoneID, c.Setting, c.To); err != nil {
+			summary.Errors[c.Setting] = err
+			continue
+		}
+		summary.Applied++
+	}
+	if len(summary.Errors) > 0 {
+		return summary, bmxerror.New(ErrCodeRestoreIncomplete,
+			fmt.Sprintf("%d of %d settings could not be updated", len(summary.Errors), len(plan.Changes)))
+	}
+	return summary, nil
+}
+
+//Copy applies the current settings of one zone to another
+func (r *Restorer) Copy(fromCisID, fromZoneID, toCisID, toZoneID string, dryRun bool) (Summary, error) {
+	snapshot, err := Take(r.Settings, fromCisID, fromZoneID)
+	if err != nil {
+		return Summary{}, err
+	}
+	return r.Restore(toCisID, toZoneID, snapshot, dryRun)
+}
+
+//Print writes the plan and, unless it was a dry run, the outcome
+func (s Summary) Print(w io.Writer) {
+	for _, c := range s.Plan.Changes {
+		fmt.Fprintf(w, "~ %s: %s -> %s\n", c.Setting, c.From, c.To)
+	}
+	for _, id := range s.Plan.ReadOnly {
+		fmt.Fprintf(w, "! %s differs but is not editable\n", id)
+	}
+	for _, id := range s.Plan.Missing {
+		fmt.Fprintf(w, "! %s does not exist in the zone\n", id)
+	}
+	if s.DryRun {
+		fmt.Fprintf(w, "Dry run: %d to change\n", len(s.Plan.Changes))
+		return
+	}
+	keys := make([]string, 0, len(s.Errors))
+	for k := range s.Errors {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(w, "failed %s: %v\n", k, s.Errors[k])
+	}
+	fmt.Fprintf(w, "%d changed, %d failed\n", s.Applied, len(s.Errors))
+}
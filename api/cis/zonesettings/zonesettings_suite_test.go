@@ -0,0 +1,13 @@
+package zonesettings_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestZonesettings(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Zonesettings Suite")
+}
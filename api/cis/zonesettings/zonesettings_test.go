@@ -0,0 +1,118 @@
+package zonesettings
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/IBM-Cloud/bluemix-go/api/cis/cisv1"
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+)
+
+type fakeSettings struct {
+	cisv1.Settings
+	zones   map[string][]cisv1.Setting
+	updated map[string]interface{}
+	fail    map[string]bool
+}
+
+func (f *fakeSettings) ListSettings(cisID, zoneID string) ([]cisv1.Setting, error) {
+	return f.zones[zoneID], nil
+}
+
+func (f *fakeSettings) UpdateSettingValue(cisID, zoneID, setting string, value interface{}) (*cisv1.Setting, error) {
+	if f.fail[setting] {
+		return nil, errors.New("update failed")
+	}
+	f.updated[setting] = value
+	return &cisv1.Setting{ID: setting}, nil
+}
+
+func setting(id, value string, editable bool) cisv1.Setting {
+	return cisv1.Setting{ID: id, Value: json.RawMessage(value), Editable: editable}
+}
+
+var _ = Describe("Zonesettings", func() {
+	var fake *fakeSettings
+	BeforeEach(func() {
+		fake = &fakeSettings{
+			zones: map[string][]cisv1.Setting{
+				"source": {
+					setting(cisv1.SettingSSL, `"strict"`, true),
+					setting(cisv1.SettingMinify, `{"css":"on","html":"on","js":"on"}`, true),
+					setting(cisv1.SettingChallengeTTL, `1800`, true),
+					setting(cisv1.SettingWAF, `"on"`, false),
+					setting(cisv1.SettingIPv6, `"on"`, true),
+					setting("new_setting", `"on"`, true),
+				},
+				"target": {
+					setting(cisv1.SettingSSL, `"full"`, true),
+					setting(cisv1.SettingMinify, `{"js":"on", "html":"on", "css":"on"}`, true),
+					setting(cisv1.SettingChallengeTTL, `900`, true),
+					setting(cisv1.SettingWAF, `"off"`, false),
+					setting(cisv1.SettingIPv6, `"off"`, true),
+				},
+			},
+			updated: map[string]interface{}{},
+			fail:    map[string]bool{},
+		}
+	})
+
+	It("should plan only changed, editable settings", func() {
+		snapshot, err := Take(fake, "crn", "source")
+		Expect(err).NotTo(HaveOccurred())
+		r := NewRestorer(fake)
+		r.Skip = []string{cisv1.SettingIPv6}
+		plan, err := r.Plan("crn", "target", snapshot)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Changes).To(HaveLen(2))
+		Expect(plan.Changes[0].Setting).To(Equal(cisv1.SettingChallengeTTL))
+		Expect(plan.Changes[1].Setting).To(Equal(cisv1.SettingSSL))
+		Expect(plan.ReadOnly).To(Equal([]string{cisv1.SettingWAF}))
+		Expect(plan.Missing).To(Equal([]string{"new_setting"}))
+	})
+
+	It("should copy settings to another zone", func() {
+		fake.fail[cisv1.SettingSSL] = true
+		summary, err := NewRestorer(fake).Copy("crn", "source", "crn", "target", false)
+		Expect(err).To(HaveOccurred())
+		Expect(err.(bmxerror.Error).Code()).To(Equal(ErrCodeRestoreIncomplete))
+		Expect(summary.Applied).To(Equal(2))
+		Expect(summary.Errors).To(HaveKey(cisv1.SettingSSL))
+		Expect(fake.updated[cisv1.SettingChallengeTTL]).To(Equal(json.RawMessage(`1800`)))
+		out := &bytes.Buffer{}
+		summary.Print(out)
+		Expect(out.String()).To(ContainSubstring("~ challenge_ttl: 900 -> 1800"))
+		Expect(out.String()).To(ContainSubstring("2 changed, 1 failed"))
+	})
+
+	It("should not update anything on a dry run", func() {
+		snapshot, _ := Take(fake, "crn", "source")
+		summary, err := NewRestorer(fake).Restore("crn", "target", snapshot, true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(summary.Plan.Changes).To(HaveLen(3))
+		Expect(fake.updated).To(BeEmpty())
+	})
+
+	It("should save and load snapshots", func() {
+		snapshot, _ := Take(fake, "crn", "source")
+		dir, err := ioutil.TempDir("", "zonesettings")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "snapshot.json")
+		f, err := os.Create(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(snapshot.Save(f)).To(Succeed())
+		f.Close()
+		loaded, err := Load(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(loaded.ZoneID).To(Equal("source"))
+		Expect(Diff(loaded, fake.zones["source"]).Changes).To(BeEmpty())
+	})
+})
@@ -0,0 +1,13 @@
+package globalsearchv2_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestGlobalsearchv2(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Globalsearchv2 Suite")
+}
@@ -0,0 +1,142 @@
+package globalsearchv2
+
+import (
+	"fmt"
+
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+)
+
+//ErrCodeSearchStalled ...
+const ErrCodeSearchStalled = "SearchStalled"
+
+//SearchIterator walks every item matched by a search, following the result
+//token until the service reports there is no more data. The token may stay
+//the same from page to page; a page that brings no new items while more data
+//is reported stops the iteration with an ErrCodeSearchStalled error.
+//
+//	it := NewSearchIterator(searches, SearchBody{Query: q.String()}, SearchParams{})
+//	for it.Next() {
+//		item := it.Item()
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type SearchIterator struct {
+	searches Searches
+	body     SearchBody
+	params   SearchParams
+
+	page     []Item
+	index    int
+	seen     int
+	done     bool
+	err      error
+	warnings []string
+}
+
+//NewSearchIterator ...
+func NewSearchIterator(searches Searches, body SearchBody, params SearchParams) *SearchIterator {
+	return &SearchIterator{
+		searches: searches,
+		body:     body,
+		params:   params,
+		index:    -1,
+	}
+}
+
+//Next advances to the next item, fetching pages as needed. It returns false
+//once every item has been visited or a request failed.
+func (it *SearchIterator) Next() bool {
+	for {
+		if it.err != nil {
+			return false
+		}
+		if it.index+1 < len(it.page) {
+			it.index++
+			return true
+		}
+		if it.done {
+			return false
+		}
+		it.fetch()
+	}
+}
+
+func (it *SearchIterator) fetch() {
+	result, err := it.searches.PostQueryWithParams(it.body, it.params)
+	if err != nil {
+		it.err = err
+		return
+	}
+	if result.FilterError {
+		it.warn("the search filter could not be fully applied, results may include resources outside of it")
+	}
+	if result.PartialData != 0 {
+		it.warn(fmt.Sprintf("the search returned partial data (code %d)", result.PartialData))
+	}
+	more := result.MoreData && result.Token != ""
+	if more && (len(result.Items) == 0 || (result.Token == it.body.Token && samePage(result.Items, it.page))) {
+		//asking again would return the same page forever
+		it.err = bmxerror.New(ErrCodeSearchStalled,
+			fmt.Sprintf("the search reported more data after %d items but returned no new ones", it.seen))
+		return
+	}
+	it.page = result.Items
+	it.index = -1
+	it.seen += len(result.Items)
+	it.done = !more
+	it.body.Token = result.Token
+}
+
+//samePage reports whether two pages hold the same items in the same order
+func samePage(a, b []Item) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].CRN != b[i].CRN || a[i].Name != b[i].Name {
+			return false
+		}
+	}
+	return true
+}
+
+//warn records message once however many pages report it
+func (it *SearchIterator) warn(message string) {
+	for _, w := range it.warnings {
+		if w == message {
+			return
+		}
+	}
+	it.warnings = append(it.warnings, message)
+}
+
+//Item returns the current item
+func (it *SearchIterator) Item() Item {
+	if it.index < 0 || it.index >= len(it.page) {
+		return Item{}
+	}
+	return it.page[it.index]
+}
+
+//Err returns the error that stopped the iteration, if any. Items visited
+//before an error are only part of the results.
+func (it *SearchIterator) Err() error {
+	return it.err
+}
+
+//Warnings returns the FilterError and PartialData conditions reported by
+//the pages fetched so far, each once
+func (it *SearchIterator) Warnings() []string {
+	return it.warnings
+}
+
+//SearchAll collects every item matched by body along with any warnings
+func SearchAll(searches Searches, body SearchBody, params SearchParams) ([]Item, []string, error) {
+	items := []Item{}
+	it := NewSearchIterator(searches, body, params)
+	for it.Next() {
+		items = append(items, it.Item())
+	}
+	return items, it.Warnings(), it.Err()
+}
@@ -0,0 +1,107 @@
+package globalsearchv2
+
+import (
+	"net/http"
+
+	"github.com/onsi/gomega/ghttp"
+
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SearchIterator", func() {
+	var server *ghttp.Server
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodPost, "/v2/resources/search", "limit=2&sort=name"),
+				ghttp.VerifyJSON(`{"query":"type:resource\\-instance","fields":["region"]}`),
+				ghttp.RespondWith(http.StatusOK, `{"items":[{"name":"a","crn":"crn:a","region":"us-south"},{"name":"b","crn":"crn:b"}],"more_data":true,"token":"t1"}`),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodPost, "/v2/resources/search", "limit=2&sort=name"),
+				ghttp.VerifyJSON(`{"query":"type:resource\\-instance","fields":["region"],"token":"t1"}`),
+				ghttp.RespondWith(http.StatusOK, `{"items":[{"name":"c","crn":"crn:c"}],"more_data":false,"partial_data":1}`),
+			),
+		)
+	})
+	AfterEach(func() {
+		server.Close()
+	})
+	It("should follow the token until there is no more data", func() {
+		items, warnings, err := SearchAll(newSearch(server.URL()), SearchBody{
+			Query:  Type("resource-instance").String(),
+			Fields: []string{"region"},
+		}, SearchParams{Limit: 2, Sort: []string{"name"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(items).Should(HaveLen(3))
+		Expect(items[0].Fields["region"]).Should(Equal("us-south"))
+		Expect(items[2].Name).Should(Equal("c"))
+		Expect(warnings).Should(HaveLen(1))
+	})
+
+	Context("When the pages repeat", func() {
+		It("should report each warning once", func() {
+			fake := &fakeSearches{pages: []SearchResult{
+				{Items: []Item{{Name: "a"}}, MoreData: true, Token: "t1", FilterError: true, PartialData: 1},
+				{Items: []Item{{Name: "b"}}, MoreData: true, Token: "t2", FilterError: true, PartialData: 1},
+				{Items: []Item{{Name: "c"}}, FilterError: true, PartialData: 2},
+			}}
+			items, warnings, err := SearchAll(fake, SearchBody{}, SearchParams{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(items).Should(HaveLen(3))
+			Expect(warnings).Should(Equal([]string{
+				"the search filter could not be fully applied, results may include resources outside of it",
+				"the search returned partial data (code 1)",
+				"the search returned partial data (code 2)",
+			}))
+		})
+		It("should keep following a token that stays the same", func() {
+			fake := &fakeSearches{pages: []SearchResult{
+				{Items: []Item{{Name: "a", CRN: "crn:a"}}, MoreData: true, Token: "t1"},
+				{Items: []Item{{Name: "b", CRN: "crn:b"}}, MoreData: true, Token: "t1"},
+				{Items: []Item{{Name: "c", CRN: "crn:c"}}, Token: "t1"},
+			}}
+			items, _, err := SearchAll(fake, SearchBody{}, SearchParams{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(items).Should(HaveLen(3))
+		})
+		It("should fail when the same page comes back", func() {
+			fake := &fakeSearches{pages: []SearchResult{
+				{Items: []Item{{Name: "a", CRN: "crn:a"}}, MoreData: true, Token: "t1"},
+				{Items: []Item{{Name: "b", CRN: "crn:b"}}, MoreData: true, Token: "t1"},
+				{Items: []Item{{Name: "b", CRN: "crn:b"}}, MoreData: true, Token: "t1"},
+			}}
+			items, _, err := SearchAll(fake, SearchBody{}, SearchParams{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.(bmxerror.Error).Code()).Should(Equal(ErrCodeSearchStalled))
+			Expect(items).Should(HaveLen(2))
+			Expect(fake.calls).Should(Equal(3))
+		})
+		It("should fail when more data is reported without items", func() {
+			fake := &fakeSearches{pages: []SearchResult{
+				{Items: []Item{{Name: "a", CRN: "crn:a"}}, MoreData: true, Token: "t1"},
+				{MoreData: true, Token: "t2"},
+			}}
+			_, _, err := SearchAll(fake, SearchBody{}, SearchParams{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.(bmxerror.Error).Code()).Should(Equal(ErrCodeSearchStalled))
+		})
+	})
+})
+
+//fakeSearches returns pages in order
+type fakeSearches struct {
+	Searches
+	pages []SearchResult
+	calls int
+}
+
+func (f *fakeSearches) PostQueryWithParams(body SearchBody, params SearchParams) (SearchResult, error) {
+	page := f.pages[f.calls]
+	f.calls++
+	return page, nil
+}
@@ -0,0 +1,142 @@
+package globalsearchv2
+
+import (
+	"strings"
+)
+
+//Query is a Lucene query expression accepted by PostQuery. Build one with
+//the field helpers and combine them with AllOf, AnyOf and the And, Or and
+//Not methods.
+type Query struct {
+	expr     string
+	compound bool
+}
+
+//luceneSpecial lists the characters that must be escaped in query values
+const luceneSpecial = `+-&|!(){}[]^"~*?:\/ `
+
+//EscapeValue escapes the Lucene special characters in v so it is matched
+//literally
+func EscapeValue(v string) string {
+	var b strings.Builder
+	for _, r := range v {
+		if strings.ContainsRune(luceneSpecial, r) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+//Field matches resources whose field equals value. The value is escaped.
+func Field(name, value string) Query {
+	return Query{expr: name + ":" + EscapeValue(value)}
+}
+
+//FieldPattern matches field against a Lucene pattern. The pattern is passed
+//through unescaped so * and ? act as wildcards.
+func FieldPattern(name, pattern string) Query {
+	return Query{expr: name + ":" + pattern}
+}
+
+//Raw wraps an expression that is already valid Lucene
+func Raw(expr string) Query {
+	return Query{expr: expr, compound: true}
+}
+
+//ServiceName ...
+func ServiceName(name string) Query {
+	return Field("service_name", name)
+}
+
+//Region ...
+func Region(region string) Query {
+	return Field("region", region)
+}
+
+//ResourceGroupID ...
+func ResourceGroupID(id string) Query {
+	return Field("resource_group_id", id)
+}
+
+//Tag matches resources carrying tag
+func Tag(tag string) Query {
+	return Field("tags", tag)
+}
+
+//Family ...
+func Family(family string) Query {
+	return Field("family", family)
+}
+
+//Type ...
+func Type(resourceType string) Query {
+	return Field("type", resourceType)
+}
+
+//All matches every resource
+func All() Query {
+	return Query{expr: "*"}
+}
+
+//AllOf matches resources matching every query. Empty queries are ignored.
+func AllOf(queries ...Query) Query {
+	return join(" AND ", queries)
+}
+
+//AnyOf matches resources matching any query. Empty queries are ignored.
+func AnyOf(queries ...Query) Query {
+	return join(" OR ", queries)
+}
+
+//And matches resources matching q and every other query
+func (q Query) And(others ...Query) Query {
+	return AllOf(append([]Query{q}, others...)...)
+}
+
+//Or matches resources matching q or any other query
+func (q Query) Or(others ...Query) Query {
+	return AnyOf(append([]Query{q}, others...)...)
+}
+
+//Not matches resources that do not match q
+func (q Query) Not() Query {
+	if q.IsEmpty() {
+		return q
+	}
+	return Query{expr: "NOT " + q.group()}
+}
+
+func join(op string, queries []Query) Query {
+	parts := make([]string, 0, len(queries))
+	for _, q := range queries {
+		if !q.IsEmpty() {
+			parts = append(parts, q.group())
+		}
+	}
+	switch len(parts) {
+	case 0:
+		return Query{}
+	case 1:
+		return Query{expr: parts[0]}
+	}
+	return Query{expr: strings.Join(parts, op), compound: true}
+}
+
+//group parenthesizes compound expressions so they can be nested
+func (q Query) group() string {
+	if q.compound {
+		return "(" + q.expr + ")"
+	}
+	return q.expr
+}
+
+//IsEmpty ...
+func (q Query) IsEmpty() bool {
+	return q.expr == ""
+}
+
+//String returns the Lucene expression
+func (q Query) String() string {
+	return q.expr
+}
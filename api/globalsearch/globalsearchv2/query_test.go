@@ -0,0 +1,25 @@
+package globalsearchv2
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Query", func() {
+	It("should escape field values", func() {
+		Expect(Field("name", "my-db (prod)").String()).Should(Equal(`name:my\-db\ \(prod\)`))
+		Expect(Tag("env:prod").String()).Should(Equal(`tags:env\:prod`))
+	})
+	It("should combine queries", func() {
+		q := AllOf(
+			ServiceName("cloud-object-storage"),
+			Region("us-south").Or(Region("eu-de")),
+			Tag("env:dev").Not(),
+			Query{},
+		)
+		Expect(q.String()).Should(Equal(`service_name:cloud\-object\-storage AND (region:us\-south OR region:eu\-de) AND NOT tags:env\:dev`))
+	})
+	It("should not escape patterns", func() {
+		Expect(FieldPattern("name", "web-*").And(Family("resource_controller")).String()).Should(Equal(`name:web-* AND family:resource_controller`))
+	})
+})
@@ -1,8 +1,14 @@
 package globalsearchv2
 
 import (
+	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
+
 	"github.com/IBM-Cloud/bluemix-go/client"
+	"github.com/IBM-Cloud/bluemix-go/helpers"
+	"github.com/IBM-Cloud/bluemix-go/rest"
 )
 
 type SearchResult struct {
@@ -18,6 +24,19 @@ type Item struct {
 	CRN         string   `json:"crn,omitempty"`
 	ServiceName string   `json:"service_name,omitempty"`
 	Tags        []string `json:"tags,omitempty"`
+
+	//Fields holds every property returned for the item, including the ones
+	//requested through SearchBody.Fields
+	Fields map[string]interface{} `json:"-"`
+}
+
+//UnmarshalJSON keeps the raw properties of the item in Fields
+func (i *Item) UnmarshalJSON(data []byte) error {
+	type Copy Item
+	if err := json.Unmarshal(data, (*Copy)(i)); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &i.Fields)
 }
 
 type SearchBody struct {
@@ -26,8 +45,20 @@ type SearchBody struct {
 	Token  string   `json:"token,omitempty"`
 }
 
+//SearchParams are the optional query parameters of a search request
+type SearchParams struct {
+	//Limit is the page size, the service allows up to 1000
+	Limit int
+	//Sort lists fields to order by, prefix a field with - for descending order
+	Sort []string
+	//Timeout in milliseconds, zero uses the service default
+	Timeout   int
+	AccountID string
+}
+
 type Searches interface {
 	PostQuery(searchBody SearchBody) (SearchResult, error)
+	PostQueryWithParams(searchBody SearchBody, params SearchParams) (SearchResult, error)
 }
 
 type searches struct {
@@ -49,3 +80,25 @@ func (r *searches) PostQuery(searchBody SearchBody) (SearchResult, error) {
 	}
 	return searchResult, nil
 }
+
+func (r *searches) PostQueryWithParams(searchBody SearchBody, params SearchParams) (SearchResult, error) {
+	searchResult := SearchResult{}
+	request := rest.PostRequest(helpers.GetFullURL(*r.client.Config.Endpoint, "/v2/resources/search"))
+	if params.Limit > 0 {
+		request = request.Query("limit", strconv.Itoa(params.Limit))
+	}
+	if len(params.Sort) > 0 {
+		request = request.Query("sort", strings.Join(params.Sort, ","))
+	}
+	if params.Timeout > 0 {
+		request = request.Query("timeout", strconv.Itoa(params.Timeout))
+	}
+	if params.AccountID != "" {
+		request = request.Query("account_id", params.AccountID)
+	}
+	_, err := r.client.SendRequest(request.Body(&searchBody), &searchResult)
+	if err != nil {
+		return searchResult, err
+	}
+	return searchResult, nil
+}
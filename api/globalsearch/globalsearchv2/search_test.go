@@ -29,8 +29,8 @@ var _ = Describe("Tasks", func() {
 						ghttp.VerifyRequest(http.MethodPost, "/v2/resources/search"),
 						ghttp.RespondWith(http.StatusOK, `
                            {
-                            "items": {
-                            }
+                            "items": [
+                            ]
                           }
                         `),
 					),
@@ -76,7 +76,7 @@ var _ = Describe("Tasks", func() {
 				}
 				searchResult, err := newSearch(server.URL()).PostQuery(searchBody)
 				Expect(err).To(HaveOccurred())
-				Expect(searchResult.Items).Should(Equal(""))
+				Expect(searchResult.Items).Should(BeEmpty())
 			})
 		})
 	})
@@ -0,0 +1,128 @@
+package globaltaggingv3
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/IBM-Cloud/bluemix-go/api/globalsearch/globalsearchv2"
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+)
+
+//ErrCodeBulkTaggingFailed ...
+const ErrCodeBulkTaggingFailed = "BulkTaggingFailed"
+
+//MaxResourcesPerRequest is the number of resources the tagging service
+//accepts in one attach or detach request
+const MaxResourcesPerRequest = 100
+
+//BulkTagResult reports the outcome of a batched attach or detach
+type BulkTagResult struct {
+	//Succeeded lists the resources that were updated
+	Succeeded []string
+	//Failed holds the service result for every rejected resource, including
+	//the resources of batches whose request failed
+	Failed []TagResult
+	//Errors holds the request errors of batches that failed as a whole,
+	//keyed by the first resource of the batch
+	Errors map[string]error
+}
+
+//HasFailures ...
+func (b BulkTagResult) HasFailures() bool {
+	return len(b.Failed) > 0 || len(b.Errors) > 0
+}
+
+//bulkUpdate sends resourceIDs to update in batches of MaxResourcesPerRequest.
+//A failed batch does not stop the remaining ones.
+func bulkUpdate(resourceIDs []string, update func(batch []string) (TagUpdateResult, error)) (BulkTagResult, error) {
+	result := BulkTagResult{
+		Succeeded: []string{},
+		Failed:    []TagResult{},
+		Errors:    map[string]error{},
+	}
+	for start := 0; start < len(resourceIDs); start += MaxResourcesPerRequest {
+		end := start + MaxResourcesPerRequest
+		if end > len(resourceIDs) {
+			end = len(resourceIDs)
+		}
+		batch := resourceIDs[start:end]
+		updated, err := update(batch)
+		if err != nil {
+			result.Errors[batch[0]] = err
+			for _, id := range batch {
+				result.Failed = append(result.Failed, TagResult{ResourceID: id, Failed: true, Message: err.Error()})
+			}
+			continue
+		}
+		reported := map[string]bool{}
+		for _, r := range updated.Results {
+			reported[r.ResourceID] = true
+			if r.Failure() {
+				result.Failed = append(result.Failed, r)
+			} else {
+				result.Succeeded = append(result.Succeeded, r.ResourceID)
+			}
+		}
+		//Resources missing from the results were accepted
+		for _, id := range batch {
+			if !reported[id] {
+				result.Succeeded = append(result.Succeeded, id)
+			}
+		}
+	}
+	if result.HasFailures() {
+		return result, bmxerror.New(ErrCodeBulkTaggingFailed,
+			fmt.Sprintf("Tagging failed for %d of %d resources", len(result.Failed), len(resourceIDs)))
+	}
+	return result, nil
+}
+
+//TagUsage is a tag and the number of resources it is attached to
+type TagUsage struct {
+	Name  string
+	Count int
+}
+
+//ListTagUsage lists every tag of tagType in the account together with the
+//number of resources it is attached to. Counts come from Global Search, so
+//unattached tags are reported with a count of zero. The warnings of the
+//search are returned with the usage; when there are any, the counts may be
+//too low.
+func ListTagUsage(tags Tags, searches globalsearchv2.Searches, tagType string) ([]TagUsage, []string, error) {
+	items, err := tags.ListTags(TagListQuery{TagType: tagType})
+	if err != nil {
+		return nil, nil, err
+	}
+	field := "tags"
+	switch tagType {
+	case TagTypeAccess:
+		field = "access_tags"
+	case TagTypeService:
+		field = "service_tags"
+	}
+	counts := map[string]int{}
+	it := globalsearchv2.NewSearchIterator(searches, globalsearchv2.SearchBody{
+		Query:  globalsearchv2.FieldPattern(field, "*").String(),
+		Fields: []string{field},
+	}, globalsearchv2.SearchParams{Limit: 1000})
+	for it.Next() {
+		values, _ := it.Item().Fields[field].([]interface{})
+		for _, v := range values {
+			if name, ok := v.(string); ok {
+				counts[name]++
+			}
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	usage := make([]TagUsage, 0, len(items))
+	for _, item := range items {
+		usage = append(usage, TagUsage{Name: item.Name, Count: counts[item.Name]})
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		return usage[i].Name < usage[j].Name
+	})
+	return usage, it.Warnings(), nil
+}
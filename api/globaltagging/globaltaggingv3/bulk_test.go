@@ -0,0 +1,118 @@
+package globaltaggingv3
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/IBM-Cloud/bluemix-go/api/globalsearch/globalsearchv2"
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Bulk tagging", func() {
+	var server *ghttp.Server
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("AttachTagsToResources", func() {
+		BeforeEach(func() {
+			server = ghttp.NewServer()
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodPost, "/v3/tags/attach", "tag_type=access"),
+					ghttp.RespondWith(http.StatusOK, `{"results":[{"resource_id":"crn-0","is_error":true}]}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodPost, "/v3/tags/attach", "tag_type=access"),
+					ghttp.VerifyJSON(`{"resources":[{"resource_id":"crn-100"}],"tag_names":["env:prod"]}`),
+					ghttp.RespondWith(http.StatusOK, `{"results":[{"resource_id":"crn-100","is_error":false}]}`),
+				),
+			)
+		})
+		It("should batch resources and report partial failures", func() {
+			ids := make([]string, 101)
+			for i := range ids {
+				ids[i] = fmt.Sprintf("crn-%d", i)
+			}
+			result, err := newTagging(server.URL()).AttachTagsToResources(ids, []string{"env:prod"}, TagTypeAccess)
+			Expect(err).To(HaveOccurred())
+			Expect(server.ReceivedRequests()).Should(HaveLen(2))
+			Expect(result.Failed).Should(HaveLen(1))
+			Expect(result.Failed[0].ResourceID).Should(Equal("crn-0"))
+			Expect(result.Succeeded).Should(HaveLen(100))
+		})
+	})
+
+	Describe("ListTagUsage", func() {
+		BeforeEach(func() {
+			server = ghttp.NewServer()
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodGet, "/v3/tags", "offset=0&limit=1000&tag_type=user"),
+					ghttp.RespondWith(http.StatusOK, `{"total_count":3,"items":[{"name":"env:prod"},{"name":"team:a"},{"name":"old"}]}`),
+				),
+			)
+		})
+		It("should count attached resources per tag", func() {
+			page := globalsearchv2.SearchResult{}
+			Expect(json.Unmarshal([]byte(`{"items":[{"crn":"a","tags":["env:prod","team:a"]},{"crn":"b","tags":["env:prod"]}]}`), &page)).To(Succeed())
+			usage, warnings, err := ListTagUsage(newTagging(server.URL()), searchesStub{result: page}, TagTypeUser)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).Should(BeEmpty())
+			Expect(usage).Should(Equal([]TagUsage{
+				{Name: "env:prod", Count: 2},
+				{Name: "old", Count: 0},
+				{Name: "team:a", Count: 1},
+			}))
+		})
+		It("should return the warnings of partial search data", func() {
+			page := globalsearchv2.SearchResult{}
+			Expect(json.Unmarshal([]byte(`{"items":[{"crn":"a","tags":["env:prod"]}],"partial_data":1}`), &page)).To(Succeed())
+			usage, warnings, err := ListTagUsage(newTagging(server.URL()), searchesStub{result: page}, TagTypeUser)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(usage).Should(HaveLen(3))
+			Expect(warnings).Should(ConsistOf(ContainSubstring("partial data")))
+		})
+	})
+
+	Describe("DeleteUnattachedTags", func() {
+		BeforeEach(func() {
+			server = ghttp.NewServer()
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodDelete, "/v3/tags", "tag_type=user"),
+					ghttp.RespondWith(http.StatusOK, `{"total_count":1,"errors":false,"items":[{"tag_name":"old","is_error":false}]}`),
+				),
+			)
+		})
+		It("should delete every unattached tag", func() {
+			result, err := newTagging(server.URL()).DeleteUnattachedTags(TagTypeUser)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Items).Should(HaveLen(1))
+		})
+		It("should return an error when some tags were not deleted", func() {
+			server.SetHandler(0, ghttp.RespondWith(http.StatusOK,
+				`{"total_count":2,"errors":true,"items":[{"tag_name":"old","is_error":false},{"tag_name":"stale","is_error":true}]}`))
+			result, err := newTagging(server.URL()).DeleteUnattachedTags(TagTypeUser)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(bmxerror.Error).Code()).Should(Equal(ErrCodeTagDeletionFailed))
+			Expect(err.Error()).Should(ContainSubstring("stale"))
+			Expect(result.Items).Should(HaveLen(2))
+		})
+	})
+})
+
+//searchesStub returns a single page of canned results
+type searchesStub struct {
+	globalsearchv2.Searches
+	result globalsearchv2.SearchResult
+}
+
+func (s searchesStub) PostQueryWithParams(body globalsearchv2.SearchBody, params globalsearchv2.SearchParams) (globalsearchv2.SearchResult, error) {
+	return s.result, nil
+}
@@ -0,0 +1,251 @@
+package globaltaggingv3
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+
+	yaml "github.com/ghodss/yaml"
+
+	"github.com/IBM-Cloud/bluemix-go/api/globalsearch/globalsearchv2"
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+)
+
+//ErrCodeInvalidTagPolicy ...
+const ErrCodeInvalidTagPolicy = "InvalidTagPolicy"
+
+//Violation kinds
+const (
+	ViolationMissing   = "missing"
+	ViolationInvalid   = "invalid"
+	ViolationForbidden = "forbidden"
+)
+
+//TagPolicy declares the tags every resource in scope must carry. It can be
+//written as YAML or JSON:
+//
+//	scope: "type:resource-instance"
+//	required:
+//	- key: env
+//	  allowed_values: "^(dev|test|prod)$"
+//	  default: dev
+//	- key: owner
+//	forbidden:
+//	- "^temp:"
+type TagPolicy struct {
+	//Scope is a Global Search query selecting the resources to check.
+	//Empty means every resource.
+	Scope     string        `json:"scope,omitempty"`
+	Required  []RequiredTag `json:"required,omitempty"`
+	Forbidden []string      `json:"forbidden,omitempty"`
+}
+
+//RequiredTag is a key that must be present as "key:value"
+type RequiredTag struct {
+	Key string `json:"key"`
+	//AllowedValues is a regular expression the value must match
+	AllowedValues string `json:"allowed_values,omitempty"`
+	//Default is the value attached when remediating a missing tag
+	Default string `json:"default,omitempty"`
+}
+
+//Violation is one policy breach on one resource
+type Violation struct {
+	CRN         string `json:"crn"`
+	Name        string `json:"name"`
+	ServiceName string `json:"service_name"`
+	Kind        string `json:"kind"`
+	Key         string `json:"key,omitempty"`
+	Tag         string `json:"tag,omitempty"`
+	Message     string `json:"message"`
+}
+
+//ComplianceReport is the result of a compliance check
+type ComplianceReport struct {
+	Checked    int         `json:"checked"`
+	Compliant  int         `json:"compliant"`
+	Violations []Violation `json:"violations"`
+	//Warnings are the search warnings, see globalsearchv2.SearchIterator
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+//LoadTagPolicy reads a policy from a YAML or JSON file
+func LoadTagPolicy(path string) (TagPolicy, error) {
+	policy := TagPolicy{}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return policy, err
+	}
+	err = yaml.Unmarshal(data, &policy)
+	return policy, err
+}
+
+type compiledRequiredTag struct {
+	RequiredTag
+	allowed *regexp.Regexp
+}
+
+//ComplianceChecker evaluates a TagPolicy against live resources
+type ComplianceChecker struct {
+	tags      Tags
+	searches  globalsearchv2.Searches
+	policy    TagPolicy
+	required  []compiledRequiredTag
+	forbidden []*regexp.Regexp
+}
+
+//NewComplianceChecker validates policy and returns a checker for it
+func NewComplianceChecker(tags Tags, searches globalsearchv2.Searches, policy TagPolicy) (*ComplianceChecker, error) {
+	c := &ComplianceChecker{
+		tags:     tags,
+		searches: searches,
+		policy:   policy,
+	}
+	for _, req := range policy.Required {
+		if req.Key == "" {
+			return nil, bmxerror.New(ErrCodeInvalidTagPolicy, "Required tags must have a key")
+		}
+		compiled := compiledRequiredTag{RequiredTag: req}
+		if req.AllowedValues != "" {
+			re, err := regexp.Compile(req.AllowedValues)
+			if err != nil {
+				return nil, bmxerror.New(ErrCodeInvalidTagPolicy,
+					fmt.Sprintf("Allowed values of %q are not a valid expression: %v", req.Key, err))
+			}
+			if req.Default != "" && !re.MatchString(req.Default) {
+				return nil, bmxerror.New(ErrCodeInvalidTagPolicy,
+					fmt.Sprintf("Default value %q of %q is not an allowed value", req.Default, req.Key))
+			}
+			compiled.allowed = re
+		}
+		c.required = append(c.required, compiled)
+	}
+	for _, f := range policy.Forbidden {
+		re, err := regexp.Compile(f)
+		if err != nil {
+			return nil, bmxerror.New(ErrCodeInvalidTagPolicy,
+				fmt.Sprintf("Forbidden tag %q is not a valid expression: %v", f, err))
+		}
+		c.forbidden = append(c.forbidden, re)
+	}
+	return c, nil
+}
+
+//Evaluate returns the violations of a single resource
+func (c *ComplianceChecker) Evaluate(item globalsearchv2.Item) []Violation {
+	violations := []Violation{}
+	newViolation := func(kind, key, tag, message string) Violation {
+		return Violation{
+			CRN:         item.CRN,
+			Name:        item.Name,
+			ServiceName: item.ServiceName,
+			Kind:        kind,
+			Key:         key,
+			Tag:         tag,
+			Message:     message,
+		}
+	}
+
+	values := map[string][]string{}
+	for _, tag := range item.Tags {
+		if i := strings.Index(tag, ":"); i > 0 {
+			values[tag[:i]] = append(values[tag[:i]], tag[i+1:])
+		}
+	}
+	for _, req := range c.required {
+		found, ok := values[req.Key]
+		if !ok {
+			violations = append(violations, newViolation(ViolationMissing, req.Key, "",
+				fmt.Sprintf("Required tag %q is missing", req.Key)))
+			continue
+		}
+		if req.allowed == nil {
+			continue
+		}
+		for _, v := range found {
+			if !req.allowed.MatchString(v) {
+				violations = append(violations, newViolation(ViolationInvalid, req.Key, req.Key+":"+v,
+					fmt.Sprintf("Value %q of tag %q does not match %q", v, req.Key, req.AllowedValues)))
+			}
+		}
+	}
+	for _, tag := range item.Tags {
+		for _, re := range c.forbidden {
+			if re.MatchString(tag) {
+				violations = append(violations, newViolation(ViolationForbidden, "", tag,
+					fmt.Sprintf("Tag %q is forbidden", tag)))
+				break
+			}
+		}
+	}
+	return violations
+}
+
+//Check evaluates the policy against every resource in its scope
+func (c *ComplianceChecker) Check() (ComplianceReport, error) {
+	report := ComplianceReport{Violations: []Violation{}}
+	query := c.policy.Scope
+	if query == "" {
+		query = globalsearchv2.All().String()
+	}
+	it := globalsearchv2.NewSearchIterator(c.searches, globalsearchv2.SearchBody{
+		Query:  query,
+		Fields: []string{"name", "crn", "service_name", "tags"},
+	}, globalsearchv2.SearchParams{Limit: 1000})
+	for it.Next() {
+		report.Checked++
+		violations := c.Evaluate(it.Item())
+		if len(violations) == 0 {
+			report.Compliant++
+		}
+		report.Violations = append(report.Violations, violations...)
+	}
+	report.Warnings = it.Warnings()
+	if err := it.Err(); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+//Remediate attaches the default value of every missing required tag that
+//has one. Other violations need a human decision and are left alone.
+func (c *ComplianceChecker) Remediate(report ComplianceReport) (BulkTagResult, error) {
+	defaults := map[string]string{}
+	for _, req := range c.required {
+		if req.Default != "" {
+			defaults[req.Key] = req.Key + ":" + req.Default
+		}
+	}
+	byTag := map[string][]string{}
+	for _, v := range report.Violations {
+		if tag, ok := defaults[v.Key]; ok && v.Kind == ViolationMissing {
+			byTag[tag] = append(byTag[tag], v.CRN)
+		}
+	}
+	tagNames := make([]string, 0, len(byTag))
+	for tag := range byTag {
+		tagNames = append(tagNames, tag)
+	}
+	sort.Strings(tagNames)
+
+	result := BulkTagResult{
+		Succeeded: []string{},
+		Failed:    []TagResult{},
+		Errors:    map[string]error{},
+	}
+	var lastErr error
+	for _, tag := range tagNames {
+		attached, err := c.tags.AttachTagsToResources(byTag[tag], []string{tag}, TagTypeUser)
+		result.Succeeded = append(result.Succeeded, attached.Succeeded...)
+		result.Failed = append(result.Failed, attached.Failed...)
+		for id, e := range attached.Errors {
+			result.Errors[id] = e
+		}
+		if err != nil {
+			lastErr = err
+		}
+	}
+	return result, lastErr
+}
@@ -0,0 +1,72 @@
+package globaltaggingv3
+
+import (
+	"encoding/json"
+
+	"github.com/IBM-Cloud/bluemix-go/api/globalsearch/globalsearchv2"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ComplianceChecker", func() {
+	policy := TagPolicy{
+		Required: []RequiredTag{
+			{Key: "env", AllowedValues: "^(dev|prod)$", Default: "dev"},
+			{Key: "owner"},
+		},
+		Forbidden: []string{"^temp:"},
+	}
+
+	It("should reject invalid policies", func() {
+		_, err := NewComplianceChecker(nil, nil, TagPolicy{Required: []RequiredTag{{Key: "env", AllowedValues: "^prod$", Default: "dev"}}})
+		Expect(err).To(HaveOccurred())
+		_, err = NewComplianceChecker(nil, nil, TagPolicy{Forbidden: []string{"("}})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should report and remediate violations", func() {
+		page := globalsearchv2.SearchResult{}
+		Expect(json.Unmarshal([]byte(`{"items":[
+			{"crn":"crn-ok","tags":["env:prod","owner:alice"]},
+			{"crn":"crn-bad","tags":["env:qa","temp:x"]},
+			{"crn":"crn-untagged"}
+		]}`), &page)).To(Succeed())
+		tags := &tagsStub{}
+		checker, err := NewComplianceChecker(tags, searchesStub{result: page}, policy)
+		Expect(err).NotTo(HaveOccurred())
+
+		report, err := checker.Check()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Checked).Should(Equal(3))
+		Expect(report.Compliant).Should(Equal(1))
+		kinds := []string{}
+		for _, v := range report.Violations {
+			kinds = append(kinds, v.CRN+"/"+v.Kind)
+		}
+		Expect(kinds).Should(Equal([]string{
+			"crn-bad/invalid", "crn-bad/missing", "crn-bad/forbidden",
+			"crn-untagged/missing", "crn-untagged/missing",
+		}))
+
+		result, err := checker.Remediate(report)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Succeeded).Should(Equal([]string{"crn-untagged"}))
+		Expect(tags.attached).Should(Equal(map[string][]string{"env:dev": {"crn-untagged"}}))
+	})
+})
+
+type tagsStub struct {
+	Tags
+	attached map[string][]string
+}
+
+func (t *tagsStub) AttachTagsToResources(resourceIDs []string, taglist []string, tagType string) (BulkTagResult, error) {
+	if t.attached == nil {
+		t.attached = map[string][]string{}
+	}
+	for _, tag := range taglist {
+		t.attached[tag] = append(t.attached[tag], resourceIDs...)
+	}
+	return BulkTagResult{Succeeded: resourceIDs}, nil
+}
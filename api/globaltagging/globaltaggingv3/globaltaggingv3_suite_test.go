@@ -0,0 +1,13 @@
+package globaltaggingv3_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestGlobaltaggingv3(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Globaltaggingv3 Suite")
+}
@@ -2,11 +2,33 @@ package globaltaggingv3
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
 	"github.com/IBM-Cloud/bluemix-go/client"
+	"github.com/IBM-Cloud/bluemix-go/helpers"
+	"github.com/IBM-Cloud/bluemix-go/rest"
 )
 
+//ErrCodeTagDeletionFailed ...
+const ErrCodeTagDeletionFailed = "TagDeletionFailed"
+
+//Tag types accepted by the tagging service
+const (
+	TagTypeUser    = "user"
+	TagTypeAccess  = "access"
+	TagTypeService = "service"
+)
+
+//MaxTagListLimit is the largest page the tag listing returns
+const MaxTagListLimit = 1000
+
 type TaggingResult struct {
-	Items []Item `json:"items"`
+	TotalCount int    `json:"total_count"`
+	Offset     int    `json:"offset"`
+	Limit      int    `json:"limit"`
+	Items      []Item `json:"items"`
 }
 
 type Item struct {
@@ -27,6 +49,34 @@ type TagResult struct {
 	HttpCode    int    `json:"httpCode"`
 	Description string `json:"description"`
 	MoreInfo    string `json:"more_info"`
+	Failed      bool   `json:"is_error,omitempty"`
+}
+
+//Failure reports whether the service rejected the change for this resource
+func (t TagResult) Failure() bool {
+	return t.Failed || t.IsError == "true"
+}
+
+//TagListQuery filters ListTags
+type TagListQuery struct {
+	TagType      string
+	AttachedTo   string
+	AttachedOnly bool
+	AccountID    string
+	Providers    []string
+}
+
+//DeleteTagsResult is returned by DeleteUnattachedTags
+type DeleteTagsResult struct {
+	TotalCount int               `json:"total_count"`
+	Errors     bool              `json:"errors"`
+	Items      []DeleteTagResult `json:"items"`
+}
+
+//DeleteTagResult ...
+type DeleteTagResult struct {
+	TagName string `json:"tag_name"`
+	IsError bool   `json:"is_error"`
 }
 
 type TaggingBody struct {
@@ -45,6 +95,10 @@ type Tags interface {
 	AttachTags(resourceID string, taglist []string) (TagUpdateResult, error)
 	DetachTags(resourceID string, taglist []string) (TagUpdateResult, error)
 	DeleteTag(tag string) (TagUpdateResult, error)
+	ListTags(query TagListQuery) ([]Item, error)
+	AttachTagsToResources(resourceIDs []string, taglist []string, tagType string) (BulkTagResult, error)
+	DetachTagsFromResources(resourceIDs []string, taglist []string, tagType string) (BulkTagResult, error)
+	DeleteUnattachedTags(tagType string) (DeleteTagsResult, error)
 }
 
 type tags struct {
@@ -112,3 +166,90 @@ func (r *tags) DeleteTag(tagin string) (TagUpdateResult, error) {
 	return tagUpdateResult, nil
 
 }
+
+func (r *tags) ListTags(query TagListQuery) ([]Item, error) {
+	items := []Item{}
+	for offset := 0; ; {
+		request := rest.GetRequest(helpers.GetFullURL(*r.client.Config.Endpoint, "/v3/tags")).
+			Query("offset", strconv.Itoa(offset)).
+			Query("limit", strconv.Itoa(MaxTagListLimit))
+		if query.TagType != "" {
+			request = request.Query("tag_type", query.TagType)
+		}
+		if query.AttachedTo != "" {
+			request = request.Query("attached_to", query.AttachedTo)
+		}
+		if query.AttachedOnly {
+			request = request.Query("attached_only", "true")
+		}
+		if query.AccountID != "" {
+			request = request.Query("account_id", query.AccountID)
+		}
+		if len(query.Providers) > 0 {
+			request = request.Query("providers", strings.Join(query.Providers, ","))
+		}
+		page := TaggingResult{}
+		_, err := r.client.SendRequest(request, &page)
+		if err != nil {
+			return items, err
+		}
+		items = append(items, page.Items...)
+		offset += len(page.Items)
+		if len(page.Items) == 0 || offset >= page.TotalCount {
+			return items, nil
+		}
+	}
+}
+
+func (r *tags) updateTags(action string, resourceIDs []string, taglist []string, tagType string) (TagUpdateResult, error) {
+	tagUpdateResult := TagUpdateResult{}
+	taggingBody := TaggingBody{
+		TagResources: make([]TagResource, 0, len(resourceIDs)),
+		TagNames:     taglist,
+	}
+	for _, id := range resourceIDs {
+		taggingBody.TagResources = append(taggingBody.TagResources, TagResource{ResourceID: id})
+	}
+	request := rest.PostRequest(helpers.GetFullURL(*r.client.Config.Endpoint, "/v3/tags/"+action))
+	if tagType != "" {
+		request = request.Query("tag_type", tagType)
+	}
+	_, err := r.client.SendRequest(request.Body(&taggingBody), &tagUpdateResult)
+	return tagUpdateResult, err
+}
+
+func (r *tags) AttachTagsToResources(resourceIDs []string, taglist []string, tagType string) (BulkTagResult, error) {
+	return bulkUpdate(resourceIDs, func(batch []string) (TagUpdateResult, error) {
+		return r.updateTags("attach", batch, taglist, tagType)
+	})
+}
+
+func (r *tags) DetachTagsFromResources(resourceIDs []string, taglist []string, tagType string) (BulkTagResult, error) {
+	return bulkUpdate(resourceIDs, func(batch []string) (TagUpdateResult, error) {
+		return r.updateTags("detach", batch, taglist, tagType)
+	})
+}
+
+func (r *tags) DeleteUnattachedTags(tagType string) (DeleteTagsResult, error) {
+	result := DeleteTagsResult{}
+	request := rest.DeleteRequest(helpers.GetFullURL(*r.client.Config.Endpoint, "/v3/tags"))
+	if tagType != "" {
+		request = request.Query("tag_type", tagType)
+	}
+	_, err := r.client.SendRequest(request, &result)
+	if err != nil {
+		return result, err
+	}
+	if result.Errors {
+		failed := []string{}
+		for _, item := range result.Items {
+			if item.IsError {
+				failed = append(failed, item.TagName)
+			}
+		}
+		return result, bmxerror.New(ErrCodeTagDeletionFailed,
+			fmt.Sprintf("%d of %d unattached tags could not be deleted: %s",
+				len(failed), len(result.Items), strings.Join(failed, ", ")))
+	}
+	return result, nil
+}
@@ -29,8 +29,8 @@ var _ = Describe("Tasks", func() {
 						ghttp.VerifyRequest(http.MethodGet, "/v3/tags"),
 						ghttp.RespondWith(http.StatusOK, `
                            {
-                            "items": {
-                            }
+                            "items": [
+                            ]
                           }
                         `),
 					),
@@ -62,7 +62,7 @@ var _ = Describe("Tasks", func() {
 				resourceID := "crn:v1:bluemix:public:databases-for-postgresql:us-south:a/4ea1882a2d3401ed1e459979941966ea:2ede6105-d368-4f20-b2a3-2e27de37f0da::"
 				taggingResult, err := newTagging(server.URL()).GetTags(resourceID)
 				Expect(err).To(HaveOccurred())
-				Expect(taggingResult.Items).Should(Equal(""))
+				Expect(taggingResult.Items).Should(BeEmpty())
 			})
 		})
 	})
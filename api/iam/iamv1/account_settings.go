@@ -0,0 +1,116 @@
+package iamv1
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/IBM-Cloud/bluemix-go/client"
+	"github.com/IBM-Cloud/bluemix-go/rest"
+)
+
+//Restriction values of RestrictCreateServiceID and
+//RestrictCreatePlatformAPIKey
+const (
+	RestrictionRestricted    = "RESTRICTED"
+	RestrictionNotRestricted = "NOT_RESTRICTED"
+	RestrictionNotSet        = "NOT_SET"
+)
+
+//MFA levels, from weakest to strongest
+const (
+	MFANone     = "NONE"
+	MFATOTP     = "TOTP"
+	MFATOTP4All = "TOTP4ALL"
+	MFALevel1   = "LEVEL1"
+	MFALevel2   = "LEVEL2"
+	MFALevel3   = "LEVEL3"
+)
+
+//SettingNotSet is the value of duration settings left to the IAM default
+const SettingNotSet = "NOT_SET"
+
+//MFALevels lists the MFA values in increasing strength
+var MFALevels = []string{MFANone, MFATOTP, MFATOTP4All, MFALevel1, MFALevel2, MFALevel3}
+
+//MFAStrength returns the position of level in MFALevels, or -1 when it is
+//unknown
+func MFAStrength(level string) int {
+	for i, l := range MFALevels {
+		if l == level {
+			return i
+		}
+	}
+	return -1
+}
+
+//AccountSettings are the IAM identity settings of an account. Durations are
+//in seconds and hold SettingNotSet when the IAM default applies.
+type AccountSettings struct {
+	AccountID                    string `json:"account_id,omitempty"`
+	EntityTag                    string `json:"entity_tag,omitempty"`
+	RestrictCreateServiceID      string `json:"restrict_create_service_id,omitempty"`
+	RestrictCreatePlatformAPIKey string `json:"restrict_create_platform_apikey,omitempty"`
+	//AllowedIPAddresses is a comma separated list of IP addresses and
+	//subnets allowed to authenticate
+	AllowedIPAddresses                    string `json:"allowed_ip_addresses,omitempty"`
+	MFA                                   string `json:"mfa,omitempty"`
+	SessionExpirationInSeconds            string `json:"session_expiration_in_seconds,omitempty"`
+	SessionInvalidationInSeconds          string `json:"session_invalidation_in_seconds,omitempty"`
+	MaxSessionsPerIdentity                string `json:"max_sessions_per_identity,omitempty"`
+	SystemAccessTokenExpirationInSeconds  string `json:"system_access_token_expiration_in_seconds,omitempty"`
+	SystemRefreshTokenExpirationInSeconds string `json:"system_refresh_token_expiration_in_seconds,omitempty"`
+}
+
+//Seconds parses a duration setting. It returns false when the setting is
+//empty or SettingNotSet.
+func Seconds(setting string) (int, bool) {
+	if setting == "" || setting == SettingNotSet {
+		return 0, false
+	}
+	n, err := strconv.Atoi(setting)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+//AccountSettingsRepository reads and updates the IAM settings of an
+//account. Update needs the entity tag returned by Get as version.
+type AccountSettingsRepository interface {
+	Get(accountID string) (AccountSettings, error)
+	Update(accountID, version string, settings AccountSettings) (AccountSettings, error)
+}
+
+type accountSettingsRepository struct {
+	client *client.Client
+}
+
+//NewAccountSettingsRepository ...
+func NewAccountSettingsRepository(c *client.Client) AccountSettingsRepository {
+	return &accountSettingsRepository{
+		client: c,
+	}
+}
+
+func accountSettingsPath(accountID string) string {
+	return fmt.Sprintf("/v1/accounts/%s/settings/identity", url.PathEscape(accountID))
+}
+
+func (r *accountSettingsRepository) Get(accountID string) (AccountSettings, error) {
+	settings := AccountSettings{}
+	_, err := r.client.Get(accountSettingsPath(accountID), &settings)
+	return settings, err
+}
+
+//Update changes the settings that are not empty. The account ID and entity
+//tag of settings are ignored.
+func (r *accountSettingsRepository) Update(accountID, version string, settings AccountSettings) (AccountSettings, error) {
+	settings.AccountID = ""
+	settings.EntityTag = ""
+	updated := AccountSettings{}
+	req := rest.PutRequest(*r.client.Config.Endpoint + accountSettingsPath(accountID)).Body(&settings)
+	req.Set("If-Match", version)
+	_, err := r.client.SendRequest(req, &updated)
+	return updated, err
+}
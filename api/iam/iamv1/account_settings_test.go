@@ -0,0 +1,101 @@
+package iamv1
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/IBM-Cloud/bluemix-go"
+
+	"github.com/IBM-Cloud/bluemix-go/client"
+	"github.com/IBM-Cloud/bluemix-go/session"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AccountSettingsRepository", func() {
+	var server *ghttp.Server
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("Get()", func() {
+		BeforeEach(func() {
+			server = ghttp.NewServer()
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodGet, "/v1/accounts/acc/settings/identity"),
+					ghttp.RespondWith(http.StatusOK, `{
+						"account_id": "acc",
+						"entity_tag": "3-abc",
+						"restrict_create_service_id": "RESTRICTED",
+						"restrict_create_platform_apikey": "NOT_SET",
+						"allowed_ip_addresses": "10.0.0.0/8",
+						"mfa": "TOTP",
+						"session_expiration_in_seconds": "7200",
+						"session_invalidation_in_seconds": "NOT_SET"
+					}`),
+				),
+			)
+		})
+
+		It("should return the settings", func() {
+			settings, err := newTestAccountSettingsRepo(server.URL()).Get("acc")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(settings.EntityTag).Should(Equal("3-abc"))
+			Expect(settings.MFA).Should(Equal(MFATOTP))
+			Expect(settings.RestrictCreateServiceID).Should(Equal(RestrictionRestricted))
+			seconds, ok := Seconds(settings.SessionExpirationInSeconds)
+			Expect(ok).Should(BeTrue())
+			Expect(seconds).Should(Equal(7200))
+			_, ok = Seconds(settings.SessionInvalidationInSeconds)
+			Expect(ok).Should(BeFalse())
+		})
+	})
+
+	Describe("Update()", func() {
+		BeforeEach(func() {
+			server = ghttp.NewServer()
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodPut, "/v1/accounts/acc/settings/identity"),
+					ghttp.VerifyHeaderKV("If-Match", "3-abc"),
+					ghttp.VerifyJSON(`{"mfa": "LEVEL1"}`),
+					ghttp.RespondWith(http.StatusOK, `{"account_id": "acc", "entity_tag": "4-def", "mfa": "LEVEL1"}`),
+				),
+			)
+		})
+
+		It("should send only the changed settings with the version", func() {
+			settings, err := newTestAccountSettingsRepo(server.URL()).Update("acc", "3-abc", AccountSettings{
+				AccountID: "acc",
+				EntityTag: "3-abc",
+				MFA:       MFALevel1,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(settings.EntityTag).Should(Equal("4-def"))
+		})
+	})
+
+	Describe("MFAStrength()", func() {
+		It("should order the levels", func() {
+			Expect(MFAStrength(MFALevel1)).Should(BeNumerically(">", MFAStrength(MFATOTP4All)))
+			Expect(MFAStrength("bogus")).Should(Equal(-1))
+		})
+	})
+})
+
+func newTestAccountSettingsRepo(url string) AccountSettingsRepository {
+	sess, err := session.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	conf := sess.Config.Copy()
+	conf.Endpoint = &url
+	client := client.Client{
+		Config:      conf,
+		ServiceName: bluemix.IAMService,
+	}
+	return NewAccountSettingsRepository(&client)
+}
@@ -64,6 +64,10 @@ type APIKeyRepository interface {
 	Create(key models.APIKey) (*models.APIKey, error)
 	Delete(uuid string) error
 	Update(uuid string, version string, key models.APIKey) (*models.APIKey, error)
+	//Lock prevents the key from being updated or deleted. It can still be
+	//used to authenticate.
+	Lock(uuid string) error
+	Unlock(uuid string) error
 }
 
 type apiKeyRepository struct {
@@ -149,3 +153,13 @@ func (r *apiKeyRepository) Update(uuid string, version string, key models.APIKey
 	keyToReturn := keyUpdated.ToModel()
 	return &keyToReturn, nil
 }
+
+func (r *apiKeyRepository) Lock(uuid string) error {
+	_, err := r.client.Post(_API_Key_Operation_Path_Root+uuid+"/lock", nil, nil)
+	return err
+}
+
+func (r *apiKeyRepository) Unlock(uuid string) error {
+	_, err := r.client.Delete(_API_Key_Operation_Path_Root + uuid + "/lock")
+	return err
+}
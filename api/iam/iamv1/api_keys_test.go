@@ -679,6 +679,28 @@ var _ = Describe("ApiKeys Repository", func() {
 			})
 		})
 	})
+
+	Describe("Lock() and Unlock()", func() {
+		BeforeEach(func() {
+			server = ghttp.NewServer()
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodPost, "/apikeys/abc/lock"),
+					ghttp.RespondWith(http.StatusNoContent, ""),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodDelete, "/apikeys/abc/lock"),
+					ghttp.RespondWith(http.StatusNoContent, ""),
+				),
+			)
+		})
+
+		It("should return success", func() {
+			repo := newTestAPIKeyRepo(server.URL())
+			Expect(repo.Lock("abc")).To(Succeed())
+			Expect(repo.Unlock("abc")).To(Succeed())
+		})
+	})
 })
 
 func newTestAPIKeyRepo(url string) APIKeyRepository {
@@ -19,6 +19,8 @@ type IAMServiceAPI interface {
 	ServicePolicies() ServicePolicyRepository
 	UserPolicies() UserPolicyRepository
 	Identity() Identity
+	TrustedProfiles() TrustedProfileRepository
+	AccountSettings() AccountSettingsRepository
 }
 
 //ErrCodeAPICreation ...
@@ -96,3 +98,13 @@ func (a *iamService) UserPolicies() UserPolicyRepository {
 func (a *iamService) Identity() Identity {
 	return NewIdentity(a.Client)
 }
+
+//TrustedProfilesAPI
+func (a *iamService) TrustedProfiles() TrustedProfileRepository {
+	return NewTrustedProfileRepository(a.Client)
+}
+
+//AccountSettingsAPI
+func (a *iamService) AccountSettings() AccountSettingsRepository {
+	return NewAccountSettingsRepository(a.Client)
+}
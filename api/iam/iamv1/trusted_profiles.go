@@ -0,0 +1,252 @@
+package iamv1
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/IBM-Cloud/bluemix-go/client"
+	"github.com/IBM-Cloud/bluemix-go/rest"
+)
+
+//Claim rule types
+const (
+	ClaimRuleTypeSAML = "Profile-SAML"
+	ClaimRuleTypeCR   = "Profile-CR"
+)
+
+//Compute resource types of claim rules and links
+const (
+	CRTypeVSI     = "VSI"
+	CRTypeIKSSA   = "IKS_SA"
+	CRTypeROKSSA  = "ROKS_SA"
+	CRTypeCE      = "CE"
+	CRTypeSession = "SESSION"
+)
+
+//TrustedProfile is an identity that federated users and compute resources
+//can assume
+type TrustedProfile struct {
+	ID          string `json:"id,omitempty"`
+	EntityTag   string `json:"entity_tag,omitempty"`
+	CRN         string `json:"crn,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	AccountID   string `json:"account_id,omitempty"`
+	IAMID       string `json:"iam_id,omitempty"`
+	CreatedAt   string `json:"created_at,omitempty"`
+	ModifiedAt  string `json:"modified_at,omitempty"`
+}
+
+//ClaimRuleCondition compares a claim of the federated user or compute
+//resource with Value. Operator is one of EQUALS, NOT_EQUALS, EQUALS_IGNORE_CASE,
+//NOT_EQUALS_IGNORE_CASE, CONTAINS or IN, whose Value is a JSON array.
+type ClaimRuleCondition struct {
+	Claim    string `json:"claim"`
+	Operator string `json:"operator"`
+	Value    string `json:"value"`
+}
+
+//ClaimRule decides which identities may assume a profile
+type ClaimRule struct {
+	ID         string               `json:"id,omitempty"`
+	EntityTag  string               `json:"entity_tag,omitempty"`
+	Name       string               `json:"name,omitempty"`
+	Type       string               `json:"type"`
+	RealmName  string               `json:"realm_name,omitempty"`
+	CRType     string               `json:"cr_type,omitempty"`
+	Conditions []ClaimRuleCondition `json:"conditions"`
+	//Expiration is the session lifetime in seconds
+	Expiration int    `json:"expiration,omitempty"`
+	CreatedAt  string `json:"created_at,omitempty"`
+	ModifiedAt string `json:"modified_at,omitempty"`
+}
+
+//ProfileLinkTarget identifies the compute resource of a link. Namespace and
+//Name are the Kubernetes namespace and service account of IKS_SA and
+//ROKS_SA links.
+type ProfileLinkTarget struct {
+	CRN       string `json:"crn"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+}
+
+//ProfileLink trusts one specific compute resource
+type ProfileLink struct {
+	ID        string            `json:"id,omitempty"`
+	EntityTag string            `json:"entity_tag,omitempty"`
+	Name      string            `json:"name,omitempty"`
+	CRType    string            `json:"cr_type"`
+	Link      ProfileLinkTarget `json:"link"`
+	CreatedAt string            `json:"created_at,omitempty"`
+}
+
+//TrustedProfileQuery filters the profiles returned by List
+type TrustedProfileQuery struct {
+	AccountID string
+	Name      string
+}
+
+//TrustedProfileRepository manages trusted profiles with their claim rules
+//and links. Updates need the entity tag of the object as version.
+type TrustedProfileRepository interface {
+	Create(profile TrustedProfile) (TrustedProfile, error)
+	Get(profileID string) (TrustedProfile, error)
+	List(query TrustedProfileQuery) ([]TrustedProfile, error)
+	Update(profileID, version string, profile TrustedProfile) (TrustedProfile, error)
+	Delete(profileID string) error
+
+	CreateClaimRule(profileID string, rule ClaimRule) (ClaimRule, error)
+	GetClaimRule(profileID, ruleID string) (ClaimRule, error)
+	ListClaimRules(profileID string) ([]ClaimRule, error)
+	UpdateClaimRule(profileID, ruleID, version string, rule ClaimRule) (ClaimRule, error)
+	DeleteClaimRule(profileID, ruleID string) error
+
+	CreateLink(profileID string, link ProfileLink) (ProfileLink, error)
+	GetLink(profileID, linkID string) (ProfileLink, error)
+	ListLinks(profileID string) ([]ProfileLink, error)
+	DeleteLink(profileID, linkID string) error
+}
+
+type trustedProfileRepository struct {
+	client *client.Client
+}
+
+//NewTrustedProfileRepository ...
+func NewTrustedProfileRepository(c *client.Client) TrustedProfileRepository {
+	return &trustedProfileRepository{
+		client: c,
+	}
+}
+
+const _Profiles_Path_Root = "/v1/profiles"
+
+func profilePath(profileID string) string {
+	return _Profiles_Path_Root + "/" + url.PathEscape(profileID)
+}
+
+func (r *trustedProfileRepository) Create(profile TrustedProfile) (TrustedProfile, error) {
+	created := TrustedProfile{}
+	_, err := r.client.Post(_Profiles_Path_Root, &profile, &created)
+	return created, err
+}
+
+func (r *trustedProfileRepository) Get(profileID string) (TrustedProfile, error) {
+	profile := TrustedProfile{}
+	_, err := r.client.Get(profilePath(profileID), &profile)
+	return profile, err
+}
+
+func (r *trustedProfileRepository) List(query TrustedProfileQuery) ([]TrustedProfile, error) {
+	profiles := []TrustedProfile{}
+	pageToken := ""
+	for {
+		req := rest.GetRequest(*r.client.Config.Endpoint + _Profiles_Path_Root)
+		if query.AccountID != "" {
+			req.Query("account_id", query.AccountID)
+		}
+		if query.Name != "" {
+			req.Query("name", query.Name)
+		}
+		if pageToken != "" {
+			req.Query(_PageTokenQuery, pageToken)
+		}
+		response := struct {
+			Profiles []TrustedProfile `json:"profiles"`
+			Next     string           `json:"next"`
+		}{}
+		_, err := r.client.SendRequest(req, &response)
+		if err != nil {
+			return []TrustedProfile{}, err
+		}
+		profiles = append(profiles, response.Profiles...)
+		next := ""
+		if u, err := url.Parse(response.Next); err == nil {
+			next = u.Query().Get(_PageTokenQuery)
+		}
+		if next == "" || next == pageToken {
+			return profiles, nil
+		}
+		pageToken = next
+	}
+}
+
+func (r *trustedProfileRepository) Update(profileID, version string, profile TrustedProfile) (TrustedProfile, error) {
+	updated := TrustedProfile{}
+	req := rest.PutRequest(*r.client.Config.Endpoint + profilePath(profileID)).Body(&TrustedProfile{
+		Name:        profile.Name,
+		Description: profile.Description,
+	})
+	req.Set("If-Match", version)
+	_, err := r.client.SendRequest(req, &updated)
+	return updated, err
+}
+
+func (r *trustedProfileRepository) Delete(profileID string) error {
+	_, err := r.client.Delete(profilePath(profileID))
+	return err
+}
+
+func (r *trustedProfileRepository) CreateClaimRule(profileID string, rule ClaimRule) (ClaimRule, error) {
+	created := ClaimRule{}
+	_, err := r.client.Post(profilePath(profileID)+"/rules", &rule, &created)
+	return created, err
+}
+
+func (r *trustedProfileRepository) GetClaimRule(profileID, ruleID string) (ClaimRule, error) {
+	rule := ClaimRule{}
+	_, err := r.client.Get(fmt.Sprintf("%s/rules/%s", profilePath(profileID), url.PathEscape(ruleID)), &rule)
+	return rule, err
+}
+
+func (r *trustedProfileRepository) ListClaimRules(profileID string) ([]ClaimRule, error) {
+	response := struct {
+		Rules []ClaimRule `json:"rules"`
+	}{}
+	_, err := r.client.Get(profilePath(profileID)+"/rules", &response)
+	if err != nil {
+		return []ClaimRule{}, err
+	}
+	return response.Rules, nil
+}
+
+func (r *trustedProfileRepository) UpdateClaimRule(profileID, ruleID, version string, rule ClaimRule) (ClaimRule, error) {
+	updated := ClaimRule{}
+	rule.ID, rule.EntityTag, rule.CreatedAt, rule.ModifiedAt = "", "", "", ""
+	req := rest.PutRequest(*r.client.Config.Endpoint + fmt.Sprintf("%s/rules/%s", profilePath(profileID), url.PathEscape(ruleID))).Body(&rule)
+	req.Set("If-Match", version)
+	_, err := r.client.SendRequest(req, &updated)
+	return updated, err
+}
+
+func (r *trustedProfileRepository) DeleteClaimRule(profileID, ruleID string) error {
+	_, err := r.client.Delete(fmt.Sprintf("%s/rules/%s", profilePath(profileID), url.PathEscape(ruleID)))
+	return err
+}
+
+func (r *trustedProfileRepository) CreateLink(profileID string, link ProfileLink) (ProfileLink, error) {
+	created := ProfileLink{}
+	_, err := r.client.Post(profilePath(profileID)+"/links", &link, &created)
+	return created, err
+}
+
+func (r *trustedProfileRepository) GetLink(profileID, linkID string) (ProfileLink, error) {
+	link := ProfileLink{}
+	_, err := r.client.Get(fmt.Sprintf("%s/links/%s", profilePath(profileID), url.PathEscape(linkID)), &link)
+	return link, err
+}
+
+func (r *trustedProfileRepository) ListLinks(profileID string) ([]ProfileLink, error) {
+	response := struct {
+		Links []ProfileLink `json:"links"`
+	}{}
+	_, err := r.client.Get(profilePath(profileID)+"/links", &response)
+	if err != nil {
+		return []ProfileLink{}, err
+	}
+	return response.Links, nil
+}
+
+func (r *trustedProfileRepository) DeleteLink(profileID, linkID string) error {
+	_, err := r.client.Delete(fmt.Sprintf("%s/links/%s", profilePath(profileID), url.PathEscape(linkID)))
+	return err
+}
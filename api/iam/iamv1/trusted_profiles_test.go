@@ -0,0 +1,178 @@
+package iamv1
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/IBM-Cloud/bluemix-go"
+
+	"github.com/IBM-Cloud/bluemix-go/client"
+	"github.com/IBM-Cloud/bluemix-go/session"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TrustedProfileRepository", func() {
+	var server *ghttp.Server
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("Create()", func() {
+		BeforeEach(func() {
+			server = ghttp.NewServer()
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodPost, "/v1/profiles"),
+					ghttp.VerifyJSON(`{"name": "deployer", "account_id": "acc"}`),
+					ghttp.RespondWith(http.StatusCreated, `{
+						"id": "Profile-1",
+						"entity_tag": "1-abc",
+						"crn": "crn:v1:bluemix:public:iam-identity::a/acc::profile:Profile-1",
+						"name": "deployer",
+						"account_id": "acc",
+						"iam_id": "iam-Profile-1"
+					}`),
+				),
+			)
+		})
+
+		It("should return the profile", func() {
+			profile, err := newTestTrustedProfileRepo(server.URL()).Create(TrustedProfile{Name: "deployer", AccountID: "acc"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(profile.ID).Should(Equal("Profile-1"))
+			Expect(profile.EntityTag).Should(Equal("1-abc"))
+			Expect(profile.IAMID).Should(Equal("iam-Profile-1"))
+		})
+	})
+
+	Describe("List()", func() {
+		BeforeEach(func() {
+			server = ghttp.NewServer()
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodGet, "/v1/profiles", "account_id=acc"),
+					ghttp.RespondWith(http.StatusOK, `{
+						"profiles": [{"id": "Profile-1", "name": "deployer"}],
+						"next": "https://iam.cloud.ibm.com/v1/profiles?account_id=acc&pagetoken=page2"
+					}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodGet, "/v1/profiles", "account_id=acc&pagetoken=page2"),
+					ghttp.RespondWith(http.StatusOK, `{"profiles": [{"id": "Profile-2", "name": "reader"}]}`),
+				),
+			)
+		})
+
+		It("should follow the page tokens", func() {
+			profiles, err := newTestTrustedProfileRepo(server.URL()).List(TrustedProfileQuery{AccountID: "acc"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(profiles).Should(HaveLen(2))
+			Expect(profiles[1].Name).Should(Equal("reader"))
+		})
+	})
+
+	Describe("Update()", func() {
+		BeforeEach(func() {
+			server = ghttp.NewServer()
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodPut, "/v1/profiles/Profile-1"),
+					ghttp.VerifyHeaderKV("If-Match", "1-abc"),
+					ghttp.VerifyJSON(`{"name": "deployer", "description": "CI deployments"}`),
+					ghttp.RespondWith(http.StatusOK, `{"id": "Profile-1", "entity_tag": "2-def", "name": "deployer", "description": "CI deployments"}`),
+				),
+			)
+		})
+
+		It("should send the entity tag", func() {
+			profile, err := newTestTrustedProfileRepo(server.URL()).Update("Profile-1", "1-abc", TrustedProfile{
+				ID:          "Profile-1",
+				Name:        "deployer",
+				Description: "CI deployments",
+				AccountID:   "acc",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(profile.EntityTag).Should(Equal("2-def"))
+		})
+	})
+
+	Describe("CreateClaimRule()", func() {
+		BeforeEach(func() {
+			server = ghttp.NewServer()
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodPost, "/v1/profiles/Profile-1/rules"),
+					ghttp.VerifyJSON(`{
+						"type": "Profile-CR",
+						"cr_type": "IKS_SA",
+						"conditions": [{"claim": "namespace", "operator": "EQUALS", "value": "\"ci\""}]
+					}`),
+					ghttp.RespondWith(http.StatusCreated, `{
+						"id": "ClaimRule-1",
+						"entity_tag": "1-abc",
+						"type": "Profile-CR",
+						"cr_type": "IKS_SA",
+						"conditions": [{"claim": "namespace", "operator": "EQUALS", "value": "\"ci\""}]
+					}`),
+				),
+			)
+		})
+
+		It("should return the rule", func() {
+			rule, err := newTestTrustedProfileRepo(server.URL()).CreateClaimRule("Profile-1", ClaimRule{
+				Type:       ClaimRuleTypeCR,
+				CRType:     CRTypeIKSSA,
+				Conditions: []ClaimRuleCondition{{Claim: "namespace", Operator: "EQUALS", Value: `"ci"`}},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rule.ID).Should(Equal("ClaimRule-1"))
+			Expect(rule.Conditions).Should(HaveLen(1))
+		})
+	})
+
+	Describe("ListLinks() and DeleteLink()", func() {
+		BeforeEach(func() {
+			server = ghttp.NewServer()
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodGet, "/v1/profiles/Profile-1/links"),
+					ghttp.RespondWith(http.StatusOK, `{"links": [{
+						"id": "ProfileLink-1",
+						"cr_type": "ROKS_SA",
+						"link": {"crn": "crn:v1:bluemix:public:containers-kubernetes:us-south:a/acc:cluster-1::", "namespace": "ci", "name": "deployer"}
+					}]}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodDelete, "/v1/profiles/Profile-1/links/ProfileLink-1"),
+					ghttp.RespondWith(http.StatusNoContent, ""),
+				),
+			)
+		})
+
+		It("should list and delete links", func() {
+			repo := newTestTrustedProfileRepo(server.URL())
+			links, err := repo.ListLinks("Profile-1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(links).Should(HaveLen(1))
+			Expect(links[0].Link.Namespace).Should(Equal("ci"))
+			Expect(repo.DeleteLink("Profile-1", links[0].ID)).To(Succeed())
+		})
+	})
+})
+
+func newTestTrustedProfileRepo(url string) TrustedProfileRepository {
+	sess, err := session.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	conf := sess.Config.Copy()
+	conf.Endpoint = &url
+	client := client.Client{
+		Config:      conf,
+		ServiceName: bluemix.IAMService,
+	}
+	return NewTrustedProfileRepository(&client)
+}
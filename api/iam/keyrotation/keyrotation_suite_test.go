@@ -0,0 +1,13 @@
+package keyrotation_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestKeyrotation(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Keyrotation Suite")
+}
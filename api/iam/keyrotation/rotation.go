@@ -0,0 +1,251 @@
+//Package keyrotation rotates API keys: a new key is created, handed to a
+//secret sink and verified before the old key is retired.
+package keyrotation
+
+import (
+	"fmt"
+	gohttp "net/http"
+	"sort"
+	"time"
+
+	bluemix "github.com/IBM-Cloud/bluemix-go"
+	"github.com/IBM-Cloud/bluemix-go/api/iam/iamv1"
+	"github.com/IBM-Cloud/bluemix-go/authentication"
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+	"github.com/IBM-Cloud/bluemix-go/http"
+	"github.com/IBM-Cloud/bluemix-go/models"
+	"github.com/IBM-Cloud/bluemix-go/rest"
+)
+
+//Error codes
+const (
+	ErrCodeSecretSinkFailed      = "SecretSinkFailed"
+	ErrCodeKeyVerificationFailed = "KeyVerificationFailed"
+	ErrCodeInvalidCreationTime   = "InvalidCreationTime"
+)
+
+//How the old key is retired
+const (
+	RetireDelete = "delete"
+	RetireLock   = "lock"
+	//RetireNone keeps the old key, for callers retiring it themselves
+	RetireNone = "none"
+)
+
+//KeyVerifier checks that an API key can get a token.
+//authentication.IAMAuthRepository implements it.
+type KeyVerifier interface {
+	AuthenticateAPIKey(apiKey string) error
+}
+
+//NewIAMVerifier returns a verifier using the IAM endpoint of config.
+//Tokens are written to a copy of config, so the caller's session is not
+//switched to the new key.
+func NewIAMVerifier(config *bluemix.Config) (KeyVerifier, error) {
+	c := config.Copy()
+	if c.HTTPClient == nil {
+		c.HTTPClient = http.NewHTTPClient(c)
+	}
+	return authentication.NewIAMAuthRepository(c, &rest.Client{
+		DefaultHeader: gohttp.Header{
+			"User-Agent": []string{http.UserAgent()},
+		},
+		HTTPClient: c.HTTPClient,
+	})
+}
+
+//Options tune a rotation
+type Options struct {
+	//Name of the new key. Defaults to the name of the old key.
+	Name        string
+	Description string
+	//GracePeriod is waited between verifying the new key and retiring the
+	//old one, so consumers can pick up the new key
+	GracePeriod time.Duration
+	//Retire is RetireDelete (the default), RetireLock or RetireNone
+	Retire string
+	//VerifyAttempts is the number of tries to authenticate with the new
+	//key, which can take a few seconds to propagate. Defaults to 5.
+	VerifyAttempts int
+	//VerifyDelay is waited between attempts. Defaults to 2 seconds.
+	VerifyDelay time.Duration
+}
+
+//Result describes a completed rotation
+type Result struct {
+	//NewKey is the created key, without its value
+	NewKey  models.APIKey
+	OldKey  models.APIKey
+	Retired string
+}
+
+//Rotator rotates API keys
+type Rotator struct {
+	keys     iamv1.APIKeyRepository
+	verifier KeyVerifier
+	sink     SecretSink
+	//sleep is replaced in tests
+	sleep func(time.Duration)
+}
+
+//NewRotator ...
+func NewRotator(keys iamv1.APIKeyRepository, verifier KeyVerifier, sink SecretSink) *Rotator {
+	return &Rotator{
+		keys:     keys,
+		verifier: verifier,
+		sink:     sink,
+		sleep:    time.Sleep,
+	}
+}
+
+//Rotate replaces old with a new key bound to the same service ID or user.
+//If storing or verifying the new key fails, the new key is deleted and the
+//old one is left untouched.
+func (r *Rotator) Rotate(old models.APIKey, opts Options) (Result, error) {
+	if opts.Name == "" {
+		opts.Name = old.Name
+	}
+	if opts.Description == "" {
+		opts.Description = old.Description
+	}
+	if opts.Retire == "" {
+		opts.Retire = RetireDelete
+	}
+	if opts.VerifyAttempts <= 0 {
+		opts.VerifyAttempts = 5
+	}
+	if opts.VerifyDelay <= 0 {
+		opts.VerifyDelay = 2 * time.Second
+	}
+
+	created, err := r.keys.Create(models.APIKey{
+		Name:        opts.Name,
+		Description: opts.Description,
+		BoundTo:     old.BoundTo,
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	if err := r.sink.Store(*created); err != nil {
+		return Result{}, r.rollback(created, bmxerror.New(ErrCodeSecretSinkFailed,
+			fmt.Sprintf("Could not store the new key %s: %v", created.UUID, err)))
+	}
+	if err := r.verify(created.APIKey, opts); err != nil {
+		return Result{}, r.rollback(created, bmxerror.New(ErrCodeKeyVerificationFailed,
+			fmt.Sprintf("The new key %s could not authenticate: %v", created.UUID, err)))
+	}
+
+	result := Result{NewKey: *created, OldKey: old, Retired: opts.Retire}
+	result.NewKey.APIKey = ""
+	if opts.GracePeriod > 0 && opts.Retire != RetireNone {
+		r.sleep(opts.GracePeriod)
+	}
+	return result, r.retire(old, opts.Retire)
+}
+
+func (r *Rotator) verify(apiKey string, opts Options) error {
+	var err error
+	for i := 0; i < opts.VerifyAttempts; i++ {
+		if i > 0 {
+			r.sleep(opts.VerifyDelay)
+		}
+		if err = r.verifier.AuthenticateAPIKey(apiKey); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+//rollback deletes a new key that could not be put in use
+func (r *Rotator) rollback(created *models.APIKey, cause error) error {
+	if err := r.keys.Delete(created.UUID); err != nil {
+		return fmt.Errorf("%v; deleting the new key also failed: %v", cause, err)
+	}
+	return cause
+}
+
+func (r *Rotator) retire(old models.APIKey, mode string) error {
+	switch mode {
+	case RetireLock:
+		if old.Locked {
+			return nil
+		}
+		return r.keys.Lock(old.UUID)
+	case RetireDelete:
+		if old.Locked {
+			if err := r.keys.Unlock(old.UUID); err != nil {
+				return err
+			}
+		}
+		return r.keys.Delete(old.UUID)
+	}
+	return nil
+}
+
+//KeyAge is a key and how long ago it was created
+type KeyAge struct {
+	Key models.APIKey
+	Age time.Duration
+}
+
+//Days returns the age in whole days
+func (k KeyAge) Days() int {
+	return int(k.Age / (24 * time.Hour))
+}
+
+//creationLayouts are the formats IAM has used for createdAt
+var creationLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04Z0700",
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05.999Z0700",
+}
+
+//CreatedAt parses the creation time of a key
+func CreatedAt(key models.APIKey) (time.Time, error) {
+	for _, layout := range creationLayouts {
+		if t, err := time.Parse(layout, key.CreatedAt); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, bmxerror.New(ErrCodeInvalidCreationTime,
+		fmt.Sprintf("Key %s has an unknown creation time %q", key.UUID, key.CreatedAt))
+}
+
+//OldKeys returns the keys created more than maxAgeDays days before now,
+//oldest first
+func OldKeys(keys []models.APIKey, maxAgeDays int, now time.Time) ([]KeyAge, error) {
+	old := []KeyAge{}
+	limit := time.Duration(maxAgeDays) * 24 * time.Hour
+	for _, k := range keys {
+		created, err := CreatedAt(k)
+		if err != nil {
+			return nil, err
+		}
+		if age := now.Sub(created); age > limit {
+			old = append(old, KeyAge{Key: k, Age: age})
+		}
+	}
+	sort.SliceStable(old, func(i, j int) bool {
+		return old[i].Age > old[j].Age
+	})
+	return old, nil
+}
+
+//ServiceIDOldKeys reports the keys older than maxAgeDays of every service
+//ID bound to boundTo
+func ServiceIDOldKeys(serviceIDs iamv1.ServiceIDRepository, keys iamv1.APIKeyRepository, boundTo string, maxAgeDays int) ([]KeyAge, error) {
+	ids, err := serviceIDs.List(boundTo)
+	if err != nil {
+		return nil, err
+	}
+	all := []models.APIKey{}
+	for _, id := range ids {
+		list, err := keys.List(id.CRN)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, list...)
+	}
+	return OldKeys(all, maxAgeDays, time.Now())
+}
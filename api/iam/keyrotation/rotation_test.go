@@ -0,0 +1,188 @@
+package keyrotation
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/IBM-Cloud/bluemix-go/api/iam/iamv1"
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+	"github.com/IBM-Cloud/bluemix-go/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type keysStub struct {
+	iamv1.APIKeyRepository
+	created  []models.APIKey
+	deleted  []string
+	locked   []string
+	unlocked []string
+}
+
+func (s *keysStub) Create(key models.APIKey) (*models.APIKey, error) {
+	key.UUID = "ApiKey-new"
+	key.APIKey = "secret-value"
+	key.CreatedAt = "2020-01-01T00:00+0000"
+	s.created = append(s.created, key)
+	return &key, nil
+}
+
+func (s *keysStub) Delete(uuid string) error {
+	s.deleted = append(s.deleted, uuid)
+	return nil
+}
+
+func (s *keysStub) Lock(uuid string) error {
+	s.locked = append(s.locked, uuid)
+	return nil
+}
+
+func (s *keysStub) Unlock(uuid string) error {
+	s.unlocked = append(s.unlocked, uuid)
+	return nil
+}
+
+type verifierStub struct {
+	failures int
+	calls    int
+}
+
+func (v *verifierStub) AuthenticateAPIKey(apiKey string) error {
+	v.calls++
+	if v.calls <= v.failures {
+		return errors.New("invalid key")
+	}
+	return nil
+}
+
+var oldKey = models.APIKey{
+	UUID:      "ApiKey-old",
+	Name:      "deployer-key",
+	BoundTo:   "crn:v1:bluemix:public:iam-identity::a/acc::serviceid:ServiceId-1",
+	CreatedAt: "2019-01-01T00:00+0000",
+}
+
+var _ = Describe("Rotator", func() {
+	var (
+		keys     *keysStub
+		verifier *verifierStub
+		stored   []models.APIKey
+		slept    []time.Duration
+		rotator  *Rotator
+	)
+
+	BeforeEach(func() {
+		keys = &keysStub{}
+		verifier = &verifierStub{}
+		stored = nil
+		slept = nil
+		rotator = NewRotator(keys, verifier, SinkFunc(func(key models.APIKey) error {
+			stored = append(stored, key)
+			return nil
+		}))
+		rotator.sleep = func(d time.Duration) { slept = append(slept, d) }
+	})
+
+	It("should create, store and verify the new key before deleting the old one", func() {
+		result, err := rotator.Rotate(oldKey, Options{GracePeriod: time.Hour})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(keys.created).Should(HaveLen(1))
+		Expect(keys.created[0].BoundTo).Should(Equal(oldKey.BoundTo))
+		Expect(keys.created[0].Name).Should(Equal("deployer-key"))
+		Expect(stored[0].APIKey).Should(Equal("secret-value"))
+		Expect(slept).Should(Equal([]time.Duration{time.Hour}))
+		Expect(keys.deleted).Should(Equal([]string{"ApiKey-old"}))
+		Expect(result.NewKey.UUID).Should(Equal("ApiKey-new"))
+		Expect(result.NewKey.APIKey).Should(BeEmpty())
+	})
+
+	It("should lock the old key when asked", func() {
+		_, err := rotator.Rotate(oldKey, Options{Retire: RetireLock})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(keys.locked).Should(Equal([]string{"ApiKey-old"}))
+		Expect(keys.deleted).Should(BeEmpty())
+	})
+
+	It("should unlock a locked key before deleting it", func() {
+		locked := oldKey
+		locked.Locked = true
+		_, err := rotator.Rotate(locked, Options{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(keys.unlocked).Should(Equal([]string{"ApiKey-old"}))
+		Expect(keys.deleted).Should(Equal([]string{"ApiKey-old"}))
+	})
+
+	It("should retry verification while the key propagates", func() {
+		verifier.failures = 2
+		_, err := rotator.Rotate(oldKey, Options{VerifyDelay: time.Second})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(verifier.calls).Should(Equal(3))
+		Expect(slept).Should(Equal([]time.Duration{time.Second, time.Second}))
+	})
+
+	It("should delete the new key when it can not authenticate", func() {
+		verifier.failures = 10
+		_, err := rotator.Rotate(oldKey, Options{VerifyAttempts: 2})
+		Expect(err).To(HaveOccurred())
+		Expect(err.(bmxerror.Error).Code()).Should(Equal(ErrCodeKeyVerificationFailed))
+		Expect(keys.deleted).Should(Equal([]string{"ApiKey-new"}))
+	})
+
+	It("should delete the new key when the sink fails", func() {
+		rotator.sink = SinkFunc(func(models.APIKey) error { return errors.New("disk full") })
+		_, err := rotator.Rotate(oldKey, Options{})
+		Expect(err).To(HaveOccurred())
+		Expect(err.(bmxerror.Error).Code()).Should(Equal(ErrCodeSecretSinkFailed))
+		Expect(keys.deleted).Should(Equal([]string{"ApiKey-new"}))
+		Expect(verifier.calls).Should(Equal(0))
+	})
+})
+
+var _ = Describe("Sinks", func() {
+	It("should write a private key file", func() {
+		dir, err := ioutil.TempDir("", "keyrotation")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(dir)
+
+		path := filepath.Join(dir, "apikey.json")
+		Expect(FileSink{Path: path}.Store(models.APIKey{Name: "k", APIKey: "secret-value"})).To(Succeed())
+		info, err := os.Stat(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode().Perm()).Should(Equal(os.FileMode(0600)))
+		data, err := ioutil.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).Should(ContainSubstring(`"apikey": "secret-value"`))
+	})
+
+	It("should set the environment variable", func() {
+		defer os.Unsetenv("KEYROTATION_TEST_KEY")
+		Expect(EnvSink{Name: "KEYROTATION_TEST_KEY"}.Store(models.APIKey{APIKey: "secret-value"})).To(Succeed())
+		Expect(os.Getenv("KEYROTATION_TEST_KEY")).Should(Equal("secret-value"))
+	})
+})
+
+var _ = Describe("OldKeys", func() {
+	It("should report keys older than the limit, oldest first", func() {
+		now := time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC)
+		keys := []models.APIKey{
+			{UUID: "recent", CreatedAt: "2020-01-20T00:00+0000"},
+			{UUID: "old", CreatedAt: "2019-12-01T00:00+0000"},
+			{UUID: "older", CreatedAt: "2019-06-01T12:00:00.000Z"},
+		}
+		old, err := OldKeys(keys, 30, now)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(old).Should(HaveLen(2))
+		Expect(old[0].Key.UUID).Should(Equal("older"))
+		Expect(old[1].Key.UUID).Should(Equal("old"))
+		Expect(old[1].Days()).Should(Equal(61))
+	})
+
+	It("should fail on unknown dates", func() {
+		_, err := OldKeys([]models.APIKey{{UUID: "k", CreatedAt: "yesterday"}}, 30, time.Now())
+		Expect(err).To(HaveOccurred())
+	})
+})
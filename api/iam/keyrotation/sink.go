@@ -0,0 +1,78 @@
+package keyrotation
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/IBM-Cloud/bluemix-go/models"
+)
+
+//SecretSink receives a newly created API key. The key value is only
+//available at creation, so a key whose sink fails is deleted again.
+type SecretSink interface {
+	Store(key models.APIKey) error
+}
+
+//SinkFunc adapts a function to a SecretSink
+type SinkFunc func(key models.APIKey) error
+
+//Store ...
+func (f SinkFunc) Store(key models.APIKey) error {
+	return f(key)
+}
+
+//FileSink writes the key to Path in the format of the ibmcloud CLI key
+//files, readable by the owner only. The file is replaced atomically.
+type FileSink struct {
+	Path string
+}
+
+type keyFile struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	CreatedAt   string `json:"createdAt"`
+	APIKey      string `json:"apikey"`
+}
+
+//Store ...
+func (s FileSink) Store(key models.APIKey) error {
+	data, err := json.MarshalIndent(keyFile{
+		Name:        key.Name,
+		Description: key.Description,
+		CreatedAt:   key.CreatedAt,
+		APIKey:      key.APIKey,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(s.Path), filepath.Base(s.Path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.Path)
+}
+
+//EnvSink sets the environment variable Name of the current process, for
+//example IC_API_KEY before the process starts its children
+type EnvSink struct {
+	Name string
+}
+
+//Store ...
+func (s EnvSink) Store(key models.APIKey) error {
+	return os.Setenv(s.Name, key.APIKey)
+}
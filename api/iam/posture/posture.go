@@ -0,0 +1,242 @@
+//Package posture compares the IAM settings of an account with a security
+//baseline
+package posture
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	yaml "github.com/ghodss/yaml"
+
+	"github.com/IBM-Cloud/bluemix-go/api/iam/iamv1"
+	"github.com/IBM-Cloud/bluemix-go/api/usermanagement/usermanagementv2"
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+)
+
+//ErrCodeInvalidBaseline ...
+const ErrCodeInvalidBaseline = "InvalidBaseline"
+
+//Finding severities
+const (
+	SeverityHigh   = "high"
+	SeverityMedium = "medium"
+	SeverityLow    = "low"
+)
+
+//Settings named in findings
+const (
+	SettingMFA                     = "mfa"
+	SettingAllowedIPAddresses      = "allowed_ip_addresses"
+	SettingRestrictCreateServiceID = "restrict_create_service_id"
+	SettingRestrictCreateAPIKey    = "restrict_create_platform_apikey"
+	SettingSessionExpiration       = "session_expiration_in_seconds"
+	SettingSessionInvalidation     = "session_invalidation_in_seconds"
+	SettingMaxSessions             = "max_sessions_per_identity"
+	SettingUserAllowedIPAddresses  = "user_allowed_ip_addresses"
+)
+
+//Baseline is the expected posture. It can be written as YAML or JSON:
+//
+//	min_mfa: LEVEL1
+//	require_ip_allowlist: true
+//	restrict_service_id_creation: true
+//	max_session_expiration_seconds: 7200
+//
+//Zero values are not checked.
+type Baseline struct {
+	//MinMFA is the weakest accepted MFA level, see iamv1.MFALevels
+	MinMFA                        string `json:"min_mfa,omitempty"`
+	RequireIPAllowlist            bool   `json:"require_ip_allowlist,omitempty"`
+	RestrictServiceIDCreation     bool   `json:"restrict_service_id_creation,omitempty"`
+	RestrictAPIKeyCreation        bool   `json:"restrict_api_key_creation,omitempty"`
+	MaxSessionExpirationSeconds   int    `json:"max_session_expiration_seconds,omitempty"`
+	MaxSessionInvalidationSeconds int    `json:"max_session_invalidation_seconds,omitempty"`
+	MaxSessionsPerIdentity        int    `json:"max_sessions_per_identity,omitempty"`
+	//RequireUserIPAllowlist flags users without their own allowed IP
+	//addresses when the account has none
+	RequireUserIPAllowlist bool `json:"require_user_ip_allowlist,omitempty"`
+}
+
+//DefaultBaseline requires MFA for every user, an IP allowlist, restricted
+//service ID and API key creation, and sessions of at most a day
+func DefaultBaseline() Baseline {
+	return Baseline{
+		MinMFA:                        iamv1.MFATOTP4All,
+		RequireIPAllowlist:            true,
+		RestrictServiceIDCreation:     true,
+		RestrictAPIKeyCreation:        true,
+		MaxSessionExpirationSeconds:   86400,
+		MaxSessionInvalidationSeconds: 7200,
+	}
+}
+
+//LoadBaseline reads a baseline from a YAML or JSON file
+func LoadBaseline(path string) (Baseline, error) {
+	baseline := Baseline{}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return baseline, err
+	}
+	if err := yaml.Unmarshal(data, &baseline); err != nil {
+		return baseline, err
+	}
+	return baseline, baseline.Validate()
+}
+
+//Validate ...
+func (b Baseline) Validate() error {
+	if b.MinMFA != "" && iamv1.MFAStrength(b.MinMFA) < 0 {
+		return bmxerror.New(ErrCodeInvalidBaseline,
+			fmt.Sprintf("Unknown MFA level %q, expected one of %s", b.MinMFA, strings.Join(iamv1.MFALevels, ", ")))
+	}
+	return nil
+}
+
+//Finding is a setting that does not meet the baseline
+type Finding struct {
+	Setting  string `json:"setting"`
+	Severity string `json:"severity"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+	//IAMID is set for findings about a single user
+	IAMID   string `json:"iam_id,omitempty"`
+	Message string `json:"message"`
+}
+
+//Report is the result of Check
+type Report struct {
+	AccountID string    `json:"account_id"`
+	Findings  []Finding `json:"findings"`
+}
+
+//Passed reports whether there were no findings
+func (r Report) Passed() bool {
+	return len(r.Findings) == 0
+}
+
+//CheckSettings compares account settings with the baseline
+func CheckSettings(s iamv1.AccountSettings, b Baseline) []Finding {
+	findings := []Finding{}
+	add := func(setting, severity, expected, actual, message string) {
+		if actual == "" {
+			actual = iamv1.SettingNotSet
+		}
+		findings = append(findings, Finding{
+			Setting:  setting,
+			Severity: severity,
+			Expected: expected,
+			Actual:   actual,
+			Message:  message,
+		})
+	}
+
+	if b.MinMFA != "" && iamv1.MFAStrength(s.MFA) < iamv1.MFAStrength(b.MinMFA) {
+		add(SettingMFA, SeverityHigh, b.MinMFA, s.MFA,
+			fmt.Sprintf("MFA is %s, the baseline requires at least %s", firstNonEmpty(s.MFA, iamv1.MFANone), b.MinMFA))
+	}
+	if b.RequireIPAllowlist && strings.TrimSpace(s.AllowedIPAddresses) == "" {
+		add(SettingAllowedIPAddresses, SeverityHigh, "an IP allowlist", s.AllowedIPAddresses,
+			"Users can log in from any IP address")
+	}
+	if b.RestrictServiceIDCreation && s.RestrictCreateServiceID != iamv1.RestrictionRestricted {
+		add(SettingRestrictCreateServiceID, SeverityMedium, iamv1.RestrictionRestricted, s.RestrictCreateServiceID,
+			"Any user can create service IDs")
+	}
+	if b.RestrictAPIKeyCreation && s.RestrictCreatePlatformAPIKey != iamv1.RestrictionRestricted {
+		add(SettingRestrictCreateAPIKey, SeverityMedium, iamv1.RestrictionRestricted, s.RestrictCreatePlatformAPIKey,
+			"Any user can create platform API keys")
+	}
+	checkMax := func(setting string, value string, max int, severity string) {
+		if max <= 0 {
+			return
+		}
+		n, ok := iamv1.Seconds(value)
+		if !ok || n > max {
+			add(setting, severity, fmt.Sprintf("at most %d", max), value,
+				fmt.Sprintf("%s must be set to at most %d", setting, max))
+		}
+	}
+	checkMax(SettingSessionExpiration, s.SessionExpirationInSeconds, b.MaxSessionExpirationSeconds, SeverityMedium)
+	checkMax(SettingSessionInvalidation, s.SessionInvalidationInSeconds, b.MaxSessionInvalidationSeconds, SeverityLow)
+	checkMax(SettingMaxSessions, s.MaxSessionsPerIdentity, b.MaxSessionsPerIdentity, SeverityLow)
+	return findings
+}
+
+//CheckUsers flags users without allowed IP addresses when the baseline
+//requires them and the account has no allowlist of its own
+func CheckUsers(s iamv1.AccountSettings, users map[string]usermanagementv2.UserSettingOptions, b Baseline) []Finding {
+	findings := []Finding{}
+	if !b.RequireUserIPAllowlist || strings.TrimSpace(s.AllowedIPAddresses) != "" {
+		return findings
+	}
+	for _, iamID := range sortedKeys(users) {
+		if strings.TrimSpace(users[iamID].AllowedIPAddresses) == "" {
+			findings = append(findings, Finding{
+				Setting:  SettingUserAllowedIPAddresses,
+				Severity: SeverityMedium,
+				Expected: "an IP allowlist",
+				Actual:   iamv1.SettingNotSet,
+				IAMID:    iamID,
+				Message:  fmt.Sprintf("User %s can log in from any IP address", iamID),
+			})
+		}
+	}
+	return findings
+}
+
+//Checker reads the live settings of an account. Users is only needed for
+//baselines with RequireUserIPAllowlist.
+type Checker struct {
+	Settings iamv1.AccountSettingsRepository
+	Users    usermanagementv2.Users
+}
+
+//Check compares the settings of accountID with the baseline
+func (c Checker) Check(accountID string, b Baseline) (Report, error) {
+	report := Report{AccountID: accountID, Findings: []Finding{}}
+	if err := b.Validate(); err != nil {
+		return report, err
+	}
+	settings, err := c.Settings.Get(accountID)
+	if err != nil {
+		return report, err
+	}
+	report.Findings = append(report.Findings, CheckSettings(settings, b)...)
+	if !b.RequireUserIPAllowlist || c.Users == nil || strings.TrimSpace(settings.AllowedIPAddresses) != "" {
+		return report, nil
+	}
+	users, err := c.Users.ListUsers(accountID)
+	if err != nil {
+		return report, err
+	}
+	userSettings := make(map[string]usermanagementv2.UserSettingOptions, len(users))
+	for _, u := range users {
+		options, err := c.Users.GetUserSettings(accountID, u.IamID)
+		if err != nil {
+			return report, err
+		}
+		userSettings[u.IamID] = options
+	}
+	report.Findings = append(report.Findings, CheckUsers(settings, userSettings, b)...)
+	return report, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func sortedKeys(m map[string]usermanagementv2.UserSettingOptions) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,13 @@
+package posture_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestPosture(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Posture Suite")
+}
@@ -0,0 +1,117 @@
+package posture
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/IBM-Cloud/bluemix-go/api/iam/iamv1"
+	"github.com/IBM-Cloud/bluemix-go/api/usermanagement/usermanagementv2"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type fakeSettings struct {
+	iamv1.AccountSettingsRepository
+	settings iamv1.AccountSettings
+}
+
+func (f *fakeSettings) Get(accountID string) (iamv1.AccountSettings, error) {
+	return f.settings, nil
+}
+
+type fakeUsers struct {
+	usermanagementv2.Users
+	settings map[string]usermanagementv2.UserSettingOptions
+}
+
+func (f *fakeUsers) ListUsers(accountID string) ([]usermanagementv2.UserInfo, error) {
+	return []usermanagementv2.UserInfo{{IamID: "IBMid-alice"}, {IamID: "IBMid-bob"}}, nil
+}
+
+func (f *fakeUsers) GetUserSettings(accountID, iamID string) (usermanagementv2.UserSettingOptions, error) {
+	return f.settings[iamID], nil
+}
+
+func settingNames(findings []Finding) []string {
+	names := []string{}
+	for _, f := range findings {
+		names = append(names, f.Setting)
+	}
+	return names
+}
+
+var _ = Describe("Posture", func() {
+	compliant := iamv1.AccountSettings{
+		MFA:                          iamv1.MFALevel1,
+		AllowedIPAddresses:           "10.0.0.0/8",
+		RestrictCreateServiceID:      iamv1.RestrictionRestricted,
+		RestrictCreatePlatformAPIKey: iamv1.RestrictionRestricted,
+		SessionExpirationInSeconds:   "3600",
+		SessionInvalidationInSeconds: "1800",
+	}
+
+	Describe("CheckSettings", func() {
+		It("should pass compliant settings", func() {
+			Expect(CheckSettings(compliant, DefaultBaseline())).To(BeEmpty())
+		})
+		It("should flag every deviation", func() {
+			findings := CheckSettings(iamv1.AccountSettings{
+				MFA:                          iamv1.MFATOTP,
+				RestrictCreateServiceID:      iamv1.RestrictionNotSet,
+				SessionExpirationInSeconds:   "172800",
+				SessionInvalidationInSeconds: iamv1.SettingNotSet,
+			}, DefaultBaseline())
+			Expect(settingNames(findings)).To(Equal([]string{
+				SettingMFA,
+				SettingAllowedIPAddresses,
+				SettingRestrictCreateServiceID,
+				SettingRestrictCreateAPIKey,
+				SettingSessionExpiration,
+				SettingSessionInvalidation,
+			}))
+			Expect(findings[0].Severity).To(Equal(SeverityHigh))
+			Expect(findings[0].Actual).To(Equal(iamv1.MFATOTP))
+			Expect(findings[3].Actual).To(Equal(iamv1.SettingNotSet))
+		})
+		It("should skip zero values of the baseline", func() {
+			Expect(CheckSettings(iamv1.AccountSettings{}, Baseline{})).To(BeEmpty())
+		})
+	})
+
+	Describe("Checker", func() {
+		It("should check users when the account has no allowlist", func() {
+			settings := compliant
+			settings.AllowedIPAddresses = ""
+			checker := Checker{
+				Settings: &fakeSettings{settings: settings},
+				Users: &fakeUsers{settings: map[string]usermanagementv2.UserSettingOptions{
+					"IBMid-alice": {AllowedIPAddresses: "192.168.0.1"},
+				}},
+			}
+			report, err := checker.Check("acc", Baseline{RequireUserIPAllowlist: true})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Passed()).To(BeFalse())
+			Expect(report.Findings).To(HaveLen(1))
+			Expect(report.Findings[0].IAMID).To(Equal("IBMid-bob"))
+		})
+		It("should reject unknown MFA levels", func() {
+			_, err := Checker{Settings: &fakeSettings{}}.Check("acc", Baseline{MinMFA: "SMS"})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("LoadBaseline", func() {
+		It("should read YAML", func() {
+			dir, err := ioutil.TempDir("", "posture")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(dir)
+			path := filepath.Join(dir, "baseline.yaml")
+			Expect(ioutil.WriteFile(path, []byte("min_mfa: LEVEL2\nrequire_ip_allowlist: true\n"), 0600)).To(Succeed())
+			b, err := LoadBaseline(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(b).To(Equal(Baseline{MinMFA: iamv1.MFALevel2, RequireIPAllowlist: true}))
+		})
+	})
+})
@@ -0,0 +1,72 @@
+package iampapv1
+
+import (
+	"strings"
+
+	"github.com/IBM-Cloud/bluemix-go/api/iampap/iampapv2"
+)
+
+//ToV2Policies converts a user access policy request into v2 access
+//policies for iamID in accountID. v2 policies have a single resource, so
+//one policy is returned per resource of the request.
+func (p AccessPolicyRequest) ToV2Policies(accountID, iamID string) []iampapv2.Policy {
+	policies := make([]iampapv2.Policy, 0, len(p.Resources))
+	for _, r := range p.Resources {
+		policy := iampapv2.Policy{Type: iampapv2.AccessPolicyType}
+		policy.SetSubjectAttribute("iam_id", iamID)
+		for _, role := range p.Roles {
+			policy.AddRoles(role.ID)
+		}
+		if r.AccountId == "" {
+			r.AccountId = accountID
+		}
+		attributes := []Attribute{
+			{Name: AccountIDAttribute, Value: r.AccountId},
+			{Name: ServiceNameAttribute, Value: r.ServiceName},
+			{Name: ServiceInstanceAttribute, Value: r.ServiceInstance},
+			{Name: RegionAttribute, Value: r.Region},
+			{Name: ResourceTypeAttribute, Value: r.ResourceType},
+			{Name: ResourceAttribute, Value: r.Resource},
+			{Name: SpaceIDAttribute, Value: r.SpaceId},
+			{Name: OrganizationIDAttribute, Value: r.OrganizationId},
+		}
+		for _, a := range attributes {
+			if a.Value != "" {
+				policy.SetResourceAttribute(a.Name, iampapv2.OperatorStringEquals, a.Value)
+			}
+		}
+		policies = append(policies, policy)
+	}
+	return policies
+}
+
+//ToV2Policies converts a policy of the v1 policies API into v2 policies,
+//one per subject and resource. Attribute values containing * are matched
+//with stringMatch.
+func (p Policy) ToV2Policies() []iampapv2.Policy {
+	policies := []iampapv2.Policy{}
+	for _, s := range p.Subjects {
+		for _, r := range p.Resources {
+			policy := iampapv2.Policy{Type: p.Type}
+			if policy.Type == "" {
+				policy.Type = iampapv2.AccessPolicyType
+			}
+			for _, a := range s.Attributes {
+				policy.SetSubjectAttribute(a.Name, a.Value)
+			}
+			for _, role := range p.Roles {
+				policy.AddRoles(role.RoleID)
+			}
+			for _, a := range r.Attributes {
+				operator := iampapv2.OperatorStringEquals
+				if strings.Contains(a.Value, "*") {
+					operator = iampapv2.OperatorStringMatch
+					policy.Pattern = iampapv2.PatternResourceWildcard
+				}
+				policy.SetResourceAttribute(a.Name, operator, a.Value)
+			}
+			policies = append(policies, policy)
+		}
+	}
+	return policies
+}
@@ -0,0 +1,52 @@
+package iampapv1
+
+import (
+	"github.com/IBM-Cloud/bluemix-go/api/iampap/iampapv2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("V2 conversion", func() {
+	It("should convert an access policy request per resource", func() {
+		req := AccessPolicyRequest{
+			Roles: []Roles{{ID: "crn:v1:bluemix:public:iam::::role:Viewer"}},
+			Resources: []Resources{
+				{ServiceName: "kms", ServiceInstance: "instance-1"},
+				{ServiceName: "cloud-object-storage", AccountId: "acc"},
+			},
+		}
+		policies := req.ToV2Policies("acc", "IBMid-123")
+		Expect(policies).Should(HaveLen(2))
+		for _, p := range policies {
+			Expect(p.Type).Should(Equal(iampapv2.AccessPolicyType))
+			Expect(p.SubjectAttribute("iam_id")).Should(Equal("IBMid-123"))
+			Expect(p.Control.Grant.Roles).Should(Equal([]iampapv2.PolicyRole{{RoleID: "crn:v1:bluemix:public:iam::::role:Viewer"}}))
+			Expect(p.Validate()).To(Succeed())
+		}
+		Expect(policies[0].Resource.Attributes).Should(Equal([]iampapv2.ResourceAttribute{
+			{Key: "accountId", Operator: "stringEquals", Value: "acc"},
+			{Key: "serviceName", Operator: "stringEquals", Value: "kms"},
+			{Key: "serviceInstance", Operator: "stringEquals", Value: "instance-1"},
+		}))
+	})
+
+	It("should convert v1 policies and wildcards", func() {
+		p := Policy{
+			Type:     "access",
+			Subjects: []Subject{{Attributes: []Attribute{{Name: "access_group_id", Value: "AccessGroupId-1"}}}},
+			Roles:    []Role{{RoleID: "crn:v1:bluemix:public:iam::::serviceRole:Reader"}},
+			Resources: []Resource{{Attributes: []Attribute{
+				{Name: "accountId", Value: "acc"},
+				{Name: "serviceName", Value: "cloud-object-storage"},
+				{Name: "prefix", Value: "logs/*"},
+			}}},
+		}
+		policies := p.ToV2Policies()
+		Expect(policies).Should(HaveLen(1))
+		Expect(policies[0].SubjectAttribute("access_group_id")).Should(Equal("AccessGroupId-1"))
+		attr, ok := policies[0].ResourceAttribute("prefix")
+		Expect(ok).Should(BeTrue())
+		Expect(attr.Operator).Should(Equal(iampapv2.OperatorStringMatch))
+		Expect(policies[0].Pattern).Should(Equal(iampapv2.PatternResourceWildcard))
+	})
+})
@@ -14,6 +14,7 @@ import (
 //IAMPAPAPIV2 is the resource client ...
 type IAMPAPAPIV2 interface {
 	IAMRoles() RoleRepository
+	IAMPolicies() PolicyRepository
 }
 
 //ErrCodeAPICreation ...
@@ -66,3 +67,8 @@ func New(sess *session.Session) (IAMPAPAPIV2, error) {
 func (a *roleService) IAMRoles() RoleRepository {
 	return NewRoleRepository(a.Client)
 }
+
+//IAMPolicies API
+func (a *roleService) IAMPolicies() PolicyRepository {
+	return NewPolicyRepository(a.Client)
+}
@@ -0,0 +1,267 @@
+package iampapv2
+
+import (
+	"fmt"
+
+	"github.com/IBM-Cloud/bluemix-go/client"
+	"github.com/IBM-Cloud/bluemix-go/rest"
+)
+
+//Policy types
+const (
+	AccessPolicyType        = "access"
+	AuthorizationPolicyType = "authorization"
+)
+
+//Attribute operators
+const (
+	OperatorStringEquals = "stringEquals"
+	OperatorStringExists = "stringExists"
+	OperatorStringMatch  = "stringMatch"
+)
+
+//Policy is a v2 IAM policy. Unlike v1 policies it has a single subject and
+//resource, and may restrict access with a rule.
+type Policy struct {
+	ID          string         `json:"id,omitempty"`
+	Type        string         `json:"type"`
+	Description string         `json:"description,omitempty"`
+	Subject     PolicySubject  `json:"subject"`
+	Control     PolicyControl  `json:"control"`
+	Resource    PolicyResource `json:"resource"`
+	Pattern     string         `json:"pattern,omitempty"`
+	Rule        *Rule          `json:"rule,omitempty"`
+	Href        string         `json:"href,omitempty"`
+	State       string         `json:"state,omitempty"`
+	CreatedAt   string         `json:"created_at,omitempty"`
+	CreatedByID string         `json:"created_by_id,omitempty"`
+	//LastModifiedAt and LastModifiedByID are set by the service
+	LastModifiedAt   string `json:"last_modified_at,omitempty"`
+	LastModifiedByID string `json:"last_modified_by_id,omitempty"`
+	//Version is the ETag of the policy, needed by Update
+	Version string `json:"-"`
+}
+
+//PolicySubject ...
+type PolicySubject struct {
+	Attributes []SubjectAttribute `json:"attributes"`
+}
+
+//SubjectAttribute ...
+type SubjectAttribute struct {
+	Key      string `json:"key"`
+	Operator string `json:"operator"`
+	Value    string `json:"value"`
+}
+
+//PolicyControl ...
+type PolicyControl struct {
+	Grant PolicyGrant `json:"grant"`
+}
+
+//PolicyGrant ...
+type PolicyGrant struct {
+	Roles []PolicyRole `json:"roles"`
+}
+
+//PolicyRole ...
+type PolicyRole struct {
+	RoleID string `json:"role_id"`
+}
+
+//PolicyResource ...
+type PolicyResource struct {
+	Attributes []ResourceAttribute `json:"attributes"`
+	Tags       []ResourceTag       `json:"tags,omitempty"`
+}
+
+//ResourceAttribute is matched with stringEquals, stringMatch (with * and ?
+//wildcards) or stringExists, whose value is a boolean
+type ResourceAttribute struct {
+	Key      string      `json:"key"`
+	Operator string      `json:"operator"`
+	Value    interface{} `json:"value"`
+}
+
+//ResourceTag restricts the policy to resources carrying an access tag
+type ResourceTag struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Operator string `json:"operator"`
+}
+
+//SubjectAttribute returns the value of a subject attribute
+func (p Policy) SubjectAttribute(key string) string {
+	for _, a := range p.Subject.Attributes {
+		if a.Key == key {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+//SetSubjectAttribute adds or replaces a subject attribute compared with
+//stringEquals
+func (p *Policy) SetSubjectAttribute(key, value string) {
+	for i, a := range p.Subject.Attributes {
+		if a.Key == key {
+			p.Subject.Attributes[i].Value = value
+			return
+		}
+	}
+	p.Subject.Attributes = append(p.Subject.Attributes, SubjectAttribute{Key: key, Operator: OperatorStringEquals, Value: value})
+}
+
+//ResourceAttribute returns a resource attribute
+func (p Policy) ResourceAttribute(key string) (ResourceAttribute, bool) {
+	for _, a := range p.Resource.Attributes {
+		if a.Key == key {
+			return a, true
+		}
+	}
+	return ResourceAttribute{}, false
+}
+
+//SetResourceAttribute adds or replaces a resource attribute
+func (p *Policy) SetResourceAttribute(key, operator string, value interface{}) {
+	for i, a := range p.Resource.Attributes {
+		if a.Key == key {
+			p.Resource.Attributes[i] = ResourceAttribute{Key: key, Operator: operator, Value: value}
+			return
+		}
+	}
+	p.Resource.Attributes = append(p.Resource.Attributes, ResourceAttribute{Key: key, Operator: operator, Value: value})
+}
+
+//AddRoles grants roles by CRN
+func (p *Policy) AddRoles(roleCRNs ...string) {
+	for _, r := range roleCRNs {
+		p.Control.Grant.Roles = append(p.Control.Grant.Roles, PolicyRole{RoleID: r})
+	}
+}
+
+//PolicyQuery filters the policies returned by List
+type PolicyQuery struct {
+	AccountID     string
+	IAMID         string
+	AccessGroupID string
+	Type          string
+	ServiceType   string
+	ServiceName   string
+	//Format is "include_last_permit" or "display" to expand role details
+	Format string
+	State  string
+	Sort   string
+}
+
+//SetQuery will set query parameter to the passed-in request
+func (q PolicyQuery) SetQuery(req *rest.Request) {
+	params := []struct{ name, value string }{
+		{"account_id", q.AccountID},
+		{"iam_id", q.IAMID},
+		{"access_group_id", q.AccessGroupID},
+		{"type", q.Type},
+		{"service_type", q.ServiceType},
+		{"service_name", q.ServiceName},
+		{"format", q.Format},
+		{"state", q.State},
+		{"sort", q.Sort},
+	}
+	for _, p := range params {
+		if p.value != "" {
+			req.Query(p.name, p.value)
+		}
+	}
+}
+
+//PolicyRepository manages v2 policies
+type PolicyRepository interface {
+	Create(policy Policy) (Policy, error)
+	Get(policyID string) (Policy, error)
+	Update(policyID string, policy Policy, version string) (Policy, error)
+	Delete(policyID string) error
+	//List returns every policy matching query, following pages
+	List(query PolicyQuery) ([]Policy, error)
+}
+
+type policyRepository struct {
+	client *client.Client
+}
+
+//NewPolicyRepository ...
+func NewPolicyRepository(c *client.Client) PolicyRepository {
+	return &policyRepository{
+		client: c,
+	}
+}
+
+func (r *policyRepository) Create(policy Policy) (Policy, error) {
+	if err := policy.Validate(); err != nil {
+		return Policy{}, err
+	}
+	res := Policy{}
+	resp, err := r.client.Post("/v2/policies", &policy, &res)
+	if err != nil {
+		return Policy{}, err
+	}
+	res.Version = resp.Header.Get("Etag")
+	return res, nil
+}
+
+func (r *policyRepository) Get(policyID string) (Policy, error) {
+	res := Policy{}
+	resp, err := r.client.Get(fmt.Sprintf("/v2/policies/%s", policyID), &res)
+	if err != nil {
+		return Policy{}, err
+	}
+	res.Version = resp.Header.Get("Etag")
+	return res, nil
+}
+
+func (r *policyRepository) Update(policyID string, policy Policy, version string) (Policy, error) {
+	if err := policy.Validate(); err != nil {
+		return Policy{}, err
+	}
+	res := Policy{}
+	header := map[string]string{"If-Match": version}
+	resp, err := r.client.Put(fmt.Sprintf("/v2/policies/%s", policyID), &policy, &res, header)
+	if err != nil {
+		return Policy{}, err
+	}
+	res.Version = resp.Header.Get("Etag")
+	return res, nil
+}
+
+func (r *policyRepository) Delete(policyID string) error {
+	_, err := r.client.Delete(fmt.Sprintf("/v2/policies/%s", policyID))
+	return err
+}
+
+type policyListResponse struct {
+	Policies []Policy `json:"policies"`
+	Next     *struct {
+		Start string `json:"start"`
+	} `json:"next,omitempty"`
+}
+
+func (r *policyRepository) List(query PolicyQuery) ([]Policy, error) {
+	policies := []Policy{}
+	start := ""
+	for {
+		req := rest.GetRequest(*r.client.Config.Endpoint + "/v2/policies")
+		query.SetQuery(req)
+		if start != "" {
+			req.Query("start", start)
+		}
+		var response policyListResponse
+		_, err := r.client.SendRequest(req, &response)
+		if err != nil {
+			return []Policy{}, err
+		}
+		policies = append(policies, response.Policies...)
+		if response.Next == nil || response.Next.Start == "" || response.Next.Start == start {
+			return policies, nil
+		}
+		start = response.Next.Start
+	}
+}
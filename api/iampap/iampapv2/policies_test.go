@@ -0,0 +1,234 @@
+package iampapv2
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	bluemix "github.com/IBM-Cloud/bluemix-go"
+	"github.com/IBM-Cloud/bluemix-go/client"
+	"github.com/IBM-Cloud/bluemix-go/session"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+const testPolicy = `{
+	"id": "policy-1",
+	"type": "access",
+	"subject": {"attributes": [{"key": "iam_id", "operator": "stringEquals", "value": "IBMid-123"}]},
+	"control": {"grant": {"roles": [{"role_id": "crn:v1:bluemix:public:iam::::role:Viewer"}]}},
+	"resource": {"attributes": [
+		{"key": "accountId", "operator": "stringEquals", "value": "acc"},
+		{"key": "serviceName", "operator": "stringEquals", "value": "cloud-object-storage"}
+	]},
+	"pattern": "time-based-conditions:once",
+	"rule": {
+		"operator": "and",
+		"conditions": [
+			{"key": "{{environment.attributes.current_date_time}}", "operator": "dateTimeGreaterThanOrEquals", "value": "2023-01-01T09:00:00+00:00"},
+			{"key": "{{environment.attributes.current_date_time}}", "operator": "dateTimeLessThanOrEquals", "value": "2023-01-31T17:00:00+00:00"}
+		]
+	},
+	"state": "active"
+}`
+
+func newTestPolicy() Policy {
+	p := Policy{Type: AccessPolicyType}
+	p.SetSubjectAttribute("iam_id", "IBMid-123")
+	p.AddRoles("crn:v1:bluemix:public:iam::::role:Viewer")
+	p.SetResourceAttribute("accountId", OperatorStringEquals, "acc")
+	p.SetResourceAttribute("serviceName", OperatorStringEquals, "cloud-object-storage")
+	return p
+}
+
+var _ = Describe("PolicyRepository", func() {
+	var server *ghttp.Server
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("Create()", func() {
+		Context("When the policy has a time based rule", func() {
+			BeforeEach(func() {
+				server = ghttp.NewServer()
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest(http.MethodPost, "/v2/policies"),
+						ghttp.VerifyJSON(`{
+							"type": "access",
+							"subject": {"attributes": [{"key": "iam_id", "operator": "stringEquals", "value": "IBMid-123"}]},
+							"control": {"grant": {"roles": [{"role_id": "crn:v1:bluemix:public:iam::::role:Viewer"}]}},
+							"resource": {"attributes": [
+								{"key": "accountId", "operator": "stringEquals", "value": "acc"},
+								{"key": "serviceName", "operator": "stringEquals", "value": "cloud-object-storage"}
+							]},
+							"pattern": "time-based-conditions:once",
+							"rule": {
+								"operator": "and",
+								"conditions": [
+									{"key": "{{environment.attributes.current_date_time}}", "operator": "dateTimeGreaterThanOrEquals", "value": "2023-01-01T09:00:00+00:00"},
+									{"key": "{{environment.attributes.current_date_time}}", "operator": "dateTimeLessThanOrEquals", "value": "2023-01-31T17:00:00+00:00"}
+								]
+							}
+						}`),
+						ghttp.RespondWith(http.StatusCreated, testPolicy, http.Header{"ETag": []string{"1-abc"}}),
+					),
+				)
+			})
+
+			It("should return the created policy", func() {
+				p := newTestPolicy()
+				p.SetTemporaryAccess(time.Date(2023, 1, 1, 9, 0, 0, 0, time.UTC), time.Date(2023, 1, 31, 17, 0, 0, 0, time.UTC))
+				created, err := newTestPolicyRepo(server.URL()).Create(p)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(created.ID).Should(Equal("policy-1"))
+				Expect(created.Version).Should(Equal("1-abc"))
+				Expect(created.Rule.Conditions).Should(HaveLen(2))
+				Expect(created.SubjectAttribute("iam_id")).Should(Equal("IBMid-123"))
+			})
+		})
+
+		Context("When the policy is invalid", func() {
+			BeforeEach(func() {
+				server = ghttp.NewServer()
+			})
+
+			It("should not send it", func() {
+				p := newTestPolicy()
+				rule := StringMatch("{{resource.attributes.prefix}}", "logs/*")
+				p.Rule = &rule
+				_, err := newTestPolicyRepo(server.URL()).Create(p)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).Should(ContainSubstring("pattern"))
+				Expect(server.ReceivedRequests()).Should(BeEmpty())
+			})
+		})
+	})
+
+	Describe("Get()", func() {
+		BeforeEach(func() {
+			server = ghttp.NewServer()
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodGet, "/v2/policies/policy-1"),
+					ghttp.RespondWith(http.StatusOK, testPolicy, http.Header{"ETag": []string{"1-abc"}}),
+				),
+			)
+		})
+
+		It("should decode the rule", func() {
+			p, err := newTestPolicyRepo(server.URL()).Get("policy-1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(p.Version).Should(Equal("1-abc"))
+			Expect(p.Pattern).Should(Equal(PatternTimeBasedOnce))
+			Expect(p.Rule.Validate()).To(Succeed())
+			attr, ok := p.ResourceAttribute("serviceName")
+			Expect(ok).Should(BeTrue())
+			Expect(attr.Value).Should(Equal("cloud-object-storage"))
+		})
+	})
+
+	Describe("Update()", func() {
+		BeforeEach(func() {
+			server = ghttp.NewServer()
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodPut, "/v2/policies/policy-1"),
+					ghttp.VerifyHeader(http.Header{"If-Match": []string{"1-abc"}}),
+					ghttp.RespondWith(http.StatusOK, testPolicy, http.Header{"ETag": []string{"2-def"}}),
+				),
+			)
+		})
+
+		It("should send the version", func() {
+			p, err := newTestPolicyRepo(server.URL()).Update("policy-1", newTestPolicy(), "1-abc")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(p.Version).Should(Equal("2-def"))
+		})
+	})
+
+	Describe("List()", func() {
+		BeforeEach(func() {
+			server = ghttp.NewServer()
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodGet, "/v2/policies", "account_id=acc&iam_id=IBMid-123"),
+					ghttp.RespondWith(http.StatusOK, `{"policies": [`+testPolicy+`], "next": {"start": "page2"}}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodGet, "/v2/policies", "account_id=acc&iam_id=IBMid-123&start=page2"),
+					ghttp.RespondWith(http.StatusOK, `{"policies": [{"id": "policy-2", "type": "access"}]}`),
+				),
+			)
+		})
+
+		It("should follow the pages", func() {
+			policies, err := newTestPolicyRepo(server.URL()).List(PolicyQuery{AccountID: "acc", IAMID: "IBMid-123"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(policies).Should(HaveLen(2))
+			Expect(policies[1].ID).Should(Equal("policy-2"))
+		})
+	})
+
+	Describe("Delete()", func() {
+		BeforeEach(func() {
+			server = ghttp.NewServer()
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodDelete, "/v2/policies/policy-1"),
+					ghttp.RespondWith(http.StatusNoContent, ""),
+				),
+			)
+		})
+
+		It("should return success", func() {
+			Expect(newTestPolicyRepo(server.URL()).Delete("policy-1")).To(Succeed())
+		})
+	})
+})
+
+var _ = Describe("Rule", func() {
+	It("should build weekly custom hours rules", func() {
+		p := newTestPolicy()
+		p.SetWeeklyAccess("+00:00", []time.Weekday{time.Monday, time.Sunday}, "09:00:00+00:00", "17:00:00+00:00")
+		Expect(p.Pattern).Should(Equal(PatternTimeBasedCustomHours))
+		Expect(p.Rule.Conditions[0].Value).Should(Equal([]string{"1+00:00", "7+00:00"}))
+		Expect(p.Validate()).To(Succeed())
+	})
+
+	It("should build weekly all day rules", func() {
+		p := newTestPolicy()
+		p.SetWeeklyAccess("+00:00", []time.Weekday{time.Friday}, "", "")
+		Expect(p.Pattern).Should(Equal(PatternTimeBasedAllDay))
+		Expect(p.Rule.Conditions).Should(HaveLen(1))
+	})
+
+	It("should reject deep nesting", func() {
+		rule := AllConditions(AnyCondition(AllConditions(StringMatch("k", "v"))))
+		Expect(rule.Validate()).NotTo(Succeed())
+		Expect(AllConditions(AnyCondition(StringMatch("k", "v"))).Validate()).To(Succeed())
+	})
+
+	It("should check condition values", func() {
+		Expect(Condition(RuleKeyDayOfWeek, RuleOperatorDayOfWeekAnyOf, "1+00:00").Validate()).NotTo(Succeed())
+		Expect(Condition(RuleKeyCurrentDateTime, RuleOperatorDateTimeLessThan, "tomorrow").Validate()).NotTo(Succeed())
+		Expect(Condition("k", "unknown", "v").Validate()).NotTo(Succeed())
+		Expect(AllConditions().Validate()).NotTo(Succeed())
+	})
+})
+
+func newTestPolicyRepo(url string) PolicyRepository {
+	sess, err := session.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	conf := sess.Config.Copy()
+	conf.Endpoint = &url
+	client := client.Client{
+		Config:      conf,
+		ServiceName: bluemix.IAMPAPServicev2,
+	}
+	return NewPolicyRepository(&client)
+}
@@ -0,0 +1,272 @@
+package iampapv2
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+)
+
+//ErrCodeInvalidPolicy ...
+const ErrCodeInvalidPolicy = "InvalidPolicy"
+
+//Policy patterns. A policy with a rule must name the pattern the rule
+//follows.
+const (
+	PatternTimeBasedOnce        = "time-based-conditions:once"
+	PatternTimeBasedAllDay      = "time-based-conditions:weekly:all-day"
+	PatternTimeBasedCustomHours = "time-based-conditions:weekly:custom-hours"
+	PatternResourceWildcard     = "attribute-based-condition:resource:literal-and-wildcard"
+)
+
+//Environment attributes rules are evaluated against
+const (
+	RuleKeyCurrentTime     = "{{environment.attributes.current_time}}"
+	RuleKeyCurrentDateTime = "{{environment.attributes.current_date_time}}"
+	RuleKeyDayOfWeek       = "{{environment.attributes.day_of_week}}"
+)
+
+//Rule operators
+const (
+	RuleOperatorAnd                         = "and"
+	RuleOperatorOr                          = "or"
+	RuleOperatorStringEquals                = "stringEquals"
+	RuleOperatorStringMatch                 = "stringMatch"
+	RuleOperatorStringEqualsAnyOf           = "stringEqualsAnyOf"
+	RuleOperatorStringMatchAnyOf            = "stringMatchAnyOf"
+	RuleOperatorDateTimeGreaterThan         = "dateTimeGreaterThan"
+	RuleOperatorDateTimeGreaterThanOrEquals = "dateTimeGreaterThanOrEquals"
+	RuleOperatorDateTimeLessThan            = "dateTimeLessThan"
+	RuleOperatorDateTimeLessThanOrEquals    = "dateTimeLessThanOrEquals"
+	RuleOperatorTimeGreaterThan             = "timeGreaterThan"
+	RuleOperatorTimeGreaterThanOrEquals     = "timeGreaterThanOrEquals"
+	RuleOperatorTimeLessThan                = "timeLessThan"
+	RuleOperatorTimeLessThanOrEquals        = "timeLessThanOrEquals"
+	RuleOperatorDayOfWeekEquals             = "dayOfWeekEquals"
+	RuleOperatorDayOfWeekAnyOf              = "dayOfWeekAnyOf"
+)
+
+//MaxRuleConditions is the number of conditions an and/or rule may hold
+const MaxRuleConditions = 10
+
+//DateTimeFormat is the layout of dateTime rule values
+const DateTimeFormat = "2006-01-02T15:04:05-07:00"
+
+//Rule is either a condition comparing Key with Value, or an "and"/"or" of
+//nested Conditions. Conditions may be nested one level deep.
+type Rule struct {
+	Key        string      `json:"key,omitempty"`
+	Operator   string      `json:"operator"`
+	Value      interface{} `json:"value,omitempty"`
+	Conditions []Rule      `json:"conditions,omitempty"`
+}
+
+//listOperators take a list of values
+var listOperators = map[string]bool{
+	RuleOperatorStringEqualsAnyOf: true,
+	RuleOperatorStringMatchAnyOf:  true,
+	RuleOperatorDayOfWeekAnyOf:    true,
+}
+
+var conditionOperators = map[string]bool{
+	RuleOperatorStringEquals:                true,
+	RuleOperatorStringMatch:                 true,
+	RuleOperatorStringEqualsAnyOf:           true,
+	RuleOperatorStringMatchAnyOf:            true,
+	RuleOperatorDateTimeGreaterThan:         true,
+	RuleOperatorDateTimeGreaterThanOrEquals: true,
+	RuleOperatorDateTimeLessThan:            true,
+	RuleOperatorDateTimeLessThanOrEquals:    true,
+	RuleOperatorTimeGreaterThan:             true,
+	RuleOperatorTimeGreaterThanOrEquals:     true,
+	RuleOperatorTimeLessThan:                true,
+	RuleOperatorTimeLessThanOrEquals:        true,
+	RuleOperatorDayOfWeekEquals:             true,
+	RuleOperatorDayOfWeekAnyOf:              true,
+}
+
+//Condition compares key with value using operator
+func Condition(key, operator string, value interface{}) Rule {
+	return Rule{Key: key, Operator: operator, Value: value}
+}
+
+//AllConditions is a rule satisfied when every condition is
+func AllConditions(conditions ...Rule) Rule {
+	return Rule{Operator: RuleOperatorAnd, Conditions: conditions}
+}
+
+//AnyCondition is a rule satisfied when one of the conditions is
+func AnyCondition(conditions ...Rule) Rule {
+	return Rule{Operator: RuleOperatorOr, Conditions: conditions}
+}
+
+//StringMatch compares key with a pattern where * and ? are wildcards
+func StringMatch(key, pattern string) Rule {
+	return Condition(key, RuleOperatorStringMatch, pattern)
+}
+
+//DateTimeGreaterThanOrEquals grants access from t
+func DateTimeGreaterThanOrEquals(t time.Time) Rule {
+	return Condition(RuleKeyCurrentDateTime, RuleOperatorDateTimeGreaterThanOrEquals, t.Format(DateTimeFormat))
+}
+
+//DateTimeGreaterThan grants access after t
+func DateTimeGreaterThan(t time.Time) Rule {
+	return Condition(RuleKeyCurrentDateTime, RuleOperatorDateTimeGreaterThan, t.Format(DateTimeFormat))
+}
+
+//DateTimeLessThanOrEquals grants access until t
+func DateTimeLessThanOrEquals(t time.Time) Rule {
+	return Condition(RuleKeyCurrentDateTime, RuleOperatorDateTimeLessThanOrEquals, t.Format(DateTimeFormat))
+}
+
+//DateTimeLessThan grants access before t
+func DateTimeLessThan(t time.Time) Rule {
+	return Condition(RuleKeyCurrentDateTime, RuleOperatorDateTimeLessThan, t.Format(DateTimeFormat))
+}
+
+//TimeGreaterThanOrEquals grants access from a time of day such as
+//"09:00:00+00:00"
+func TimeGreaterThanOrEquals(clock string) Rule {
+	return Condition(RuleKeyCurrentTime, RuleOperatorTimeGreaterThanOrEquals, clock)
+}
+
+//TimeLessThanOrEquals grants access until a time of day such as
+//"17:00:00+00:00"
+func TimeLessThanOrEquals(clock string) Rule {
+	return Condition(RuleKeyCurrentTime, RuleOperatorTimeLessThanOrEquals, clock)
+}
+
+//DayOfWeekAnyOf grants access on days, in the time zone offset such as
+//"+00:00"
+func DayOfWeekAnyOf(offset string, days ...time.Weekday) Rule {
+	values := make([]string, len(days))
+	for i, d := range days {
+		values[i] = fmt.Sprintf("%d%s", isoWeekday(d), offset)
+	}
+	return Condition(RuleKeyDayOfWeek, RuleOperatorDayOfWeekAnyOf, values)
+}
+
+//isoWeekday numbers days from 1 for Monday to 7 for Sunday, as IAM does
+func isoWeekday(d time.Weekday) int {
+	if d == time.Sunday {
+		return 7
+	}
+	return int(d)
+}
+
+//SetTemporaryAccess restricts the policy to the period from from until
+//until
+func (p *Policy) SetTemporaryAccess(from, until time.Time) {
+	rule := AllConditions(DateTimeGreaterThanOrEquals(from), DateTimeLessThanOrEquals(until))
+	p.Pattern = PatternTimeBasedOnce
+	p.Rule = &rule
+}
+
+//SetWeeklyAccess restricts the policy to days between the times of day
+//from and until, such as "09:00:00+00:00". With empty times the days are
+//allowed in full, in the time zone offset of the days.
+func (p *Policy) SetWeeklyAccess(offset string, days []time.Weekday, from, until string) {
+	rule := AllConditions(DayOfWeekAnyOf(offset, days...))
+	p.Pattern = PatternTimeBasedAllDay
+	if from != "" || until != "" {
+		rule.Conditions = append(rule.Conditions, TimeGreaterThanOrEquals(from), TimeLessThanOrEquals(until))
+		p.Pattern = PatternTimeBasedCustomHours
+	}
+	p.Rule = &rule
+}
+
+//Validate checks the rule structure and the condition values
+func (r Rule) Validate() error {
+	return r.validate(0)
+}
+
+func (r Rule) validate(depth int) error {
+	switch r.Operator {
+	case RuleOperatorAnd, RuleOperatorOr:
+		if depth > 1 {
+			return bmxerror.New(ErrCodeInvalidPolicy, "Rule conditions may only be nested one level deep")
+		}
+		if r.Key != "" || r.Value != nil {
+			return bmxerror.New(ErrCodeInvalidPolicy, fmt.Sprintf("An %q rule can not have a key or value", r.Operator))
+		}
+		if len(r.Conditions) == 0 || len(r.Conditions) > MaxRuleConditions {
+			return bmxerror.New(ErrCodeInvalidPolicy,
+				fmt.Sprintf("An %q rule must have between 1 and %d conditions", r.Operator, MaxRuleConditions))
+		}
+		for _, c := range r.Conditions {
+			if err := c.validate(depth + 1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if !conditionOperators[r.Operator] {
+		return bmxerror.New(ErrCodeInvalidPolicy, fmt.Sprintf("Unknown rule operator %q", r.Operator))
+	}
+	if r.Key == "" {
+		return bmxerror.New(ErrCodeInvalidPolicy, fmt.Sprintf("The %q condition has no key", r.Operator))
+	}
+	if len(r.Conditions) != 0 {
+		return bmxerror.New(ErrCodeInvalidPolicy, fmt.Sprintf("The %q condition can not have nested conditions", r.Operator))
+	}
+	values, isList := stringValues(r.Value)
+	if isList != listOperators[r.Operator] || len(values) == 0 {
+		return bmxerror.New(ErrCodeInvalidPolicy, fmt.Sprintf("Invalid value %v for the %q condition on %s", r.Value, r.Operator, r.Key))
+	}
+	if strings.HasPrefix(r.Operator, "dateTime") {
+		if _, err := time.Parse(DateTimeFormat, values[0]); err != nil {
+			return bmxerror.New(ErrCodeInvalidPolicy, fmt.Sprintf("Invalid date and time %q, use the %s format", values[0], DateTimeFormat))
+		}
+	}
+	return nil
+}
+
+//stringValues returns the values of a condition and whether it is a list.
+//Decoded JSON lists hold interface{} elements.
+func stringValues(value interface{}) ([]string, bool) {
+	switch v := value.(type) {
+	case string:
+		if v == "" {
+			return nil, false
+		}
+		return []string{v}, false
+	case []string:
+		return v, true
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, e := range v {
+			s, ok := e.(string)
+			if !ok {
+				return nil, true
+			}
+			values = append(values, s)
+		}
+		return values, true
+	}
+	return nil, false
+}
+
+//Validate checks that the policy can be submitted
+func (p Policy) Validate() error {
+	if p.Type == "" {
+		return bmxerror.New(ErrCodeInvalidPolicy, "The policy has no type")
+	}
+	if len(p.Subject.Attributes) == 0 {
+		return bmxerror.New(ErrCodeInvalidPolicy, "The policy has no subject")
+	}
+	if len(p.Control.Grant.Roles) == 0 {
+		return bmxerror.New(ErrCodeInvalidPolicy, "The policy grants no roles")
+	}
+	if len(p.Resource.Attributes) == 0 {
+		return bmxerror.New(ErrCodeInvalidPolicy, "The policy has no resource")
+	}
+	if p.Rule == nil {
+		return nil
+	}
+	if p.Pattern == "" {
+		return bmxerror.New(ErrCodeInvalidPolicy, "A policy with a rule must have a pattern")
+	}
+	return p.Rule.Validate()
+}
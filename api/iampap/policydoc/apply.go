@@ -0,0 +1,121 @@
+package policydoc
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/IBM-Cloud/bluemix-go/api/iampap/iampapv1"
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+)
+
+//ErrCodePolicyConflict ...
+const ErrCodePolicyConflict = "PolicyConflict"
+
+//ApplyResult records the outcome of every change by key
+type ApplyResult struct {
+	//Applied maps keys to the ID of the created, updated or deleted policy
+	Applied map[string]string
+	Errors  map[string]error
+}
+
+//Failed reports whether any change failed
+func (r ApplyResult) Failed() bool {
+	return len(r.Errors) > 0
+}
+
+//Apply issues the changes against the account. Updates read the policy
+//first and send its ETag, so a policy modified since the live document was
+//exported fails with ErrCodePolicyConflict instead of being overwritten.
+//Updates only replace the roles; other subjects and resources of the live
+//policy are kept. Deletions read the policy first as well and fail with
+//ErrCodePolicyConflict when it no longer matches the live document. Every
+//change is attempted; failures are collected in the result.
+func Apply(policies iampapv1.V1PolicyRepository, names *Names, accountID string, set ChangeSet) ApplyResult {
+	if names == nil {
+		names = NewNames()
+	}
+	result := ApplyResult{
+		Applied: map[string]string{},
+		Errors:  map[string]error{},
+	}
+	for _, c := range set.Changes {
+		id, err := applyChange(policies, names, accountID, c)
+		if err != nil {
+			result.Errors[c.Key] = err
+			continue
+		}
+		result.Applied[c.Key] = id
+	}
+	return result
+}
+
+func applyChange(policies iampapv1.V1PolicyRepository, names *Names, accountID string, c Change) (string, error) {
+	switch c.Action {
+	case ActionCreate:
+		p, err := FromSpec(c.Desired, names, accountID)
+		if err != nil {
+			return "", err
+		}
+		created, err := policies.Create(p)
+		if err != nil {
+			return "", err
+		}
+		return created.ID, nil
+	case ActionUpdate:
+		desired, err := FromSpec(c.Desired, names, accountID)
+		if err != nil {
+			return "", err
+		}
+		current, err := policies.Get(c.Live.ID)
+		if err != nil {
+			return "", err
+		}
+		if !sameRoles(roleNames(current, names), c.Live.Roles) {
+			return "", bmxerror.New(ErrCodePolicyConflict,
+				fmt.Sprintf("Policy %s was modified since it was exported", c.Live.ID))
+		}
+		current.Roles = desired.Roles
+		updated, err := policies.Update(c.Live.ID, current, current.Version)
+		if err != nil {
+			if reqErr, ok := err.(bmxerror.RequestFailure); ok && reqErr.StatusCode() == http.StatusPreconditionFailed {
+				return "", bmxerror.New(ErrCodePolicyConflict,
+					fmt.Sprintf("Policy %s was modified while it was updated", c.Live.ID))
+			}
+			return "", err
+		}
+		return updated.ID, nil
+	case ActionDelete:
+		current, err := policies.Get(c.Live.ID)
+		if err != nil {
+			return "", err
+		}
+		if !holds(current, c.Live, names, accountID) {
+			return "", bmxerror.New(ErrCodePolicyConflict,
+				fmt.Sprintf("Policy %s was modified since it was exported", c.Live.ID))
+		}
+		return c.Live.ID, policies.Delete(c.Live.ID)
+	}
+	return "", fmt.Errorf("Unknown action %q", c.Action)
+}
+
+//holds reports whether p still grants the roles of spec to its subject on
+//its resource
+func holds(p iampapv1.Policy, spec PolicySpec, names *Names, accountID string) bool {
+	for _, current := range ToSpec(p, names, accountID) {
+		if current.Key() == spec.Key() {
+			sort.Strings(current.Roles)
+			return sameRoles(current.Roles, spec.Roles)
+		}
+	}
+	return false
+}
+
+func roleNames(p iampapv1.Policy, names *Names) []string {
+	roles := make([]string, 0, len(p.Roles))
+	for _, r := range p.Roles {
+		roles = append(roles, names.RoleName(r.RoleID))
+	}
+	sort.Strings(roles)
+	return roles
+}
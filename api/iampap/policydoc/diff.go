@@ -0,0 +1,119 @@
+package policydoc
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+)
+
+//Change actions
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+//Change is one difference between the desired and the live document
+type Change struct {
+	Action string
+	Key    string
+	//Desired is empty for deletions
+	Desired PolicySpec
+	//Live is empty for creations. Its ID is the policy to update or delete.
+	Live PolicySpec
+}
+
+//ChangeSet lists the changes that turn the live document into the desired
+//one, sorted by key
+type ChangeSet struct {
+	Changes []Change
+}
+
+//Diff compares a desired document with the live one. Policies are matched
+//by key, so a policy whose subject or resource changed is deleted and
+//created again. Only the roles of matched policies are compared.
+func Diff(desired, live Document) (ChangeSet, error) {
+	if err := desired.Validate(); err != nil {
+		return ChangeSet{}, err
+	}
+	if desired.AccountID != live.AccountID {
+		return ChangeSet{}, bmxerror.New(ErrCodeInvalidPolicyDocument,
+			fmt.Sprintf("Desired document is for account %s but live document is for account %s", desired.AccountID, live.AccountID))
+	}
+	desired.Policies = append([]PolicySpec{}, desired.Policies...)
+	desired.Normalize()
+	live.Policies = append([]PolicySpec{}, live.Policies...)
+	live.Normalize()
+
+	liveByKey := map[string]PolicySpec{}
+	for _, p := range live.Policies {
+		liveByKey[p.Key()] = p
+	}
+	set := ChangeSet{Changes: []Change{}}
+	desiredKeys := map[string]bool{}
+	for _, p := range desired.Policies {
+		key := p.Key()
+		desiredKeys[key] = true
+		current, ok := liveByKey[key]
+		switch {
+		case !ok:
+			set.Changes = append(set.Changes, Change{Action: ActionCreate, Key: key, Desired: p})
+		case !sameRoles(p.Roles, current.Roles):
+			set.Changes = append(set.Changes, Change{Action: ActionUpdate, Key: key, Desired: p, Live: current})
+		}
+	}
+	for _, p := range live.Policies {
+		if key := p.Key(); !desiredKeys[key] {
+			set.Changes = append(set.Changes, Change{Action: ActionDelete, Key: key, Live: p})
+		}
+	}
+	return set, nil
+}
+
+//sameRoles compares sorted role lists
+func sameRoles(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+//Empty reports whether the documents are in sync
+func (s ChangeSet) Empty() bool {
+	return len(s.Changes) == 0
+}
+
+//Count returns the number of changes with action
+func (s ChangeSet) Count(action string) int {
+	n := 0
+	for _, c := range s.Changes {
+		if c.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+//Print writes the changes in a plan-like form
+func (s ChangeSet) Print(w io.Writer) {
+	for _, c := range s.Changes {
+		switch c.Action {
+		case ActionCreate:
+			fmt.Fprintf(w, "+ %s\n    roles: %s\n", c.Key, strings.Join(c.Desired.Roles, ", "))
+		case ActionUpdate:
+			fmt.Fprintf(w, "~ %s (%s)\n    roles: %s => %s\n", c.Key, c.Live.ID,
+				strings.Join(c.Live.Roles, ", "), strings.Join(c.Desired.Roles, ", "))
+		case ActionDelete:
+			fmt.Fprintf(w, "- %s (%s)\n", c.Key, c.Live.ID)
+		}
+	}
+	fmt.Fprintf(w, "%d to create, %d to update, %d to delete\n",
+		s.Count(ActionCreate), s.Count(ActionUpdate), s.Count(ActionDelete))
+}
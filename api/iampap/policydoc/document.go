@@ -0,0 +1,144 @@
+//Package policydoc manages IAM policies as code. An account's policies are
+//exported into a Document with IDs replaced by names, compared with a
+//desired Document and the differences applied back to the account.
+package policydoc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	yaml "github.com/ghodss/yaml"
+
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+)
+
+//ErrCodeInvalidPolicyDocument ...
+const ErrCodeInvalidPolicyDocument = "InvalidPolicyDocument"
+
+//DocumentVersion is the format version written by Export
+const DocumentVersion = 1
+
+//Subject keys holding resolved names
+const (
+	SubjectUser        = "user"
+	SubjectServiceID   = "service_id"
+	SubjectAccessGroup = "access_group"
+)
+
+//ResourceGroupKey holds the resolved name of the resourceGroupId attribute
+const ResourceGroupKey = "resourceGroup"
+
+//Document is the declarative form of an account's policies
+type Document struct {
+	Version   int          `json:"version"`
+	AccountID string       `json:"account_id"`
+	Policies  []PolicySpec `json:"policies"`
+}
+
+//PolicySpec is one policy. Subject and Resource hold the policy attributes
+//by name, except that IDs are replaced by names where they can be resolved:
+//a subject is written as user, service_id or access_group and a resource
+//group as resourceGroup. The accountId attribute is implied by the document.
+type PolicySpec struct {
+	//ID is informational. It is filled by Export and ignored by Diff.
+	ID       string            `json:"id,omitempty"`
+	Type     string            `json:"type"`
+	Subject  map[string]string `json:"subject"`
+	Roles    []string          `json:"roles"`
+	Resource map[string]string `json:"resource"`
+}
+
+//Key identifies the policy by type, subject and resource. A document holds
+//at most one policy per key.
+func (p PolicySpec) Key() string {
+	return p.Type + " " + canonical(p.Subject) + " -> " + canonical(p.Resource)
+}
+
+func canonical(attrs map[string]string) string {
+	names := sortedKeys(attrs)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + "=" + attrs[name]
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func sortedKeys(attrs map[string]string) []string {
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//Normalize sorts roles and policies so that equal documents serialize
+//identically
+func (d *Document) Normalize() {
+	for i := range d.Policies {
+		roles := append([]string{}, d.Policies[i].Roles...)
+		sort.Strings(roles)
+		d.Policies[i].Roles = roles
+	}
+	sort.SliceStable(d.Policies, func(i, j int) bool {
+		return d.Policies[i].Key() < d.Policies[j].Key()
+	})
+}
+
+//Validate checks that every policy is complete and unique
+func (d Document) Validate() error {
+	if d.AccountID == "" {
+		return bmxerror.New(ErrCodeInvalidPolicyDocument, "The document has no account ID")
+	}
+	seen := map[string]bool{}
+	for _, p := range d.Policies {
+		key := p.Key()
+		switch {
+		case p.Type == "":
+			return bmxerror.New(ErrCodeInvalidPolicyDocument, fmt.Sprintf("Policy %s has no type", key))
+		case len(p.Subject) == 0:
+			return bmxerror.New(ErrCodeInvalidPolicyDocument, fmt.Sprintf("Policy %s has no subject", key))
+		case len(p.Roles) == 0:
+			return bmxerror.New(ErrCodeInvalidPolicyDocument, fmt.Sprintf("Policy %s has no roles", key))
+		case seen[key]:
+			return bmxerror.New(ErrCodeInvalidPolicyDocument, fmt.Sprintf("Policy %s is declared more than once", key))
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+//LoadDocument reads a document from a YAML or JSON file
+func LoadDocument(path string) (Document, error) {
+	doc := Document{}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return doc, err
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return doc, err
+	}
+	return doc, doc.Validate()
+}
+
+//Save writes the normalized document to path, as JSON when the file name
+//ends in .json and as YAML otherwise
+func (d Document) Save(path string) error {
+	d.Policies = append([]PolicySpec{}, d.Policies...)
+	d.Normalize()
+	var data []byte
+	var err error
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		data, err = json.MarshalIndent(d, "", "  ")
+	} else {
+		data, err = yaml.Marshal(d)
+	}
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
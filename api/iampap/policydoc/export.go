@@ -0,0 +1,153 @@
+package policydoc
+
+import (
+	"github.com/IBM-Cloud/bluemix-go/api/iampap/iampapv1"
+)
+
+//Export converts the access and authorization policies of accountID into a
+//normalized document. names may be nil, in which case IDs are kept.
+func Export(policies iampapv1.V1PolicyRepository, names *Names, accountID string) (Document, error) {
+	if names == nil {
+		names = NewNames()
+	}
+	doc := Document{
+		Version:   DocumentVersion,
+		AccountID: accountID,
+		Policies:  []PolicySpec{},
+	}
+	for _, policyType := range []string{iampapv1.AccessPolicyType, iampapv1.AuthorizationPolicyType} {
+		list, err := policies.List(iampapv1.SearchParams{AccountID: accountID, Type: policyType})
+		if err != nil {
+			return Document{}, err
+		}
+		for _, p := range list {
+			doc.Policies = append(doc.Policies, ToSpec(p, names, accountID)...)
+		}
+	}
+	doc.Normalize()
+	return doc, nil
+}
+
+//ToSpec converts a policy into one spec per subject and resource, with IDs
+//replaced by names
+func ToSpec(p iampapv1.Policy, names *Names, accountID string) []PolicySpec {
+	specs := []PolicySpec{}
+	for _, s := range p.Subjects {
+		for _, r := range p.Resources {
+			spec := PolicySpec{
+				ID:       p.ID,
+				Type:     p.Type,
+				Subject:  subjectSpec(s, names, accountID),
+				Roles:    make([]string, 0, len(p.Roles)),
+				Resource: resourceSpec(r, names, accountID),
+			}
+			if spec.Type == "" {
+				spec.Type = iampapv1.AccessPolicyType
+			}
+			for _, role := range p.Roles {
+				spec.Roles = append(spec.Roles, names.RoleName(role.RoleID))
+			}
+			specs = append(specs, spec)
+		}
+	}
+	return specs
+}
+
+func subjectSpec(s iampapv1.Subject, names *Names, accountID string) map[string]string {
+	spec := map[string]string{}
+	for _, a := range s.Attributes {
+		switch {
+		case a.Name == iampapv1.AccountIDAttribute && a.Value == accountID:
+			continue
+		case a.Name == "iam_id":
+			if name, ok := names.Name(KindUser, a.Value); ok {
+				spec[SubjectUser] = name
+				continue
+			}
+			if name, ok := names.Name(KindServiceID, a.Value); ok {
+				spec[SubjectServiceID] = name
+				continue
+			}
+		case a.Name == "access_group_id":
+			if name, ok := names.Name(KindAccessGroup, a.Value); ok {
+				spec[SubjectAccessGroup] = name
+				continue
+			}
+		}
+		spec[a.Name] = a.Value
+	}
+	return spec
+}
+
+func resourceSpec(r iampapv1.Resource, names *Names, accountID string) map[string]string {
+	spec := map[string]string{}
+	for _, a := range r.Attributes {
+		switch {
+		case a.Name == iampapv1.AccountIDAttribute && a.Value == accountID:
+			continue
+		case a.Name == iampapv1.ResourceGroupIDAttribute:
+			if name, ok := names.Name(KindResourceGroup, a.Value); ok {
+				spec[ResourceGroupKey] = name
+				continue
+			}
+		}
+		spec[a.Name] = a.Value
+	}
+	return spec
+}
+
+//FromSpec converts a spec back into a policy, resolving names to IDs
+func FromSpec(spec PolicySpec, names *Names, accountID string) (iampapv1.Policy, error) {
+	p := iampapv1.Policy{
+		Type:      spec.Type,
+		Subjects:  []iampapv1.Subject{{Attributes: []iampapv1.Attribute{}}},
+		Roles:     []iampapv1.Role{},
+		Resources: []iampapv1.Resource{{Attributes: []iampapv1.Attribute{}}},
+	}
+	subject := &p.Subjects[0]
+	if spec.Type == iampapv1.AuthorizationPolicyType {
+		subject.Attributes = append(subject.Attributes, iampapv1.Attribute{Name: iampapv1.AccountIDAttribute, Value: accountID})
+	}
+	for _, name := range sortedKeys(spec.Subject) {
+		value := spec.Subject[name]
+		var err error
+		switch name {
+		case SubjectUser:
+			name = "iam_id"
+			value, err = names.ID(KindUser, value)
+		case SubjectServiceID:
+			name = "iam_id"
+			value, err = names.ID(KindServiceID, value)
+		case SubjectAccessGroup:
+			name = "access_group_id"
+			value, err = names.ID(KindAccessGroup, value)
+		}
+		if err != nil {
+			return iampapv1.Policy{}, err
+		}
+		subject.Attributes = append(subject.Attributes, iampapv1.Attribute{Name: name, Value: value})
+	}
+
+	resource := &p.Resources[0]
+	resource.Attributes = append(resource.Attributes, iampapv1.Attribute{Name: iampapv1.AccountIDAttribute, Value: accountID})
+	for _, name := range sortedKeys(spec.Resource) {
+		value := spec.Resource[name]
+		if name == ResourceGroupKey {
+			id, err := names.ID(KindResourceGroup, value)
+			if err != nil {
+				return iampapv1.Policy{}, err
+			}
+			name, value = iampapv1.ResourceGroupIDAttribute, id
+		}
+		resource.Attributes = append(resource.Attributes, iampapv1.Attribute{Name: name, Value: value})
+	}
+
+	for _, role := range spec.Roles {
+		roleCRN, err := names.RoleCRN(spec.Resource[iampapv1.ServiceNameAttribute], role)
+		if err != nil {
+			return iampapv1.Policy{}, err
+		}
+		p.Roles = append(p.Roles, iampapv1.Role{RoleID: roleCRN})
+	}
+	return p, nil
+}
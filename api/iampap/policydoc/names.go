@@ -0,0 +1,195 @@
+package policydoc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/IBM-Cloud/bluemix-go/api/iam/iamv1"
+	"github.com/IBM-Cloud/bluemix-go/api/iampap/iampapv2"
+	"github.com/IBM-Cloud/bluemix-go/api/iamuum/iamuumv2"
+	"github.com/IBM-Cloud/bluemix-go/api/resource/resourcev2/managementv2"
+	"github.com/IBM-Cloud/bluemix-go/api/usermanagement/usermanagementv2"
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+)
+
+//ErrCodeUnresolvedName ...
+const ErrCodeUnresolvedName = "UnresolvedName"
+
+//Name kinds
+const (
+	KindUser          = "user"
+	KindServiceID     = "service ID"
+	KindAccessGroup   = "access group"
+	KindResourceGroup = "resource group"
+)
+
+//Names translates between IDs and names. A name shared by several IDs is
+//ambiguous: IDs carrying it are exported as is and the name can not be
+//resolved back.
+type Names struct {
+	names map[string]map[string]string
+	ids   map[string]map[string][]string
+	//roles are keyed by service name, system roles by ""
+	roleNames map[string]string
+	roleCRNs  map[string]map[string]string
+}
+
+//NewNames returns an empty translator
+func NewNames() *Names {
+	return &Names{
+		names:     map[string]map[string]string{},
+		ids:       map[string]map[string][]string{},
+		roleNames: map[string]string{},
+		roleCRNs:  map[string]map[string]string{},
+	}
+}
+
+//Add records the name of an ID of the given kind
+func (n *Names) Add(kind, id, name string) {
+	if id == "" || name == "" {
+		return
+	}
+	if n.names[kind] == nil {
+		n.names[kind] = map[string]string{}
+		n.ids[kind] = map[string][]string{}
+	}
+	if _, ok := n.names[kind][id]; ok {
+		return
+	}
+	n.names[kind][id] = name
+	n.ids[kind][name] = append(n.ids[kind][name], id)
+}
+
+//AddRole records the display name of a role. serviceName is empty for
+//system roles.
+func (n *Names) AddRole(serviceName, roleCRN, displayName string) {
+	if roleCRN == "" || displayName == "" {
+		return
+	}
+	n.roleNames[roleCRN] = displayName
+	if n.roleCRNs[serviceName] == nil {
+		n.roleCRNs[serviceName] = map[string]string{}
+	}
+	n.roleCRNs[serviceName][displayName] = roleCRN
+}
+
+//Name returns the unambiguous name of id
+func (n *Names) Name(kind, id string) (string, bool) {
+	name, ok := n.names[kind][id]
+	if !ok || len(n.ids[kind][name]) != 1 {
+		return "", false
+	}
+	return name, true
+}
+
+//ID resolves name back to its ID
+func (n *Names) ID(kind, name string) (string, error) {
+	ids := n.ids[kind][name]
+	switch len(ids) {
+	case 0:
+		return "", bmxerror.New(ErrCodeUnresolvedName, fmt.Sprintf("No %s named %q", kind, name))
+	case 1:
+		return ids[0], nil
+	}
+	return "", bmxerror.New(ErrCodeUnresolvedName, fmt.Sprintf("%d %ss are named %q", len(ids), kind, name))
+}
+
+//RoleName returns the display name of a role, or the CRN if it is unknown
+func (n *Names) RoleName(roleCRN string) string {
+	if name, ok := n.roleNames[roleCRN]; ok {
+		return name
+	}
+	return roleCRN
+}
+
+//RoleCRN resolves the display name of a role of serviceName, falling back
+//to system roles. Role CRNs are returned unchanged.
+func (n *Names) RoleCRN(serviceName, role string) (string, error) {
+	if _, ok := n.roleNames[role]; ok {
+		return role, nil
+	}
+	if crn, ok := n.roleCRNs[serviceName][role]; ok {
+		return crn, nil
+	}
+	if crn, ok := n.roleCRNs[""][role]; ok {
+		return crn, nil
+	}
+	if strings.HasPrefix(role, "crn:") {
+		return role, nil
+	}
+	return "", bmxerror.New(ErrCodeUnresolvedName, fmt.Sprintf("No role named %q for service %q", role, serviceName))
+}
+
+//NameSources are the repositories Names are loaded from. Nil sources are
+//skipped.
+type NameSources struct {
+	Users        usermanagementv2.Users
+	ServiceIDs   iamv1.ServiceIDRepository
+	AccessGroups iamuumv2.AccessGroupRepository
+	//ResourceGroups lists the resource groups of the account
+	ResourceGroups managementv2.ResourceGroupRepository
+	Roles          iampapv2.RoleRepository
+	//BoundTo is the account CRN service IDs are bound to, see
+	//utils.GenerateBoundToCRN
+	BoundTo string
+}
+
+//LoadNames reads the names of the users, service IDs, access groups,
+//resource groups and roles of accountID. Roles are loaded for the system
+//and for each of services.
+func LoadNames(accountID string, sources NameSources, services ...string) (*Names, error) {
+	n := NewNames()
+	if sources.Users != nil {
+		users, err := sources.Users.ListUsers(accountID)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range users {
+			n.Add(KindUser, u.IamID, u.Email)
+		}
+	}
+	if sources.ServiceIDs != nil {
+		ids, err := sources.ServiceIDs.List(sources.BoundTo)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range ids {
+			n.Add(KindServiceID, s.IAMID, s.Name)
+		}
+	}
+	if sources.AccessGroups != nil {
+		groups, err := sources.AccessGroups.List(accountID)
+		if err != nil {
+			return nil, err
+		}
+		for _, g := range groups {
+			n.Add(KindAccessGroup, g.ID, g.Name)
+		}
+	}
+	if sources.ResourceGroups != nil {
+		groups, err := sources.ResourceGroups.List(&managementv2.ResourceGroupQuery{AccountID: accountID})
+		if err != nil {
+			return nil, err
+		}
+		for _, g := range groups {
+			n.Add(KindResourceGroup, g.ID, g.Name)
+		}
+	}
+	if sources.Roles != nil {
+		seen := map[string]bool{}
+		for _, s := range append([]string{""}, services...) {
+			if seen[s] {
+				continue
+			}
+			seen[s] = true
+			roles, err := sources.Roles.ListAll(iampapv2.RoleQuery{AccountID: accountID, ServiceName: s})
+			if err != nil {
+				return nil, err
+			}
+			for _, r := range roles {
+				n.AddRole(r.ServiceName, r.Crn, r.DisplayName)
+			}
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,13 @@
+package policydoc_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestPolicydoc(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Policydoc Suite")
+}
@@ -0,0 +1,281 @@
+package policydoc
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/IBM-Cloud/bluemix-go/api/iampap/iampapv1"
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	viewerCRN = "crn:v1:bluemix:public:iam::::role:Viewer"
+	editorCRN = "crn:v1:bluemix:public:iam::::role:Editor"
+	readerCRN = "crn:v1:bluemix:public:iam::::serviceRole:Reader"
+)
+
+type policiesStub struct {
+	iampapv1.V1PolicyRepository
+	policies map[string]iampapv1.Policy
+	versions map[string]string
+	nextID   int
+	deleted  []string
+}
+
+func newPoliciesStub(policies ...iampapv1.Policy) *policiesStub {
+	s := &policiesStub{policies: map[string]iampapv1.Policy{}, versions: map[string]string{}}
+	for _, p := range policies {
+		s.policies[p.ID] = p
+		s.versions[p.ID] = "1"
+	}
+	return s
+}
+
+func (s *policiesStub) List(params iampapv1.SearchParams) ([]iampapv1.Policy, error) {
+	list := []iampapv1.Policy{}
+	for _, p := range s.policies {
+		if p.Type == params.Type {
+			list = append(list, p)
+		}
+	}
+	return list, nil
+}
+
+func (s *policiesStub) Get(id string) (iampapv1.Policy, error) {
+	p := s.policies[id]
+	p.Version = s.versions[id]
+	return p, nil
+}
+
+func (s *policiesStub) Create(p iampapv1.Policy) (iampapv1.Policy, error) {
+	s.nextID++
+	p.ID = "new-" + string(rune('0'+s.nextID))
+	s.policies[p.ID] = p
+	return p, nil
+}
+
+func (s *policiesStub) Update(id string, p iampapv1.Policy, version string) (iampapv1.Policy, error) {
+	if version != s.versions[id] {
+		return iampapv1.Policy{}, bmxerror.NewRequestFailure("Precondition Failed", "version mismatch", 412)
+	}
+	s.policies[id] = p
+	s.versions[id] += "1"
+	return p, nil
+}
+
+func (s *policiesStub) Delete(id string) error {
+	delete(s.policies, id)
+	s.deleted = append(s.deleted, id)
+	return nil
+}
+
+func attrs(kv ...string) []iampapv1.Attribute {
+	list := []iampapv1.Attribute{}
+	for i := 0; i < len(kv); i += 2 {
+		list = append(list, iampapv1.Attribute{Name: kv[i], Value: kv[i+1]})
+	}
+	return list
+}
+
+func testNames() *Names {
+	n := NewNames()
+	n.Add(KindUser, "IBMid-alice", "alice@example.com")
+	n.Add(KindServiceID, "iam-ServiceId-1", "deployer")
+	n.Add(KindAccessGroup, "AccessGroupId-ops", "ops")
+	n.Add(KindResourceGroup, "rg-1", "default")
+	n.AddRole("", viewerCRN, "Viewer")
+	n.AddRole("", editorCRN, "Editor")
+	n.AddRole("cloud-object-storage", readerCRN, "Reader")
+	return n
+}
+
+func livePolicies() *policiesStub {
+	return newPoliciesStub(
+		iampapv1.Policy{
+			ID:        "p1",
+			Type:      "access",
+			Subjects:  []iampapv1.Subject{{Attributes: attrs("iam_id", "IBMid-alice")}},
+			Roles:     []iampapv1.Role{{RoleID: viewerCRN}},
+			Resources: []iampapv1.Resource{{Attributes: attrs("accountId", "acc", "resourceGroupId", "rg-1")}},
+		},
+		iampapv1.Policy{
+			ID:        "p2",
+			Type:      "access",
+			Subjects:  []iampapv1.Subject{{Attributes: attrs("access_group_id", "AccessGroupId-ops")}},
+			Roles:     []iampapv1.Role{{RoleID: readerCRN}, {RoleID: viewerCRN}},
+			Resources: []iampapv1.Resource{{Attributes: attrs("accountId", "acc", "serviceName", "cloud-object-storage")}},
+		},
+		iampapv1.Policy{
+			ID:        "p3",
+			Type:      "authorization",
+			Subjects:  []iampapv1.Subject{{Attributes: attrs("accountId", "acc", "serviceName", "databases-for-redis")}},
+			Roles:     []iampapv1.Role{{RoleID: readerCRN}},
+			Resources: []iampapv1.Resource{{Attributes: attrs("accountId", "acc", "serviceName", "kms")}},
+		},
+	)
+}
+
+var _ = Describe("Policy documents", func() {
+	Describe("Export", func() {
+		It("should resolve names and sort policies", func() {
+			doc, err := Export(livePolicies(), testNames(), "acc")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(doc.Version).Should(Equal(DocumentVersion))
+			Expect(doc.Policies).Should(HaveLen(3))
+
+			Expect(doc.Policies[0].Subject).Should(Equal(map[string]string{"access_group": "ops"}))
+			Expect(doc.Policies[0].Roles).Should(Equal([]string{"Reader", "Viewer"}))
+			Expect(doc.Policies[0].Resource).Should(Equal(map[string]string{"serviceName": "cloud-object-storage"}))
+			Expect(doc.Policies[1].Subject).Should(Equal(map[string]string{"user": "alice@example.com"}))
+			Expect(doc.Policies[1].Resource).Should(Equal(map[string]string{"resourceGroup": "default"}))
+			Expect(doc.Policies[2].Type).Should(Equal("authorization"))
+			Expect(doc.Policies[2].Subject).Should(Equal(map[string]string{"serviceName": "databases-for-redis"}))
+		})
+		It("should keep IDs of ambiguous names", func() {
+			names := testNames()
+			names.Add(KindUser, "IBMid-alice2", "alice@example.com")
+			doc, err := Export(livePolicies(), names, "acc")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(doc.Policies[1].Subject).Should(Equal(map[string]string{"iam_id": "IBMid-alice"}))
+		})
+	})
+
+	Describe("Save and LoadDocument", func() {
+		It("should round trip through YAML and JSON", func() {
+			dir, err := ioutil.TempDir("", "policydoc")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(dir)
+
+			doc, err := Export(livePolicies(), testNames(), "acc")
+			Expect(err).NotTo(HaveOccurred())
+			for _, name := range []string{"policies.yaml", "policies.json"} {
+				path := filepath.Join(dir, name)
+				Expect(doc.Save(path)).To(Succeed())
+				loaded, err := LoadDocument(path)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(loaded).Should(Equal(doc))
+			}
+		})
+	})
+
+	Describe("Validate", func() {
+		It("should reject duplicate policies", func() {
+			spec := PolicySpec{Type: "access", Subject: map[string]string{"user": "a"}, Roles: []string{"Viewer"}}
+			err := Document{AccountID: "acc", Policies: []PolicySpec{spec, spec}}.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).Should(ContainSubstring("more than once"))
+		})
+	})
+
+	Describe("Diff and Apply", func() {
+		var (
+			live    *policiesStub
+			names   *Names
+			current Document
+			desired Document
+		)
+
+		BeforeEach(func() {
+			live = livePolicies()
+			names = testNames()
+			var err error
+			current, err = Export(live, names, "acc")
+			Expect(err).NotTo(HaveOccurred())
+			desired = Document{AccountID: "acc", Policies: []PolicySpec{
+				{Type: "access", Subject: map[string]string{"access_group": "ops"}, Roles: []string{"Reader"},
+					Resource: map[string]string{"serviceName": "cloud-object-storage"}},
+				{Type: "access", Subject: map[string]string{"user": "alice@example.com"}, Roles: []string{"Viewer"},
+					Resource: map[string]string{"resourceGroup": "default"}},
+				{Type: "access", Subject: map[string]string{"service_id": "deployer"}, Roles: []string{"Editor"},
+					Resource: map[string]string{"resourceGroup": "default"}},
+			}}
+		})
+
+		It("should list creations, updates and deletions", func() {
+			set, err := Diff(desired, current)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(set.Count(ActionCreate)).Should(Equal(1))
+			Expect(set.Count(ActionUpdate)).Should(Equal(1))
+			Expect(set.Count(ActionDelete)).Should(Equal(1))
+
+			var out strings.Builder
+			set.Print(&out)
+			Expect(out.String()).Should(ContainSubstring("roles: Reader, Viewer => Reader"))
+			Expect(out.String()).Should(ContainSubstring("1 to create, 1 to update, 1 to delete"))
+		})
+
+		It("should report no changes for the exported document", func() {
+			set, err := Diff(current, current)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(set.Empty()).Should(BeTrue())
+		})
+
+		It("should apply the changes with resolved IDs", func() {
+			set, err := Diff(desired, current)
+			Expect(err).NotTo(HaveOccurred())
+			result := Apply(live, names, "acc", set)
+			Expect(result.Failed()).Should(BeFalse())
+			Expect(live.deleted).Should(Equal([]string{"p3"}))
+			Expect(live.policies["p2"].Roles).Should(Equal([]iampapv1.Role{{RoleID: readerCRN}}))
+
+			created := live.policies["new-1"]
+			Expect(created.Subjects[0].IAMID()).Should(Equal("iam-ServiceId-1"))
+			Expect(created.Resources[0].ResourceGroupID()).Should(Equal("rg-1"))
+			Expect(created.Resources[0].AccountID()).Should(Equal("acc"))
+			Expect(created.Roles).Should(Equal([]iampapv1.Role{{RoleID: editorCRN}}))
+
+			after, err := Export(live, names, "acc")
+			Expect(err).NotTo(HaveOccurred())
+			set, err = Diff(desired, after)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(set.Empty()).Should(BeTrue())
+		})
+
+		It("should not overwrite policies changed since the export", func() {
+			set, err := Diff(desired, current)
+			Expect(err).NotTo(HaveOccurred())
+			p2 := live.policies["p2"]
+			p2.Roles = []iampapv1.Role{{RoleID: editorCRN}}
+			live.policies["p2"] = p2
+
+			result := Apply(live, names, "acc", set)
+			Expect(result.Errors).Should(HaveLen(1))
+			for _, err := range result.Errors {
+				Expect(err.(bmxerror.Error).Code()).Should(Equal(ErrCodePolicyConflict))
+			}
+			Expect(live.policies["p2"].Roles).Should(Equal([]iampapv1.Role{{RoleID: editorCRN}}))
+		})
+
+		It("should not delete policies changed since the export", func() {
+			set, err := Diff(desired, current)
+			Expect(err).NotTo(HaveOccurred())
+			p3 := live.policies["p3"]
+			p3.Roles = append(p3.Roles, iampapv1.Role{RoleID: editorCRN})
+			live.policies["p3"] = p3
+
+			result := Apply(live, names, "acc", set)
+			Expect(result.Errors).Should(HaveLen(1))
+			for _, err := range result.Errors {
+				Expect(err.(bmxerror.Error).Code()).Should(Equal(ErrCodePolicyConflict))
+			}
+			Expect(live.deleted).Should(BeEmpty())
+			Expect(live.policies).Should(HaveKey("p3"))
+		})
+
+		It("should fail on unknown names", func() {
+			desired.Policies[2].Subject = map[string]string{"service_id": "unknown"}
+			set, err := Diff(desired, current)
+			Expect(err).NotTo(HaveOccurred())
+			result := Apply(live, names, "acc", set)
+			Expect(result.Errors).Should(HaveLen(1))
+			for _, err := range result.Errors {
+				Expect(err.(bmxerror.Error).Code()).Should(Equal(ErrCodeUnresolvedName))
+			}
+		})
+	})
+})
@@ -0,0 +1,310 @@
+//Package policyeval answers access questions against an in-memory copy of an
+//account's IAM policies, access groups and roles
+package policyeval
+
+import (
+	"sort"
+
+	"github.com/IBM-Cloud/bluemix-go/api/iampap/iampapv1"
+	"github.com/IBM-Cloud/bluemix-go/crn"
+	"github.com/IBM-Cloud/bluemix-go/models"
+)
+
+//Grant is a policy normalized for evaluation. It is assigned to exactly one
+//of IAMID or AccessGroupID.
+type Grant struct {
+	PolicyID      string
+	IAMID         string
+	AccessGroupID string
+	//Roles are role CRNs
+	Roles []string
+	//Resource holds the policy resource attributes by name
+	Resource map[string]string
+}
+
+//Target is the resource being accessed
+type Target struct {
+	CRN crn.CRN
+	//ResourceGroupID is needed to match policies scoped to a resource group,
+	//because CRNs do not carry it
+	ResourceGroupID string
+	//Attributes are compared with custom policy resource attributes
+	Attributes map[string]string
+}
+
+//Reason explains how a subject got access
+type Reason struct {
+	PolicyID string
+	//AccessGroupID is empty when the policy is assigned to the subject
+	AccessGroupID   string
+	AccessGroupName string
+	Roles           []string
+	RoleNames       []string
+}
+
+//Decision is the effective access of a subject on a target
+type Decision struct {
+	Roles   []string
+	Actions []string
+	Reasons []Reason
+}
+
+//Allows reports whether action is among the granted actions
+func (d Decision) Allows(action string) bool {
+	for _, a := range d.Actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+//Evaluator holds the account data needed to evaluate access offline
+type Evaluator struct {
+	AccountID string
+
+	grants      []Grant
+	memberOf    map[string][]string
+	groupNames  map[string]string
+	roleActions map[string][]string
+	roleNames   map[string]string
+}
+
+//NewEvaluator returns an empty evaluator for accountID. Populate it with the
+//Add methods or use Loader.
+func NewEvaluator(accountID string) *Evaluator {
+	return &Evaluator{
+		AccountID:   accountID,
+		memberOf:    map[string][]string{},
+		groupNames:  map[string]string{},
+		roleActions: map[string][]string{},
+		roleNames:   map[string]string{},
+	}
+}
+
+//AddGrant ...
+func (e *Evaluator) AddGrant(g Grant) {
+	e.grants = append(e.grants, g)
+}
+
+//AddPolicy adds an access policy from the v1 policies API. Authorization
+//policies are between services and are skipped.
+func (e *Evaluator) AddPolicy(p iampapv1.Policy) {
+	if p.Type != "" && p.Type != "access" {
+		return
+	}
+	for _, s := range p.Subjects {
+		g := Grant{
+			PolicyID:      p.ID,
+			IAMID:         s.IAMID(),
+			AccessGroupID: s.AccessGroupID(),
+			Resource:      map[string]string{},
+		}
+		for _, r := range p.Roles {
+			g.Roles = append(g.Roles, r.RoleID)
+		}
+		for _, r := range p.Resources {
+			for _, a := range r.Attributes {
+				g.Resource[a.Name] = a.Value
+			}
+		}
+		e.AddGrant(g)
+	}
+}
+
+//AddLegacyPolicy adds a policy of iamID from the user or service ID policy
+//APIs
+func (e *Evaluator) AddLegacyPolicy(iamID string, p models.Policy) {
+	for _, r := range p.Resources {
+		g := Grant{
+			PolicyID: p.ID,
+			IAMID:    iamID,
+			Resource: map[string]string{},
+		}
+		for _, role := range p.Roles {
+			g.Roles = append(g.Roles, role.ID.String())
+			e.AddRole(role.ID.String(), role.DisplayName, roleActionIDs(role.Actions))
+		}
+		set := func(name, value string) {
+			if value != "" {
+				g.Resource[name] = value
+			}
+		}
+		set(iampapv1.AccountIDAttribute, r.AccountID)
+		set(iampapv1.ServiceNameAttribute, r.ServiceName)
+		set(iampapv1.ServiceInstanceAttribute, r.ServiceInstance)
+		set(iampapv1.RegionAttribute, r.Region)
+		set(iampapv1.ResourceTypeAttribute, r.ResourceType)
+		set(iampapv1.ResourceAttribute, r.Resource)
+		set(iampapv1.SpaceIDAttribute, r.SpaceID)
+		set(iampapv1.OrganizationIDAttribute, r.OrganizationID)
+		set(iampapv1.ResourceGroupIDAttribute, r.ResourceGroupID)
+		e.AddGrant(g)
+	}
+}
+
+func roleActionIDs(actions []models.RoleAction) []string {
+	ids := make([]string, 0, len(actions))
+	for _, a := range actions {
+		ids = append(ids, a.ID)
+	}
+	return ids
+}
+
+//AddGroup records an access group and its members
+func (e *Evaluator) AddGroup(groupID, name string, memberIAMIDs []string) {
+	e.groupNames[groupID] = name
+	for _, m := range memberIAMIDs {
+		e.memberOf[m] = append(e.memberOf[m], groupID)
+	}
+}
+
+//AddRole records the actions of a role. Actions of a role added twice are
+//merged.
+func (e *Evaluator) AddRole(roleCRN, name string, actions []string) {
+	if name != "" {
+		e.roleNames[roleCRN] = name
+	}
+	e.roleActions[roleCRN] = union(e.roleActions[roleCRN], actions)
+}
+
+//Evaluate returns the roles and actions subject holds on target and the
+//policies that granted them
+func (e *Evaluator) Evaluate(subjectIAMID string, target Target) Decision {
+	groups := map[string]bool{}
+	for _, g := range e.memberOf[subjectIAMID] {
+		groups[g] = true
+	}
+	d := Decision{Roles: []string{}, Actions: []string{}, Reasons: []Reason{}}
+	for _, g := range e.grants {
+		if g.IAMID != subjectIAMID && !(g.AccessGroupID != "" && groups[g.AccessGroupID]) {
+			continue
+		}
+		if !e.matches(g.Resource, target) {
+			continue
+		}
+		reason := Reason{
+			PolicyID:        g.PolicyID,
+			AccessGroupID:   g.AccessGroupID,
+			AccessGroupName: e.groupNames[g.AccessGroupID],
+			Roles:           g.Roles,
+		}
+		for _, r := range g.Roles {
+			reason.RoleNames = append(reason.RoleNames, e.roleName(r))
+			d.Actions = union(d.Actions, e.roleActions[r])
+		}
+		d.Roles = union(d.Roles, g.Roles)
+		d.Reasons = append(d.Reasons, reason)
+	}
+	return d
+}
+
+//Can reports whether subject may perform action on target and why
+func (e *Evaluator) Can(subjectIAMID, action string, target Target) (bool, []Reason) {
+	d := e.Evaluate(subjectIAMID, target)
+	reasons := []Reason{}
+	for _, r := range d.Reasons {
+		for _, role := range r.Roles {
+			if contains(e.roleActions[role], action) {
+				reasons = append(reasons, r)
+				break
+			}
+		}
+	}
+	return len(reasons) > 0, reasons
+}
+
+//WhoCan returns every known subject allowed to perform action on target,
+//with the policies that allow it. Access groups are expanded to members.
+func (e *Evaluator) WhoCan(action string, target Target) map[string][]Reason {
+	subjects := map[string]bool{}
+	for _, g := range e.grants {
+		if g.IAMID != "" {
+			subjects[g.IAMID] = true
+		}
+	}
+	for m := range e.memberOf {
+		subjects[m] = true
+	}
+	result := map[string][]Reason{}
+	for s := range subjects {
+		if ok, reasons := e.Can(s, action, target); ok {
+			result[s] = reasons
+		}
+	}
+	return result
+}
+
+func (e *Evaluator) roleName(roleCRN string) string {
+	if name, ok := e.roleNames[roleCRN]; ok {
+		return name
+	}
+	if c, err := crn.Parse(roleCRN); err == nil && c.Resource != "" {
+		return c.Resource
+	}
+	return roleCRN
+}
+
+//matches compares every resource attribute of a grant with the target. A
+//grant without attributes other than the account applies to the whole
+//account.
+func (e *Evaluator) matches(resource map[string]string, t Target) bool {
+	for name, value := range resource {
+		var actual string
+		switch name {
+		case iampapv1.AccountIDAttribute:
+			if t.CRN.ScopeType == crn.ScopeAccount {
+				actual = t.CRN.Scope
+			} else {
+				actual = e.AccountID
+			}
+		case iampapv1.ServiceTypeAttribute:
+			//"service" covers every IAM enabled service, "platform_service"
+			//covers account management services
+			if value == "service" && t.CRN.ServiceName != "" {
+				continue
+			}
+			actual = t.Attributes[name]
+		case iampapv1.ServiceNameAttribute:
+			actual = t.CRN.ServiceName
+		case iampapv1.ServiceInstanceAttribute:
+			actual = t.CRN.ServiceInstance
+		case iampapv1.RegionAttribute:
+			actual = t.CRN.Region
+		case iampapv1.ResourceTypeAttribute:
+			actual = t.CRN.ResourceType
+		case iampapv1.ResourceAttribute:
+			actual = t.CRN.Resource
+		case iampapv1.ResourceGroupIDAttribute:
+			actual = t.ResourceGroupID
+		default:
+			actual = t.Attributes[name]
+		}
+		if value != "*" && actual != value {
+			return false
+		}
+	}
+	return true
+}
+
+func union(a, b []string) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	out := make([]string, 0, len(a)+len(b))
+	for _, s := range append(append([]string{}, a...), b...) {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,347 @@
+package policyeval
+
+import (
+	"fmt"
+
+	"github.com/IBM-Cloud/bluemix-go/api/iam/iamv1"
+	"github.com/IBM-Cloud/bluemix-go/api/iampap/iampapv1"
+	"github.com/IBM-Cloud/bluemix-go/api/iampap/iampapv2"
+	"github.com/IBM-Cloud/bluemix-go/api/iamuum/iamuumv2"
+	"github.com/IBM-Cloud/bluemix-go/api/usermanagement/usermanagementv2"
+	"github.com/IBM-Cloud/bluemix-go/crn"
+	"github.com/IBM-Cloud/bluemix-go/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	writerRole  = "crn:v1:bluemix:public:iam::::serviceRole:Writer"
+	managerRole = "crn:v1:bluemix:public:iam::::serviceRole:Manager"
+	viewerRole  = "crn:v1:bluemix:public:iam::::role:Viewer"
+)
+
+func accessPolicy(id string, subject iampapv1.Subject, roleID string, attrs ...iampapv1.Attribute) iampapv1.Policy {
+	return iampapv1.Policy{
+		ID:        id,
+		Type:      "access",
+		Subjects:  []iampapv1.Subject{subject},
+		Roles:     []iampapv1.Role{{RoleID: roleID}},
+		Resources: []iampapv1.Resource{{Attributes: attrs}},
+	}
+}
+
+func attr(name, value string) iampapv1.Attribute {
+	return iampapv1.Attribute{Name: name, Value: value}
+}
+
+func userSubject(iamID string) iampapv1.Subject {
+	return iampapv1.Subject{Attributes: []iampapv1.Attribute{attr("iam_id", iamID)}}
+}
+
+func groupSubject(groupID string) iampapv1.Subject {
+	return iampapv1.Subject{Attributes: []iampapv1.Attribute{attr("access_group_id", groupID)}}
+}
+
+func bucket(name string) Target {
+	c, err := crn.BucketCRN("acc", "cos-1", name)
+	Expect(err).NotTo(HaveOccurred())
+	return Target{CRN: c, ResourceGroupID: "rg-1"}
+}
+
+func newTestEvaluator() *Evaluator {
+	e := NewEvaluator("acc")
+	e.AddRole(writerRole, "Writer", []string{"cloud-object-storage.object.put"})
+	e.AddRole(managerRole, "Manager", []string{"cloud-object-storage.object.put", "cloud-object-storage.bucket.delete"})
+	e.AddRole(viewerRole, "Viewer", []string{"resource-controller.instance.retrieve"})
+	e.AddGroup("AccessGroupId-ops", "ops", []string{"IBMid-bob"})
+	return e
+}
+
+var _ = Describe("Evaluator", func() {
+	var e *Evaluator
+
+	BeforeEach(func() {
+		e = newTestEvaluator()
+	})
+
+	Context("When the policy is assigned to the subject", func() {
+		It("should grant the roles on matching resources", func() {
+			e.AddPolicy(accessPolicy("p1", userSubject("IBMid-alice"), writerRole,
+				attr("accountId", "acc"), attr("serviceName", "cloud-object-storage"), attr("serviceInstance", "cos-1")))
+
+			d := e.Evaluate("IBMid-alice", bucket("logs"))
+			Expect(d.Roles).Should(Equal([]string{writerRole}))
+			Expect(d.Allows("cloud-object-storage.object.put")).Should(BeTrue())
+			Expect(d.Allows("cloud-object-storage.bucket.delete")).Should(BeFalse())
+			Expect(d.Reasons).Should(HaveLen(1))
+			Expect(d.Reasons[0].PolicyID).Should(Equal("p1"))
+			Expect(d.Reasons[0].RoleNames).Should(Equal([]string{"Writer"}))
+		})
+		It("should not grant on other instances", func() {
+			e.AddPolicy(accessPolicy("p1", userSubject("IBMid-alice"), writerRole,
+				attr("serviceName", "cloud-object-storage"), attr("serviceInstance", "cos-2")))
+
+			d := e.Evaluate("IBMid-alice", bucket("logs"))
+			Expect(d.Roles).Should(BeEmpty())
+			Expect(d.Reasons).Should(BeEmpty())
+		})
+	})
+
+	Context("When the policy is assigned to an access group", func() {
+		It("should grant the members and name the group", func() {
+			e.AddPolicy(accessPolicy("p2", groupSubject("AccessGroupId-ops"), managerRole,
+				attr("serviceName", "cloud-object-storage"), attr("resourceType", "bucket"), attr("resource", "logs")))
+
+			ok, reasons := e.Can("IBMid-bob", "cloud-object-storage.bucket.delete", bucket("logs"))
+			Expect(ok).Should(BeTrue())
+			Expect(reasons).Should(HaveLen(1))
+			Expect(reasons[0].AccessGroupName).Should(Equal("ops"))
+
+			ok, _ = e.Can("IBMid-bob", "cloud-object-storage.bucket.delete", bucket("other"))
+			Expect(ok).Should(BeFalse())
+			ok, _ = e.Can("IBMid-alice", "cloud-object-storage.bucket.delete", bucket("logs"))
+			Expect(ok).Should(BeFalse())
+		})
+	})
+
+	Context("When the policy is scoped to a resource group", func() {
+		It("should match resources in that group only", func() {
+			e.AddPolicy(accessPolicy("p3", userSubject("IBMid-alice"), managerRole,
+				attr("accountId", "acc"), attr("resourceGroupId", "rg-1")))
+
+			Expect(e.Evaluate("IBMid-alice", bucket("logs")).Allows("cloud-object-storage.bucket.delete")).Should(BeTrue())
+			other := bucket("logs")
+			other.ResourceGroupID = "rg-2"
+			Expect(e.Evaluate("IBMid-alice", other).Roles).Should(BeEmpty())
+		})
+	})
+
+	Context("When the policy covers all IAM services", func() {
+		It("should match any service resource", func() {
+			e.AddPolicy(accessPolicy("p4", userSubject("IBMid-alice"), viewerRole,
+				attr("accountId", "acc"), attr("serviceType", "service")))
+
+			Expect(e.Evaluate("IBMid-alice", bucket("logs")).Roles).Should(Equal([]string{viewerRole}))
+		})
+	})
+
+	Context("When the policy uses custom attributes", func() {
+		It("should compare them with the target attributes", func() {
+			e.AddPolicy(accessPolicy("p5", userSubject("IBMid-alice"), writerRole,
+				attr("serviceName", "cloud-object-storage"), attr("prefix", "reports/")))
+
+			target := bucket("logs")
+			Expect(e.Evaluate("IBMid-alice", target).Roles).Should(BeEmpty())
+			target.Attributes = map[string]string{"prefix": "reports/"}
+			Expect(e.Evaluate("IBMid-alice", target).Roles).Should(Equal([]string{writerRole}))
+		})
+	})
+
+	Context("When the policy is an authorization policy", func() {
+		It("should be ignored", func() {
+			p := accessPolicy("p6", userSubject("IBMid-alice"), managerRole)
+			p.Type = "authorization"
+			e.AddPolicy(p)
+			Expect(e.Evaluate("IBMid-alice", bucket("logs")).Roles).Should(BeEmpty())
+		})
+	})
+
+	Context("When asking who can perform an action", func() {
+		It("should return direct and group subjects", func() {
+			e.AddPolicy(accessPolicy("p1", userSubject("IBMid-alice"), writerRole,
+				attr("serviceName", "cloud-object-storage")))
+			e.AddPolicy(accessPolicy("p2", groupSubject("AccessGroupId-ops"), managerRole,
+				attr("serviceName", "cloud-object-storage")))
+
+			who := e.WhoCan("cloud-object-storage.bucket.delete", bucket("logs"))
+			Expect(who).Should(HaveLen(1))
+			Expect(who).Should(HaveKey("IBMid-bob"))
+
+			who = e.WhoCan("cloud-object-storage.object.put", bucket("logs"))
+			Expect(who).Should(HaveLen(2))
+		})
+	})
+
+	Context("When adding legacy policies", func() {
+		It("should use the roles and actions they embed", func() {
+			readerRole, err := crn.Parse("crn:v1:bluemix:public:iam::::serviceRole:Reader")
+			Expect(err).NotTo(HaveOccurred())
+			e.AddLegacyPolicy("iam-ServiceId-1", models.Policy{
+				ID: "legacy",
+				Roles: []models.PolicyRole{{
+					ID:          readerRole,
+					DisplayName: "Reader",
+					Actions:     []models.RoleAction{{ID: "cloud-object-storage.object.get"}},
+				}},
+				Resources: []models.PolicyResource{{ServiceName: "cloud-object-storage", ServiceInstance: "cos-1"}},
+			})
+
+			ok, reasons := e.Can("iam-ServiceId-1", "cloud-object-storage.object.get", bucket("logs"))
+			Expect(ok).Should(BeTrue())
+			Expect(reasons[0].RoleNames).Should(Equal([]string{"Reader"}))
+		})
+	})
+})
+
+type policiesStub struct {
+	iampapv1.V1PolicyRepository
+	policies []iampapv1.Policy
+}
+
+func (s policiesStub) List(params iampapv1.SearchParams) ([]iampapv1.Policy, error) {
+	return s.policies, nil
+}
+
+type groupsStub struct {
+	iamuumv2.AccessGroupRepository
+}
+
+func (groupsStub) List(accountID string, queryParams ...string) ([]models.AccessGroupV2, error) {
+	return []models.AccessGroupV2{{AccessGroup: models.AccessGroup{ID: "AccessGroupId-ops", Name: "ops"}}}, nil
+}
+
+type membersStub struct {
+	iamuumv2.AccessGroupMemberRepositoryV2
+}
+
+func (membersStub) List(groupID string) ([]models.AccessGroupMemberV2, error) {
+	return []models.AccessGroupMemberV2{{ID: "IBMid-bob"}}, nil
+}
+
+type rolesStub struct {
+	iampapv2.RoleRepository
+	queried []string
+}
+
+func (s *rolesStub) ListAll(query iampapv2.RoleQuery) ([]iampapv2.Role, error) {
+	s.queried = append(s.queried, query.ServiceName)
+	role := func(roleCRN, name, action string) iampapv2.Role {
+		r := iampapv2.Role{Crn: roleCRN}
+		r.DisplayName = name
+		r.Actions = []string{action}
+		return r
+	}
+	switch query.ServiceName {
+	case "cloud-object-storage":
+		return []iampapv2.Role{
+			role(managerRole, "Manager", "cloud-object-storage.bucket.delete"),
+			role(writerRole, "Writer", "cloud-object-storage.object.put"),
+		}, nil
+	case "kms":
+		return []iampapv2.Role{role(viewerRole, "Viewer", "kms.secrets.list")}, nil
+	}
+	return nil, nil
+}
+
+type usersStub struct {
+	usermanagementv2.Users
+}
+
+func (usersStub) ListUsers(accountID string) ([]usermanagementv2.UserInfo, error) {
+	Expect(accountID).Should(Equal("acc"))
+	return []usermanagementv2.UserInfo{{IamID: "IBMid-carol"}}, nil
+}
+
+type serviceIDsStub struct {
+	iamv1.ServiceIDRepository
+}
+
+func (serviceIDsStub) List(boundTo string) ([]models.ServiceID, error) {
+	Expect(boundTo).Should(Equal("crn:v1:bluemix:public:::a/acc:::"))
+	return []models.ServiceID{{UUID: "ServiceId-1", IAMID: "iam-ServiceId-1"}}, nil
+}
+
+//legacyPolicies serves the user and service ID policy APIs, keyed by scope
+//and subject
+type legacyPolicies map[string][]models.Policy
+
+func (p legacyPolicies) list(scope string, id string) ([]models.Policy, error) {
+	policies, ok := p[scope+" "+id]
+	if !ok {
+		return nil, fmt.Errorf("unexpected policy listing %s %s", scope, id)
+	}
+	return policies, nil
+}
+
+type userPoliciesStub struct {
+	iamv1.UserPolicyRepository
+	legacyPolicies
+}
+
+func (s userPoliciesStub) List(scope string, ibmUniqueID string) ([]models.Policy, error) {
+	return s.list(scope, ibmUniqueID)
+}
+
+type servicePoliciesStub struct {
+	iamv1.ServicePolicyRepository
+	legacyPolicies
+}
+
+func (s servicePoliciesStub) List(scope string, serviceID string) ([]models.Policy, error) {
+	return s.list(scope, serviceID)
+}
+
+func legacyPolicy(id, roleID, roleName, action, serviceName string) models.Policy {
+	role, err := crn.Parse(roleID)
+	Expect(err).NotTo(HaveOccurred())
+	return models.Policy{
+		ID:        id,
+		Roles:     []models.PolicyRole{{ID: role, DisplayName: roleName, Actions: []models.RoleAction{{ID: action}}}},
+		Resources: []models.PolicyResource{{ServiceName: serviceName}},
+	}
+}
+
+var _ = Describe("Loader", func() {
+	It("should load policies, group members and the roles of referenced services", func() {
+		roles := &rolesStub{}
+		l := Loader{
+			Policies: policiesStub{policies: []iampapv1.Policy{
+				accessPolicy("p2", groupSubject("AccessGroupId-ops"), managerRole, attr("serviceName", "cloud-object-storage")),
+			}},
+			Groups:  groupsStub{},
+			Members: membersStub{},
+			Roles:   roles,
+		}
+		e, err := l.Load("acc")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(roles.queried).Should(Equal([]string{"", "cloud-object-storage"}))
+
+		ok, reasons := e.Can("IBMid-bob", "cloud-object-storage.bucket.delete", bucket("logs"))
+		Expect(ok).Should(BeTrue())
+		Expect(reasons[0].AccessGroupName).Should(Equal("ops"))
+		Expect(reasons[0].RoleNames).Should(Equal([]string{"Manager"}))
+	})
+	It("should merge the user and service ID policies", func() {
+		roles := &rolesStub{}
+		l := Loader{
+			//the access policy listing returns u1 as well
+			Policies: policiesStub{policies: []iampapv1.Policy{
+				accessPolicy("u1", userSubject("IBMid-carol"), writerRole, attr("serviceName", "cloud-object-storage")),
+			}},
+			Groups:  groupsStub{},
+			Members: membersStub{},
+			Roles:   roles,
+			Users:   usersStub{},
+			UserPolicies: userPoliciesStub{legacyPolicies: legacyPolicies{
+				"a/acc IBMid-carol": {legacyPolicy("u1", writerRole, "Writer", "cloud-object-storage.object.put", "cloud-object-storage")},
+			}},
+			ServiceIDs: serviceIDsStub{},
+			ServicePolicies: servicePoliciesStub{legacyPolicies: legacyPolicies{
+				"a/acc ServiceId-1": {legacyPolicy("s1", viewerRole, "Viewer", "kms.secrets.list", "kms")},
+			}},
+		}
+		e, err := l.Load("acc")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(roles.queried).Should(Equal([]string{"", "cloud-object-storage", "kms"}))
+
+		ok, reasons := e.Can("IBMid-carol", "cloud-object-storage.object.put", bucket("logs"))
+		Expect(ok).Should(BeTrue())
+		Expect(reasons).Should(HaveLen(1))
+		Expect(reasons[0].PolicyID).Should(Equal("u1"))
+		ok, reasons = e.Can("iam-ServiceId-1", "kms.secrets.list", Target{CRN: crn.CRN{ServiceName: "kms", ScopeType: "a", Scope: "acc"}})
+		Expect(ok).Should(BeTrue())
+		Expect(reasons).Should(HaveLen(1))
+		Expect(reasons[0].PolicyID).Should(Equal("s1"))
+	})
+})
@@ -0,0 +1,140 @@
+package policyeval
+
+import (
+	"sort"
+
+	"github.com/IBM-Cloud/bluemix-go/api/iam/iamv1"
+	"github.com/IBM-Cloud/bluemix-go/api/iampap/iampapv1"
+	"github.com/IBM-Cloud/bluemix-go/api/iampap/iampapv2"
+	"github.com/IBM-Cloud/bluemix-go/api/iamuum/iamuumv2"
+	"github.com/IBM-Cloud/bluemix-go/api/usermanagement/usermanagementv2"
+	"github.com/IBM-Cloud/bluemix-go/crn"
+	"github.com/IBM-Cloud/bluemix-go/models"
+)
+
+//Loader reads an account's policies, access groups and roles into an
+//Evaluator. The policies of the user and service ID policy APIs are merged
+//in when Users and UserPolicies, or ServiceIDs and ServicePolicies, are
+//set. Policies those APIs return that the access policy listing already
+//returned are added once.
+type Loader struct {
+	Policies iampapv1.V1PolicyRepository
+	Groups   iamuumv2.AccessGroupRepository
+	Members  iamuumv2.AccessGroupMemberRepositoryV2
+	Roles    iampapv2.RoleRepository
+
+	Users           usermanagementv2.Users
+	UserPolicies    iamv1.UserPolicyRepository
+	ServiceIDs      iamv1.ServiceIDRepository
+	ServicePolicies iamv1.ServicePolicyRepository
+}
+
+//Load fetches everything needed to evaluate access in accountID
+func (l Loader) Load(accountID string) (*Evaluator, error) {
+	e := NewEvaluator(accountID)
+
+	policies, err := l.Policies.List(iampapv1.SearchParams{AccountID: accountID, Type: "access"})
+	if err != nil {
+		return nil, err
+	}
+	services := map[string]bool{}
+	loaded := map[string]bool{}
+	for _, p := range policies {
+		e.AddPolicy(p)
+		loaded[p.ID] = true
+		for _, r := range p.Resources {
+			if name := r.ServiceName(); name != "" {
+				services[name] = true
+			}
+		}
+	}
+
+	if err := l.loadLegacyPolicies(e, accountID, services, loaded); err != nil {
+		return nil, err
+	}
+
+	groups, err := l.Groups.List(accountID)
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range groups {
+		members, err := l.Members.List(g.ID)
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]string, 0, len(members))
+		for _, m := range members {
+			ids = append(ids, m.ID)
+		}
+		e.AddGroup(g.ID, g.Name, ids)
+	}
+
+	//System roles come with every listing, service roles only when the
+	//service is named
+	serviceNames := []string{""}
+	for s := range services {
+		serviceNames = append(serviceNames, s)
+	}
+	sort.Strings(serviceNames)
+	for _, s := range serviceNames {
+		roles, err := l.Roles.ListAll(iampapv2.RoleQuery{AccountID: accountID, ServiceName: s})
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range roles {
+			e.AddRole(r.Crn, r.DisplayName, r.Actions)
+		}
+	}
+	return e, nil
+}
+
+//loadLegacyPolicies adds the policies of every user and service ID of the
+//account that are not in loaded and records the services they name
+func (l Loader) loadLegacyPolicies(e *Evaluator, accountID string, services, loaded map[string]bool) error {
+	scope := crn.ScopeAccount + "/" + accountID
+	add := func(iamID string, policies []models.Policy) {
+		for _, p := range policies {
+			if p.ID != "" && loaded[p.ID] {
+				continue
+			}
+			loaded[p.ID] = true
+			e.AddLegacyPolicy(iamID, p)
+			for _, r := range p.Resources {
+				if r.ServiceName != "" {
+					services[r.ServiceName] = true
+				}
+			}
+		}
+	}
+	if l.Users != nil && l.UserPolicies != nil {
+		users, err := l.Users.ListUsers(accountID)
+		if err != nil {
+			return err
+		}
+		for _, u := range users {
+			policies, err := l.UserPolicies.List(scope, u.IamID)
+			if err != nil {
+				return err
+			}
+			add(u.IamID, policies)
+		}
+	}
+	if l.ServiceIDs != nil && l.ServicePolicies != nil {
+		boundTo, err := crn.AccountCRN(accountID)
+		if err != nil {
+			return err
+		}
+		serviceIDs, err := l.ServiceIDs.List(boundTo.String())
+		if err != nil {
+			return err
+		}
+		for _, s := range serviceIDs {
+			policies, err := l.ServicePolicies.List(scope, s.UUID)
+			if err != nil {
+				return err
+			}
+			add(s.IAMID, policies)
+		}
+	}
+	return nil
+}
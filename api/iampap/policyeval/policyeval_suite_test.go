@@ -0,0 +1,13 @@
+package policyeval_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestPolicyeval(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Policyeval Suite")
+}
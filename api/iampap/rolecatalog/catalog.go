@@ -0,0 +1,159 @@
+//Package rolecatalog collects the actions of each service from its built-in
+//roles, so custom roles can be checked before they are created and compared
+//with the built-in roles.
+package rolecatalog
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/IBM-Cloud/bluemix-go/api/iampap/iampapv2"
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+)
+
+//ErrCodeInvalidCustomRole ...
+const ErrCodeInvalidCustomRole = "InvalidCustomRole"
+
+//ErrCodeUnknownService ...
+const ErrCodeUnknownService = "UnknownService"
+
+//ErrCodeUnknownRole ...
+const ErrCodeUnknownRole = "UnknownRole"
+
+//Role kinds, derived from the role CRN
+const (
+	KindPlatform = "platform"
+	KindService  = "service"
+	KindCustom   = "custom"
+)
+
+//customRoleName is the format IAM accepts for custom role names
+var customRoleName = regexp.MustCompile(`^[A-Z][a-zA-Z0-9]{0,29}$`)
+
+//RoleKind tells platform, service and custom roles apart by their CRN
+func RoleKind(role iampapv2.Role) string {
+	switch {
+	case strings.Contains(role.Crn, ":customRole:"):
+		return KindCustom
+	case strings.Contains(role.Crn, ":serviceRole:"):
+		return KindService
+	case strings.Contains(role.Crn, ":role:"):
+		return KindPlatform
+	}
+	if role.AccountID != "" {
+		return KindCustom
+	}
+	return KindService
+}
+
+//ServiceActions are the known actions and built-in roles of one service
+type ServiceActions struct {
+	ServiceName string
+	actions     map[string]bool
+	builtin     map[string]iampapv2.Role
+}
+
+//Actions returns the known actions, sorted
+func (s *ServiceActions) Actions() []string {
+	actions := make([]string, 0, len(s.actions))
+	for a := range s.actions {
+		actions = append(actions, a)
+	}
+	sort.Strings(actions)
+	return actions
+}
+
+//Has reports whether action is known
+func (s *ServiceActions) Has(action string) bool {
+	return s.actions[action]
+}
+
+//BuiltinRoles returns the display names of the built-in roles, sorted
+func (s *ServiceActions) BuiltinRoles() []string {
+	names := make([]string, 0, len(s.builtin))
+	for name := range s.builtin {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//Catalog holds the actions of several services
+type Catalog struct {
+	services map[string]*ServiceActions
+}
+
+//NewCatalog returns an empty catalog. Populate it with AddRoles or use
+//LoadCatalog.
+func NewCatalog() *Catalog {
+	return &Catalog{services: map[string]*ServiceActions{}}
+}
+
+//LoadCatalog lists the roles of every service and builds its catalog
+func LoadCatalog(roles iampapv2.RoleRepository, serviceNames ...string) (*Catalog, error) {
+	c := NewCatalog()
+	for _, name := range serviceNames {
+		list, err := roles.ListAll(iampapv2.RoleQuery{ServiceName: name})
+		if err != nil {
+			return nil, err
+		}
+		c.AddRoles(name, list)
+	}
+	return c, nil
+}
+
+//AddRoles records the actions of the platform and service roles of a
+//service. Custom roles are ignored so they cannot vouch for their own
+//actions.
+func (c *Catalog) AddRoles(serviceName string, roles []iampapv2.Role) {
+	s, ok := c.services[serviceName]
+	if !ok {
+		s = &ServiceActions{
+			ServiceName: serviceName,
+			actions:     map[string]bool{},
+			builtin:     map[string]iampapv2.Role{},
+		}
+		c.services[serviceName] = s
+	}
+	for _, r := range roles {
+		if RoleKind(r) == KindCustom {
+			continue
+		}
+		s.builtin[roleName(r)] = r
+		for _, a := range r.Actions {
+			s.actions[a] = true
+		}
+	}
+}
+
+//Service returns the actions of serviceName
+func (c *Catalog) Service(serviceName string) (*ServiceActions, error) {
+	s, ok := c.services[serviceName]
+	if !ok {
+		return nil, bmxerror.New(ErrCodeUnknownService,
+			fmt.Sprintf("Service %q is not in the action catalog", serviceName))
+	}
+	return s, nil
+}
+
+//Services returns the names of the services in the catalog, sorted
+func (c *Catalog) Services() []string {
+	names := make([]string, 0, len(c.services))
+	for name := range c.services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func roleName(r iampapv2.Role) string {
+	if r.DisplayName != "" {
+		return r.DisplayName
+	}
+	if i := strings.LastIndex(r.Crn, ":"); i >= 0 {
+		return r.Crn[i+1:]
+	}
+	return r.Name
+}
@@ -0,0 +1,136 @@
+package rolecatalog
+
+import (
+	"bytes"
+
+	"github.com/IBM-Cloud/bluemix-go/api/iampap/iampapv2"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func builtinRole(crn, displayName string, actions ...string) iampapv2.Role {
+	return iampapv2.Role{
+		CreateRoleRequest: iampapv2.CreateRoleRequest{DisplayName: displayName, Actions: actions},
+		Crn:               crn,
+	}
+}
+
+var cosRoles = []iampapv2.Role{
+	builtinRole("crn:v1:bluemix:public:iam::::role:Viewer", "Viewer", "iam.policy.read"),
+	builtinRole("crn:v1:bluemix:public:cloud-object-storage::::serviceRole:Reader", "Reader",
+		"cloud-object-storage.object.get", "cloud-object-storage.bucket.list"),
+	builtinRole("crn:v1:bluemix:public:cloud-object-storage::::serviceRole:Writer", "Writer",
+		"cloud-object-storage.object.get", "cloud-object-storage.bucket.list", "cloud-object-storage.object.put"),
+	{
+		CreateRoleRequest: iampapv2.CreateRoleRequest{DisplayName: "Uploader", AccountID: "acc", Actions: []string{"made.up.action"}},
+		Crn:               "crn:v1:bluemix:public:iam::a/acc::customRole:Uploader",
+	},
+}
+
+type fakeRoles struct {
+	iampapv2.RoleRepository
+	created []iampapv2.CreateRoleRequest
+}
+
+func (f *fakeRoles) ListAll(query iampapv2.RoleQuery) ([]iampapv2.Role, error) {
+	return cosRoles, nil
+}
+
+func (f *fakeRoles) Create(request iampapv2.CreateRoleRequest) (iampapv2.Role, error) {
+	f.created = append(f.created, request)
+	return iampapv2.Role{CreateRoleRequest: request, ID: "role-1"}, nil
+}
+
+var _ = Describe("Catalog", func() {
+	var (
+		roles   *fakeRoles
+		catalog *Catalog
+	)
+	BeforeEach(func() {
+		roles = &fakeRoles{}
+		var err error
+		catalog, err = LoadCatalog(roles, "cloud-object-storage")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should collect the actions of built-in roles only", func() {
+		s, err := catalog.Service("cloud-object-storage")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Actions()).To(Equal([]string{
+			"cloud-object-storage.bucket.list",
+			"cloud-object-storage.object.get",
+			"cloud-object-storage.object.put",
+			"iam.policy.read",
+		}))
+		Expect(s.BuiltinRoles()).To(Equal([]string{"Reader", "Viewer", "Writer"}))
+		Expect(RoleKind(cosRoles[3])).To(Equal(KindCustom))
+	})
+
+	Describe("Validate", func() {
+		request := iampapv2.CreateRoleRequest{
+			Name:        "Uploader",
+			AccountID:   "acc",
+			ServiceName: "cloud-object-storage",
+			DisplayName: "Uploader",
+			Actions:     []string{"cloud-object-storage.object.put"},
+		}
+		It("should accept a valid role", func() {
+			Expect(catalog.Validate(request)).To(Succeed())
+		})
+		It("should suggest the closest action for typos", func() {
+			bad := request
+			bad.Name = "uploader"
+			bad.Actions = []string{"cloud-object-storage.objects.put"}
+			err := catalog.Validate(bad)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(`name "uploader"`))
+			Expect(err.Error()).To(ContainSubstring(`did you mean "cloud-object-storage.object.put"?`))
+		})
+		It("should reject unknown services", func() {
+			bad := request
+			bad.ServiceName = "kms"
+			Expect(catalog.Validate(bad)).NotTo(Succeed())
+		})
+		It("should validate before creating", func() {
+			repo := NewValidatingRoleRepository(roles, catalog)
+			bad := request
+			bad.Actions = nil
+			_, err := repo.Create(bad)
+			Expect(err).To(HaveOccurred())
+			Expect(roles.created).To(BeEmpty())
+			_, err = repo.Create(request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(roles.created).To(HaveLen(1))
+		})
+	})
+
+	Describe("Compare", func() {
+		actions := []string{"cloud-object-storage.object.get", "cloud-object-storage.object.put"}
+		It("should set actions against built-in roles", func() {
+			comparisons, err := catalog.Compare("cloud-object-storage", actions, "Reader", "Writer")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(comparisons[0]).To(Equal(Comparison{
+				Builtin: "Reader",
+				Common:  []string{"cloud-object-storage.object.get"},
+				Extra:   []string{"cloud-object-storage.object.put"},
+				Missing: []string{"cloud-object-storage.bucket.list"},
+			}))
+			Expect(comparisons[1].Covers()).To(BeTrue())
+			Expect(comparisons[1].Equivalent()).To(BeFalse())
+
+			var out bytes.Buffer
+			PrintComparisons(&out, comparisons[1:])
+			Expect(out.String()).To(Equal("Writer (custom role is a subset): 2 common\n  - cloud-object-storage.bucket.list\n"))
+		})
+		It("should find the closest built-in role", func() {
+			closest, err := catalog.Closest("cloud-object-storage", actions)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(closest.Builtin).To(Equal("Writer"))
+		})
+		It("should reject unknown built-in roles", func() {
+			_, err := catalog.Compare("cloud-object-storage", actions, "Manager")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
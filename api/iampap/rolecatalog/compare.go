@@ -0,0 +1,124 @@
+package rolecatalog
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+)
+
+//Comparison sets the actions of a custom role against a built-in role
+type Comparison struct {
+	Builtin string
+	//Common are granted by both roles
+	Common []string
+	//Extra are granted only by the custom role
+	Extra []string
+	//Missing are granted only by the built-in role
+	Missing []string
+}
+
+//Equivalent reports whether both roles grant the same actions
+func (c Comparison) Equivalent() bool {
+	return len(c.Extra) == 0 && len(c.Missing) == 0
+}
+
+//Covers reports whether the built-in role grants every action of the
+//custom role
+func (c Comparison) Covers() bool {
+	return len(c.Extra) == 0
+}
+
+//Compare sets actions against each named built-in role of serviceName, or
+//against all of them when no name is given
+func (c *Catalog) Compare(serviceName string, actions []string, builtin ...string) ([]Comparison, error) {
+	s, err := c.Service(serviceName)
+	if err != nil {
+		return nil, err
+	}
+	if len(builtin) == 0 {
+		builtin = s.BuiltinRoles()
+	}
+	comparisons := make([]Comparison, 0, len(builtin))
+	for _, name := range builtin {
+		role, ok := s.builtin[name]
+		if !ok {
+			return nil, bmxerror.New(ErrCodeUnknownRole, fmt.Sprintf("%s has no built-in role %q, expected one of %s",
+				serviceName, name, strings.Join(s.BuiltinRoles(), ", ")))
+		}
+		comparisons = append(comparisons, compare(name, actions, role.Actions))
+	}
+	return comparisons, nil
+}
+
+//Closest returns the comparison with the built-in role sharing the most
+//actions with the custom role, ties broken by the fewest differences
+func (c *Catalog) Closest(serviceName string, actions []string) (Comparison, error) {
+	comparisons, err := c.Compare(serviceName, actions)
+	if err != nil || len(comparisons) == 0 {
+		return Comparison{}, err
+	}
+	sort.SliceStable(comparisons, func(i, j int) bool {
+		a, b := comparisons[i], comparisons[j]
+		if len(a.Common) != len(b.Common) {
+			return len(a.Common) > len(b.Common)
+		}
+		return len(a.Extra)+len(a.Missing) < len(b.Extra)+len(b.Missing)
+	})
+	return comparisons[0], nil
+}
+
+func compare(name string, custom, builtin []string) Comparison {
+	in := map[string]bool{}
+	for _, a := range builtin {
+		in[a] = true
+	}
+	c := Comparison{Builtin: name, Common: []string{}, Extra: []string{}, Missing: []string{}}
+	mine := map[string]bool{}
+	for _, a := range custom {
+		if mine[a] {
+			continue
+		}
+		mine[a] = true
+		if in[a] {
+			c.Common = append(c.Common, a)
+		} else {
+			c.Extra = append(c.Extra, a)
+		}
+	}
+	for _, a := range builtin {
+		if !mine[a] {
+			c.Missing = append(c.Missing, a)
+			mine[a] = true
+		}
+	}
+	sort.Strings(c.Common)
+	sort.Strings(c.Extra)
+	sort.Strings(c.Missing)
+	return c
+}
+
+//PrintComparisons writes one block per comparison, prefixing actions only
+//the custom role grants with + and actions it lacks with -
+func PrintComparisons(w io.Writer, comparisons []Comparison) {
+	for _, c := range comparisons {
+		summary := "differs"
+		switch {
+		case c.Equivalent():
+			summary = "same actions"
+		case c.Covers():
+			summary = "custom role is a subset"
+		case len(c.Missing) == 0:
+			summary = "custom role is a superset"
+		}
+		fmt.Fprintf(w, "%s (%s): %d common\n", c.Builtin, summary, len(c.Common))
+		for _, a := range c.Extra {
+			fmt.Fprintf(w, "  + %s\n", a)
+		}
+		for _, a := range c.Missing {
+			fmt.Fprintf(w, "  - %s\n", a)
+		}
+	}
+}
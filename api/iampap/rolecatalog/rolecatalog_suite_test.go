@@ -0,0 +1,13 @@
+package rolecatalog_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestRolecatalog(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Rolecatalog Suite")
+}
@@ -0,0 +1,145 @@
+package rolecatalog
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/IBM-Cloud/bluemix-go/api/iampap/iampapv2"
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+)
+
+//Validate checks a custom role before it is created: the name format, the
+//display name and that every action is known for the service. Unknown
+//actions are reported with the closest known action.
+func (c *Catalog) Validate(request iampapv2.CreateRoleRequest) error {
+	problems := []string{}
+	if !customRoleName.MatchString(request.Name) {
+		problems = append(problems, fmt.Sprintf("name %q must start with a capital letter and have at most 30 letters and digits", request.Name))
+	}
+	if request.AccountID == "" {
+		problems = append(problems, "an account ID is required")
+	}
+	problems = append(problems, c.check(request.ServiceName, request.DisplayName, request.Actions)...)
+	return problemsError(request.Name, problems)
+}
+
+//ValidateUpdate checks the display name and actions of an update to a
+//custom role of serviceName
+func (c *Catalog) ValidateUpdate(serviceName string, request iampapv2.UpdateRoleRequest) error {
+	return problemsError(request.DisplayName, c.check(serviceName, request.DisplayName, request.Actions))
+}
+
+func (c *Catalog) check(serviceName, displayName string, actions []string) []string {
+	problems := []string{}
+	if displayName == "" || len(displayName) > 50 {
+		problems = append(problems, "the display name must have between 1 and 50 characters")
+	}
+	if len(actions) == 0 {
+		problems = append(problems, "at least one action is required")
+	}
+	s, err := c.Service(serviceName)
+	if err != nil {
+		return append(problems, err.Error())
+	}
+	seen := map[string]bool{}
+	for _, a := range actions {
+		if seen[a] {
+			problems = append(problems, fmt.Sprintf("action %q is listed twice", a))
+			continue
+		}
+		seen[a] = true
+		if s.Has(a) {
+			continue
+		}
+		msg := fmt.Sprintf("action %q is not an action of %s", a, serviceName)
+		if suggestion := closest(a, s.Actions()); suggestion != "" {
+			msg += fmt.Sprintf(", did you mean %q?", suggestion)
+		}
+		problems = append(problems, msg)
+	}
+	return problems
+}
+
+func problemsError(name string, problems []string) error {
+	if len(problems) == 0 {
+		return nil
+	}
+	return bmxerror.New(ErrCodeInvalidCustomRole,
+		fmt.Sprintf("Custom role %s is invalid: %s", name, strings.Join(problems, "; ")))
+}
+
+//closest returns the candidate with the smallest edit distance to s, when
+//it is close enough to be a likely typo
+func closest(s string, candidates []string) string {
+	best, bestDistance := "", len(s)/3+1
+	for _, c := range candidates {
+		if d := distance(s, c); d < bestDistance {
+			best, bestDistance = c, d
+		}
+	}
+	return best
+}
+
+//distance is the Levenshtein distance between a and b
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func min(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
+
+//ValidatingRoleRepository checks custom roles against a catalog before
+//creating or updating them
+type ValidatingRoleRepository struct {
+	iampapv2.RoleRepository
+	Catalog *Catalog
+}
+
+//NewValidatingRoleRepository ...
+func NewValidatingRoleRepository(roles iampapv2.RoleRepository, catalog *Catalog) iampapv2.RoleRepository {
+	return &ValidatingRoleRepository{RoleRepository: roles, Catalog: catalog}
+}
+
+//Create validates request before creating the role
+func (r *ValidatingRoleRepository) Create(request iampapv2.CreateRoleRequest) (iampapv2.Role, error) {
+	if err := r.Catalog.Validate(request); err != nil {
+		return iampapv2.Role{}, err
+	}
+	return r.RoleRepository.Create(request)
+}
+
+//Update reads the role for its service, then validates request before
+//updating it
+func (r *ValidatingRoleRepository) Update(request iampapv2.UpdateRoleRequest, roleID, etag string) (iampapv2.Role, error) {
+	role, _, err := r.RoleRepository.Get(roleID)
+	if err != nil {
+		return iampapv2.Role{}, err
+	}
+	if err := r.Catalog.ValidateUpdate(role.ServiceName, request); err != nil {
+		return iampapv2.Role{}, err
+	}
+	return r.RoleRepository.Update(request, roleID, etag)
+}
@@ -0,0 +1,412 @@
+//Package groupsync keeps access group membership in line with an external
+//source of truth, such as a file exported from a corporate directory.
+package groupsync
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	yaml "github.com/ghodss/yaml"
+
+	"github.com/IBM-Cloud/bluemix-go/api/iam/iamv1"
+	"github.com/IBM-Cloud/bluemix-go/api/iamuum/iamuumv2"
+	"github.com/IBM-Cloud/bluemix-go/api/usermanagement/usermanagementv2"
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+	"github.com/IBM-Cloud/bluemix-go/crn"
+	"github.com/IBM-Cloud/bluemix-go/models"
+)
+
+//ErrCodeSyncIncomplete ...
+const ErrCodeSyncIncomplete = "SyncIncomplete"
+
+//Change actions
+const (
+	ActionAdd    = "add"
+	ActionRemove = "remove"
+)
+
+//Membership lists the desired members of one group. Users are given by
+//email and service IDs by name; IAMIDs are used as is.
+type Membership struct {
+	Users      []string `json:"users,omitempty"`
+	ServiceIDs []string `json:"service_ids,omitempty"`
+	IAMIDs     []string `json:"iam_ids,omitempty"`
+}
+
+//DesiredState maps access group names to their members. Groups that are not
+//listed are left alone.
+//
+//	groups:
+//	  developers:
+//	    users: [alice@example.com, bob@example.com]
+//	    service_ids: [ci-deployer]
+type DesiredState struct {
+	Groups map[string]Membership `json:"groups"`
+}
+
+//LoadDesiredState reads a desired state from a YAML or JSON file
+func LoadDesiredState(path string) (DesiredState, error) {
+	state := DesiredState{}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return state, err
+	}
+	err = yaml.Unmarshal(data, &state)
+	return state, err
+}
+
+//Change adds or removes one member of one group
+type Change struct {
+	Action  string
+	Group   string
+	GroupID string
+	IAMID   string
+	//Member is the email or name the member is known by
+	Member string
+	//Type is iamuumv2.AccessGroupMemberUser or AccessGroupMemberService
+	Type string
+}
+
+//Plan lists the changes needed to reach the desired state
+type Plan struct {
+	Changes []Change
+	//MissingGroups are desired groups that do not exist in the account
+	MissingGroups []string
+	//Unresolved lists, by group, the desired members whose IAM ID is
+	//unknown. Members are not removed from such groups, since an unresolved
+	//member could be one of the live ones.
+	Unresolved map[string][]string
+}
+
+//Directory resolves user emails and service ID names to IAM IDs. Emails are
+//compared without case. A name shared by several IDs is ambiguous and does
+//not resolve.
+type Directory struct {
+	users      map[string][]string
+	serviceIDs map[string][]string
+}
+
+//NewDirectory returns an empty directory
+func NewDirectory() *Directory {
+	return &Directory{
+		users:      map[string][]string{},
+		serviceIDs: map[string][]string{},
+	}
+}
+
+//LoadDirectory reads the users and service IDs of accountID. A nil
+//repository is skipped.
+func LoadDirectory(accountID string, users usermanagementv2.Users, serviceIDs iamv1.ServiceIDRepository) (*Directory, error) {
+	d := NewDirectory()
+	if users != nil {
+		list, err := users.ListUsers(accountID)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range list {
+			d.AddUser(u.IamID, u.Email)
+		}
+	}
+	if serviceIDs != nil {
+		boundTo, err := crn.AccountCRN(accountID)
+		if err != nil {
+			return nil, err
+		}
+		list, err := serviceIDs.List(boundTo.String())
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range list {
+			d.AddServiceID(s.IAMID, s.Name)
+		}
+	}
+	return d, nil
+}
+
+//AddUser records the email of a user
+func (d *Directory) AddUser(iamID, email string) {
+	add(d.users, iamID, strings.ToLower(email))
+}
+
+//AddServiceID records the name of a service ID
+func (d *Directory) AddServiceID(iamID, name string) {
+	add(d.serviceIDs, iamID, name)
+}
+
+func add(ids map[string][]string, iamID, name string) {
+	if iamID == "" || name == "" {
+		return
+	}
+	for _, id := range ids[name] {
+		if id == iamID {
+			return
+		}
+	}
+	ids[name] = append(ids[name], iamID)
+}
+
+func lookup(ids map[string][]string, name string) (string, bool) {
+	if len(ids[name]) != 1 {
+		return "", false
+	}
+	return ids[name][0], true
+}
+
+//Syncer computes and applies membership changes
+type Syncer struct {
+	groups    iamuumv2.AccessGroupRepository
+	members   iamuumv2.AccessGroupMemberRepositoryV2
+	directory *Directory
+}
+
+//NewSyncer returns a syncer resolving emails and service ID names with
+//directory, see LoadDirectory
+func NewSyncer(groups iamuumv2.AccessGroupRepository, members iamuumv2.AccessGroupMemberRepositoryV2, directory *Directory) *Syncer {
+	if directory == nil {
+		directory = NewDirectory()
+	}
+	return &Syncer{
+		groups:    groups,
+		members:   members,
+		directory: directory,
+	}
+}
+
+type desiredMember struct {
+	iamID, name, memberType string
+}
+
+//Plan compares the desired state with the live groups of accountID
+func (s *Syncer) Plan(accountID string, desired DesiredState) (Plan, error) {
+	plan := Plan{
+		Changes:       []Change{},
+		MissingGroups: []string{},
+		Unresolved:    map[string][]string{},
+	}
+	groups, err := s.groups.List(accountID)
+	if err != nil {
+		return plan, err
+	}
+	groupIDs := map[string]string{}
+	for _, g := range groups {
+		groupIDs[g.Name] = g.ID
+	}
+
+	names := make([]string, 0, len(desired.Groups))
+	for name := range desired.Groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		groupID, ok := groupIDs[name]
+		if !ok {
+			plan.MissingGroups = append(plan.MissingGroups, name)
+			continue
+		}
+		want, unresolved := s.resolve(desired.Groups[name])
+		if len(unresolved) > 0 {
+			plan.Unresolved[name] = unresolved
+		}
+		live, err := s.members.List(groupID)
+		if err != nil {
+			return plan, err
+		}
+		have := map[string]bool{}
+		for _, m := range live {
+			have[m.ID] = true
+		}
+		for _, m := range want {
+			if !have[m.iamID] {
+				plan.Changes = append(plan.Changes, Change{Action: ActionAdd, Group: name, GroupID: groupID,
+					IAMID: m.iamID, Member: m.name, Type: m.memberType})
+			}
+		}
+		if len(unresolved) > 0 {
+			continue
+		}
+		wanted := map[string]bool{}
+		for _, m := range want {
+			wanted[m.iamID] = true
+		}
+		for _, m := range live {
+			if !wanted[m.ID] {
+				plan.Changes = append(plan.Changes, Change{Action: ActionRemove, Group: name, GroupID: groupID,
+					IAMID: m.ID, Member: memberName(m), Type: m.Type})
+			}
+		}
+	}
+	return plan, nil
+}
+
+func (s *Syncer) resolve(m Membership) ([]desiredMember, []string) {
+	members := []desiredMember{}
+	unresolved := []string{}
+	seen := map[string]bool{}
+	add := func(iamID, name, memberType string) {
+		if !seen[iamID] {
+			seen[iamID] = true
+			members = append(members, desiredMember{iamID: iamID, name: name, memberType: memberType})
+		}
+	}
+	for _, email := range m.Users {
+		if id, ok := lookup(s.directory.users, strings.ToLower(email)); ok {
+			add(id, email, iamuumv2.AccessGroupMemberUser)
+		} else {
+			unresolved = append(unresolved, email)
+		}
+	}
+	for _, name := range m.ServiceIDs {
+		if id, ok := lookup(s.directory.serviceIDs, name); ok {
+			add(id, name, iamuumv2.AccessGroupMemberService)
+		} else {
+			unresolved = append(unresolved, name)
+		}
+	}
+	for _, id := range m.IAMIDs {
+		memberType := iamuumv2.AccessGroupMemberUser
+		if strings.HasPrefix(id, "iam-ServiceId-") {
+			memberType = iamuumv2.AccessGroupMemberService
+		}
+		add(id, id, memberType)
+	}
+	return members, unresolved
+}
+
+func memberName(m models.AccessGroupMemberV2) string {
+	switch {
+	case m.Email != "":
+		return m.Email
+	case m.Name != "":
+		return m.Name
+	}
+	return m.ID
+}
+
+//Summary is the outcome of Sync
+type Summary struct {
+	DryRun  bool
+	Plan    Plan
+	Added   int
+	Removed int
+	//Errors are keyed by "group/member"
+	Errors map[string]error
+}
+
+//Sync plans the changes and applies them unless dryRun is set. Changes
+//that fail are recorded in the summary and reported as an
+//ErrCodeSyncIncomplete error once the others are applied.
+func (s *Syncer) Sync(accountID string, desired DesiredState, dryRun bool) (Summary, error) {
+	plan, err := s.Plan(accountID, desired)
+	if err != nil {
+		return Summary{}, err
+	}
+	summary := Summary{DryRun: dryRun, Plan: plan, Errors: map[string]error{}}
+	if dryRun {
+		return summary, nil
+	}
+	s.apply(plan, &summary)
+	if len(summary.Errors) > 0 {
+		return summary, bmxerror.New(ErrCodeSyncIncomplete,
+			fmt.Sprintf("%d of %d changes could not be applied", len(summary.Errors), len(plan.Changes)))
+	}
+	return summary, nil
+}
+
+//apply adds the members of each group in one request and removes members
+//one by one
+func (s *Syncer) apply(plan Plan, summary *Summary) {
+	adds := map[string][]Change{}
+	order := []string{}
+	for _, c := range plan.Changes {
+		if c.Action != ActionAdd {
+			continue
+		}
+		if _, ok := adds[c.GroupID]; !ok {
+			order = append(order, c.GroupID)
+		}
+		adds[c.GroupID] = append(adds[c.GroupID], c)
+	}
+	for _, groupID := range order {
+		changes := adds[groupID]
+		request := iamuumv2.AddGroupMemberRequestV2{}
+		for _, c := range changes {
+			request.Members = append(request.Members, models.AccessGroupMemberV2{ID: c.IAMID, Type: c.Type})
+		}
+		response, err := s.members.Add(groupID, request)
+		if err != nil {
+			for _, c := range changes {
+				summary.Errors[c.Group+"/"+c.Member] = err
+			}
+			continue
+		}
+		failed := map[string]error{}
+		for _, m := range response.Members {
+			if len(m.Errors) > 0 {
+				failed[m.ID] = fmt.Errorf("%s: %s", m.Errors[0].Code, m.Errors[0].Message)
+			}
+		}
+		for _, c := range changes {
+			if err, ok := failed[c.IAMID]; ok {
+				summary.Errors[c.Group+"/"+c.Member] = err
+				continue
+			}
+			summary.Added++
+		}
+	}
+	for _, c := range plan.Changes {
+		if c.Action != ActionRemove {
+			continue
+		}
+		if err := s.members.Remove(c.GroupID, c.IAMID); err != nil {
+			summary.Errors[c.Group+"/"+c.Member] = err
+			continue
+		}
+		summary.Removed++
+	}
+}
+
+//Print writes the plan and, unless it was a dry run, the outcome
+func (s Summary) Print(w io.Writer) {
+	for _, c := range s.Plan.Changes {
+		sign := "+"
+		if c.Action == ActionRemove {
+			sign = "-"
+		}
+		fmt.Fprintf(w, "%s %s: %s (%s)\n", sign, c.Group, c.Member, c.IAMID)
+	}
+	for _, g := range s.Plan.MissingGroups {
+		fmt.Fprintf(w, "! group %s does not exist\n", g)
+	}
+	groups := make([]string, 0, len(s.Plan.Unresolved))
+	for g := range s.Plan.Unresolved {
+		groups = append(groups, g)
+	}
+	sort.Strings(groups)
+	for _, g := range groups {
+		fmt.Fprintf(w, "! %s: could not resolve %s, no members removed\n", g, strings.Join(s.Plan.Unresolved[g], ", "))
+	}
+	if s.DryRun {
+		adds, removes := 0, 0
+		for _, c := range s.Plan.Changes {
+			if c.Action == ActionAdd {
+				adds++
+			} else {
+				removes++
+			}
+		}
+		fmt.Fprintf(w, "Dry run: %d to add, %d to remove\n", adds, removes)
+		return
+	}
+	keys := make([]string, 0, len(s.Errors))
+	for k := range s.Errors {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(w, "failed %s: %v\n", k, s.Errors[k])
+	}
+	fmt.Fprintf(w, "%d added, %d removed, %d failed\n", s.Added, s.Removed, len(s.Errors))
+}
@@ -0,0 +1,13 @@
+package groupsync_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestGroupsync(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Groupsync Suite")
+}
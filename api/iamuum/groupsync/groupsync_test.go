@@ -0,0 +1,192 @@
+package groupsync
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/IBM-Cloud/bluemix-go/api/iamuum/iamuumv2"
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+	"github.com/IBM-Cloud/bluemix-go/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type groupsStub struct {
+	iamuumv2.AccessGroupRepository
+}
+
+func (groupsStub) List(accountID string, queryParams ...string) ([]models.AccessGroupV2, error) {
+	return []models.AccessGroupV2{
+		{AccessGroup: models.AccessGroup{ID: "AccessGroupId-dev", Name: "developers"}},
+		{AccessGroup: models.AccessGroup{ID: "AccessGroupId-ops", Name: "operators"}},
+	}, nil
+}
+
+type membersStub struct {
+	iamuumv2.AccessGroupMemberRepositoryV2
+	members map[string][]models.AccessGroupMemberV2
+	added   map[string][]models.AccessGroupMemberV2
+	removed []string
+	reject  string
+}
+
+func (s *membersStub) List(groupID string) ([]models.AccessGroupMemberV2, error) {
+	return s.members[groupID], nil
+}
+
+func (s *membersStub) Add(groupID string, request iamuumv2.AddGroupMemberRequestV2) (iamuumv2.AddGroupMemberResponseV2, error) {
+	response := iamuumv2.AddGroupMemberResponseV2{}
+	for _, m := range request.Members {
+		added := iamuumv2.AddedGroupMemberV2{ID: m.ID, Type: m.Type, StatusCode: 200}
+		if m.ID == s.reject {
+			added.StatusCode = 400
+			added.Errors = []iamuumv2.Error{{Code: "invalid_member", Message: "member can not be added"}}
+		} else {
+			s.added[groupID] = append(s.added[groupID], m)
+		}
+		response.Members = append(response.Members, added)
+	}
+	return response, nil
+}
+
+func (s *membersStub) Remove(groupID, memberID string) error {
+	if memberID == s.reject {
+		return errors.New("forbidden")
+	}
+	s.removed = append(s.removed, groupID+"/"+memberID)
+	return nil
+}
+
+func testDirectory() *Directory {
+	d := NewDirectory()
+	d.AddUser("IBMid-alice", "alice@example.com")
+	d.AddUser("IBMid-bob", "bob@example.com")
+	d.AddServiceID("iam-ServiceId-ci", "ci-deployer")
+	return d
+}
+
+var _ = Describe("Syncer", func() {
+	var (
+		members *membersStub
+		syncer  *Syncer
+		desired DesiredState
+	)
+
+	BeforeEach(func() {
+		members = &membersStub{
+			members: map[string][]models.AccessGroupMemberV2{
+				"AccessGroupId-dev": {
+					{ID: "IBMid-alice", Type: "user", Email: "alice@example.com"},
+					{ID: "IBMid-carol", Type: "user", Email: "carol@example.com"},
+				},
+				"AccessGroupId-ops": {
+					{ID: "IBMid-dave", Type: "user", Email: "dave@example.com"},
+				},
+			},
+			added: map[string][]models.AccessGroupMemberV2{},
+		}
+		syncer = NewSyncer(groupsStub{}, members, testDirectory())
+		desired = DesiredState{Groups: map[string]Membership{
+			"developers": {Users: []string{"alice@example.com", "bob@example.com"}, ServiceIDs: []string{"ci-deployer"}},
+			"auditors":   {Users: []string{"alice@example.com"}},
+		}}
+	})
+
+	It("should plan adds and removes", func() {
+		plan, err := syncer.Plan("acc", desired)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.MissingGroups).Should(Equal([]string{"auditors"}))
+		Expect(plan.Changes).Should(Equal([]Change{
+			{Action: ActionAdd, Group: "developers", GroupID: "AccessGroupId-dev", IAMID: "IBMid-bob", Member: "bob@example.com", Type: "user"},
+			{Action: ActionAdd, Group: "developers", GroupID: "AccessGroupId-dev", IAMID: "iam-ServiceId-ci", Member: "ci-deployer", Type: "service"},
+			{Action: ActionRemove, Group: "developers", GroupID: "AccessGroupId-dev", IAMID: "IBMid-carol", Member: "carol@example.com", Type: "user"},
+		}))
+	})
+
+	It("should not remove members of groups with unresolved names", func() {
+		desired.Groups["developers"] = Membership{Users: []string{"alice@example.com", "unknown@example.com"}}
+		plan, err := syncer.Plan("acc", desired)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Unresolved).Should(Equal(map[string][]string{"developers": {"unknown@example.com"}}))
+		Expect(plan.Changes).Should(BeEmpty())
+	})
+
+	It("should not change anything in dry run mode", func() {
+		summary, err := syncer.Sync("acc", desired, true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(members.added).Should(BeEmpty())
+		Expect(members.removed).Should(BeEmpty())
+
+		var out strings.Builder
+		summary.Print(&out)
+		Expect(out.String()).Should(ContainSubstring("+ developers: bob@example.com (IBMid-bob)"))
+		Expect(out.String()).Should(ContainSubstring("- developers: carol@example.com (IBMid-carol)"))
+		Expect(out.String()).Should(ContainSubstring("! group auditors does not exist"))
+		Expect(out.String()).Should(ContainSubstring("Dry run: 2 to add, 1 to remove"))
+	})
+
+	It("should apply the changes and report failures", func() {
+		members.reject = "iam-ServiceId-ci"
+		summary, err := syncer.Sync("acc", desired, false)
+		Expect(err).To(HaveOccurred())
+		Expect(err.(bmxerror.Error).Code()).Should(Equal(ErrCodeSyncIncomplete))
+		Expect(members.added["AccessGroupId-dev"]).Should(Equal([]models.AccessGroupMemberV2{{ID: "IBMid-bob", Type: "user"}}))
+		Expect(members.removed).Should(Equal([]string{"AccessGroupId-dev/IBMid-carol"}))
+		Expect(summary.Added).Should(Equal(1))
+		Expect(summary.Removed).Should(Equal(1))
+		Expect(summary.Errors).Should(HaveKey("developers/ci-deployer"))
+
+		var out strings.Builder
+		summary.Print(&out)
+		Expect(out.String()).Should(ContainSubstring("1 added, 1 removed, 1 failed"))
+	})
+
+	It("should leave names shared by several IDs unresolved", func() {
+		directory := testDirectory()
+		directory.AddServiceID("iam-ServiceId-ci2", "ci-deployer")
+		plan, err := NewSyncer(groupsStub{}, members, directory).Plan("acc", desired)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Unresolved["developers"]).Should(Equal([]string{"ci-deployer"}))
+	})
+
+	It("should resolve emails whatever their case", func() {
+		directory := NewDirectory()
+		directory.AddUser("IBMid-alice", "alice@example.com")
+		directory.AddUser("IBMid-bob", "Bob@Example.com")
+		directory.AddUser("IBMid-carol", "carol@example.com")
+		desired.Groups["developers"] = Membership{Users: []string{"ALICE@example.com", "bob@example.com"}}
+		plan, err := NewSyncer(groupsStub{}, members, directory).Plan("acc", desired)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Unresolved).Should(BeEmpty())
+		Expect(plan.Changes).Should(Equal([]Change{
+			{Action: ActionAdd, Group: "developers", GroupID: "AccessGroupId-dev", IAMID: "IBMid-bob", Member: "bob@example.com", Type: "user"},
+			{Action: ActionRemove, Group: "developers", GroupID: "AccessGroupId-dev", IAMID: "IBMid-carol", Member: "carol@example.com", Type: "user"},
+		}))
+	})
+
+	It("should load the desired state from YAML", func() {
+		dir, err := ioutil.TempDir("", "groupsync")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "groups.yaml")
+		Expect(ioutil.WriteFile(path, []byte(`
+groups:
+  developers:
+    users: [alice@example.com]
+    service_ids: [ci-deployer]
+    iam_ids: [iam-ServiceId-other]
+`), 0600)).To(Succeed())
+		state, err := LoadDesiredState(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(state.Groups["developers"].ServiceIDs).Should(Equal([]string{"ci-deployer"}))
+
+		plan, err := syncer.Plan("acc", state)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Changes).Should(ContainElement(Change{Action: ActionAdd, Group: "developers", GroupID: "AccessGroupId-dev",
+			IAMID: "iam-ServiceId-other", Member: "iam-ServiceId-other", Type: "service"}))
+	})
+})
@@ -0,0 +1,199 @@
+package rulesim
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//Finding kinds
+const (
+	//FindingContradictory is a rule whose conditions can never all hold
+	FindingContradictory = "contradictory"
+	//FindingUnmatched is a rule no sample claim set matched
+	FindingUnmatched = "unmatched"
+	//FindingDuplicate is a rule with the same realm and conditions as another
+	FindingDuplicate = "duplicate"
+	//FindingOverlap is a pair of rules matched by the same sample
+	FindingOverlap = "overlap"
+)
+
+//Finding is a problem found in the rules
+type Finding struct {
+	Kind  string
+	Rule  Rule
+	Other *Rule
+	//Samples are the names of the claim sets that produced an overlap
+	Samples []string
+	Message string
+}
+
+//Analysis is the result of Analyze
+type Analysis struct {
+	//Results are the evaluations of each sample by name
+	Results  map[string]Result
+	Findings []Finding
+}
+
+//Unreachable returns the contradictory and unmatched findings
+func (a Analysis) Unreachable() []Finding {
+	return a.filter(FindingContradictory, FindingUnmatched)
+}
+
+//Overlaps returns the duplicate and overlap findings
+func (a Analysis) Overlaps() []Finding {
+	return a.filter(FindingDuplicate, FindingOverlap)
+}
+
+func (a Analysis) filter(kinds ...string) []Finding {
+	found := []Finding{}
+	for _, f := range a.Findings {
+		for _, k := range kinds {
+			if f.Kind == k {
+				found = append(found, f)
+			}
+		}
+	}
+	return found
+}
+
+//Analyze evaluates every sample and flags rules that can never match, that
+//no sample matched, or that grant a user the same way as another rule.
+//Overlaps between rules of different groups are expected when a user must
+//join several groups, so they are reported only with the samples as
+//evidence and left for the caller to judge.
+func (s *Simulator) Analyze(samples []ClaimSet) Analysis {
+	a := Analysis{Results: map[string]Result{}, Findings: []Finding{}}
+	contradictory := map[int]bool{}
+	for i, r := range s.rules {
+		if msg := r.contradiction(); msg != "" {
+			contradictory[i] = true
+			a.Findings = append(a.Findings, Finding{Kind: FindingContradictory, Rule: r.Rule, Message: msg})
+		}
+	}
+	for i := range s.rules {
+		for j := i + 1; j < len(s.rules); j++ {
+			if s.rules[i].key() == s.rules[j].key() {
+				other := s.rules[j].Rule
+				a.Findings = append(a.Findings, Finding{
+					Kind:    FindingDuplicate,
+					Rule:    s.rules[i].Rule,
+					Other:   &other,
+					Message: fmt.Sprintf("Rules %s and %s have the same conditions", s.rules[i].Rule, other),
+				})
+			}
+		}
+	}
+	if len(samples) == 0 {
+		return a
+	}
+
+	matched := make([][]string, len(s.rules))
+	for _, sample := range samples {
+		a.Results[sample.Name] = s.Evaluate(sample)
+		for i, r := range s.rules {
+			if r.matches(sample) {
+				matched[i] = append(matched[i], sample.Name)
+			}
+		}
+	}
+	for i, r := range s.rules {
+		if len(matched[i]) == 0 && !contradictory[i] {
+			a.Findings = append(a.Findings, Finding{
+				Kind:    FindingUnmatched,
+				Rule:    r.Rule,
+				Message: fmt.Sprintf("Rule %s matched none of the %d samples", r.Rule, len(samples)),
+			})
+		}
+	}
+	for i := range s.rules {
+		for j := i + 1; j < len(s.rules); j++ {
+			if s.rules[i].key() == s.rules[j].key() {
+				continue
+			}
+			common := intersect(matched[i], matched[j])
+			if len(common) == 0 {
+				continue
+			}
+			other := s.rules[j].Rule
+			a.Findings = append(a.Findings, Finding{
+				Kind:    FindingOverlap,
+				Rule:    s.rules[i].Rule,
+				Other:   &other,
+				Samples: common,
+				Message: fmt.Sprintf("Rules %s and %s both match %s", s.rules[i].Rule, other, strings.Join(common, ", ")),
+			})
+		}
+	}
+	return a
+}
+
+//contradiction describes why the conditions of a rule cannot all hold, or
+//returns an empty string. A claim may carry several values, so only a
+//positive and a negative condition on the same value conflict.
+func (r compiledRule) contradiction() string {
+	for i, a := range r.conditions {
+		for _, b := range r.conditions[i+1:] {
+			if a.claim != b.claim {
+				continue
+			}
+			if conflicts(a, b) || conflicts(b, a) {
+				return fmt.Sprintf("Claim %s cannot be both %s %q and %s %q",
+					a.claim, a.operator, strings.Join(a.values, ","), b.operator, strings.Join(b.values, ","))
+			}
+		}
+	}
+	return ""
+}
+
+//conflicts reports whether the negative condition neg excludes every value
+//the positive condition pos accepts
+func conflicts(pos, neg condition) bool {
+	var excluded func(string) bool
+	switch neg.operator {
+	case OperatorNotEquals:
+		excluded = func(v string) bool { return v == neg.values[0] }
+	case OperatorNotEqualsIgnoreCase:
+		excluded = func(v string) bool { return strings.EqualFold(v, neg.values[0]) }
+	default:
+		return false
+	}
+	switch pos.operator {
+	case OperatorEquals, OperatorIn:
+		for _, v := range pos.values {
+			if !excluded(v) {
+				return false
+			}
+		}
+		return true
+	case OperatorEqualsIgnoreCase:
+		return neg.operator == OperatorNotEqualsIgnoreCase && excluded(pos.values[0])
+	}
+	return false
+}
+
+//key identifies the realm and conditions of a rule regardless of order
+func (r compiledRule) key() string {
+	parts := make([]string, 0, len(r.conditions))
+	for _, c := range r.conditions {
+		values := append([]string{}, c.values...)
+		sort.Strings(values)
+		parts = append(parts, c.claim+"\x00"+c.operator+"\x00"+strings.Join(values, "\x01"))
+	}
+	sort.Strings(parts)
+	return r.RealmName + "\x02" + strings.Join(parts, "\x02")
+}
+
+func intersect(a, b []string) []string {
+	in := make(map[string]bool, len(a))
+	for _, v := range a {
+		in[v] = true
+	}
+	common := []string{}
+	for _, v := range b {
+		if in[v] {
+			common = append(common, v)
+		}
+	}
+	return common
+}
@@ -0,0 +1,29 @@
+package rulesim
+
+import (
+	"github.com/IBM-Cloud/bluemix-go/api/iamuum/iamuumv2"
+)
+
+//LoadRules reads the dynamic rules of every access group in accountID
+func LoadRules(groups iamuumv2.AccessGroupRepository, rules iamuumv2.DynamicRuleRepository, accountID string) ([]Rule, error) {
+	list, err := groups.List(accountID)
+	if err != nil {
+		return nil, err
+	}
+	loaded := []Rule{}
+	for _, g := range list {
+		groupRules, err := rules.List(g.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range groupRules {
+			loaded = append(loaded, Rule{
+				GroupID:           g.ID,
+				GroupName:         g.Name,
+				RuleID:            r.RuleID,
+				CreateRuleRequest: r.CreateRuleRequest,
+			})
+		}
+	}
+	return loaded, nil
+}
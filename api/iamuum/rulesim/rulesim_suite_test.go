@@ -0,0 +1,13 @@
+package rulesim_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestRulesim(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Rulesim Suite")
+}
@@ -0,0 +1,202 @@
+//Package rulesim evaluates access group dynamic rules locally, so rules can
+//be tested against sample SAML claims before they are created.
+package rulesim
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/IBM-Cloud/bluemix-go/api/iamuum/iamuumv2"
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+)
+
+//ErrCodeInvalidRule ...
+const ErrCodeInvalidRule = "InvalidRule"
+
+//Condition operators supported by dynamic rules
+const (
+	OperatorEquals              = "EQUALS"
+	OperatorNotEquals           = "NOT_EQUALS"
+	OperatorEqualsIgnoreCase    = "EQUALS_IGNORE_CASE"
+	OperatorNotEqualsIgnoreCase = "NOT_EQUALS_IGNORE_CASE"
+	OperatorContains            = "CONTAINS"
+	OperatorIn                  = "IN"
+)
+
+//Rule is a dynamic rule of an access group
+type Rule struct {
+	GroupID   string
+	GroupName string
+	RuleID    string
+	iamuumv2.CreateRuleRequest
+}
+
+//String names the rule in reports
+func (r Rule) String() string {
+	group := r.GroupName
+	if group == "" {
+		group = r.GroupID
+	}
+	return fmt.Sprintf("%s/%s", group, r.Name)
+}
+
+//ClaimSet is the SAML assertion of one sample user. Attributes may have
+//several values.
+type ClaimSet struct {
+	Name   string
+	Realm  string
+	Claims map[string][]string
+}
+
+type condition struct {
+	claim    string
+	operator string
+	values   []string
+}
+
+type compiledRule struct {
+	Rule
+	conditions []condition
+}
+
+//Simulator evaluates rules against claim sets
+type Simulator struct {
+	rules []compiledRule
+}
+
+//NewSimulator validates rules and returns a simulator for them
+func NewSimulator(rules ...Rule) (*Simulator, error) {
+	s := &Simulator{}
+	for _, r := range rules {
+		compiled := compiledRule{Rule: r}
+		for _, c := range r.Conditions {
+			cond, err := compileCondition(c)
+			if err != nil {
+				return nil, bmxerror.New(ErrCodeInvalidRule, fmt.Sprintf("Rule %s: %v", r, err))
+			}
+			compiled.conditions = append(compiled.conditions, cond)
+		}
+		s.rules = append(s.rules, compiled)
+	}
+	return s, nil
+}
+
+//compileCondition decodes the condition value. Values may be plain or JSON
+//encoded strings; IN takes a JSON array or a comma separated list.
+func compileCondition(c iamuumv2.Condition) (condition, error) {
+	cond := condition{claim: c.Claim, operator: strings.ToUpper(c.Operator)}
+	if cond.claim == "" {
+		return cond, fmt.Errorf("a condition has no claim")
+	}
+	switch cond.operator {
+	case OperatorEquals, OperatorNotEquals, OperatorEqualsIgnoreCase, OperatorNotEqualsIgnoreCase, OperatorContains:
+		cond.values = []string{unquote(c.Value)}
+	case OperatorIn:
+		var list []string
+		if err := json.Unmarshal([]byte(c.Value), &list); err != nil {
+			list = strings.Split(unquote(c.Value), ",")
+		}
+		for _, v := range list {
+			if v = strings.TrimSpace(v); v != "" {
+				cond.values = append(cond.values, v)
+			}
+		}
+		if len(cond.values) == 0 {
+			return cond, fmt.Errorf("the IN condition on %s has no values", c.Claim)
+		}
+	default:
+		return cond, fmt.Errorf("unsupported operator %q", c.Operator)
+	}
+	return cond, nil
+}
+
+func unquote(v string) string {
+	var s string
+	if err := json.Unmarshal([]byte(v), &s); err == nil {
+		return s
+	}
+	return v
+}
+
+//matches reports whether the claim values satisfy the condition. An absent
+//claim satisfies no condition, negative ones included.
+func (c condition) matches(values []string) bool {
+	if len(values) == 0 {
+		return false
+	}
+	switch c.operator {
+	case OperatorNotEquals:
+		return !anyValue(values, func(v string) bool { return v == c.values[0] })
+	case OperatorNotEqualsIgnoreCase:
+		return !anyValue(values, func(v string) bool { return strings.EqualFold(v, c.values[0]) })
+	}
+	return anyValue(values, func(v string) bool {
+		switch c.operator {
+		case OperatorEquals:
+			return v == c.values[0]
+		case OperatorEqualsIgnoreCase:
+			return strings.EqualFold(v, c.values[0])
+		case OperatorContains:
+			return strings.Contains(v, c.values[0])
+		case OperatorIn:
+			for _, want := range c.values {
+				if v == want {
+					return true
+				}
+			}
+		}
+		return false
+	})
+}
+
+func anyValue(values []string, f func(string) bool) bool {
+	for _, v := range values {
+		if f(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r compiledRule) matches(claims ClaimSet) bool {
+	if r.RealmName != "" && r.RealmName != claims.Realm {
+		return false
+	}
+	for _, c := range r.conditions {
+		if !c.matches(claims.Claims[c.claim]) {
+			return false
+		}
+	}
+	return true
+}
+
+//Result is where a user would land
+type Result struct {
+	//Groups are the names, or IDs when unnamed, of the groups joined
+	Groups []string
+	Rules  []Rule
+}
+
+//Evaluate returns the groups a user with claims would be added to
+func (s *Simulator) Evaluate(claims ClaimSet) Result {
+	result := Result{Groups: []string{}, Rules: []Rule{}}
+	seen := map[string]bool{}
+	for _, r := range s.rules {
+		if !r.matches(claims) {
+			continue
+		}
+		result.Rules = append(result.Rules, r.Rule)
+		group := r.GroupName
+		if group == "" {
+			group = r.GroupID
+		}
+		if !seen[group] {
+			seen[group] = true
+			result.Groups = append(result.Groups, group)
+		}
+	}
+	sort.Strings(result.Groups)
+	return result
+}
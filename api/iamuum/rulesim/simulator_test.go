@@ -0,0 +1,129 @@
+package rulesim
+
+import (
+	"github.com/IBM-Cloud/bluemix-go/api/iamuum/iamuumv2"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func rule(group, name string, conditions ...iamuumv2.Condition) Rule {
+	return Rule{
+		GroupID:   "AccessGroupId-" + group,
+		GroupName: group,
+		CreateRuleRequest: iamuumv2.CreateRuleRequest{
+			Name:       name,
+			Expiration: 24,
+			RealmName:  "https://idp.example.com",
+			Conditions: conditions,
+		},
+	}
+}
+
+func cond(claim, operator, value string) iamuumv2.Condition {
+	return iamuumv2.Condition{Claim: claim, Operator: operator, Value: value}
+}
+
+func claims(name string, attributes map[string][]string) ClaimSet {
+	return ClaimSet{Name: name, Realm: "https://idp.example.com", Claims: attributes}
+}
+
+var _ = Describe("Simulator", func() {
+	Describe("NewSimulator", func() {
+		It("should reject unsupported operators", func() {
+			_, err := NewSimulator(rule("devs", "r", cond("dept", "STARTS_WITH", "eng")))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("STARTS_WITH"))
+		})
+		It("should reject an empty IN list", func() {
+			_, err := NewSimulator(rule("devs", "r", cond("dept", OperatorIn, "[]")))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Evaluate", func() {
+		var s *Simulator
+		BeforeEach(func() {
+			var err error
+			s, err = NewSimulator(
+				rule("devs", "engineering", cond("dept", OperatorEquals, `"engineering"`)),
+				rule("admins", "admins", cond("groups", OperatorContains, "admin"), cond("dept", OperatorNotEquals, "sales")),
+				rule("emea", "emea", cond("country", OperatorIn, `["DE","FR","IE"]`)),
+				rule("ops", "ops", cond("role", OperatorEqualsIgnoreCase, "SRE")),
+			)
+			Expect(err).NotTo(HaveOccurred())
+		})
+		It("should return every matching group", func() {
+			r := s.Evaluate(claims("alice", map[string][]string{
+				"dept":    {"engineering"},
+				"groups":  {"users", "cloud-admins"},
+				"country": {"IE"},
+				"role":    {"sre"},
+			}))
+			Expect(r.Groups).To(Equal([]string{"admins", "devs", "emea", "ops"}))
+			Expect(r.Rules).To(HaveLen(4))
+		})
+		It("should fail NOT_EQUALS when any value is excluded", func() {
+			r := s.Evaluate(claims("bob", map[string][]string{
+				"dept":   {"engineering", "sales"},
+				"groups": {"admin"},
+			}))
+			Expect(r.Groups).To(Equal([]string{"devs"}))
+		})
+		It("should not match absent claims or other realms", func() {
+			Expect(s.Evaluate(claims("carol", map[string][]string{"groups": {"admin"}})).Groups).To(BeEmpty())
+			other := ClaimSet{Realm: "https://other", Claims: map[string][]string{"dept": {"engineering"}}}
+			Expect(s.Evaluate(other).Groups).To(BeEmpty())
+		})
+		It("should accept comma separated IN values", func() {
+			s, err := NewSimulator(rule("emea", "emea", cond("country", OperatorIn, "DE, FR")))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.Evaluate(claims("d", map[string][]string{"country": {"FR"}})).Groups).To(Equal([]string{"emea"}))
+		})
+	})
+
+	Describe("Analyze", func() {
+		It("should flag contradictory, unmatched, duplicate and overlapping rules", func() {
+			s, err := NewSimulator(
+				rule("devs", "engineering", cond("dept", OperatorEquals, "engineering")),
+				rule("devs", "engineering-copy", cond("dept", OperatorEquals, "engineering")),
+				rule("all", "everyone", cond("dept", OperatorIn, `["engineering","sales"]`)),
+				rule("nobody", "broken", cond("dept", OperatorIn, `["sales"]`), cond("dept", OperatorNotEquals, "sales")),
+				rule("finance", "finance", cond("dept", OperatorEquals, "finance")),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			a := s.Analyze([]ClaimSet{
+				claims("alice", map[string][]string{"dept": {"engineering"}}),
+				claims("bob", map[string][]string{"dept": {"sales"}}),
+			})
+
+			unreachable := a.Unreachable()
+			Expect(unreachable).To(HaveLen(2))
+			Expect(unreachable[0].Kind).To(Equal(FindingContradictory))
+			Expect(unreachable[0].Rule.Name).To(Equal("broken"))
+			Expect(unreachable[1].Kind).To(Equal(FindingUnmatched))
+			Expect(unreachable[1].Rule.Name).To(Equal("finance"))
+
+			overlaps := a.Overlaps()
+			Expect(overlaps).To(HaveLen(3))
+			Expect(overlaps[0].Kind).To(Equal(FindingDuplicate))
+			Expect(overlaps[0].Other.Name).To(Equal("engineering-copy"))
+			Expect(overlaps[1].Kind).To(Equal(FindingOverlap))
+			Expect(overlaps[1].Rule.Name).To(Equal("engineering"))
+			Expect(overlaps[1].Other.Name).To(Equal("everyone"))
+			Expect(overlaps[1].Samples).To(Equal([]string{"alice"}))
+			Expect(overlaps[2].Rule.Name).To(Equal("engineering-copy"))
+
+			Expect(a.Results["bob"].Groups).To(Equal([]string{"all"}))
+		})
+		It("should report only static findings without samples", func() {
+			s, err := NewSimulator(
+				rule("a", "a", cond("role", OperatorEqualsIgnoreCase, "Admin"), cond("role", OperatorNotEqualsIgnoreCase, "admin")),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			a := s.Analyze(nil)
+			Expect(a.Findings).To(HaveLen(1))
+			Expect(a.Findings[0].Kind).To(Equal(FindingContradictory))
+		})
+	})
+})
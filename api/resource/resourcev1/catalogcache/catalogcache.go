@@ -0,0 +1,455 @@
+//Package catalogcache serves resource catalog lookups from an in-memory index
+//that can be saved to and loaded from an offline snapshot.
+package catalogcache
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/IBM-Cloud/bluemix-go/api/resource/resourcev1/catalog"
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+	"github.com/IBM-Cloud/bluemix-go/models"
+	"github.com/IBM-Cloud/bluemix-go/utils"
+)
+
+//ErrCodeInvalidSnapshot ...
+const ErrCodeInvalidSnapshot = "CatalogSnapshotError"
+
+//SnapshotVersion is the format version written by SaveSnapshot
+const SnapshotVersion = 1
+
+//Cache resolves catalog names and IDs from an in-memory index.
+//Entries are fetched lazily from the underlying repository and refreshed
+//once they are older than the configured TTL. A cache created without a
+//repository only serves what was loaded from a snapshot.
+type Cache interface {
+	GetServiceID(serviceName string) (string, error)
+	GetServiceName(serviceID string) (string, error)
+	GetService(serviceID string) (models.Service, error)
+	GetServicePlanID(service models.Service, planName string) (string, error)
+	GetServicePlanName(servicePlanID string) (string, error)
+	ListServicePlans(service models.Service) ([]models.ServicePlan, error)
+	ListDeployments(servicePlanID string) ([]models.ServiceDeployment, error)
+	GetDeploymentAlias(servicePlanID string, instanceTarget string, regionID string) (*models.ServiceDeploymentAlias, error)
+	Invalidate()
+	Snapshot() Snapshot
+	SaveSnapshot(path string) error
+	LoadSnapshot(path string) error
+}
+
+//Snapshot is the portable form of the cache index
+type Snapshot struct {
+	Version     int                                        `json:"version"`
+	CreatedAt   time.Time                                  `json:"created_at"`
+	Services    []models.Service                           `json:"services"`
+	Plans       map[string][]models.ServicePlan            `json:"plans,omitempty"`
+	Deployments map[string][]models.ServiceDeployment      `json:"deployments,omitempty"`
+	Aliases     map[string][]models.ServiceDeploymentAlias `json:"aliases,omitempty"`
+}
+
+type catalogCache struct {
+	repo catalog.ResourceCatalogRepository
+	ttl  time.Duration
+
+	mu               sync.Mutex
+	servicesLoadedAt time.Time
+	services         map[string]models.Service
+	serviceIDs       map[string]string
+	plansLoadedAt    map[string]time.Time
+	plans            map[string][]models.ServicePlan
+	planByID         map[string]models.ServicePlan
+	planLoadedAt     map[string]time.Time
+	deployLoadedAt   map[string]time.Time
+	deployments      map[string][]models.ServiceDeployment
+	aliasLoadedAt    map[string]time.Time
+	aliases          map[string][]models.ServiceDeploymentAlias
+}
+
+//New returns a cache in front of repo. A ttl of zero or less
+//keeps entries until Invalidate is called.
+func New(repo catalog.ResourceCatalogRepository, ttl time.Duration) Cache {
+	c := &catalogCache{
+		repo: repo,
+		ttl:  ttl,
+	}
+	c.reset()
+	return c
+}
+
+//NewOffline returns a cache that serves lookups from the snapshot
+//at path and never calls the resource catalog
+func NewOffline(path string) (Cache, error) {
+	c := New(nil, 0)
+	if err := c.LoadSnapshot(path); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *catalogCache) reset() {
+	c.servicesLoadedAt = time.Time{}
+	c.services = map[string]models.Service{}
+	c.serviceIDs = map[string]string{}
+	c.plansLoadedAt = map[string]time.Time{}
+	c.plans = map[string][]models.ServicePlan{}
+	c.planByID = map[string]models.ServicePlan{}
+	c.planLoadedAt = map[string]time.Time{}
+	c.deployLoadedAt = map[string]time.Time{}
+	c.deployments = map[string][]models.ServiceDeployment{}
+	c.aliasLoadedAt = map[string]time.Time{}
+	c.aliases = map[string][]models.ServiceDeploymentAlias{}
+}
+
+//fresh reports whether an entry loaded at t can be served without a refetch.
+//Offline caches treat every loaded entry as fresh.
+func (c *catalogCache) fresh(t time.Time) bool {
+	if t.IsZero() {
+		return false
+	}
+	if c.repo == nil || c.ttl <= 0 {
+		return true
+	}
+	return time.Since(t) < c.ttl
+}
+
+func (c *catalogCache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reset()
+}
+
+func (c *catalogCache) loadServices() error {
+	if c.fresh(c.servicesLoadedAt) || c.repo == nil {
+		return nil
+	}
+	services := []models.Service{}
+	err := c.repo.ListServices(func(service models.Service) bool {
+		services = append(services, service)
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	c.services = map[string]models.Service{}
+	c.serviceIDs = map[string]string{}
+	for _, service := range services {
+		c.indexService(service)
+	}
+	c.servicesLoadedAt = time.Now()
+	return nil
+}
+
+func (c *catalogCache) indexService(service models.Service) {
+	c.services[service.ID] = service
+	if _, ok := c.serviceIDs[service.Name]; !ok {
+		c.serviceIDs[service.Name] = service.ID
+	}
+}
+
+func (c *catalogCache) GetServiceID(serviceName string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.loadServices(); err != nil {
+		return "", err
+	}
+	if id, ok := c.serviceIDs[serviceName]; ok {
+		return id, nil
+	}
+	return "", bmxerror.New(catalog.ErrCodeServiceDoesnotExist,
+		fmt.Sprintf("Given service : %q doesn't exist", serviceName))
+}
+
+func (c *catalogCache) GetService(serviceID string) (models.Service, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.loadServices(); err != nil {
+		return models.Service{}, err
+	}
+	if service, ok := c.services[serviceID]; ok {
+		return service, nil
+	}
+	if c.repo == nil {
+		return models.Service{}, bmxerror.New(catalog.ErrCodeServiceDoesnotExist,
+			fmt.Sprintf("Given service : %q doesn't exist", serviceID))
+	}
+	//Services that are not listed at the top level can still be fetched by ID
+	service, err := c.repo.Get(serviceID, false)
+	if err != nil {
+		return models.Service{}, err
+	}
+	c.indexService(service)
+	return service, nil
+}
+
+func (c *catalogCache) GetServiceName(serviceID string) (string, error) {
+	service, err := c.GetService(serviceID)
+	if err != nil {
+		return "", err
+	}
+	return service.Name, nil
+}
+
+func (c *catalogCache) loadPlans(service models.Service) error {
+	if c.fresh(c.plansLoadedAt[service.ID]) || c.repo == nil {
+		return nil
+	}
+	plans := []models.ServicePlan{}
+	err := c.repo.ListServicePlans(func(plan models.ServicePlan) bool {
+		plans = append(plans, plan)
+		return true
+	}, service)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	c.indexPlans(service.ID, plans, now)
+	c.plansLoadedAt[service.ID] = now
+	return nil
+}
+
+func (c *catalogCache) indexPlans(serviceID string, plans []models.ServicePlan, loadedAt time.Time) {
+	//plans removed from the service must no longer resolve by ID
+	for _, plan := range c.plans[serviceID] {
+		delete(c.planByID, plan.ID)
+		delete(c.planLoadedAt, plan.ID)
+	}
+	c.plans[serviceID] = plans
+	for _, plan := range plans {
+		c.indexPlan(plan, loadedAt)
+	}
+}
+
+func (c *catalogCache) indexPlan(plan models.ServicePlan, loadedAt time.Time) {
+	c.planByID[plan.ID] = plan
+	c.planLoadedAt[plan.ID] = loadedAt
+}
+
+func (c *catalogCache) ListServicePlans(service models.Service) ([]models.ServicePlan, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.loadPlans(service); err != nil {
+		return nil, err
+	}
+	plans, ok := c.plans[service.ID]
+	if !ok {
+		return nil, bmxerror.New(catalog.ErrCodeServiceDoesnotExist,
+			fmt.Sprintf("Given service : %q doesn't exist", service.ID))
+	}
+	return append([]models.ServicePlan{}, plans...), nil
+}
+
+func (c *catalogCache) GetServicePlanID(service models.Service, planName string) (string, error) {
+	plans, err := c.ListServicePlans(service)
+	if err != nil {
+		return "", err
+	}
+	for _, plan := range plans {
+		if plan.Name == planName {
+			return plan.ID, nil
+		}
+	}
+	return "", bmxerror.New(catalog.ErrCodeServicePlanDoesnotExist,
+		fmt.Sprintf("Given service plan : %q doesn't exist for service %q", planName, service.Name))
+}
+
+func (c *catalogCache) GetServicePlanName(servicePlanID string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if plan, ok := c.planByID[servicePlanID]; ok && c.fresh(c.planLoadedAt[servicePlanID]) {
+		return plan.Name, nil
+	}
+	if c.repo == nil {
+		return "", bmxerror.New(catalog.ErrCodeServicePlanDoesnotExist,
+			fmt.Sprintf("Given service plan : %q doesn't exist", servicePlanID))
+	}
+	plan, err := c.repo.GetServicePlan(servicePlanID)
+	if err != nil {
+		return "", err
+	}
+	c.indexPlan(plan, time.Now())
+	return plan.Name, nil
+}
+
+func (c *catalogCache) loadDeployments(servicePlanID string) error {
+	if c.fresh(c.deployLoadedAt[servicePlanID]) || c.repo == nil {
+		return nil
+	}
+	deployments, err := c.repo.ListDeployments(servicePlanID)
+	if err != nil {
+		return err
+	}
+	c.deployments[servicePlanID] = deployments
+	c.deployLoadedAt[servicePlanID] = time.Now()
+	return nil
+}
+
+func (c *catalogCache) ListDeployments(servicePlanID string) ([]models.ServiceDeployment, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.loadDeployments(servicePlanID); err != nil {
+		return nil, err
+	}
+	return append([]models.ServiceDeployment{}, c.deployments[servicePlanID]...), nil
+}
+
+func (c *catalogCache) loadAliases(deploymentID string) error {
+	if c.fresh(c.aliasLoadedAt[deploymentID]) || c.repo == nil {
+		return nil
+	}
+	aliases, err := c.repo.ListDeploymentAliases(deploymentID)
+	if err != nil {
+		return err
+	}
+	c.aliases[deploymentID] = aliases
+	c.aliasLoadedAt[deploymentID] = time.Now()
+	return nil
+}
+
+func (c *catalogCache) GetDeploymentAlias(servicePlanID string, instanceTarget string, currentRegion string) (*models.ServiceDeploymentAlias, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.loadDeployments(servicePlanID); err != nil {
+		return nil, err
+	}
+	var deploymentID string
+	for _, deployment := range c.deployments[servicePlanID] {
+		if utils.GetLocationFromTargetCRN(deployment.Metadata.Deployment.TargetCrn.Resource) == instanceTarget {
+			deploymentID = deployment.ID
+			break
+		}
+	}
+	if deploymentID == "" {
+		return nil, bmxerror.New(catalog.ErrCodeServiceDeploymentNotFound,
+			fmt.Sprintf("Service alias Deployment doesn't exist for %q", instanceTarget))
+	}
+	if err := c.loadAliases(deploymentID); err != nil {
+		return nil, err
+	}
+	for _, alias := range c.aliases[deploymentID] {
+		if alias.Metadata.Deployment.Location == currentRegion {
+			alias := alias
+			return &alias, nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *catalogCache) Snapshot() Snapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	snapshot := Snapshot{
+		Version:     SnapshotVersion,
+		CreatedAt:   time.Now().UTC(),
+		Services:    make([]models.Service, 0, len(c.services)),
+		Plans:       map[string][]models.ServicePlan{},
+		Deployments: map[string][]models.ServiceDeployment{},
+		Aliases:     map[string][]models.ServiceDeploymentAlias{},
+	}
+	for _, service := range c.services {
+		snapshot.Services = append(snapshot.Services, service)
+	}
+	sort.Slice(snapshot.Services, func(i, j int) bool {
+		return snapshot.Services[i].ID < snapshot.Services[j].ID
+	})
+	for id, plans := range c.plans {
+		snapshot.Plans[id] = plans
+	}
+	//Plans looked up only by ID are kept under an empty service ID
+	for id, plan := range c.planByID {
+		if !c.planIndexed(id) {
+			snapshot.Plans[""] = append(snapshot.Plans[""], plan)
+		}
+	}
+	for id, deployments := range c.deployments {
+		snapshot.Deployments[id] = deployments
+	}
+	for id, aliases := range c.aliases {
+		snapshot.Aliases[id] = aliases
+	}
+	return snapshot
+}
+
+func (c *catalogCache) planIndexed(planID string) bool {
+	for _, plans := range c.plans {
+		for _, plan := range plans {
+			if plan.ID == planID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c *catalogCache) SaveSnapshot(path string) error {
+	data, err := json.MarshalIndent(c.Snapshot(), "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (c *catalogCache) LoadSnapshot(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return bmxerror.New(ErrCodeInvalidSnapshot,
+			fmt.Sprintf("Unable to parse catalog snapshot %q: %v", path, err))
+	}
+	if snapshot.Version != SnapshotVersion {
+		return bmxerror.New(ErrCodeInvalidSnapshot,
+			fmt.Sprintf("Unsupported catalog snapshot version %d in %q", snapshot.Version, path))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reset()
+	loadedAt := snapshot.CreatedAt
+	if loadedAt.IsZero() {
+		loadedAt = time.Now()
+	}
+	for _, service := range snapshot.Services {
+		c.indexService(service)
+	}
+	c.servicesLoadedAt = loadedAt
+	for id, plans := range snapshot.Plans {
+		if id == "" {
+			for _, plan := range plans {
+				c.indexPlan(plan, loadedAt)
+			}
+			continue
+		}
+		c.indexPlans(id, plans, loadedAt)
+		c.plansLoadedAt[id] = loadedAt
+	}
+	for id, deployments := range snapshot.Deployments {
+		c.deployments[id] = deployments
+		c.deployLoadedAt[id] = loadedAt
+	}
+	for id, aliases := range snapshot.Aliases {
+		c.aliases[id] = aliases
+		c.aliasLoadedAt[id] = loadedAt
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+package catalogcache_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestCatalogcache(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Catalogcache Suite")
+}
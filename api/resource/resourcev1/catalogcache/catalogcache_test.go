@@ -0,0 +1,194 @@
+package catalogcache
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/IBM-Cloud/bluemix-go/api/resource/resourcev1/catalog"
+	"github.com/IBM-Cloud/bluemix-go/models"
+)
+
+//fakeRepo serves a fixed catalog and counts the calls by method
+type fakeRepo struct {
+	catalog.ResourceCatalogRepository
+	services []models.Service
+	plans    map[string][]models.ServicePlan
+	calls    map[string]int
+}
+
+func (f *fakeRepo) ListServices(cb func(service models.Service) bool) error {
+	f.calls["ListServices"]++
+	for _, s := range f.services {
+		if !cb(s) {
+			break
+		}
+	}
+	return nil
+}
+
+func (f *fakeRepo) ListServicePlans(cb func(servicePlan models.ServicePlan) bool, service models.Service) error {
+	f.calls["ListServicePlans"]++
+	for _, p := range f.plans[service.ID] {
+		if !cb(p) {
+			break
+		}
+	}
+	return nil
+}
+
+func (f *fakeRepo) ListDeployments(servicePlanID string) ([]models.ServiceDeployment, error) {
+	f.calls["ListDeployments"]++
+	return []models.ServiceDeployment{{ID: "deployment-1", Name: "global"}}, nil
+}
+
+func (f *fakeRepo) GetServicePlan(servicePlanID string) (models.ServicePlan, error) {
+	f.calls["GetServicePlan"]++
+	for _, plans := range f.plans {
+		for _, p := range plans {
+			if p.ID == servicePlanID {
+				return p, nil
+			}
+		}
+	}
+	return models.ServicePlan{}, os.ErrNotExist
+}
+
+var _ = Describe("Cache", func() {
+	var repo *fakeRepo
+	var tmpDir string
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "catalogcache")
+		Expect(err).ShouldNot(HaveOccurred())
+		repo = &fakeRepo{
+			services: []models.Service{
+				{ID: "svc-1", Name: "cloudantnosqldb", Kind: "service"},
+				{ID: "svc-2", Name: "cloud-object-storage", Kind: "iaas"},
+			},
+			plans: map[string][]models.ServicePlan{
+				"svc-1": {{ID: "plan-1", Name: "lite", Kind: "plan"}, {ID: "plan-2", Name: "standard", Kind: "plan"}},
+				"svc-2": {{ID: "plan-3", Name: "lite", Kind: "plan"}},
+			},
+			calls: map[string]int{},
+		}
+	})
+	AfterEach(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	Describe("Lookups", func() {
+		It("should fetch each listing only once", func() {
+			cache := New(repo, time.Hour)
+
+			id, err := cache.GetServiceID("cloudantnosqldb")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(id).Should(Equal("svc-1"))
+			name, err := cache.GetServiceName("svc-2")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(name).Should(Equal("cloud-object-storage"))
+
+			service, err := cache.GetService("svc-1")
+			Expect(err).ShouldNot(HaveOccurred())
+			planID, err := cache.GetServicePlanID(service, "standard")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(planID).Should(Equal("plan-2"))
+			planName, err := cache.GetServicePlanName("plan-1")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(planName).Should(Equal("lite"))
+
+			Expect(repo.calls).Should(Equal(map[string]int{"ListServices": 1, "ListServicePlans": 1}))
+		})
+		It("should return an error for unknown names", func() {
+			cache := New(repo, time.Hour)
+
+			_, err := cache.GetServiceID("missing")
+			Expect(err).Should(HaveOccurred())
+			Expect(err.Error()).Should(ContainSubstring(catalog.ErrCodeServiceDoesnotExist))
+		})
+		It("should refetch plans looked up by ID once they expire", func() {
+			cache := New(repo, 20*time.Millisecond)
+
+			_, err := cache.GetServicePlanName("plan-3")
+			Expect(err).ShouldNot(HaveOccurred())
+			_, err = cache.GetServicePlanName("plan-3")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(repo.calls["GetServicePlan"]).Should(Equal(1))
+
+			repo.plans["svc-2"][0].Name = "standard"
+			time.Sleep(30 * time.Millisecond)
+			name, err := cache.GetServicePlanName("plan-3")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(name).Should(Equal("standard"))
+			Expect(repo.calls["GetServicePlan"]).Should(Equal(2))
+		})
+		It("should forget plans removed from a service when its plans are refetched", func() {
+			cache := New(repo, 20*time.Millisecond)
+			service, err := cache.GetService("svc-1")
+			Expect(err).ShouldNot(HaveOccurred())
+			_, err = cache.ListServicePlans(service)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			repo.plans["svc-1"] = repo.plans["svc-1"][:1]
+			time.Sleep(30 * time.Millisecond)
+			plans, err := cache.ListServicePlans(service)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(plans).Should(HaveLen(1))
+			Expect(cache.Snapshot().Plans).ShouldNot(HaveKey(""))
+
+			_, err = cache.GetServicePlanName("plan-2")
+			Expect(err).Should(HaveOccurred())
+			Expect(repo.calls["GetServicePlan"]).Should(Equal(1))
+		})
+	})
+
+	Describe("Snapshot", func() {
+		It("should serve lookups offline after a round trip", func() {
+			cache := New(repo, time.Hour)
+			service, err := cache.GetService("svc-1")
+			Expect(err).ShouldNot(HaveOccurred())
+			_, err = cache.ListServicePlans(service)
+			Expect(err).ShouldNot(HaveOccurred())
+			_, err = cache.GetServicePlanName("plan-3")
+			Expect(err).ShouldNot(HaveOccurred())
+			//the deployment has no target CRN
+			_, err = cache.ListDeployments("plan-1")
+			Expect(err).ShouldNot(HaveOccurred())
+
+			path := filepath.Join(tmpDir, "catalog.json")
+			Expect(cache.SaveSnapshot(path)).Should(Succeed())
+
+			offline, err := NewOffline(path)
+			Expect(err).ShouldNot(HaveOccurred())
+			id, err := offline.GetServiceID("cloud-object-storage")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(id).Should(Equal("svc-2"))
+			planID, err := offline.GetServicePlanID(service, "lite")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(planID).Should(Equal("plan-1"))
+			planName, err := offline.GetServicePlanName("plan-3")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(planName).Should(Equal("lite"))
+			_, err = offline.GetServicePlanName("plan-unknown")
+			Expect(err).Should(HaveOccurred())
+			deployments, err := offline.ListDeployments("plan-1")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(deployments).Should(HaveLen(1))
+
+			Expect(repo.calls).Should(Equal(map[string]int{"ListServices": 1, "ListServicePlans": 1,
+				"GetServicePlan": 1, "ListDeployments": 1}))
+		})
+		It("should reject snapshots of another version", func() {
+			path := filepath.Join(tmpDir, "catalog.json")
+			Expect(ioutil.WriteFile(path, []byte(`{"version":99}`), 0600)).Should(Succeed())
+
+			_, err := NewOffline(path)
+			Expect(err).Should(HaveOccurred())
+			Expect(err.Error()).Should(ContainSubstring(ErrCodeInvalidSnapshot))
+		})
+	})
+})
@@ -23,11 +23,12 @@ type CreateServiceInstanceRequest struct {
 }
 
 type UpdateServiceInstanceRequest struct {
-	Name          string                 `json:"name,omitempty"`
-	ServicePlanID string                 `json:"resource_plan_id,omitempty"`
-	Tags          []string               `json:"tags,omitempty"`
-	Parameters    map[string]interface{} `json:"parameters,omitempty"`
-	UpdateTime    int64                  `json:"update_time,omitempty"`
+	Name            string                 `json:"name,omitempty"`
+	ServicePlanID   string                 `json:"resource_plan_id,omitempty"`
+	ResourceGroupID string                 `json:"resource_group_id,omitempty"`
+	Tags            []string               `json:"tags,omitempty"`
+	Parameters      map[string]interface{} `json:"parameters,omitempty"`
+	UpdateTime      int64                  `json:"update_time,omitempty"`
 }
 
 type ServiceInstanceQuery struct {
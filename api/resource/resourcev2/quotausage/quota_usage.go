@@ -0,0 +1,189 @@
+//Package quotausage compares resource group usage with quota definitions
+package quotausage
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/IBM-Cloud/bluemix-go/api/resource/resourcev2/controllerv2"
+	"github.com/IBM-Cloud/bluemix-go/api/resource/resourcev2/managementv2"
+)
+
+//ResourceServiceInstances is the usage key for the total instance count
+const ResourceServiceInstances = "service_instances"
+
+//Level classifies how close a usage is to its limit
+type Level string
+
+//Usage levels, in increasing severity
+const (
+	LevelOK       Level = "ok"
+	LevelWarning  Level = "warning"
+	LevelCritical Level = "critical"
+	LevelExceeded Level = "exceeded"
+)
+
+//Thresholds are utilization percentages at which usages are flagged
+type Thresholds struct {
+	Warning  float64
+	Critical float64
+}
+
+//DefaultThresholds ...
+var DefaultThresholds = Thresholds{Warning: 80, Critical: 95}
+
+//level classifies percent. A usage at its limit is exceeded since nothing
+//more can be provisioned.
+func (t Thresholds) level(percent float64) Level {
+	switch {
+	case percent >= 100:
+		return LevelExceeded
+	case percent >= t.Critical:
+		return LevelCritical
+	case percent >= t.Warning:
+		return LevelWarning
+	}
+	return LevelOK
+}
+
+//Usage is the consumption of one quota limit
+type Usage struct {
+	//Resource is ResourceServiceInstances or a catalog service ID
+	Resource string  `json:"resource"`
+	Used     int     `json:"used"`
+	Limit    int     `json:"limit"`
+	Percent  float64 `json:"percent"`
+	Level    Level   `json:"level"`
+}
+
+//GroupReport is the quota utilization of one resource group
+type GroupReport struct {
+	GroupID   string  `json:"group_id"`
+	GroupName string  `json:"group_name"`
+	QuotaID   string  `json:"quota_id"`
+	QuotaName string  `json:"quota_name"`
+	Usages    []Usage `json:"usages"`
+}
+
+//Finding is a usage at or above the warning threshold
+type Finding struct {
+	GroupName string
+	Usage
+}
+
+//Report holds one GroupReport per resource group
+type Report struct {
+	Groups []GroupReport `json:"groups"`
+}
+
+//Findings returns every usage that is not LevelOK
+func (r Report) Findings() []Finding {
+	findings := []Finding{}
+	for _, g := range r.Groups {
+		for _, u := range g.Usages {
+			if u.Level != LevelOK {
+				findings = append(findings, Finding{GroupName: g.GroupName, Usage: u})
+			}
+		}
+	}
+	return findings
+}
+
+//Print writes the report as a table
+func (r Report) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "GROUP\tQUOTA\tRESOURCE\tUSED\tLIMIT\tPERCENT\tLEVEL")
+	for _, g := range r.Groups {
+		for _, u := range g.Usages {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%.1f%%\t%s\n", g.GroupName, g.QuotaName, u.Resource, u.Used, u.Limit, u.Percent, u.Level)
+		}
+	}
+	return tw.Flush()
+}
+
+//UsageReporter gathers usage from the resource controller
+type UsageReporter struct {
+	groups     managementv2.ResourceGroupRepository
+	quotas     managementv2.ResourceQuotaRepository
+	instances  controllerv2.ResourceServiceInstanceRepository
+	Thresholds Thresholds
+}
+
+//NewUsageReporter ...
+func NewUsageReporter(groups managementv2.ResourceGroupRepository, quotas managementv2.ResourceQuotaRepository, instances controllerv2.ResourceServiceInstanceRepository) *UsageReporter {
+	return &UsageReporter{
+		groups:     groups,
+		quotas:     quotas,
+		instances:  instances,
+		Thresholds: DefaultThresholds,
+	}
+}
+
+//Report builds the utilization report for every resource group in accountID.
+//Limits of zero mean unlimited and are left out of the report.
+func (r *UsageReporter) Report(accountID string) (Report, error) {
+	groups, err := r.groups.List(&managementv2.ResourceGroupQuery{AccountID: accountID})
+	if err != nil {
+		return Report{}, err
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Name < groups[j].Name
+	})
+
+	quotas := map[string]*managementv2.QuotaDefinition{}
+	report := Report{Groups: []GroupReport{}}
+	for _, group := range groups {
+		gr := GroupReport{
+			GroupID:   group.ID,
+			GroupName: group.Name,
+			QuotaID:   group.QuotaID,
+			Usages:    []Usage{},
+		}
+		if group.QuotaID == "" {
+			report.Groups = append(report.Groups, gr)
+			continue
+		}
+		quota, ok := quotas[group.QuotaID]
+		if !ok {
+			quota, err = r.quotas.Get(group.QuotaID)
+			if err != nil {
+				return Report{}, err
+			}
+			quotas[group.QuotaID] = quota
+		}
+		gr.QuotaName = quota.Name
+
+		instances, err := r.instances.ListInstances(controllerv2.ServiceInstanceQuery{ResourceGroupID: group.ID})
+		if err != nil {
+			return Report{}, err
+		}
+		byService := map[string]int{}
+		for _, instance := range instances {
+			byService[instance.ServiceID]++
+		}
+
+		if quota.ServiceInstanceCountLimit > 0 {
+			gr.Usages = append(gr.Usages, r.usage(ResourceServiceInstances, len(instances), quota.ServiceInstanceCountLimit))
+		}
+		for _, rq := range quota.ResourceQuotas {
+			if rq.Limit > 0 {
+				gr.Usages = append(gr.Usages, r.usage(rq.ResourceID, byService[rq.ResourceID], rq.Limit))
+			}
+		}
+		report.Groups = append(report.Groups, gr)
+	}
+	return report, nil
+}
+
+func (r *UsageReporter) usage(resource string, used, limit int) Usage {
+	percent := float64(used) * 100 / float64(limit)
+	return Usage{
+		Resource: resource,
+		Used:     used,
+		Limit:    limit,
+		Percent:  percent,
+		Level:    r.Thresholds.level(percent),
+	}
+}
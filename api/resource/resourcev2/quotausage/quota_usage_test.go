@@ -0,0 +1,108 @@
+package quotausage
+
+import (
+	"bytes"
+
+	"github.com/IBM-Cloud/bluemix-go/api/resource/resourcev2/controllerv2"
+	"github.com/IBM-Cloud/bluemix-go/api/resource/resourcev2/managementv2"
+	"github.com/IBM-Cloud/bluemix-go/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("UsageReporter", func() {
+	It("should compare instance counts against the group quota", func() {
+		reporter := NewUsageReporter(
+			&fakeGroups{groups: []models.ResourceGroupv2{
+				newGroup("rg-1", "prod", "q-1"),
+				newGroup("rg-2", "dev", ""),
+			}},
+			&fakeQuotas{quotas: map[string]managementv2.QuotaDefinition{
+				"q-1": {
+					ID:                        "q-1",
+					Name:                      "Trial",
+					ServiceInstanceCountLimit: 5,
+					ResourceQuotas: []managementv2.ResourceQuota{
+						{ResourceID: "kms", Limit: 1},
+						{ResourceID: "cloudantnosqldb", Limit: 10},
+					},
+				},
+			}},
+			&fakeInstances{byGroup: map[string][]string{
+				"rg-1": {"kms", "kms", "cloudantnosqldb", "cloud-object-storage"},
+			}},
+		)
+
+		report, err := reporter.Report("account")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(report.Groups).Should(HaveLen(2))
+		Expect(report.Groups[0].GroupName).Should(Equal("dev"))
+		Expect(report.Groups[0].Usages).Should(BeEmpty())
+
+		prod := report.Groups[1]
+		Expect(prod.QuotaName).Should(Equal("Trial"))
+		Expect(prod.Usages).Should(Equal([]Usage{
+			{Resource: ResourceServiceInstances, Used: 4, Limit: 5, Percent: 80, Level: LevelWarning},
+			{Resource: "kms", Used: 2, Limit: 1, Percent: 200, Level: LevelExceeded},
+			{Resource: "cloudantnosqldb", Used: 1, Limit: 10, Percent: 10, Level: LevelOK},
+		}))
+
+		findings := report.Findings()
+		Expect(findings).Should(HaveLen(2))
+		Expect(findings[1].Resource).Should(Equal("kms"))
+
+		var out bytes.Buffer
+		Expect(report.Print(&out)).Should(Succeed())
+		Expect(out.String()).Should(ContainSubstring("exceeded"))
+	})
+
+	It("should flag a usage at its limit as exceeded", func() {
+		Expect(DefaultThresholds.level(99.9)).Should(Equal(LevelCritical))
+		Expect(DefaultThresholds.level(100)).Should(Equal(LevelExceeded))
+		Expect(DefaultThresholds.level(80)).Should(Equal(LevelWarning))
+		Expect(DefaultThresholds.level(79.9)).Should(Equal(LevelOK))
+	})
+})
+
+func newGroup(id, name, quotaID string) models.ResourceGroupv2 {
+	group := models.ResourceGroupv2{}
+	group.ID = id
+	group.Name = name
+	group.QuotaID = quotaID
+	return group
+}
+
+type fakeGroups struct {
+	managementv2.ResourceGroupRepository
+	groups []models.ResourceGroupv2
+}
+
+func (f *fakeGroups) List(query *managementv2.ResourceGroupQuery) ([]models.ResourceGroupv2, error) {
+	return f.groups, nil
+}
+
+type fakeQuotas struct {
+	managementv2.ResourceQuotaRepository
+	quotas map[string]managementv2.QuotaDefinition
+}
+
+func (f *fakeQuotas) Get(id string) (*managementv2.QuotaDefinition, error) {
+	quota := f.quotas[id]
+	return &quota, nil
+}
+
+type fakeInstances struct {
+	controllerv2.ResourceServiceInstanceRepository
+	byGroup map[string][]string
+}
+
+func (f *fakeInstances) ListInstances(query controllerv2.ServiceInstanceQuery) ([]models.ServiceInstanceV2, error) {
+	instances := []models.ServiceInstanceV2{}
+	for _, serviceID := range f.byGroup[query.ResourceGroupID] {
+		instance := models.ServiceInstanceV2{}
+		instance.ServiceID = serviceID
+		instances = append(instances, instance)
+	}
+	return instances, nil
+}
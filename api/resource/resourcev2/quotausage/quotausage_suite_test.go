@@ -0,0 +1,13 @@
+package quotausage_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestQuotaUsage(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Quota Usage Suite")
+}
@@ -0,0 +1,143 @@
+package rehome
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/IBM-Cloud/bluemix-go/api/resource/resourcev1/controller"
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+)
+
+//ErrCodeMoveFailed ...
+const ErrCodeMoveFailed = "RehomeMoveFailed"
+
+//Journal entry statuses
+const (
+	StatusMoved  = "moved"
+	StatusFailed = "failed"
+)
+
+//JournalEntry records the outcome of one move
+type JournalEntry struct {
+	Move
+	Status string    `json:"status"`
+	Error  string    `json:"error,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+//Journal records every move attempted by an Executor
+type Journal struct {
+	Entries []JournalEntry `json:"entries"`
+}
+
+//Failed returns the entries that were not applied
+func (j Journal) Failed() []JournalEntry {
+	failed := []JournalEntry{}
+	for _, e := range j.Entries {
+		if e.Status != StatusMoved {
+			failed = append(failed, e)
+		}
+	}
+	return failed
+}
+
+//Reverse returns a plan that moves every applied instance back to the group
+//it came from
+func (j Journal) Reverse() Plan {
+	plan := Plan{Moves: []Move{}}
+	for _, e := range j.Entries {
+		if e.Status != StatusMoved {
+			continue
+		}
+		m := e.Move
+		m.FromGroupID, m.ToGroupID = m.ToGroupID, m.FromGroupID
+		m.FromGroupName, m.ToGroupName = m.ToGroupName, m.FromGroupName
+		plan.Moves = append(plan.Moves, m)
+	}
+	return plan
+}
+
+//Save writes the journal to path as JSON
+func (j Journal) Save(path string) error {
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+//LoadJournal reads a journal written by Save
+func LoadJournal(path string) (Journal, error) {
+	var j Journal
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return j, err
+	}
+	err = json.Unmarshal(data, &j)
+	return j, err
+}
+
+//Executor applies a Plan through the resource controller
+type Executor struct {
+	instances controller.ResourceServiceInstanceRepository
+	//Concurrency bounds the number of moves in flight. Values below one
+	//are treated as one.
+	Concurrency int
+}
+
+//NewExecutor ...
+func NewExecutor(instances controller.ResourceServiceInstanceRepository, concurrency int) *Executor {
+	return &Executor{
+		instances:   instances,
+		Concurrency: concurrency,
+	}
+}
+
+//Execute applies every move in plan. It returns the journal of all attempts
+//and an error if any move failed. Entries are in plan order.
+func (e *Executor) Execute(plan Plan) (Journal, error) {
+	workers := e.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	entries := make([]JournalEntry, len(plan.Moves))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, move := range plan.Moves {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, move Move) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			entries[i] = e.apply(move)
+		}(i, move)
+	}
+	wg.Wait()
+
+	journal := Journal{Entries: entries}
+	if failed := journal.Failed(); len(failed) > 0 {
+		return journal, bmxerror.New(ErrCodeMoveFailed,
+			fmt.Sprintf("%d of %d moves failed, first failure: %s: %s", len(failed), len(entries), failed[0].InstanceName, failed[0].Error))
+	}
+	return journal, nil
+}
+
+func (e *Executor) apply(move Move) JournalEntry {
+	entry := JournalEntry{Move: move, Status: StatusFailed}
+	instance, err := e.instances.UpdateInstance(move.InstanceID, controller.UpdateServiceInstanceRequest{
+		ResourceGroupID: move.ToGroupID,
+	})
+	entry.Time = time.Now().UTC()
+	switch {
+	case err != nil:
+		entry.Error = err.Error()
+	case instance.ResourceGroupID != move.ToGroupID:
+		entry.Error = fmt.Sprintf("instance is still in resource group %q", instance.ResourceGroupID)
+	default:
+		entry.Status = StatusMoved
+	}
+	return entry
+}
@@ -0,0 +1,223 @@
+//Package rehome plans and executes moves of service instances between
+//resource groups
+package rehome
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/IBM-Cloud/bluemix-go/api/resource/resourcev2/controllerv2"
+	"github.com/IBM-Cloud/bluemix-go/api/resource/resourcev2/managementv2"
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+	"github.com/IBM-Cloud/bluemix-go/models"
+)
+
+//ErrCodeInvalidRule ...
+const ErrCodeInvalidRule = "InvalidRehomeRule"
+
+//Rule selects instances and names the resource group they belong in.
+//All non-empty selectors must match. Rules are evaluated in order and the
+//first matching rule wins.
+type Rule struct {
+	Tag         string
+	ServiceID   string
+	NamePattern string
+
+	TargetGroupID   string
+	TargetGroupName string
+}
+
+//Move is a single planned instance move
+type Move struct {
+	InstanceID    string `json:"instance_id"`
+	InstanceName  string `json:"instance_name"`
+	CRN           string `json:"crn"`
+	FromGroupID   string `json:"from_group_id"`
+	FromGroupName string `json:"from_group_name,omitempty"`
+	ToGroupID     string `json:"to_group_id"`
+	ToGroupName   string `json:"to_group_name,omitempty"`
+	Rule          int    `json:"rule"`
+}
+
+//Plan is the ordered list of moves computed by a Planner
+type Plan struct {
+	Moves []Move `json:"moves"`
+	//Unchanged counts matched instances already in their target group
+	Unchanged int `json:"unchanged"`
+	//Unmatched counts instances no rule selected
+	Unmatched int `json:"unmatched"`
+}
+
+//Planner computes which instances need to move
+type Planner struct {
+	instances controllerv2.ResourceServiceInstanceRepository
+	groups    managementv2.ResourceGroupRepository
+}
+
+//NewPlanner ...
+func NewPlanner(instances controllerv2.ResourceServiceInstanceRepository, groups managementv2.ResourceGroupRepository) *Planner {
+	return &Planner{
+		instances: instances,
+		groups:    groups,
+	}
+}
+
+type compiledRule struct {
+	Rule
+	name *regexp.Regexp
+}
+
+func (r compiledRule) matches(instance models.ServiceInstanceV2) bool {
+	if r.ServiceID != "" && instance.ServiceID != r.ServiceID {
+		return false
+	}
+	if r.name != nil && !r.name.MatchString(instance.Name) {
+		return false
+	}
+	if r.Tag != "" {
+		found := false
+		for _, tag := range instance.Tags {
+			if tag == r.Tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+//Plan lists the instances selected by query and returns the moves needed to
+//satisfy rules. Group names in rules are resolved to IDs within accountID.
+func (p *Planner) Plan(accountID string, query controllerv2.ServiceInstanceQuery, rules []Rule) (Plan, error) {
+	groupNames, err := p.groupNames(accountID)
+	if err != nil {
+		return Plan{}, err
+	}
+	compiled, err := compileRules(rules, groupNames)
+	if err != nil {
+		return Plan{}, err
+	}
+	instances, err := p.instances.ListInstances(query)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	plan := Plan{Moves: []Move{}}
+	for _, instance := range instances {
+		matched := -1
+		for i, rule := range compiled {
+			if rule.matches(instance) {
+				matched = i
+				break
+			}
+		}
+		if matched < 0 {
+			plan.Unmatched++
+			continue
+		}
+		target := compiled[matched].TargetGroupID
+		if instance.ResourceGroupID == target {
+			plan.Unchanged++
+			continue
+		}
+		plan.Moves = append(plan.Moves, Move{
+			InstanceID:    instanceID(instance),
+			InstanceName:  instance.Name,
+			CRN:           instance.Crn.String(),
+			FromGroupID:   instance.ResourceGroupID,
+			FromGroupName: groupNames[instance.ResourceGroupID],
+			ToGroupID:     target,
+			ToGroupName:   groupNames[target],
+			Rule:          matched,
+		})
+	}
+	sort.SliceStable(plan.Moves, func(i, j int) bool {
+		return plan.Moves[i].InstanceName < plan.Moves[j].InstanceName
+	})
+	return plan, nil
+}
+
+//groupNames maps resource group IDs to names
+func (p *Planner) groupNames(accountID string) (map[string]string, error) {
+	groups, err := p.groups.List(&managementv2.ResourceGroupQuery{AccountID: accountID})
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(groups))
+	for _, group := range groups {
+		names[group.ID] = group.Name
+	}
+	return names, nil
+}
+
+func compileRules(rules []Rule, groupNames map[string]string) ([]compiledRule, error) {
+	compiled := make([]compiledRule, 0, len(rules))
+	for i, rule := range rules {
+		if rule.Tag == "" && rule.ServiceID == "" && rule.NamePattern == "" {
+			return nil, bmxerror.New(ErrCodeInvalidRule,
+				fmt.Sprintf("Rule %d has no tag, service or name pattern", i))
+		}
+		c := compiledRule{Rule: rule}
+		if rule.NamePattern != "" {
+			re, err := regexp.Compile(rule.NamePattern)
+			if err != nil {
+				return nil, bmxerror.New(ErrCodeInvalidRule,
+					fmt.Sprintf("Rule %d has an invalid name pattern: %v", i, err))
+			}
+			c.name = re
+		}
+		if c.TargetGroupID == "" {
+			for id, name := range groupNames {
+				if name == rule.TargetGroupName {
+					c.TargetGroupID = id
+					break
+				}
+			}
+		}
+		if _, ok := groupNames[c.TargetGroupID]; !ok {
+			return nil, bmxerror.New(managementv2.ErrCodeResourceGroupDoesnotExist,
+				fmt.Sprintf("Rule %d targets resource group %q which doesn't exist", i, targetName(rule)))
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled, nil
+}
+
+//instanceID guards against instances listed without metadata
+func instanceID(instance models.ServiceInstanceV2) string {
+	if instance.MetadataType == nil {
+		return instance.Crn.String()
+	}
+	return instance.ID
+}
+
+func targetName(rule Rule) string {
+	if rule.TargetGroupID != "" {
+		return rule.TargetGroupID
+	}
+	return rule.TargetGroupName
+}
+
+//Print writes the plan as a table
+func (p Plan) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "INSTANCE\tFROM\tTO\tRULE")
+	for _, m := range p.Moves {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\n", m.InstanceName, groupLabel(m.FromGroupName, m.FromGroupID), groupLabel(m.ToGroupName, m.ToGroupID), m.Rule)
+	}
+	fmt.Fprintf(tw, "\n%d to move, %d already in place, %d unmatched\n", len(p.Moves), p.Unchanged, p.Unmatched)
+	return tw.Flush()
+}
+
+func groupLabel(name, id string) string {
+	if name == "" {
+		return id
+	}
+	return name
+}
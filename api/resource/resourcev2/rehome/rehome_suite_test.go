@@ -0,0 +1,13 @@
+package rehome_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestRehome(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Rehome Suite")
+}
@@ -0,0 +1,130 @@
+package rehome
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/IBM-Cloud/bluemix-go/api/resource/resourcev1/controller"
+	"github.com/IBM-Cloud/bluemix-go/api/resource/resourcev2/controllerv2"
+	"github.com/IBM-Cloud/bluemix-go/api/resource/resourcev2/managementv2"
+	"github.com/IBM-Cloud/bluemix-go/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Rehome", func() {
+	var planner *Planner
+	BeforeEach(func() {
+		planner = NewPlanner(&fakeInstances{instances: []models.ServiceInstanceV2{
+			newInstance("db-prod", "rg-default", "cloudantnosqldb", "env:prod"),
+			newInstance("db-dev", "rg-dev", "cloudantnosqldb", "env:dev"),
+			newInstance("cos-prod", "rg-default", "cloud-object-storage", "env:prod"),
+			newInstance("kms", "rg-default", "kms"),
+		}}, &fakeGroups{groups: []models.ResourceGroupv2{
+			newGroup("rg-default", "Default"),
+			newGroup("rg-dev", "dev"),
+			newGroup("rg-prod", "prod"),
+		}})
+	})
+
+	Describe("Plan", func() {
+		It("should move instances selected by the first matching rule", func() {
+			plan, err := planner.Plan("account", controllerv2.ServiceInstanceQuery{}, []Rule{
+				{Tag: "env:prod", TargetGroupName: "prod"},
+				{NamePattern: "-dev$", TargetGroupID: "rg-dev"},
+			})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(plan.Moves).Should(HaveLen(2))
+			Expect(plan.Moves[0].InstanceName).Should(Equal("cos-prod"))
+			Expect(plan.Moves[1].InstanceName).Should(Equal("db-prod"))
+			Expect(plan.Moves[1].FromGroupName).Should(Equal("Default"))
+			Expect(plan.Moves[1].ToGroupID).Should(Equal("rg-prod"))
+			Expect(plan.Unchanged).Should(Equal(1))
+			Expect(plan.Unmatched).Should(Equal(1))
+		})
+		It("should reject rules targeting unknown groups", func() {
+			_, err := planner.Plan("account", controllerv2.ServiceInstanceQuery{}, []Rule{
+				{ServiceID: "kms", TargetGroupName: "security"},
+			})
+			Expect(err).Should(HaveOccurred())
+		})
+		It("should reject rules without selectors", func() {
+			_, err := planner.Plan("account", controllerv2.ServiceInstanceQuery{}, []Rule{
+				{TargetGroupName: "prod"},
+			})
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+
+	Describe("Execute", func() {
+		It("should journal each move and build a reverse plan", func() {
+			plan, err := planner.Plan("account", controllerv2.ServiceInstanceQuery{}, []Rule{
+				{Tag: "env:prod", TargetGroupName: "prod"},
+			})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			updater := &fakeUpdater{fail: map[string]bool{"cos-prod": true}}
+			journal, err := NewExecutor(updater, 2).Execute(plan)
+			Expect(err).Should(HaveOccurred())
+			Expect(journal.Entries).Should(HaveLen(2))
+			Expect(journal.Entries[0].Status).Should(Equal(StatusFailed))
+			Expect(journal.Entries[1].Status).Should(Equal(StatusMoved))
+
+			reverse := journal.Reverse()
+			Expect(reverse.Moves).Should(HaveLen(1))
+			Expect(reverse.Moves[0].InstanceName).Should(Equal("db-prod"))
+			Expect(reverse.Moves[0].ToGroupID).Should(Equal("rg-default"))
+		})
+	})
+})
+
+func newInstance(name, groupID, serviceID string, tags ...string) models.ServiceInstanceV2 {
+	instance := models.ServiceInstanceV2{}
+	instance.MetadataType = &models.MetadataType{ID: name}
+	instance.Name = name
+	instance.ResourceGroupID = groupID
+	instance.ServiceID = serviceID
+	instance.Tags = tags
+	return instance
+}
+
+func newGroup(id, name string) models.ResourceGroupv2 {
+	group := models.ResourceGroupv2{}
+	group.ID = id
+	group.Name = name
+	return group
+}
+
+type fakeInstances struct {
+	controllerv2.ResourceServiceInstanceRepository
+	instances []models.ServiceInstanceV2
+}
+
+func (f *fakeInstances) ListInstances(query controllerv2.ServiceInstanceQuery) ([]models.ServiceInstanceV2, error) {
+	return f.instances, nil
+}
+
+type fakeGroups struct {
+	managementv2.ResourceGroupRepository
+	groups []models.ResourceGroupv2
+}
+
+func (f *fakeGroups) List(query *managementv2.ResourceGroupQuery) ([]models.ResourceGroupv2, error) {
+	return f.groups, nil
+}
+
+type fakeUpdater struct {
+	controller.ResourceServiceInstanceRepository
+	mu   sync.Mutex
+	fail map[string]bool
+}
+
+func (f *fakeUpdater) UpdateInstance(id string, req controller.UpdateServiceInstanceRequest) (models.ServiceInstance, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fail[id] {
+		return models.ServiceInstance{}, errors.New("update rejected")
+	}
+	return models.ServiceInstance{ResourceGroupID: req.ResourceGroupID}, nil
+}
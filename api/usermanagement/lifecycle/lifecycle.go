@@ -0,0 +1,211 @@
+//Package lifecycle onboards and offboards account users across user
+//management, IAM access groups and policies, and Cloud Foundry roles.
+package lifecycle
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/IBM-Cloud/bluemix-go/api/iam/iamv1"
+	"github.com/IBM-Cloud/bluemix-go/api/iampap/iampapv1"
+	"github.com/IBM-Cloud/bluemix-go/api/iamuum/iamuumv2"
+	"github.com/IBM-Cloud/bluemix-go/api/mccp/mccpv2"
+	"github.com/IBM-Cloud/bluemix-go/api/usermanagement/usermanagementv2"
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+	"github.com/IBM-Cloud/bluemix-go/models"
+)
+
+//Error codes
+const (
+	ErrCodeInvalidRequest     = "InvalidLifecycleRequest"
+	ErrCodeUserNotFound       = "UserNotFound"
+	ErrCodeOnboardFailed      = "OnboardFailed"
+	ErrCodeOffboardIncomplete = "OffboardIncomplete"
+)
+
+//Cloud Foundry roles
+const (
+	CFRoleOrgUser        = "OrgUser"
+	CFRoleOrgManager     = "OrgManager"
+	CFRoleOrgAuditor     = "OrgAuditor"
+	CFRoleBillingManager = "BillingManager"
+	CFRoleSpaceManager   = "SpaceManager"
+	CFRoleSpaceDeveloper = "SpaceDeveloper"
+	CFRoleSpaceAuditor   = "SpaceAuditor"
+)
+
+//Step actions
+const (
+	StepInvite           = "invite"
+	StepJoinAccessGroup  = "join-access-group"
+	StepCreatePolicy     = "create-policy"
+	StepAssignCFRole     = "assign-cf-role"
+	StepLeaveAccessGroup = "leave-access-group"
+	StepDeletePolicy     = "delete-policy"
+	StepUnassignCFRole   = "unassign-cf-role"
+	StepDeleteAPIKey     = "delete-api-key"
+	StepRemoveUser       = "remove-user"
+)
+
+//Lifecycle holds the clients used by the workflows. Organizations and
+//Spaces may be nil when the account does not use Cloud Foundry.
+type Lifecycle struct {
+	Users         usermanagementv2.Users
+	AccessGroups  iamuumv2.AccessGroupRepository
+	Members       iamuumv2.AccessGroupMemberRepositoryV2
+	Policies      iampapv1.IAMPolicy
+	APIKeys       iamv1.APIKeyRepository
+	ServiceIDs    iamv1.ServiceIDRepository
+	Organizations mccpv2.Organizations
+	Spaces        mccpv2.Spaces
+}
+
+//Step is one change made by a workflow
+type Step struct {
+	Action string
+	//Target is the access group, policy, API key or Cloud Foundry role
+	//changed
+	Target string
+	Err    error
+	//Compensated is set when the step was undone after a later step failed
+	Compensated     bool
+	CompensationErr error
+}
+
+//CFRole is a Cloud Foundry role of a user. SpaceGUID is empty for
+//organization roles.
+type CFRole struct {
+	Region    string
+	OrgGUID   string
+	OrgName   string
+	SpaceGUID string
+	SpaceName string
+	Role      string
+}
+
+func (r CFRole) String() string {
+	if r.SpaceGUID != "" {
+		return fmt.Sprintf("%s in space %s", r.Role, firstNonEmpty(r.SpaceName, r.SpaceGUID))
+	}
+	return fmt.Sprintf("%s in org %s", r.Role, firstNonEmpty(r.OrgName, r.OrgGUID))
+}
+
+//FindUser returns the account user with the given email or IAM ID
+func (l *Lifecycle) FindUser(accountID, emailOrIAMID string) (usermanagementv2.UserInfo, error) {
+	users, err := l.Users.ListUsers(accountID)
+	if err != nil {
+		return usermanagementv2.UserInfo{}, err
+	}
+	for _, u := range users {
+		if u.IamID == emailOrIAMID || strings.EqualFold(u.Email, emailOrIAMID) {
+			return u, nil
+		}
+	}
+	return usermanagementv2.UserInfo{}, bmxerror.New(ErrCodeUserNotFound,
+		fmt.Sprintf("User %s is not in account %s", emailOrIAMID, accountID))
+}
+
+func (l *Lifecycle) cfEnabled() bool {
+	return l.Organizations != nil && l.Spaces != nil
+}
+
+func (l *Lifecycle) associate(role CFRole, email string) error {
+	var err error
+	switch role.Role {
+	case CFRoleOrgUser:
+		_, err = l.Organizations.AssociateUser(role.OrgGUID, email)
+	case CFRoleOrgManager:
+		_, err = l.Organizations.AssociateManager(role.OrgGUID, email)
+	case CFRoleOrgAuditor:
+		_, err = l.Organizations.AssociateAuditor(role.OrgGUID, email)
+	case CFRoleBillingManager:
+		_, err = l.Organizations.AssociateBillingManager(role.OrgGUID, email)
+	case CFRoleSpaceManager:
+		_, err = l.Spaces.AssociateManager(role.SpaceGUID, email)
+	case CFRoleSpaceDeveloper:
+		_, err = l.Spaces.AssociateDeveloper(role.SpaceGUID, email)
+	case CFRoleSpaceAuditor:
+		_, err = l.Spaces.AssociateAuditor(role.SpaceGUID, email)
+	default:
+		err = bmxerror.New(ErrCodeInvalidRequest, fmt.Sprintf("Unknown Cloud Foundry role %q", role.Role))
+	}
+	return err
+}
+
+func (l *Lifecycle) disassociate(role CFRole, email string) error {
+	switch role.Role {
+	case CFRoleOrgUser:
+		return l.Organizations.DisassociateUser(role.OrgGUID, email)
+	case CFRoleOrgManager:
+		return l.Organizations.DisassociateManager(role.OrgGUID, email)
+	case CFRoleOrgAuditor:
+		return l.Organizations.DisassociateAuditor(role.OrgGUID, email)
+	case CFRoleBillingManager:
+		return l.Organizations.DisassociateBillingManager(role.OrgGUID, email)
+	case CFRoleSpaceManager:
+		return l.Spaces.DisassociateManager(role.SpaceGUID, email)
+	case CFRoleSpaceDeveloper:
+		return l.Spaces.DisassociateDeveloper(role.SpaceGUID, email)
+	case CFRoleSpaceAuditor:
+		return l.Spaces.DisassociateAuditor(role.SpaceGUID, email)
+	}
+	return bmxerror.New(ErrCodeInvalidRequest, fmt.Sprintf("Unknown Cloud Foundry role %q", role.Role))
+}
+
+//hasCFRole reports whether email already holds role
+func (l *Lifecycle) hasCFRole(role CFRole, email string) (bool, error) {
+	names := []string{}
+	switch role.Role {
+	case CFRoleOrgUser, CFRoleOrgManager, CFRoleOrgAuditor, CFRoleBillingManager:
+		list := map[string]func(string, ...string) ([]mccpv2.OrgRole, error){
+			CFRoleOrgUser:        l.Organizations.ListUsers,
+			CFRoleOrgManager:     l.Organizations.ListManager,
+			CFRoleOrgAuditor:     l.Organizations.ListAuditors,
+			CFRoleBillingManager: l.Organizations.ListBillingManager,
+		}[role.Role]
+		users, err := list(role.OrgGUID)
+		if err != nil {
+			return false, err
+		}
+		for _, u := range users {
+			names = append(names, u.UserName)
+		}
+	case CFRoleSpaceManager, CFRoleSpaceDeveloper, CFRoleSpaceAuditor:
+		list := map[string]func(string, ...string) ([]mccpv2.SpaceRole, error){
+			CFRoleSpaceManager:   l.Spaces.ListManagers,
+			CFRoleSpaceDeveloper: l.Spaces.ListDevelopers,
+			CFRoleSpaceAuditor:   l.Spaces.ListAuditors,
+		}[role.Role]
+		users, err := list(role.SpaceGUID)
+		if err != nil {
+			return false, err
+		}
+		for _, u := range users {
+			names = append(names, u.UserName)
+		}
+	}
+	for _, name := range names {
+		if strings.EqualFold(name, email) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func memberOf(members []models.AccessGroupMemberV2, iamID string) bool {
+	for _, m := range members {
+		if m.ID == iamID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,375 @@
+package lifecycle
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/IBM-Cloud/bluemix-go/api/iam/iamv1"
+	"github.com/IBM-Cloud/bluemix-go/api/iampap/iampapv1"
+	"github.com/IBM-Cloud/bluemix-go/api/iamuum/iamuumv2"
+	"github.com/IBM-Cloud/bluemix-go/api/mccp/mccpv2"
+	"github.com/IBM-Cloud/bluemix-go/api/usermanagement/usermanagementv2"
+	"github.com/IBM-Cloud/bluemix-go/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+//calls records every mutating call made on the fakes, in order
+type calls []string
+
+func (c *calls) add(s string) { *c = append(*c, s) }
+
+type fakeUsers struct {
+	usermanagementv2.Users
+	log     *calls
+	users   []usermanagementv2.UserInfo
+	invited usermanagementv2.UserInfo
+}
+
+func (f *fakeUsers) ListUsers(accountID string) ([]usermanagementv2.UserInfo, error) {
+	return f.users, nil
+}
+
+func (f *fakeUsers) InviteUsers(accountID string, invite usermanagementv2.UserInvite) (usermanagementv2.UserInvite, error) {
+	f.log.add("invite " + invite.Users[0].Email)
+	f.users = append(f.users, f.invited)
+	return invite, nil
+}
+
+func (f *fakeUsers) RemoveUsers(accountID, iamID string) error {
+	f.log.add("remove " + iamID)
+	return nil
+}
+
+type fakeGroups struct {
+	iamuumv2.AccessGroupRepository
+}
+
+func (f *fakeGroups) List(accountID string, params ...string) ([]models.AccessGroupV2, error) {
+	return []models.AccessGroupV2{
+		{AccessGroup: models.AccessGroup{ID: "AccessGroupId-dev", Name: "developers"}},
+		{AccessGroup: models.AccessGroup{ID: "AccessGroupId-ops", Name: "operators"}},
+	}, nil
+}
+
+type fakeMembers struct {
+	iamuumv2.AccessGroupMemberRepositoryV2
+	log     *calls
+	members map[string][]models.AccessGroupMemberV2
+	failAdd string
+}
+
+func (f *fakeMembers) List(groupID string) ([]models.AccessGroupMemberV2, error) {
+	return f.members[groupID], nil
+}
+
+func (f *fakeMembers) Add(groupID string, req iamuumv2.AddGroupMemberRequestV2) (iamuumv2.AddGroupMemberResponseV2, error) {
+	if groupID == f.failAdd {
+		return iamuumv2.AddGroupMemberResponseV2{}, errors.New("add failed")
+	}
+	f.log.add("join " + groupID)
+	return iamuumv2.AddGroupMemberResponseV2{}, nil
+}
+
+func (f *fakeMembers) Remove(groupID, iamID string) error {
+	f.log.add("leave " + groupID)
+	return nil
+}
+
+type fakePolicies struct {
+	iampapv1.IAMPolicy
+	log        *calls
+	existing   []iampapv1.AccessPolicyResponse
+	failCreate bool
+	failDelete bool
+}
+
+func (f *fakePolicies) Create(scope, userID string, req iampapv1.AccessPolicyRequest) (iampapv1.AccessPolicyResponse, string, error) {
+	if f.failCreate {
+		return iampapv1.AccessPolicyResponse{}, "", errors.New("create failed")
+	}
+	f.log.add("create-policy")
+	return iampapv1.AccessPolicyResponse{ID: "policy-1"}, "1-a", nil
+}
+
+func (f *fakePolicies) List(scope, userID string) (iampapv1.AccessPolicyListResponse, error) {
+	return iampapv1.AccessPolicyListResponse{Policies: f.existing}, nil
+}
+
+func (f *fakePolicies) Delete(scope, userID, policyID string) error {
+	if f.failDelete {
+		return errors.New("delete failed")
+	}
+	f.log.add("delete-policy " + policyID)
+	return nil
+}
+
+type fakeAPIKeys struct {
+	iamv1.APIKeyRepository
+	log *calls
+}
+
+//aliceCRN is what the API keys and service IDs of alice are bound to
+const aliceCRN = "crn:v1:bluemix:public:iam::a/acc:IBMid:user:alice"
+
+func (f *fakeAPIKeys) List(boundTo string) ([]models.APIKey, error) {
+	if boundTo != aliceCRN {
+		return nil, fmt.Errorf("unexpected boundTo %s", boundTo)
+	}
+	return []models.APIKey{{UUID: "ApiKey-1", Name: "laptop", BoundTo: boundTo}}, nil
+}
+
+func (f *fakeAPIKeys) Delete(uuid string) error {
+	f.log.add("delete-key " + uuid)
+	return nil
+}
+
+type fakeServiceIDs struct {
+	iamv1.ServiceIDRepository
+}
+
+func (f *fakeServiceIDs) List(boundTo string) ([]models.ServiceID, error) {
+	if boundTo != aliceCRN {
+		return nil, fmt.Errorf("unexpected boundTo %s", boundTo)
+	}
+	return []models.ServiceID{{UUID: "ServiceId-1", Name: "ci", BoundTo: boundTo}}, nil
+}
+
+type fakeOrgs struct {
+	mccpv2.Organizations
+	log *calls
+}
+
+func (f *fakeOrgs) List(region string) ([]mccpv2.Organization, error) {
+	return []mccpv2.Organization{{GUID: "org-1", Name: "acme", Region: region}}, nil
+}
+
+func (f *fakeOrgs) ListManager(orgGUID string, filters ...string) ([]mccpv2.OrgRole, error) {
+	return []mccpv2.OrgRole{{UserName: "ALICE@example.com"}}, nil
+}
+
+func (f *fakeOrgs) ListBillingManager(orgGUID string, filters ...string) ([]mccpv2.OrgRole, error) {
+	return nil, nil
+}
+
+func (f *fakeOrgs) ListAuditors(orgGUID string, filters ...string) ([]mccpv2.OrgRole, error) {
+	return []mccpv2.OrgRole{{UserName: "bob@example.com"}}, nil
+}
+
+func (f *fakeOrgs) ListUsers(orgGUID string, filters ...string) ([]mccpv2.OrgRole, error) {
+	return []mccpv2.OrgRole{{UserName: "alice@example.com"}}, nil
+}
+
+func (f *fakeOrgs) AssociateUser(orgGUID, email string) (*mccpv2.OrganizationFields, error) {
+	f.log.add("org-user " + orgGUID)
+	return nil, nil
+}
+
+func (f *fakeOrgs) AssociateManager(orgGUID, email string) (*mccpv2.OrganizationFields, error) {
+	f.log.add("org-manager " + orgGUID)
+	return nil, nil
+}
+
+func (f *fakeOrgs) DisassociateUser(orgGUID, email string) error {
+	f.log.add("remove-org-user " + orgGUID)
+	return nil
+}
+
+func (f *fakeOrgs) DisassociateManager(orgGUID, email string) error {
+	f.log.add("remove-org-manager " + orgGUID)
+	return nil
+}
+
+type fakeSpaces struct {
+	mccpv2.Spaces
+	log *calls
+}
+
+func (f *fakeSpaces) ListSpacesInOrg(orgGUID, region string) ([]mccpv2.Space, error) {
+	return []mccpv2.Space{{GUID: "space-1", Name: "dev", OrgGUID: orgGUID}}, nil
+}
+
+func (f *fakeSpaces) ListManagers(spaceGUID string, filters ...string) ([]mccpv2.SpaceRole, error) {
+	return nil, nil
+}
+
+func (f *fakeSpaces) ListDevelopers(spaceGUID string, filters ...string) ([]mccpv2.SpaceRole, error) {
+	return []mccpv2.SpaceRole{{UserName: "alice@example.com"}}, nil
+}
+
+func (f *fakeSpaces) ListAuditors(spaceGUID string, filters ...string) ([]mccpv2.SpaceRole, error) {
+	return nil, nil
+}
+
+func (f *fakeSpaces) AssociateDeveloper(spaceGUID, email string) (*mccpv2.SpaceFields, error) {
+	f.log.add("space-developer " + spaceGUID)
+	return nil, nil
+}
+
+func (f *fakeSpaces) DisassociateDeveloper(spaceGUID, email string) error {
+	f.log.add("remove-space-developer " + spaceGUID)
+	return nil
+}
+
+var _ = Describe("Lifecycle", func() {
+	var (
+		log      calls
+		users    *fakeUsers
+		members  *fakeMembers
+		policies *fakePolicies
+		l        *Lifecycle
+		alice    = usermanagementv2.UserInfo{IamID: "IBMid-alice", Email: "alice@example.com"}
+	)
+	BeforeEach(func() {
+		log = calls{}
+		users = &fakeUsers{log: &log, invited: alice}
+		members = &fakeMembers{log: &log, members: map[string][]models.AccessGroupMemberV2{}}
+		policies = &fakePolicies{log: &log}
+		l = &Lifecycle{
+			Users:         users,
+			AccessGroups:  &fakeGroups{},
+			Members:       members,
+			Policies:      policies,
+			APIKeys:       &fakeAPIKeys{log: &log},
+			ServiceIDs:    &fakeServiceIDs{},
+			Organizations: &fakeOrgs{log: &log},
+			Spaces:        &fakeSpaces{log: &log},
+		}
+	})
+
+	Describe("Onboard", func() {
+		request := OnboardRequest{
+			AccountID:    "acc",
+			Email:        "alice@example.com",
+			AccessGroups: []string{"AccessGroupId-dev"},
+			Policies:     []iampapv1.AccessPolicyRequest{{}},
+			CFRoles: []CFRole{
+				{OrgGUID: "org-1", SpaceGUID: "space-1", Role: CFRoleSpaceDeveloper},
+				{OrgGUID: "org-1", Role: CFRoleOrgManager},
+			},
+		}
+		It("should invite the user and grant access in order", func() {
+			result, err := l.Onboard(request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.User.IamID).To(Equal("IBMid-alice"))
+			Expect(result.RolledBack).To(BeFalse())
+			Expect([]string(log)).To(Equal([]string{
+				"invite alice@example.com",
+				"join AccessGroupId-dev",
+				"create-policy",
+				"org-user org-1",
+				"space-developer space-1",
+				"org-manager org-1",
+			}))
+			Expect(result.Steps).To(HaveLen(6))
+		})
+		It("should undo completed steps when one fails", func() {
+			policies.failCreate = true
+			result, err := l.Onboard(request)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("create failed"))
+			Expect(result.RolledBack).To(BeTrue())
+			Expect([]string(log)).To(Equal([]string{
+				"invite alice@example.com",
+				"join AccessGroupId-dev",
+				"leave AccessGroupId-dev",
+				"remove IBMid-alice",
+			}))
+			Expect(result.Steps).To(HaveLen(3))
+			Expect(result.Steps[0].Compensated).To(BeTrue())
+			Expect(result.Steps[1].Compensated).To(BeTrue())
+			Expect(result.Steps[2].Err).To(HaveOccurred())
+		})
+		It("should not invite or remove an existing user", func() {
+			users.users = []usermanagementv2.UserInfo{alice}
+			members.failAdd = "AccessGroupId-dev"
+			_, err := l.Onboard(request)
+			Expect(err).To(HaveOccurred())
+			Expect([]string(log)).To(BeEmpty())
+		})
+		It("should only undo the access an existing user did not have before", func() {
+			users.users = []usermanagementv2.UserInfo{alice}
+			members.members["AccessGroupId-dev"] = []models.AccessGroupMemberV2{{ID: "IBMid-alice", Type: "user"}}
+			req := request
+			req.AccessGroups = []string{"AccessGroupId-dev", "AccessGroupId-ops"}
+			policies.failCreate = true
+			result, err := l.Onboard(req)
+			Expect(err).To(HaveOccurred())
+			Expect([]string(log)).To(Equal([]string{
+				"join AccessGroupId-ops",
+				"leave AccessGroupId-ops",
+			}))
+			Expect(result.Steps).To(HaveLen(2))
+			Expect(result.Steps[0].Target).To(Equal("AccessGroupId-ops"))
+		})
+		It("should not assign Cloud Foundry roles an existing user has", func() {
+			users.users = []usermanagementv2.UserInfo{alice}
+			result, err := l.Onboard(request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect([]string(log)).To(Equal([]string{
+				"join AccessGroupId-dev",
+				"create-policy",
+			}))
+			Expect(result.Steps).To(HaveLen(2))
+		})
+		It("should require Cloud Foundry clients for Cloud Foundry roles", func() {
+			l.Organizations = nil
+			_, err := l.Onboard(request)
+			Expect(err).To(HaveOccurred())
+			Expect(log).To(BeEmpty())
+		})
+	})
+
+	Describe("Offboard", func() {
+		BeforeEach(func() {
+			users.users = []usermanagementv2.UserInfo{alice}
+			members.members["AccessGroupId-ops"] = []models.AccessGroupMemberV2{{ID: "IBMid-alice", Type: "user"}}
+			policies.existing = []iampapv1.AccessPolicyResponse{{ID: "policy-9"}}
+		})
+		It("should report what the user owns in a dry run", func() {
+			report, err := l.Offboard(OffboardRequest{AccountID: "acc", User: "IBMid-alice", Regions: []string{"us-south"}, DryRun: true})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.APIKeys).To(HaveLen(1))
+			Expect(report.ServiceIDs).To(HaveLen(1))
+			Expect(report.AccessGroups).To(Equal([]string{"AccessGroupId-ops"}))
+			Expect(report.Policies).To(Equal([]string{"policy-9"}))
+			Expect(report.CFRoles).To(Equal([]CFRole{
+				{Region: "us-south", OrgGUID: "org-1", OrgName: "acme", Role: CFRoleOrgManager},
+				{Region: "us-south", OrgGUID: "org-1", OrgName: "acme", Role: CFRoleOrgUser},
+				{Region: "us-south", OrgGUID: "org-1", OrgName: "acme", SpaceGUID: "space-1", SpaceName: "dev", Role: CFRoleSpaceDeveloper},
+			}))
+			Expect(log).To(BeEmpty())
+		})
+		It("should remove all access and then the user", func() {
+			report, err := l.Offboard(OffboardRequest{AccountID: "acc", User: "alice@example.com", Regions: []string{"us-south"}, DeleteAPIKeys: true})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Removed).To(BeTrue())
+			Expect([]string(log)).To(Equal([]string{
+				"remove-space-developer space-1",
+				"remove-org-manager org-1",
+				"remove-org-user org-1",
+				"leave AccessGroupId-ops",
+				"delete-policy policy-9",
+				"delete-key ApiKey-1",
+				"remove IBMid-alice",
+			}))
+		})
+		It("should keep the user when a step fails", func() {
+			policies.failDelete = true
+			report, err := l.Offboard(OffboardRequest{AccountID: "acc", User: "alice@example.com"})
+			Expect(err).To(HaveOccurred())
+			Expect(report.Removed).To(BeFalse())
+			Expect([]string(log)).To(Equal([]string{"leave AccessGroupId-ops"}))
+		})
+		It("should fail for unknown users", func() {
+			_, err := l.Offboard(OffboardRequest{AccountID: "acc", User: "nobody@example.com"})
+			Expect(err).To(HaveOccurred())
+		})
+		It("should bind API keys to the realm and ID of the user", func() {
+			boundTo, err := userCRN("acc", usermanagementv2.UserInfo{IamID: "IBMid-270004WA4U", Realm: "IBMid"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(boundTo.String()).To(Equal("crn:v1:bluemix:public:iam::a/acc:IBMid:user:270004WA4U"))
+		})
+	})
+})
@@ -0,0 +1,254 @@
+package lifecycle
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/IBM-Cloud/bluemix-go/api/mccp/mccpv2"
+	"github.com/IBM-Cloud/bluemix-go/api/usermanagement/usermanagementv2"
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+	"github.com/IBM-Cloud/bluemix-go/crn"
+	"github.com/IBM-Cloud/bluemix-go/models"
+)
+
+//OffboardRequest selects the user to remove
+type OffboardRequest struct {
+	AccountID string
+	//User is the email or IAM ID of the user
+	User string
+	//Regions are searched for Cloud Foundry organizations
+	Regions []string
+	//DeleteAPIKeys deletes the user's API keys. They are only reported
+	//otherwise.
+	DeleteAPIKeys bool
+	//DryRun reports what the user owns without changing anything
+	DryRun bool
+}
+
+//OffboardReport lists everything the user owned and what was done about it
+type OffboardReport struct {
+	User    usermanagementv2.UserInfo
+	APIKeys []models.APIKey
+	//ServiceIDs are the service IDs bound to the user. Service IDs bound to
+	//the account do not record who created them.
+	ServiceIDs   []models.ServiceID
+	CFRoles      []CFRole
+	AccessGroups []string
+	Policies     []string
+	Steps        []Step
+	//Removed is set once the user has been removed from the account
+	Removed bool
+}
+
+//Offboard reports the API keys, service IDs, Cloud Foundry roles, access
+//groups and policies of a user, then removes all access and the user.
+//Failed steps do not stop the workflow, but the user is removed only once
+//every other step succeeded, so a second run can finish the clean up.
+func (l *Lifecycle) Offboard(req OffboardRequest) (OffboardReport, error) {
+	report := OffboardReport{
+		APIKeys:      []models.APIKey{},
+		ServiceIDs:   []models.ServiceID{},
+		CFRoles:      []CFRole{},
+		AccessGroups: []string{},
+		Policies:     []string{},
+		Steps:        []Step{},
+	}
+	user, err := l.FindUser(req.AccountID, req.User)
+	if err != nil {
+		return report, err
+	}
+	report.User = user
+
+	if err := l.inventory(req, &report); err != nil {
+		return report, err
+	}
+	if req.DryRun {
+		return report, nil
+	}
+
+	failed := 0
+	record := func(action, target string, err error) {
+		report.Steps = append(report.Steps, Step{Action: action, Target: target, Err: err})
+		if err != nil {
+			failed++
+		}
+	}
+	//Space roles go before organization roles, and OrgUser goes last
+	//because Cloud Foundry refuses to remove it while others remain
+	for _, pass := range []func(CFRole) bool{
+		func(r CFRole) bool { return r.SpaceGUID != "" },
+		func(r CFRole) bool { return r.SpaceGUID == "" && r.Role != CFRoleOrgUser },
+		func(r CFRole) bool { return r.Role == CFRoleOrgUser },
+	} {
+		for _, role := range report.CFRoles {
+			if pass(role) {
+				record(StepUnassignCFRole, role.String(), l.disassociate(role, user.Email))
+			}
+		}
+	}
+	for _, groupID := range report.AccessGroups {
+		record(StepLeaveAccessGroup, groupID, l.Members.Remove(groupID, user.IamID))
+	}
+	for _, policyID := range report.Policies {
+		record(StepDeletePolicy, policyID, l.Policies.Delete(req.AccountID, user.IamID, policyID))
+	}
+	if req.DeleteAPIKeys {
+		for _, key := range report.APIKeys {
+			record(StepDeleteAPIKey, key.UUID, l.APIKeys.Delete(key.UUID))
+		}
+	}
+	if failed > 0 {
+		return report, bmxerror.New(ErrCodeOffboardIncomplete,
+			fmt.Sprintf("%d steps failed, user %s was not removed", failed, firstNonEmpty(user.Email, user.IamID)))
+	}
+	err = l.Users.RemoveUsers(req.AccountID, user.IamID)
+	record(StepRemoveUser, firstNonEmpty(user.Email, user.IamID), err)
+	if err != nil {
+		return report, err
+	}
+	report.Removed = true
+	return report, nil
+}
+
+//userCRN returns the CRN API keys and service IDs of user are bound to,
+//such as crn:v1:bluemix:public:iam::a/<account>:IBMid:user:<id>
+func userCRN(accountID string, user usermanagementv2.UserInfo) (crn.CRN, error) {
+	realm := user.Realm
+	if realm == "" {
+		realm = strings.SplitN(user.IamID, "-", 2)[0]
+	}
+	return crn.NewBuilder().Service(crn.ServiceIAM).Account(accountID).
+		Instance(realm).Resource(crn.ResourceTypeUser, strings.TrimPrefix(user.IamID, realm+"-")).Build()
+}
+
+//inventory fills the report with what the user owns
+func (l *Lifecycle) inventory(req OffboardRequest, report *OffboardReport) error {
+	user := report.User
+	boundTo, err := userCRN(req.AccountID, user)
+	if err != nil {
+		return err
+	}
+	if l.APIKeys != nil {
+		keys, err := l.APIKeys.List(boundTo.String())
+		if err != nil {
+			return err
+		}
+		report.APIKeys = append(report.APIKeys, keys...)
+	}
+	if l.ServiceIDs != nil {
+		ids, err := l.ServiceIDs.List(boundTo.String())
+		if err != nil {
+			return err
+		}
+		report.ServiceIDs = append(report.ServiceIDs, ids...)
+	}
+
+	groups, err := l.AccessGroups.List(req.AccountID)
+	if err != nil {
+		return err
+	}
+	for _, g := range groups {
+		members, err := l.Members.List(g.ID)
+		if err != nil {
+			return err
+		}
+		if memberOf(members, user.IamID) {
+			report.AccessGroups = append(report.AccessGroups, g.ID)
+		}
+	}
+
+	policies, err := l.Policies.List(req.AccountID, user.IamID)
+	if err != nil {
+		return err
+	}
+	for _, p := range policies.Policies {
+		report.Policies = append(report.Policies, p.ID)
+	}
+
+	if l.cfEnabled() && user.Email != "" {
+		roles, err := l.cfRoles(req.Regions, user.Email)
+		if err != nil {
+			return err
+		}
+		report.CFRoles = roles
+	}
+	return nil
+}
+
+func (l *Lifecycle) cfRoles(regions []string, email string) ([]CFRole, error) {
+	roles := []CFRole{}
+	has := func(list []string) bool {
+		for _, name := range list {
+			if strings.EqualFold(name, email) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, region := range regions {
+		orgs, err := l.Organizations.List(region)
+		if err != nil {
+			return nil, err
+		}
+		for _, org := range orgs {
+			orgListers := []struct {
+				role string
+				list func(string, ...string) ([]mccpv2.OrgRole, error)
+			}{
+				{CFRoleOrgManager, l.Organizations.ListManager},
+				{CFRoleBillingManager, l.Organizations.ListBillingManager},
+				{CFRoleOrgAuditor, l.Organizations.ListAuditors},
+				{CFRoleOrgUser, l.Organizations.ListUsers},
+			}
+			for _, lister := range orgListers {
+				users, err := lister.list(org.GUID)
+				if err != nil {
+					return nil, err
+				}
+				names := make([]string, 0, len(users))
+				for _, u := range users {
+					names = append(names, u.UserName)
+				}
+				if has(names) {
+					roles = append(roles, CFRole{Region: region, OrgGUID: org.GUID, OrgName: org.Name, Role: lister.role})
+				}
+			}
+
+			spaces, err := l.Spaces.ListSpacesInOrg(org.GUID, region)
+			if err != nil {
+				return nil, err
+			}
+			for _, space := range spaces {
+				spaceListers := []struct {
+					role string
+					list func(string, ...string) ([]mccpv2.SpaceRole, error)
+				}{
+					{CFRoleSpaceManager, l.Spaces.ListManagers},
+					{CFRoleSpaceDeveloper, l.Spaces.ListDevelopers},
+					{CFRoleSpaceAuditor, l.Spaces.ListAuditors},
+				}
+				for _, lister := range spaceListers {
+					users, err := lister.list(space.GUID)
+					if err != nil {
+						return nil, err
+					}
+					names := make([]string, 0, len(users))
+					for _, u := range users {
+						names = append(names, u.UserName)
+					}
+					if has(names) {
+						roles = append(roles, CFRole{
+							Region:    region,
+							OrgGUID:   org.GUID,
+							OrgName:   org.Name,
+							SpaceGUID: space.GUID,
+							SpaceName: space.Name,
+							Role:      lister.role,
+						})
+					}
+				}
+			}
+		}
+	}
+	return roles, nil
+}
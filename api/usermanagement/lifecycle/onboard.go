@@ -0,0 +1,196 @@
+package lifecycle
+
+import (
+	"fmt"
+
+	"github.com/IBM-Cloud/bluemix-go/api/iampap/iampapv1"
+	"github.com/IBM-Cloud/bluemix-go/api/iamuum/iamuumv2"
+	"github.com/IBM-Cloud/bluemix-go/api/usermanagement/usermanagementv2"
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+	"github.com/IBM-Cloud/bluemix-go/models"
+)
+
+//OnboardRequest describes a new user and the access to give them
+type OnboardRequest struct {
+	AccountID string
+	Email     string
+	//AccountRole defaults to Member
+	AccountRole string
+	//AccessGroups are access group IDs
+	AccessGroups []string
+	Policies     []iampapv1.AccessPolicyRequest
+	//CFRoles need only OrgGUID, SpaceGUID for space roles, and Role. The
+	//OrgUser role, which Cloud Foundry requires before any other, is added
+	//when missing.
+	CFRoles []CFRole
+}
+
+//OnboardResult records the steps taken
+type OnboardResult struct {
+	User  usermanagementv2.UserInfo
+	Steps []Step
+	//RolledBack is set when a step failed and the earlier ones were undone
+	RolledBack bool
+}
+
+type undo func() error
+
+//Onboard invites the user and grants the requested access. When a step
+//fails, the steps already done are undone in reverse order and the error is
+//returned along with a result describing both. A user who already belongs
+//to the account is not invited, and is not removed on failure; access groups
+//and Cloud Foundry roles they already have are left out of the steps, so a
+//failure never takes them away.
+func (l *Lifecycle) Onboard(req OnboardRequest) (OnboardResult, error) {
+	result := OnboardResult{Steps: []Step{}}
+	if req.AccountID == "" || req.Email == "" {
+		return result, bmxerror.New(ErrCodeInvalidRequest, "An account ID and an email are required")
+	}
+	cfRoles := withOrgUser(req.CFRoles)
+	if len(cfRoles) > 0 && !l.cfEnabled() {
+		return result, bmxerror.New(ErrCodeInvalidRequest, "Cloud Foundry roles requested but no Cloud Foundry client is configured")
+	}
+
+	var undos []undo
+	fail := func(step Step) (OnboardResult, error) {
+		result.Steps = append(result.Steps, step)
+		for i := len(undos) - 1; i >= 0; i-- {
+			if undos[i] == nil {
+				continue
+			}
+			result.Steps[i].Compensated = true
+			result.Steps[i].CompensationErr = undos[i]()
+		}
+		result.RolledBack = true
+		return result, bmxerror.New(ErrCodeOnboardFailed,
+			fmt.Sprintf("Onboarding %s failed at %s %s: %v", req.Email, step.Action, step.Target, step.Err))
+	}
+	done := func(step Step, u undo) {
+		result.Steps = append(result.Steps, step)
+		undos = append(undos, u)
+	}
+
+	user, err := l.FindUser(req.AccountID, req.Email)
+	existing := err == nil
+	if err != nil {
+		if !isNotFound(err) {
+			return result, err
+		}
+		role := req.AccountRole
+		if role == "" {
+			role = "Member"
+		}
+		_, err = l.Users.InviteUsers(req.AccountID, usermanagementv2.UserInvite{
+			Users: []usermanagementv2.User{{Email: req.Email, AccountRole: role}},
+		})
+		if err != nil {
+			return fail(Step{Action: StepInvite, Target: req.Email, Err: err})
+		}
+		user, err = l.FindUser(req.AccountID, req.Email)
+		if err != nil {
+			return fail(Step{Action: StepInvite, Target: req.Email, Err: err})
+		}
+		iamID := user.IamID
+		done(Step{Action: StepInvite, Target: req.Email}, func() error {
+			return l.Users.RemoveUsers(req.AccountID, iamID)
+		})
+	}
+	result.User = user
+
+	for _, groupID := range req.AccessGroups {
+		groupID := groupID
+		step := Step{Action: StepJoinAccessGroup, Target: groupID}
+		if existing {
+			members, err := l.Members.List(groupID)
+			if err != nil {
+				step.Err = err
+				return fail(step)
+			}
+			if memberOf(members, user.IamID) {
+				continue
+			}
+		}
+		resp, err := l.Members.Add(groupID, iamuumv2.AddGroupMemberRequestV2{
+			Members: []models.AccessGroupMemberV2{{ID: user.IamID, Type: "user"}},
+		})
+		if err == nil {
+			err = memberError(resp)
+		}
+		if err != nil {
+			step.Err = err
+			return fail(step)
+		}
+		done(step, func() error {
+			return l.Members.Remove(groupID, user.IamID)
+		})
+	}
+
+	for _, p := range req.Policies {
+		created, _, err := l.Policies.Create(req.AccountID, user.IamID, p)
+		if err != nil {
+			return fail(Step{Action: StepCreatePolicy, Err: err})
+		}
+		policyID := created.ID
+		done(Step{Action: StepCreatePolicy, Target: policyID}, func() error {
+			return l.Policies.Delete(req.AccountID, user.IamID, policyID)
+		})
+	}
+
+	for _, role := range cfRoles {
+		role := role
+		step := Step{Action: StepAssignCFRole, Target: role.String()}
+		if existing {
+			has, err := l.hasCFRole(role, req.Email)
+			if err != nil {
+				step.Err = err
+				return fail(step)
+			}
+			if has {
+				continue
+			}
+		}
+		if err := l.associate(role, req.Email); err != nil {
+			step.Err = err
+			return fail(step)
+		}
+		done(step, func() error {
+			return l.disassociate(role, req.Email)
+		})
+	}
+	return result, nil
+}
+
+//withOrgUser puts the OrgUser role of every organization first, adding it
+//when it was not requested
+func withOrgUser(roles []CFRole) []CFRole {
+	ordered := []CFRole{}
+	seen := map[string]bool{}
+	for _, r := range roles {
+		if !seen[r.OrgGUID] {
+			seen[r.OrgGUID] = true
+			ordered = append(ordered, CFRole{Region: r.Region, OrgGUID: r.OrgGUID, OrgName: r.OrgName, Role: CFRoleOrgUser})
+		}
+	}
+	for _, r := range roles {
+		if r.Role != CFRoleOrgUser {
+			ordered = append(ordered, r)
+		}
+	}
+	return ordered
+}
+
+func memberError(resp iamuumv2.AddGroupMemberResponseV2) error {
+	for _, m := range resp.Members {
+		if len(m.Errors) > 0 {
+			return bmxerror.New(m.Errors[0].Code, m.Errors[0].Message)
+		}
+	}
+	return nil
+}
+
+func isNotFound(err error) bool {
+	if e, ok := err.(bmxerror.Error); ok {
+		return e.Code() == ErrCodeUserNotFound
+	}
+	return false
+}
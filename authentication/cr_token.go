@@ -0,0 +1,156 @@
+package authentication
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	bluemix "github.com/IBM-Cloud/bluemix-go"
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+	"github.com/IBM-Cloud/bluemix-go/client"
+	"github.com/IBM-Cloud/bluemix-go/rest"
+)
+
+//ErrCodeCRTokenUnavailable ...
+const ErrCodeCRTokenUnavailable = "CRTokenUnavailable"
+
+//ErrCodeInvalidCRTokenProfile ...
+const ErrCodeInvalidCRTokenProfile = "InvalidCRTokenProfile"
+
+//ErrCodeUnsupportedGrant ...
+const ErrCodeUnsupportedGrant = "UnsupportedGrant"
+
+//DefaultCRTokenFiles are read in order when no token file is configured.
+//They are where IKS and OpenShift project service account tokens.
+var DefaultCRTokenFiles = []string{
+	"/var/run/secrets/tokens/vault-token",
+	"/var/run/secrets/tokens/sa-token",
+}
+
+//CRTokenProfile names the trusted profile to assume. Set ProfileID,
+//ProfileCRN, or ProfileName together with AccountID.
+type CRTokenProfile struct {
+	ProfileID   string
+	ProfileCRN  string
+	ProfileName string
+	AccountID   string
+	//TokenFile is the compute resource token. Defaults to the first of
+	//DefaultCRTokenFiles that exists.
+	TokenFile string
+}
+
+//CRTokenAuthRepository gets IAM tokens for a trusted profile by exchanging
+//the compute resource token of the workload it runs on. The CR token file
+//is read again on every refresh, as the platform rotates it.
+//
+//It implements client.TokenProvider, so it can replace IAMAuthRepository:
+//
+//	auth, err := authentication.NewCRTokenAuthRepository(config, restClient, profile)
+//	err = auth.Authenticate()
+//	c := client.New(config, bluemix.IAMService, auth)
+type CRTokenAuthRepository struct {
+	config   *bluemix.Config
+	client   *rest.Client
+	endpoint string
+	profile  CRTokenProfile
+}
+
+var _ client.TokenProvider = &CRTokenAuthRepository{}
+
+//NewCRTokenAuthRepository ...
+func NewCRTokenAuthRepository(config *bluemix.Config, client *rest.Client, profile CRTokenProfile) (*CRTokenAuthRepository, error) {
+	if profile.ProfileID == "" && profile.ProfileCRN == "" && (profile.ProfileName == "" || profile.AccountID == "") {
+		return nil, bmxerror.New(ErrCodeInvalidCRTokenProfile, "A profile ID, profile CRN, or profile name and account ID is required")
+	}
+	var endpoint string
+	if config.TokenProviderEndpoint != nil {
+		endpoint = *config.TokenProviderEndpoint
+	} else {
+		var err error
+		endpoint, err = config.EndpointLocator.IAMEndpoint()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &CRTokenAuthRepository{
+		config:   config,
+		client:   client,
+		endpoint: endpoint,
+		profile:  profile,
+	}, nil
+}
+
+//Authenticate exchanges the CR token for IAM tokens stored in the config
+func (auth *CRTokenAuthRepository) Authenticate() error {
+	crToken, err := auth.readCRToken()
+	if err != nil {
+		return err
+	}
+	request := rest.PostRequest(auth.endpoint+"/identity/token").
+		Field("grant_type", "urn:ibm:params:oauth:grant-type:cr-token").
+		Field("cr_token", crToken)
+	switch {
+	case auth.profile.ProfileID != "":
+		request.Field("profile_id", auth.profile.ProfileID)
+	case auth.profile.ProfileCRN != "":
+		request.Field("profile_crn", auth.profile.ProfileCRN)
+	default:
+		request.Field("profile_name", auth.profile.ProfileName).
+			Field("account_id", auth.profile.AccountID)
+	}
+
+	tokens, err := requestIAMToken(auth.client, request)
+	if err != nil {
+		return err
+	}
+	auth.config.IAMAccessToken = fmt.Sprintf("%s %s", tokens.TokenType, tokens.AccessToken)
+	auth.config.IAMRefreshToken = tokens.RefreshToken
+	return nil
+}
+
+func (auth *CRTokenAuthRepository) readCRToken() (string, error) {
+	files := DefaultCRTokenFiles
+	if auth.profile.TokenFile != "" {
+		files = []string{auth.profile.TokenFile}
+	}
+	var lastErr error
+	for _, f := range files {
+		data, err := ioutil.ReadFile(f)
+		if err != nil {
+			lastErr = err
+			if os.IsNotExist(err) {
+				continue
+			}
+			break
+		}
+		if token := strings.TrimSpace(string(data)); token != "" {
+			return token, nil
+		}
+		lastErr = fmt.Errorf("%s is empty", f)
+	}
+	return "", bmxerror.New(ErrCodeCRTokenUnavailable, fmt.Sprintf("Could not read the compute resource token: %v", lastErr))
+}
+
+//RefreshToken gets a new access token with the current CR token
+func (auth *CRTokenAuthRepository) RefreshToken() (string, error) {
+	if err := auth.Authenticate(); err != nil {
+		return "", err
+	}
+	return auth.config.IAMAccessToken, nil
+}
+
+//GetPasscode is not supported for trusted profiles
+func (auth *CRTokenAuthRepository) GetPasscode() (string, error) {
+	return "", bmxerror.New(ErrCodeUnsupportedGrant, "Passcodes can not be obtained with a compute resource token")
+}
+
+//AuthenticatePassword is not supported for trusted profiles
+func (auth *CRTokenAuthRepository) AuthenticatePassword(username string, password string) error {
+	return bmxerror.New(ErrCodeUnsupportedGrant, "A trusted profile can not authenticate with a password")
+}
+
+//AuthenticateAPIKey is not supported for trusted profiles
+func (auth *CRTokenAuthRepository) AuthenticateAPIKey(apiKey string) error {
+	return bmxerror.New(ErrCodeUnsupportedGrant, "A trusted profile can not authenticate with an API key")
+}
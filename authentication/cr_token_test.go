@@ -0,0 +1,152 @@
+package authentication
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	bluemix "github.com/IBM-Cloud/bluemix-go"
+	"github.com/IBM-Cloud/bluemix-go/bmxerror"
+	"github.com/IBM-Cloud/bluemix-go/rest"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const crTokenGrant = "urn:ibm:params:oauth:grant-type:cr-token"
+
+var _ = Describe("CRTokenAuthRepository", func() {
+	var server *ghttp.Server
+	var config *bluemix.Config
+	var tmpDir string
+	var defaultFiles []string
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		url := server.URL()
+		config = &bluemix.Config{TokenProviderEndpoint: &url}
+		var err error
+		tmpDir, err = ioutil.TempDir("", "crtoken")
+		Expect(err).ShouldNot(HaveOccurred())
+		defaultFiles = DefaultCRTokenFiles
+	})
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(tmpDir)
+		DefaultCRTokenFiles = defaultFiles
+	})
+
+	writeToken := func(name, token string) string {
+		path := filepath.Join(tmpDir, name)
+		Expect(ioutil.WriteFile(path, []byte(token), 0600)).Should(Succeed())
+		return path
+	}
+	respondWithToken := func(accessToken string) http.HandlerFunc {
+		return ghttp.RespondWith(http.StatusOK, `{
+			"access_token": "`+accessToken+`",
+			"refresh_token": "refresh",
+			"token_type": "Bearer"
+		}`)
+	}
+
+	Describe("New", func() {
+		It("should reject a profile without an ID, CRN or name and account", func() {
+			_, err := NewCRTokenAuthRepository(config, rest.NewClient(), CRTokenProfile{ProfileName: "deployer"})
+			Expect(err).Should(HaveOccurred())
+			Expect(err.(bmxerror.Error).Code()).Should(Equal(ErrCodeInvalidCRTokenProfile))
+		})
+	})
+
+	Describe("Authenticate", func() {
+		It("should send the grant type, CR token and profile ID", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodPost, "/identity/token"),
+					ghttp.VerifyFormKV("grant_type", crTokenGrant),
+					ghttp.VerifyFormKV("cr_token", "cr-token-1"),
+					ghttp.VerifyFormKV("profile_id", "Profile-1234"),
+					respondWithToken("access"),
+				),
+			)
+			auth, err := NewCRTokenAuthRepository(config, rest.NewClient(),
+				CRTokenProfile{ProfileID: "Profile-1234", TokenFile: writeToken("token", "cr-token-1\n")})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Expect(auth.Authenticate()).Should(Succeed())
+			Expect(config.IAMAccessToken).Should(Equal("Bearer access"))
+			Expect(config.IAMRefreshToken).Should(Equal("refresh"))
+		})
+		It("should send the profile name with the account ID", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodPost, "/identity/token"),
+					ghttp.VerifyFormKV("grant_type", crTokenGrant),
+					ghttp.VerifyFormKV("profile_name", "deployer"),
+					ghttp.VerifyFormKV("account_id", "acc"),
+					func(w http.ResponseWriter, r *http.Request) {
+						Expect(r.Form).ShouldNot(HaveKey("profile_id"))
+						Expect(r.Form).ShouldNot(HaveKey("profile_crn"))
+					},
+					respondWithToken("access"),
+				),
+			)
+			auth, err := NewCRTokenAuthRepository(config, rest.NewClient(),
+				CRTokenProfile{ProfileName: "deployer", AccountID: "acc", TokenFile: writeToken("token", "cr-token-1")})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Expect(auth.Authenticate()).Should(Succeed())
+		})
+		It("should fall back to the next default token file", func() {
+			DefaultCRTokenFiles = []string{filepath.Join(tmpDir, "missing"), writeToken("sa-token", "cr-token-2")}
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodPost, "/identity/token"),
+					ghttp.VerifyFormKV("cr_token", "cr-token-2"),
+					ghttp.VerifyFormKV("profile_crn", "crn:v1:bluemix:public:iam-identity::a/acc::profile:Profile-1234"),
+					respondWithToken("access"),
+				),
+			)
+			auth, err := NewCRTokenAuthRepository(config, rest.NewClient(),
+				CRTokenProfile{ProfileCRN: "crn:v1:bluemix:public:iam-identity::a/acc::profile:Profile-1234"})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Expect(auth.Authenticate()).Should(Succeed())
+		})
+		It("should fail without calling IAM when no token file can be read", func() {
+			DefaultCRTokenFiles = []string{filepath.Join(tmpDir, "missing"), writeToken("empty", " \n")}
+			auth, err := NewCRTokenAuthRepository(config, rest.NewClient(), CRTokenProfile{ProfileID: "Profile-1234"})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			err = auth.Authenticate()
+			Expect(err).Should(HaveOccurred())
+			Expect(err.(bmxerror.Error).Code()).Should(Equal(ErrCodeCRTokenUnavailable))
+			Expect(server.ReceivedRequests()).Should(BeEmpty())
+		})
+	})
+
+	Describe("RefreshToken", func() {
+		It("should read the token file again", func() {
+			path := writeToken("token", "cr-token-1")
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyFormKV("cr_token", "cr-token-1"),
+					respondWithToken("access-1"),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyFormKV("cr_token", "cr-token-2"),
+					respondWithToken("access-2"),
+				),
+			)
+			auth, err := NewCRTokenAuthRepository(config, rest.NewClient(),
+				CRTokenProfile{ProfileID: "Profile-1234", TokenFile: path})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(auth.Authenticate()).Should(Succeed())
+
+			writeToken("token", "cr-token-2")
+			token, err := auth.RefreshToken()
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(token).Should(Equal("Bearer access-2"))
+		})
+	})
+})
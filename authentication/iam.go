@@ -136,29 +136,38 @@ func (auth *IAMAuthRepository) getToken(data map[string]string) error {
 		request.Field(k, v)
 	}
 
+	tokens, err := requestIAMToken(auth.client, request)
+	if err != nil {
+		return err
+	}
+
+	auth.config.IAMAccessToken = fmt.Sprintf("%s %s", tokens.TokenType, tokens.AccessToken)
+	auth.config.IAMRefreshToken = tokens.RefreshToken
+
+	return nil
+}
+
+//requestIAMToken sends a token request and maps IAM errors
+func requestIAMToken(client *rest.Client, request *rest.Request) (IAMTokenResponse, error) {
 	var tokens IAMTokenResponse
 	var apiErr IAMError
 
-	resp, err := auth.client.Do(request, &tokens, &apiErr)
+	resp, err := client.Do(request, &tokens, &apiErr)
 	if err != nil {
-		return err
+		return tokens, err
 	}
 
 	if apiErr.ErrorCode != "" {
 		if apiErr.ErrorCode == "BXNIM0407E" {
 			if resp != nil && resp.Header != nil {
-				return bmxerror.New(ErrCodeInvalidToken, fmt.Sprintf("Transaction-Id:%s %s", resp.Header["Transaction-Id"], apiErr.Description()))
+				return tokens, bmxerror.New(ErrCodeInvalidToken, fmt.Sprintf("Transaction-Id:%s %s", resp.Header["Transaction-Id"], apiErr.Description()))
 			}
-			return bmxerror.New(ErrCodeInvalidToken, apiErr.Description())
+			return tokens, bmxerror.New(ErrCodeInvalidToken, apiErr.Description())
 		}
 		if resp != nil && resp.Header != nil {
-			return bmxerror.NewRequestFailure(apiErr.ErrorCode, fmt.Sprintf("Transaction-Id:%s %s", resp.Header["Transaction-Id"], apiErr.Description()), resp.StatusCode)
+			return tokens, bmxerror.NewRequestFailure(apiErr.ErrorCode, fmt.Sprintf("Transaction-Id:%s %s", resp.Header["Transaction-Id"], apiErr.Description()), resp.StatusCode)
 		}
-		return bmxerror.NewRequestFailure(apiErr.ErrorCode, apiErr.Description(), resp.StatusCode)
+		return tokens, bmxerror.NewRequestFailure(apiErr.ErrorCode, apiErr.Description(), resp.StatusCode)
 	}
-
-	auth.config.IAMAccessToken = fmt.Sprintf("%s %s", tokens.TokenType, tokens.AccessToken)
-	auth.config.IAMRefreshToken = tokens.RefreshToken
-
-	return nil
+	return tokens, nil
 }
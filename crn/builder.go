@@ -0,0 +1,134 @@
+package crn
+
+const (
+	ServiceResourceController = "resource-controller"
+	ServiceCOS                = "cloud-object-storage"
+	ServiceIAMIdentity        = "iam-identity"
+	ServiceIAMGroups          = "iam-groups"
+	ServiceKMS                = "kms"
+	ServiceCIS                = "internet-svcs"
+
+	ResourceTypeResourceGroup = "resource-group"
+	ResourceTypeBucket        = "bucket"
+	ResourceTypeServiceID     = "serviceid"
+	ResourceTypeUser          = "user"
+	ResourceTypeAccessGroup   = "access-group"
+	ResourceTypeKey           = "key"
+	ResourceTypeDomain        = "domain"
+)
+
+//Builder assembles a CRN segment by segment. Build validates the result.
+//
+//	c, err := crn.NewBuilder().Service(crn.ServiceCOS).Region("global").
+//		Account(accountID).Instance(instanceID).Resource(crn.ResourceTypeBucket, name).Build()
+type Builder struct {
+	crn CRN
+}
+
+//NewBuilder starts a CRN in the public bluemix cloud
+func NewBuilder() *Builder {
+	return &Builder{crn: New("bluemix", "public")}
+}
+
+//From starts a builder from an existing CRN
+func From(c CRN) *Builder {
+	return &Builder{crn: c}
+}
+
+//Cloud sets the cloud name and type, for example "staging" and "public"
+func (b *Builder) Cloud(cloudName, cloudType string) *Builder {
+	b.crn.CName, b.crn.CType = cloudName, cloudType
+	return b
+}
+
+//Service ...
+func (b *Builder) Service(serviceName string) *Builder {
+	b.crn.ServiceName = serviceName
+	return b
+}
+
+//Region ...
+func (b *Builder) Region(region string) *Builder {
+	b.crn.Region = region
+	return b
+}
+
+//Account scopes the CRN to an account
+func (b *Builder) Account(accountID string) *Builder {
+	return b.scope(ScopeAccount, accountID)
+}
+
+//Organization scopes the CRN to a Cloud Foundry organization
+func (b *Builder) Organization(orgID string) *Builder {
+	return b.scope(ScopeOrganization, orgID)
+}
+
+//Space scopes the CRN to a Cloud Foundry space
+func (b *Builder) Space(spaceID string) *Builder {
+	return b.scope(ScopeSpace, spaceID)
+}
+
+//Project ...
+func (b *Builder) Project(projectID string) *Builder {
+	return b.scope(ScopeProject, projectID)
+}
+
+func (b *Builder) scope(scopeType, scope string) *Builder {
+	b.crn.ScopeType, b.crn.Scope = scopeType, scope
+	return b
+}
+
+//Instance ...
+func (b *Builder) Instance(serviceInstance string) *Builder {
+	b.crn.ServiceInstance = serviceInstance
+	return b
+}
+
+//Resource ...
+func (b *Builder) Resource(resourceType, resource string) *Builder {
+	b.crn.ResourceType, b.crn.Resource = resourceType, resource
+	return b
+}
+
+//Build validates and returns the CRN
+func (b *Builder) Build() (CRN, error) {
+	if err := b.crn.Validate(); err != nil {
+		return CRN{}, err
+	}
+	return b.crn, nil
+}
+
+//AccountCRN returns the CRN of an account
+func AccountCRN(accountID string) (CRN, error) {
+	return NewBuilder().Account(accountID).Build()
+}
+
+//ResourceGroupCRN ...
+func ResourceGroupCRN(accountID, resourceGroupID string) (CRN, error) {
+	return NewBuilder().Service(ServiceResourceController).Account(accountID).
+		Resource(ResourceTypeResourceGroup, resourceGroupID).Build()
+}
+
+//ServiceInstanceCRN ...
+func ServiceInstanceCRN(serviceName, region, accountID, instanceID string) (CRN, error) {
+	return NewBuilder().Service(serviceName).Region(region).Account(accountID).
+		Instance(instanceID).Build()
+}
+
+//BucketCRN returns the CRN of a Cloud Object Storage bucket
+func BucketCRN(accountID, instanceID, bucket string) (CRN, error) {
+	return NewBuilder().Service(ServiceCOS).Region("global").Account(accountID).
+		Instance(instanceID).Resource(ResourceTypeBucket, bucket).Build()
+}
+
+//ServiceIDCRN ...
+func ServiceIDCRN(accountID, serviceID string) (CRN, error) {
+	return NewBuilder().Service(ServiceIAMIdentity).Account(accountID).
+		Resource(ResourceTypeServiceID, serviceID).Build()
+}
+
+//AccessGroupCRN ...
+func AccessGroupCRN(accountID, accessGroupID string) (CRN, error) {
+	return NewBuilder().Service(ServiceIAMGroups).Account(accountID).
+		Resource(ResourceTypeAccessGroup, accessGroupID).Build()
+}
@@ -76,6 +76,11 @@ func (c *CRN) UnmarshalJSON(b []byte) error {
 }
 
 func (c CRN) MarshalJSON() ([]byte, error) {
+	//The empty CRN is written as "", which Parse reads back as CRN{}. Its
+	//String() form of bare separators would not parse.
+	if c == (CRN{}) {
+		return json.Marshal("")
+	}
 	return json.Marshal(c.String())
 }
 
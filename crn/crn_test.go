@@ -0,0 +1,91 @@
+package crn
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CRN", func() {
+	const instance = "crn:v1:bluemix:public:cloud-object-storage:global:a/123:inst-1::"
+	const bucket = "crn:v1:bluemix:public:cloud-object-storage:global:a/123:inst-1:bucket:logs"
+
+	Describe("Builder", func() {
+		It("should build service CRNs", func() {
+			c, err := BucketCRN("123", "inst-1", "logs")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.String()).Should(Equal(bucket))
+
+			c, err = ResourceGroupCRN("123", "rg-1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.String()).Should(Equal("crn:v1:bluemix:public:resource-controller::a/123::resource-group:rg-1"))
+		})
+		It("should describe the invalid segment", func() {
+			_, err := NewBuilder().Service("Bad_Name").Build()
+			Expect(err).To(HaveOccurred())
+			Expect(err.(*ValidationError).Segment).Should(Equal(SegmentServiceName))
+
+			_, err = NewBuilder().Service(ServiceCOS).scope("x", "123").Build()
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("ParseStrict", func() {
+		It("should reject malformed CRNs", func() {
+			_, err := ParseStrict(instance)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = ParseStrict("crn:v2:bluemix:public:cloud-object-storage:global:a/123:inst-1::")
+			Expect(err.(*ValidationError).Segment).Should(Equal(SegmentVersion))
+			_, err = ParseStrict("crn:v1:bluemix:public:cloud-object-storage:global:a/123:inst-1::logs")
+			Expect(err.(*ValidationError).Segment).Should(Equal(SegmentResource))
+			_, err = ParseStrict("crn:v1:bluemix")
+			Expect(err).Should(Equal(ErrMalformedCRN))
+		})
+	})
+
+	Describe("Contains", func() {
+		It("should contain resources of the same account and instance", func() {
+			account, _ := AccountCRN("123")
+			inst, _ := Parse(instance)
+			b, _ := Parse(bucket)
+			Expect(account.Contains(b)).Should(BeTrue())
+			Expect(inst.Contains(b)).Should(BeTrue())
+			Expect(b.Contains(inst)).Should(BeFalse())
+			other, _ := AccountCRN("456")
+			Expect(other.Contains(b)).Should(BeFalse())
+			Expect(b.InAccount("123")).Should(BeTrue())
+		})
+	})
+
+	Describe("Pattern", func() {
+		It("should match wildcards and missing segments", func() {
+			Expect(MatchString("crn:v1:bluemix:public:cloud-object-storage:*:a/123::", bucket)).Should(BeTrue())
+			Expect(MatchString("crn:v1:bluemix:public:cloud-object-storage:*:a/456::", bucket)).Should(BeFalse())
+			Expect(MatchString("crn:v1:bluemix:public:*:global:a/123:*:bucket:lo*", bucket)).Should(BeTrue())
+			Expect(MatchString("crn:v1:bluemix:public:kms", bucket)).Should(BeFalse())
+			_, err := ParsePattern("arn:aws")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("JSON", func() {
+		It("should round trip CRNs", func() {
+			c, _ := Parse(bucket)
+			data, err := json.Marshal(c)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).Should(Equal(`"` + bucket + `"`))
+			var out CRN
+			Expect(json.Unmarshal(data, &out)).To(Succeed())
+			Expect(out).Should(Equal(c))
+		})
+		It("should round trip the empty CRN as an empty string", func() {
+			data, err := json.Marshal(struct{ Target CRN }{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).Should(Equal(`{"Target":""}`))
+			var out struct{ Target CRN }
+			Expect(json.Unmarshal(data, &out)).To(Succeed())
+			Expect(out.Target).Should(Equal(CRN{}))
+		})
+	})
+})
@@ -0,0 +1,121 @@
+package crn
+
+import (
+	"strings"
+)
+
+//Wildcard matches any value of a pattern segment
+const Wildcard = "*"
+
+//segments returns the ten segments of c in CRN order
+func (c CRN) segments() []string {
+	return []string{
+		c.Scheme,
+		c.Version,
+		c.CName,
+		c.CType,
+		c.ServiceName,
+		c.Region,
+		c.ScopeSegment(),
+		c.ServiceInstance,
+		c.ResourceType,
+		c.Resource,
+	}
+}
+
+//Equal reports whether c and other identify the same resource
+func (c CRN) Equal(other CRN) bool {
+	return c == other
+}
+
+//Contains reports whether other lives within c. Every segment set in c must
+//equal the same segment of other, so an account CRN contains every CRN in
+//that account and a service instance CRN contains the resources of that
+//instance.
+func (c CRN) Contains(other CRN) bool {
+	mine, theirs := c.segments(), other.segments()
+	for i := range mine {
+		if mine[i] != "" && mine[i] != theirs[i] {
+			return false
+		}
+	}
+	return true
+}
+
+//InAccount reports whether c is scoped to accountID
+func (c CRN) InAccount(accountID string) bool {
+	return c.ScopeType == ScopeAccount && c.Scope == accountID
+}
+
+//Pattern is a CRN in which segments may be wildcards. Empty segments, a
+//segment of "*" and missing trailing segments match any value, and * inside
+//a segment matches any run of characters, so
+//"crn:v1:bluemix:public:cloud-object-storage:*:a/123::" matches every Cloud
+//Object Storage resource in account 123.
+type Pattern struct {
+	segments []string
+}
+
+//ParsePattern ...
+func ParsePattern(s string) (Pattern, error) {
+	segments := strings.Split(s, crnSeparator)
+	if len(segments) > 10 || segments[0] != crn {
+		return Pattern{}, ErrMalformedCRN
+	}
+	for len(segments) < 10 {
+		segments = append(segments, "")
+	}
+	return Pattern{segments: segments}, nil
+}
+
+//Matches reports whether c matches the pattern
+func (p Pattern) Matches(c CRN) bool {
+	theirs := c.segments()
+	for i, seg := range p.segments {
+		if seg == "" || seg == Wildcard {
+			continue
+		}
+		if !globMatch(seg, theirs[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+//String ...
+func (p Pattern) String() string {
+	return strings.Join(p.segments, crnSeparator)
+}
+
+//MatchString reports whether the CRN s matches pattern
+func MatchString(pattern, s string) (bool, error) {
+	p, err := ParsePattern(pattern)
+	if err != nil {
+		return false, err
+	}
+	c, err := Parse(s)
+	if err != nil {
+		return false, err
+	}
+	return p.Matches(c), nil
+}
+
+//globMatch matches s against pattern where * matches any run of characters
+func globMatch(pattern, s string) bool {
+	parts := strings.Split(pattern, Wildcard)
+	if len(parts) == 1 {
+		return pattern == s
+	}
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, part)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(part):]
+	}
+	return strings.HasSuffix(s, parts[len(parts)-1])
+}
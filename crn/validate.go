@@ -0,0 +1,105 @@
+package crn
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+//Segment names used in validation errors
+const (
+	SegmentScheme          = "scheme"
+	SegmentVersion         = "version"
+	SegmentCName           = "cname"
+	SegmentCType           = "ctype"
+	SegmentServiceName     = "service-name"
+	SegmentRegion          = "region"
+	SegmentScope           = "scope"
+	SegmentServiceInstance = "service-instance"
+	SegmentResourceType    = "resource-type"
+	SegmentResource        = "resource"
+)
+
+var (
+	namePattern         = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+	resourceTypePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+	validCTypes         = map[string]bool{"public": true, "dedicated": true, "local": true}
+	validScopeTypes     = map[string]bool{ScopeAccount: true, ScopeOrganization: true, ScopeSpace: true, ScopeProject: true}
+)
+
+//ValidationError describes the segment of a CRN that failed validation
+type ValidationError struct {
+	Segment string
+	Value   string
+	Reason  string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid CRN %s %q: %s", e.Segment, e.Value, e.Reason)
+}
+
+func invalid(segment, value, reason string) error {
+	return &ValidationError{Segment: segment, Value: value, Reason: reason}
+}
+
+//Validate checks every segment of c and returns a *ValidationError for the
+//first one that is not well formed
+func (c CRN) Validate() error {
+	if c.Scheme != crn {
+		return invalid(SegmentScheme, c.Scheme, `must be "crn"`)
+	}
+	if c.Version != version {
+		return invalid(SegmentVersion, c.Version, `must be "v1"`)
+	}
+	if !namePattern.MatchString(c.CName) {
+		return invalid(SegmentCName, c.CName, "must be lowercase letters, digits and dashes")
+	}
+	if !validCTypes[c.CType] {
+		return invalid(SegmentCType, c.CType, "must be one of public, dedicated or local")
+	}
+	if c.ServiceName != "" && !namePattern.MatchString(c.ServiceName) {
+		return invalid(SegmentServiceName, c.ServiceName, "must be lowercase letters, digits and dashes")
+	}
+	if c.Region != "" && !namePattern.MatchString(c.Region) {
+		return invalid(SegmentRegion, c.Region, "must be lowercase letters, digits and dashes")
+	}
+	if c.ScopeType != "" || (c.Scope != "" && c.Scope != "global") {
+		if !validScopeTypes[c.ScopeType] {
+			return invalid(SegmentScope, c.ScopeSegment(), "scope type must be one of a, o, s or p")
+		}
+		if c.Scope == "" || strings.ContainsAny(c.Scope, ":/") {
+			return invalid(SegmentScope, c.ScopeSegment(), "scope ID must be non-empty and must not contain : or /")
+		}
+	}
+	if strings.Contains(c.ServiceInstance, crnSeparator) {
+		return invalid(SegmentServiceInstance, c.ServiceInstance, "must not contain :")
+	}
+	if c.ServiceInstance != "" && c.ServiceName == "" {
+		return invalid(SegmentServiceInstance, c.ServiceInstance, "requires a service name")
+	}
+	if c.ResourceType != "" && !resourceTypePattern.MatchString(c.ResourceType) {
+		return invalid(SegmentResourceType, c.ResourceType, "must be letters, digits, dots, dashes and underscores")
+	}
+	if strings.Contains(c.Resource, crnSeparator) {
+		return invalid(SegmentResource, c.Resource, "must not contain :")
+	}
+	if c.Resource != "" && c.ResourceType == "" {
+		return invalid(SegmentResource, c.Resource, "requires a resource type")
+	}
+	return nil
+}
+
+//ParseStrict parses s and validates the result
+func ParseStrict(s string) (CRN, error) {
+	if s == "" {
+		return CRN{}, ErrMalformedCRN
+	}
+	c, err := Parse(s)
+	if err != nil {
+		return CRN{}, err
+	}
+	if err := c.Validate(); err != nil {
+		return CRN{}, err
+	}
+	return c, nil
+}
@@ -158,3 +158,16 @@ func EscapeUrlParm(urlParm string) string {
 	}
 	return urlParm
 }
+
+//InstanceInResourceGroup reports whether instance belongs to the resource
+//group identified by group. Instance CRNs do not carry their resource group,
+//so membership is read from the instance itself.
+func InstanceInResourceGroup(instance models.ServiceInstanceV2, group crn.CRN) bool {
+	if group.ResourceType != crn.ResourceTypeResourceGroup {
+		return false
+	}
+	if instance.ResourceGroupCrn != "" {
+		return instance.ResourceGroupCrn == group.String()
+	}
+	return instance.ResourceGroupID == group.Resource && instance.Crn.InAccount(group.Scope)
+}
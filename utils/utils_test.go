@@ -0,0 +1,43 @@
+package utils
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/IBM-Cloud/bluemix-go/crn"
+	"github.com/IBM-Cloud/bluemix-go/models"
+)
+
+var _ = Describe("InstanceInResourceGroup", func() {
+	var group crn.CRN
+	var instance models.ServiceInstanceV2
+	BeforeEach(func() {
+		var err error
+		group, err = crn.ResourceGroupCRN("123", "rg-1")
+		Expect(err).NotTo(HaveOccurred())
+		instance = models.ServiceInstanceV2{}
+		instance.Crn, err = crn.Parse("crn:v1:bluemix:public:cloudantnosqldb:us-south:a/123:inst-1::")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should compare the resource group CRN of the instance", func() {
+		instance.ResourceGroupCrn = group.String()
+		Expect(InstanceInResourceGroup(instance, group)).Should(BeTrue())
+
+		other, _ := crn.ResourceGroupCRN("123", "rg-2")
+		instance.ResourceGroupCrn = other.String()
+		Expect(InstanceInResourceGroup(instance, group)).Should(BeFalse())
+	})
+	It("should fall back to the resource group ID within the account", func() {
+		instance.ResourceGroupID = "rg-1"
+		Expect(InstanceInResourceGroup(instance, group)).Should(BeTrue())
+
+		other, _ := crn.ResourceGroupCRN("456", "rg-1")
+		Expect(InstanceInResourceGroup(instance, other)).Should(BeFalse())
+	})
+	It("should reject CRNs that are not resource groups", func() {
+		instance.ResourceGroupID = "rg-1"
+		account, _ := crn.AccountCRN("123")
+		Expect(InstanceInResourceGroup(instance, account)).Should(BeFalse())
+	})
+})